@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateAccessTokenDTO struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 for no expiry
+}
+
+type AccessTokenController struct {
+	accessTokenUsecase domain.IPersonalAccessTokenUsecase
+}
+
+func NewAccessTokenController(pu domain.IPersonalAccessTokenUsecase) *AccessTokenController {
+	return &AccessTokenController{accessTokenUsecase: pu}
+}
+
+func (ac *AccessTokenController) Create(ctx *gin.Context) {
+	var body CreateAccessTokenDTO
+	if err := ctx.ShouldBindJSON(&body); err != nil || body.ExpiresInDays < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	var expiresAt *time.Time
+	if body.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, body.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	token, secret, err := ac.accessTokenUsecase.Create(ctx.Request.Context(), ctx.GetInt64("user_id"), body.Name, body.Scopes, expiresAt)
+	if err != nil {
+		switch {
+		case err.Error() == "unable to create token", err.Error() == "user not found":
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "only admins"):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "too many access tokens"):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{
+		"token":   secret,
+		"data":    token,
+		"message": "copy the token now, it will not be shown again",
+	})
+}
+
+func (ac *AccessTokenController) List(ctx *gin.Context) {
+	tokens, err := ac.accessTokenUsecase.List(ctx.Request.Context(), ctx.GetInt64("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tokens"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+func (ac *AccessTokenController) Revoke(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid token id"})
+		return
+	}
+	if err := ac.accessTokenUsecase.Revoke(ctx.Request.Context(), ctx.GetInt64("user_id"), id); err != nil {
+		if err.Error() == "token not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "token revoked"})
+}
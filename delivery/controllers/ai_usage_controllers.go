@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type SetAIQuotaDTO struct {
+	DailyTokens *int64 `json:"daily_tokens"` // -1 for no limit
+}
+
+type AIUsageController struct {
+	aiUsageUsecase domain.IAIUsageUsecase
+}
+
+func NewAIUsageController(au domain.IAIUsageUsecase) *AIUsageController {
+	return &AIUsageController{aiUsageUsecase: au}
+}
+
+// usagePeriod reads the from and to query parameters as YYYY-MM-DD dates
+// in UTC, both inclusive, defaulting to the last 30 days.
+func usagePeriod(ctx *gin.Context) (time.Time, time.Time, bool) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, to := today.AddDate(0, 0, -29), today
+	var err error
+	if value := ctx.Query("from"); value != "" {
+		if from, err = time.Parse(time.DateOnly, value); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date like 2006-01-02"})
+			return from, to, false
+		}
+	}
+	if value := ctx.Query("to"); value != "" {
+		if to, err = time.Parse(time.DateOnly, value); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date like 2006-01-02"})
+			return from, to, false
+		}
+	}
+	return from, to.AddDate(0, 0, 1), true
+}
+
+func userIDParam(ctx *gin.Context) (int64, bool) {
+	userID, err := strconv.ParseInt(ctx.Param("user_id"), 10, 64)
+	if err != nil || userID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, false
+	}
+	return userID, true
+}
+
+func writeAIUsageError(ctx *gin.Context, err error) {
+	switch err.Error() {
+	case "user not found", "quota not found":
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "failed to save quota", "failed to delete quota", "failed to fetch quota":
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// UsageByUser lists the totals of every user with usage in the period,
+// most expensive first.
+func (ac *AIUsageController) UsageByUser(ctx *gin.Context) {
+	from, to, ok := usagePeriod(ctx)
+	if !ok {
+		return
+	}
+	usage, err := ac.aiUsageUsecase.UsageByUser(ctx.Request.Context(), from, to)
+	if err != nil {
+		writeAIUsageError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"usage": usage, "from": from.Format(time.DateOnly), "to": to.AddDate(0, 0, -1).Format(time.DateOnly)})
+}
+
+// UsageForUser lists one user's totals per day.
+func (ac *AIUsageController) UsageForUser(ctx *gin.Context) {
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	from, to, ok := usagePeriod(ctx)
+	if !ok {
+		return
+	}
+	usage, err := ac.aiUsageUsecase.UsageForUser(ctx.Request.Context(), userID, from, to)
+	if err != nil {
+		writeAIUsageError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"usage": usage, "from": from.Format(time.DateOnly), "to": to.AddDate(0, 0, -1).Format(time.DateOnly)})
+}
+
+func (ac *AIUsageController) GetQuota(ctx *gin.Context) {
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	quota, err := ac.aiUsageUsecase.GetQuota(ctx.Request.Context(), userID)
+	if err != nil {
+		writeAIUsageError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"quota": quota})
+}
+
+func (ac *AIUsageController) SetQuota(ctx *gin.Context) {
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	var body SetAIQuotaDTO
+	if err := ctx.ShouldBindJSON(&body); err != nil || body.DailyTokens == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "daily_tokens is required"})
+		return
+	}
+	quota, err := ac.aiUsageUsecase.SetQuota(ctx.Request.Context(), userID, *body.DailyTokens)
+	if err != nil {
+		writeAIUsageError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"quota": quota})
+}
+
+// ClearQuota returns the user to their role's quota.
+func (ac *AIUsageController) ClearQuota(ctx *gin.Context) {
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+	if err := ac.aiUsageUsecase.ClearQuota(ctx.Request.Context(), userID); err != nil {
+		writeAIUsageError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "quota override removed"})
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsController struct {
+	analyticsUsecase domain.IAnalyticsUsecase
+}
+
+func NewAnalyticsController(au domain.IAnalyticsUsecase) *AnalyticsController {
+	return &AnalyticsController{analyticsUsecase: au}
+}
+
+func writeAnalyticsError(ctx *gin.Context, err error) {
+	switch err.Error() {
+	case "blog not found":
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "failed to fetch stats", "failed to fetch posts":
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// csvCell keeps spreadsheets from running cells as formulas.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func formatReferrers(referrers []domain.ReferrerCount) string {
+	parts := make([]string, 0, len(referrers))
+	for _, r := range referrers {
+		parts = append(parts, fmt.Sprintf("%s=%d", r.Referrer, r.Views))
+	}
+	return csvCell(strings.Join(parts, ";"))
+}
+
+func countCells(c domain.ActivityCounts) []string {
+	return []string{strconv.Itoa(c.Views), strconv.Itoa(c.UniqueViewers), strconv.Itoa(c.Likes), strconv.Itoa(c.Comments)}
+}
+
+// writeAnalytics answers with data as JSON, or as a file download when the
+// format query parameter is json or csv. rows builds the CSV lazily.
+func writeAnalytics(ctx *gin.Context, filename string, data interface{}, rows func() [][]string) {
+	switch ctx.Query("format") {
+	case "":
+		ctx.JSON(http.StatusOK, data)
+	case "json":
+		body, err := json.Marshal(data)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export stats"})
+			return
+		}
+		ctx.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		ctx.Data(http.StatusOK, "application/json", body)
+	case "csv":
+		ctx.Header("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Status(http.StatusOK)
+		w := csv.NewWriter(ctx.Writer)
+		w.WriteAll(rows())
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+	}
+}
+
+// BlogAnalytics lists the activity of a post per day.
+func (ac *AnalyticsController) BlogAnalytics(ctx *gin.Context) {
+	blogID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || blogID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid blog id"})
+		return
+	}
+	from, to, ok := usagePeriod(ctx)
+	if !ok {
+		return
+	}
+	analytics, err := ac.analyticsUsecase.BlogAnalytics(ctx.Request.Context(), blogID, from, to)
+	if err != nil {
+		writeAnalyticsError(ctx, err)
+		return
+	}
+	filename := fmt.Sprintf("blog-%d-%s-%s", blogID, analytics.From, analytics.To)
+	writeAnalytics(ctx, filename, analytics, func() [][]string {
+		rows := [][]string{{"day", "views", "unique_viewers", "likes", "comments", "referrers"}}
+		for _, day := range analytics.Days {
+			row := append([]string{day.Day}, countCells(day.ActivityCounts)...)
+			rows = append(rows, append(row, formatReferrers(day.Referrers)))
+		}
+		return rows
+	})
+}
+
+// AuthorAnalytics sums the activity of the caller's posts per day.
+func (ac *AnalyticsController) AuthorAnalytics(ctx *gin.Context) {
+	from, to, ok := usagePeriod(ctx)
+	if !ok {
+		return
+	}
+	analytics, err := ac.analyticsUsecase.AuthorAnalytics(ctx.Request.Context(), ctx.GetInt64("user_id"), from, to)
+	if err != nil {
+		writeAnalyticsError(ctx, err)
+		return
+	}
+	filename := fmt.Sprintf("author-%d-%s-%s", analytics.AuthorID, analytics.From, analytics.To)
+	writeAnalytics(ctx, filename, analytics, func() [][]string {
+		// one row per post and day with activity
+		rows := [][]string{{"day", "blog_id", "title", "views", "unique_viewers", "likes", "comments"}}
+		for _, post := range analytics.Posts {
+			for _, day := range post.Days {
+				row := []string{day.Day, strconv.FormatInt(post.BlogID, 10), csvCell(post.Title)}
+				rows = append(rows, append(row, countCells(day.ActivityCounts)...))
+			}
+		}
+		sort.SliceStable(rows[1:], func(i, j int) bool { return rows[i+1][0] < rows[j+1][0] })
+		return rows
+	})
+}
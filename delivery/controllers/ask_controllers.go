@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type AskController struct {
+	askUsecase domain.IAskUsecase
+}
+
+func NewAskController(au domain.IAskUsecase) *AskController {
+	return &AskController{askUsecase: au}
+}
+
+type AskRequest struct {
+	Question string `json:"question" binding:"required"`
+}
+
+// Ask answers a question from the blog's posts, citing the posts and
+// paragraphs the answer is based on: POST /blogs/ask
+func (ac *AskController) Ask(ctx *gin.Context) {
+	var req AskRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	answer, err := ac.askUsecase.Ask(aiContext(ctx), req.Question)
+	if err != nil {
+		switch err.Error() {
+		case "question is required", "question cannot be longer than 500 characters":
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			writeSemanticSearchError(ctx, err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, answer)
+}
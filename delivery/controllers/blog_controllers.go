@@ -1,10 +1,13 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blog-platform/domain"
 	"github.com/gin-gonic/gin"
@@ -53,7 +56,8 @@ func (c *BlogController) CreateBlog(ctx *gin.Context) {
 		}
 	}
 
-	er := c.blogUsecase.CreateBlog(ctx.Request.Context(), &blog, tags)
+	// insights generated for the new post count against the author's quota
+	er := c.blogUsecase.CreateBlog(aiContext(ctx), &blog, tags)
 	if er != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create blog"})
 		return
@@ -181,17 +185,28 @@ func (h *BlogController) FetchPaginatedBlogs(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"data": blogs, "total": total, "page": page, "limit": limit, "total_pages": (total + int64(limit) - 1) / int64(limit)})
 }
 
-func (c *BlogController) TrackView(ctx *gin.Context) {
-	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
-	if err != nil || id <= 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid blog id"})
-		return
+// TrendingBlogs lists posts by their trending score in window, 24h or 7d.
+func (h *BlogController) TrendingBlogs(ctx *gin.Context) {
+	window := ctx.DefaultQuery("window", domain.TrendingDay)
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		limit = 10
 	}
-	if err := c.blogUsecase.TrackView(ctx.Request.Context(), id); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to track view"})
+
+	blogs, total, err := h.blogUsecase.FetchTrendingBlogs(ctx.Request.Context(), window, page, limit)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "window must be") {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"message": "view tracked"})
+	ctx.JSON(http.StatusOK, gin.H{"data": blogs, "window": window, "total": total, "page": page, "limit": limit, "total_pages": (total + int64(limit) - 1) / int64(limit)})
 }
 
 func (c *BlogController) LikeBlog(ctx *gin.Context) {
@@ -200,7 +215,8 @@ func (c *BlogController) LikeBlog(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid blog id"})
 		return
 	}
-	if err := c.blogUsecase.LikeBlog(ctx.Request.Context(), id, 0); err != nil {
+	userID := ctx.MustGet("user_id").(int64)
+	if err := c.blogUsecase.LikeBlog(ctx.Request.Context(), id, userID); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to like blog"})
 		return
 	}
@@ -213,7 +229,8 @@ func (c *BlogController) UnlikeBlog(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid blog id"})
 		return
 	}
-	if err := c.blogUsecase.UnlikeBlog(ctx.Request.Context(), id, 0); err != nil {
+	userID := ctx.MustGet("user_id").(int64)
+	if err := c.blogUsecase.UnlikeBlog(ctx.Request.Context(), id, userID); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlike blog"})
 		return
 	}
@@ -269,8 +286,67 @@ func (h *BlogController) SearchBlogs(ctx *gin.Context) {
 	})
 }
 
+// aiContext is the request context carrying the user for AI quotas and
+// usage accounting.
+func aiContext(ctx *gin.Context) context.Context {
+	return domain.WithAICaller(ctx.Request.Context(), domain.AICaller{
+		UserID: ctx.GetInt64("user_id"),
+		Role:   ctx.GetString("role"),
+	})
+}
+
+// writeAIError maps AI provider failures to gateway statuses.
+func writeAIError(ctx *gin.Context, err error) {
+	status := aiErrorStatus(err)
+	switch {
+	case status == http.StatusTooManyRequests:
+		// quotas reset at midnight UTC
+		reset := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		ctx.Header("Retry-After", strconv.Itoa(int(time.Until(reset).Seconds())+1))
+		ctx.JSON(status, gin.H{"error": err.Error()})
+	case status != 0:
+		ctx.JSON(status, gin.H{"error": err.Error()})
+	case err.Error() == "context canceled":
+		// the client went away; nobody reads the response
+		ctx.Status(499)
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// aiErrorStatus returns the status for the AI provider failures whose
+// messages are safe to show, or 0 for any other error.
+func aiErrorStatus(err error) int {
+	if errors.Is(err, domain.ErrAIQuotaExceeded) {
+		return http.StatusTooManyRequests
+	}
+	switch err.Error() {
+	case "AI provider is not configured":
+		return http.StatusServiceUnavailable
+	case "AI provider timed out":
+		return http.StatusGatewayTimeout
+	case "AI provider request failed", "AI provider returned an invalid response":
+		return http.StatusBadGateway
+	}
+	return 0
+}
+
+// writePromptError maps failures to pick or render a prompt template, and
+// then AI provider failures.
+func writePromptError(ctx *gin.Context, err error) {
+	switch err.Error() {
+	case "prompt template not found":
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "invalid template version", "tone, audience and language must be single lines of at most 50 characters":
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		writeAIError(ctx, err)
+	}
+}
+
 type BlogIdeaRequest struct {
 	Topic string `json:"topic" binding:"required"`
+	domain.PromptOptions
 }
 
 func (c *BlogController) GenerateBlogIdeas(ctx *gin.Context) {
@@ -280,9 +356,9 @@ func (c *BlogController) GenerateBlogIdeas(ctx *gin.Context) {
 		return
 	}
 
-	ideas, err := c.blogUsecase.GenerateBlogIdeas(req.Topic)
+	ideas, err := c.blogUsecase.GenerateBlogIdeas(aiContext(ctx), req.Topic, req.PromptOptions)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writePromptError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"ideas": ideas})
@@ -290,6 +366,7 @@ func (c *BlogController) GenerateBlogIdeas(ctx *gin.Context) {
 
 type BlogImproveRequest struct {
 	Content string `json:"content" binding:"required"`
+	domain.PromptOptions
 }
 
 func (c *BlogController) SuggestBlogImprovements(ctx *gin.Context) {
@@ -299,15 +376,105 @@ func (c *BlogController) SuggestBlogImprovements(ctx *gin.Context) {
 		return
 	}
 
-	suggestion, err := c.blogUsecase.SuggestBlogImprovements(req.Content)
+	suggestion, err := c.blogUsecase.SuggestBlogImprovements(aiContext(ctx), req.Content, req.PromptOptions)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writePromptError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"suggestion": suggestion})
 
 }
 
+func (c *BlogController) RegenerateBlogInsights(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid blog id"})
+		return
+	}
+
+	insights, err := c.blogUsecase.RegenerateBlogInsights(aiContext(ctx), id)
+	if err != nil {
+		if err.Error() == "blog not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		writeAIError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"insights": insights})
+}
+
+// streamAI runs generate and sends every delta as a server-sent "delta"
+// event, then a "done" event with the full text. The response only starts
+// with the first delta, so failures before that get a regular JSON error;
+// later ones are sent as an "error" event. A client disconnect cancels the
+// request context and with it the upstream request.
+func streamAI(ctx *gin.Context, generate func(onDelta func(string) error) (string, error)) {
+	started := false
+	text, err := generate(func(delta string) error {
+		if !started {
+			started = true
+			header := ctx.Writer.Header()
+			header.Set("Content-Type", "text/event-stream")
+			header.Set("Cache-Control", "no-cache")
+			header.Set("Connection", "keep-alive")
+			// stop nginx from buffering the stream
+			header.Set("X-Accel-Buffering", "no")
+			ctx.Status(http.StatusOK)
+		}
+		ctx.SSEvent("delta", gin.H{"text": delta})
+		ctx.Writer.Flush()
+		return ctx.Request.Context().Err()
+	})
+	if !started {
+		if err != nil {
+			writePromptError(ctx, err)
+			return
+		}
+		// a provider may answer without streaming anything
+		ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+		ctx.Status(http.StatusOK)
+	}
+	if err != nil {
+		if ctx.Request.Context().Err() == nil {
+			// only known messages are sent; others may quote the provider
+			message := "AI provider request failed"
+			if aiErrorStatus(err) != 0 {
+				message = err.Error()
+			}
+			ctx.SSEvent("error", gin.H{"error": message})
+			ctx.Writer.Flush()
+		}
+		return
+	}
+	ctx.SSEvent("done", gin.H{"text": text})
+	ctx.Writer.Flush()
+}
+
+func (c *BlogController) StreamBlogIdeas(ctx *gin.Context) {
+	var req BlogIdeaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	streamAI(ctx, func(onDelta func(string) error) (string, error) {
+		return c.blogUsecase.StreamBlogIdeas(aiContext(ctx), req.Topic, req.PromptOptions, onDelta)
+	})
+}
+
+func (c *BlogController) StreamBlogImprovements(ctx *gin.Context) {
+	var req BlogImproveRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	streamAI(ctx, func(onDelta func(string) error) (string, error) {
+		return c.blogUsecase.StreamBlogImprovements(aiContext(ctx), req.Content, req.PromptOptions, onDelta)
+	})
+}
+
 func (bc *BlogController) FilterBlogs(c *gin.Context) {
 	// Parse query params
 	title := c.Query("title")
@@ -340,10 +507,16 @@ func (bc *BlogController) FilterBlogs(c *gin.Context) {
 		UserID:        userIDPtr,
 		Limit:         limit,
 		Offset:        offset,
+		Sort:          c.Query("sort"),
+		Window:        c.Query("window"),
 	}
 
 	blogs, err := bc.blogUsecase.FetchBlogsByFilter(c.Request.Context(), filter)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "sort must be") || strings.HasPrefix(err.Error(), "window must be") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -375,6 +548,40 @@ func (c *BlogController) AddComment(ctx *gin.Context) {
 	}
 	comment, err := c.blogUsecase.AddComment(ctx.Request.Context(), blogID, userID, req.Content)
 	if err != nil {
+		if err.Error() == "blog not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"comment": comment})
+}
+
+func (c *BlogController) ReplyToComment(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+	blogID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || blogID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid blog id"})
+		return
+	}
+	parentID, err := strconv.ParseInt(ctx.Param("comment_id"), 10, 64)
+	if err != nil || parentID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid comment id"})
+		return
+	}
+	var req AddCommentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	comment, err := c.blogUsecase.ReplyToComment(ctx.Request.Context(), blogID, parentID, userID, req.Content)
+	if err != nil {
+		if err.Error() == "comment not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -407,3 +614,53 @@ func (c *BlogController) ListComments(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, gin.H{"comments": comments, "meta": gin.H{"total": total, "page": page, "limit": limit}})
 }
+
+// ListHeldComments lists comments held by moderation, oldest first.
+func (c *BlogController) ListHeldComments(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	comments, total, err := c.blogUsecase.ListHeldComments(ctx.Request.Context(), page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch held comments"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"comments": comments, "meta": gin.H{"total": total, "page": page, "limit": limit}})
+}
+
+func (c *BlogController) ApproveComment(ctx *gin.Context) {
+	c.reviewComment(ctx, true)
+}
+
+func (c *BlogController) RejectComment(ctx *gin.Context) {
+	c.reviewComment(ctx, false)
+}
+
+func (c *BlogController) reviewComment(ctx *gin.Context, approve bool) {
+	reviewerID := ctx.MustGet("user_id").(int64)
+	commentID, err := strconv.ParseInt(ctx.Param("comment_id"), 10, 64)
+	if err != nil || commentID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid comment id"})
+		return
+	}
+	comment, err := c.blogUsecase.ReviewComment(ctx.Request.Context(), commentID, reviewerID, approve)
+	if err != nil {
+		switch err.Error() {
+		case "held comment not found":
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "failed to review comment":
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"comment": comment})
+}
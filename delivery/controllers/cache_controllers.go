@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type CacheController struct {
+	stats func() []domain.CacheStats
+}
+
+func NewCacheController(stats func() []domain.CacheStats) *CacheController {
+	return &CacheController{stats: stats}
+}
+
+// Stats reports the in-memory caches of the instance serving the request.
+func (cc *CacheController) Stats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"caches": cc.stats()})
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type FollowController struct {
+	followUsecase domain.IFollowUsecase
+}
+
+func NewFollowController(fu domain.IFollowUsecase) *FollowController {
+	return &FollowController{followUsecase: fu}
+}
+
+func (fc *FollowController) Follow(ctx *gin.Context) {
+	followerID := ctx.MustGet("user_id").(int64)
+	followeeID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || followeeID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := fc.followUsecase.Follow(ctx.Request.Context(), followerID, followeeID); err != nil {
+		switch err.Error() {
+		case "user not found":
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "you cannot follow yourself":
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "followed"})
+}
+
+func (fc *FollowController) Unfollow(ctx *gin.Context) {
+	followerID := ctx.MustGet("user_id").(int64)
+	followeeID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || followeeID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := fc.followUsecase.Unfollow(ctx.Request.Context(), followerID, followeeID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "unfollowed"})
+}
+
+func (fc *FollowController) ListFollowers(ctx *gin.Context) {
+	fc.listUsers(ctx, fc.followUsecase.GetFollowers)
+}
+
+func (fc *FollowController) ListFollowing(ctx *gin.Context) {
+	fc.listUsers(ctx, fc.followUsecase.GetFollowing)
+}
+
+type listUsersFunc func(ctx context.Context, userID int64, page, limit int) ([]*domain.PublicUser, int64, error)
+
+func (fc *FollowController) listUsers(ctx *gin.Context, list listUsersFunc) {
+	userID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	users, total, err := list(ctx.Request.Context(), userID, page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"users": users, "meta": gin.H{"total": total, "page": page, "limit": limit}})
+}
+
+func (fc *FollowController) GetFeed(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	blogs, next, err := fc.followUsecase.GetFeed(ctx.Request.Context(), userID, ctx.Query("cursor"), limit)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch feed"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"blogs": blogs, "next_cursor": next})
+}
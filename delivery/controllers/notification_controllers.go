@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController struct {
+	notificationUsecase domain.INotificationUsecase
+}
+
+func NewNotificationController(nu domain.INotificationUsecase) *NotificationController {
+	return &NotificationController{notificationUsecase: nu}
+}
+
+func (nc *NotificationController) ListNotifications(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	unreadOnly := ctx.Query("unread") == "true"
+
+	notifications, total, err := nc.notificationUsecase.ListNotifications(ctx.Request.Context(), userID, unreadOnly, page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch notifications"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"notifications": notifications, "meta": gin.H{"total": total, "page": page, "limit": limit}})
+}
+
+func (nc *NotificationController) UnreadCount(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+	count, err := nc.notificationUsecase.UnreadCount(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count notifications"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"unread": count})
+}
+
+func (nc *NotificationController) MarkRead(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification id"})
+		return
+	}
+	if err := nc.notificationUsecase.MarkRead(ctx.Request.Context(), userID, id); err != nil {
+		if err.Error() == "notification not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "notification marked as read"})
+}
+
+func (nc *NotificationController) MarkAllRead(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+	if err := nc.notificationUsecase.MarkAllRead(ctx.Request.Context(), userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "all notifications marked as read"})
+}
+
+func (nc *NotificationController) GetPreferences(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+	prefs, err := nc.notificationUsecase.GetPreferences(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
+
+func (nc *NotificationController) UpdatePreferences(ctx *gin.Context) {
+	userID := ctx.MustGet("user_id").(int64)
+	var prefs map[string]bool
+	if err := ctx.ShouldBindJSON(&prefs); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if err := nc.notificationUsecase.UpdatePreferences(ctx.Request.Context(), userID, prefs); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "preferences updated"})
+}
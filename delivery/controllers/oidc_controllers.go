@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCController struct {
+	oidcUsecase domain.IOIDCUsecase
+}
+
+func NewOIDCController(ou domain.IOIDCUsecase) *OIDCController {
+	return &OIDCController{oidcUsecase: ou}
+}
+
+func (oc *OIDCController) Login(ctx *gin.Context) {
+	url, err := oc.oidcUsecase.StartLogin(ctx.Request.Context(), ctx.Param("provider"))
+	if err != nil {
+		if err.Error() == "unknown identity provider" {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "unable to reach identity provider"})
+		return
+	}
+	ctx.Redirect(http.StatusFound, url)
+}
+
+// Link returns the provider URL to open for linking the identity to the signed
+// in user. It is not a redirect because the request carries a bearer token.
+func (oc *OIDCController) Link(ctx *gin.Context) {
+	userID, _ := ctx.Get("user_id")
+	id, _ := userID.(int64)
+	url, err := oc.oidcUsecase.StartLink(ctx.Request.Context(), ctx.Param("provider"), id)
+	if err != nil {
+		if err.Error() == "unknown identity provider" {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "unable to reach identity provider"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"url": url})
+}
+
+func (oc *OIDCController) Callback(ctx *gin.Context) {
+	if errCode := ctx.Query("error"); errCode != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "identity provider returned " + errCode})
+		return
+	}
+
+	result, err := oc.oidcUsecase.FinishLogin(ctx.Request.Context(), ctx.Param("provider"), ctx.Query("state"), ctx.Query("code"), ctx.ClientIP())
+	if err != nil {
+		switch err.Error() {
+		case "unknown identity provider":
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "invalid or expired login state", "identity provider did not return a verified email":
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "identity is linked to another account",
+			"an account with this email already exists; sign in to it and link the identity provider from your account":
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case "identity provider login failed":
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	writeLoginResult(ctx, result)
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type SavePromptTemplateDTO struct {
+	Feature      string `json:"feature" binding:"required"`
+	SystemPrompt string `json:"system_prompt"`
+	UserPrompt   string `json:"user_prompt"`
+}
+
+type PromptTemplateController struct {
+	promptUsecase domain.IPromptTemplateUsecase
+}
+
+func NewPromptTemplateController(pu domain.IPromptTemplateUsecase) *PromptTemplateController {
+	return &PromptTemplateController{promptUsecase: pu}
+}
+
+func writePromptTemplateError(ctx *gin.Context, err error) {
+	switch err.Error() {
+	case "prompt template not found":
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "failed to save prompt template", "failed to fetch prompt template", "failed to fetch prompt templates", "failed to activate prompt template":
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// ListTemplates lists the active version of every template.
+func (pc *PromptTemplateController) ListTemplates(ctx *gin.Context) {
+	templates, err := pc.promptUsecase.ListTemplates(ctx.Request.Context())
+	if err != nil {
+		writePromptTemplateError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+// ListVersions lists every version of a template, newest first.
+func (pc *PromptTemplateController) ListVersions(ctx *gin.Context) {
+	versions, err := pc.promptUsecase.ListVersions(ctx.Request.Context(), ctx.Param("name"))
+	if err != nil {
+		writePromptTemplateError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+// SaveVersion saves a new version of a template and makes it active.
+func (pc *PromptTemplateController) SaveVersion(ctx *gin.Context) {
+	var body SavePromptTemplateDTO
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := pc.promptUsecase.SaveTemplate(ctx.Request.Context(), &domain.PromptTemplate{
+		Name:         ctx.Param("name"),
+		Feature:      body.Feature,
+		SystemPrompt: body.SystemPrompt,
+		UserPrompt:   body.UserPrompt,
+	}, ctx.GetInt64("user_id"))
+	if err != nil {
+		writePromptTemplateError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"template": t})
+}
+
+// ActivateVersion makes an earlier version active again, e.g. to roll back.
+func (pc *PromptTemplateController) ActivateVersion(ctx *gin.Context) {
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid template version"})
+		return
+	}
+	t, err := pc.promptUsecase.ActivateVersion(ctx.Request.Context(), ctx.Param("name"), version)
+	if err != nil {
+		writePromptTemplateError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"template": t})
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type SemanticSearchController struct {
+	semanticUsecase domain.ISemanticSearchUsecase
+}
+
+func NewSemanticSearchController(su domain.ISemanticSearchUsecase) *SemanticSearchController {
+	return &SemanticSearchController{semanticUsecase: su}
+}
+
+func writeSemanticSearchError(ctx *gin.Context, err error) {
+	switch err.Error() {
+	case "semantic search is not configured":
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case "blog not found":
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "failed to search blogs", "failed to fetch blogs", "failed to index blog":
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	case "query is required", "query cannot be longer than 500 characters", "invalid blog ID":
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		// embedding provider failures
+		writeAIError(ctx, err)
+	}
+}
+
+func resultLimit(ctx *gin.Context) (int, bool) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return 0, false
+	}
+	return limit, true
+}
+
+// SemanticSearch finds posts by meaning rather than keywords:
+// GET /blogs/semantic-search?q=...&limit=10
+func (sc *SemanticSearchController) SemanticSearch(ctx *gin.Context) {
+	limit, ok := resultLimit(ctx)
+	if !ok {
+		return
+	}
+	results, err := sc.semanticUsecase.SemanticSearch(ctx.Request.Context(), ctx.Query("q"), limit)
+	if err != nil {
+		writeSemanticSearchError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+func (sc *SemanticSearchController) RelatedBlogs(ctx *gin.Context) {
+	blogID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || blogID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid blog id"})
+		return
+	}
+	limit, ok := resultLimit(ctx)
+	if !ok {
+		return
+	}
+	results, err := sc.semanticUsecase.RelatedBlogs(ctx.Request.Context(), blogID, limit)
+	if err != nil {
+		writeSemanticSearchError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// Reindex embeds every post again, e.g. after changing EMBEDDING_MODEL.
+func (sc *SemanticSearchController) Reindex(ctx *gin.Context) {
+	indexed, err := sc.semanticUsecase.ReindexAll(ctx.Request.Context())
+	if err != nil {
+		writeSemanticSearchError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"indexed": indexed})
+}
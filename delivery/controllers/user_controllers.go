@@ -1,9 +1,13 @@
 package controllers
 
 import (
+	"bytes"
+	"html/template"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/blog-platform/domain"
 	"github.com/gin-gonic/gin"
@@ -20,6 +24,25 @@ type UserLoginDTO struct {
 	Password   string `json:"password"`
 }
 
+type MagicLinkDTO struct {
+	Email string `json:"email"`
+}
+
+// VerifyMagicLinkDTO is sent as JSON by clients, or as a form by the page of
+// MagicLinkPage.
+type VerifyMagicLinkDTO struct {
+	Token string `json:"token" form:"token"`
+}
+
+type VerifyMFADTO struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"` // TOTP or recovery code
+}
+
+type TOTPCodeDTO struct {
+	Code string `json:"code"`
+}
+
 type ResetPasswordDTO struct {
 	OldPassword string `json:"old_password"`
 	NewPassword string `json:"new_password"`
@@ -33,13 +56,29 @@ type UpdatePasswordDirectDTO struct {
 	NewPassword string `json:"new_password"`
 }
 
+// UserProfileResponse flattens the user fields and adds follow counts.
+type UserProfileResponse struct {
+	*domain.User
+	domain.FollowCounts
+}
+
+// LockedUserResponse adds the lockout state, which is hidden from the user
+// JSON, for admins.
+type LockedUserResponse struct {
+	*domain.User
+	FailedLogins int        `json:"failed_logins"`
+	LockedUntil  *time.Time `json:"locked_until"`
+}
+
 type UserController struct {
-	userUsecase domain.IUserUsecase
+	userUsecase   domain.IUserUsecase
+	followUsecase domain.IFollowUsecase
 }
 
-func NewUserController(uu domain.IUserUsecase) *UserController {
+func NewUserController(uu domain.IUserUsecase, fu domain.IFollowUsecase) *UserController {
 	return &UserController{
-		userUsecase: uu,
+		userUsecase:   uu,
+		followUsecase: fu,
 	}
 }
 
@@ -85,19 +124,189 @@ func (uc *UserController) Login(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, err := uc.userUsecase.Login(userInput.Identifier, userInput.Password)
+	result, err := uc.userUsecase.Login(userInput.Identifier, userInput.Password, ctx.ClientIP())
 	if err != nil {
+		if err.Error() == "too many failed login attempts" {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
+	writeLoginResult(ctx, result)
+}
+
+// writeLoginResult responds with the issued tokens, or with the challenge for
+// the second step when the account has two-factor authentication.
+func writeLoginResult(ctx *gin.Context, result *domain.LoginResult) {
+	if result.MFAToken != "" {
+		ctx.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+			"message":      "enter the code from your authenticator app",
+		})
+		return
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"access":  accessToken,
-		"refresh": refreshToken,
+		"access":  result.AccessToken,
+		"refresh": result.RefreshToken,
 		"message": "Logged in successfully",
 	})
 }
 
+func (uc *UserController) RequestMagicLink(ctx *gin.Context) {
+	var body MagicLinkDTO
+	if err := ctx.ShouldBindJSON(&body); err != nil || body.Email == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := uc.userUsecase.RequestMagicLink(body.Email, ctx.ClientIP()); err != nil {
+		if err.Error() == "too many failed login attempts" {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "if an account uses this email, a sign-in link has been sent to it"})
+}
+
+// magicLinkPage asks the reader to confirm the sign-in, so that link scanners
+// opening the emailed link do not use it up.
+var magicLinkPage = template.Must(template.New("magic_link").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Sign in</title></head>
+<body>
+<form method="post" action="/login/magic/verify">
+<input type="hidden" name="token" value="{{.}}">
+<button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
+
+// MagicLinkPage is what the emailed link opens by default. It only shows a
+// button posting the token to VerifyMagicLink.
+func (uc *UserController) MagicLinkPage(ctx *gin.Context) {
+	var page bytes.Buffer
+	if err := magicLinkPage.Execute(&page, ctx.Query("token")); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render page"})
+		return
+	}
+	// the token is in the URL; keep it out of caches and Referer headers
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Referrer-Policy", "no-referrer")
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+func (uc *UserController) VerifyMagicLink(ctx *gin.Context) {
+	var body VerifyMagicLinkDTO
+	if err := ctx.ShouldBind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	result, err := uc.userUsecase.ConsumeMagicLink(body.Token, ctx.ClientIP())
+	if err != nil {
+		if err.Error() == "too many failed login attempts" {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	writeLoginResult(ctx, result)
+}
+
+func (uc *UserController) VerifyMFA(ctx *gin.Context) {
+	var body VerifyMFADTO
+	if err := ctx.ShouldBindJSON(&body); err != nil || body.MFAToken == "" || body.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	result, err := uc.userUsecase.VerifyMFA(body.MFAToken, body.Code, ctx.ClientIP())
+	if err != nil {
+		if err.Error() == "too many failed login attempts" {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"access":  result.AccessToken,
+		"refresh": result.RefreshToken,
+		"message": "Logged in successfully",
+	})
+}
+
+func (uc *UserController) SetupTOTP(ctx *gin.Context) {
+	userID := ctx.GetInt64("user_id")
+	setup, err := uc.userUsecase.SetupTOTP(userID)
+	if err != nil {
+		twoFactorError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, setup)
+}
+
+func (uc *UserController) ConfirmTOTP(ctx *gin.Context) {
+	var body TOTPCodeDTO
+	if err := ctx.ShouldBindJSON(&body); err != nil || body.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	codes, err := uc.userUsecase.ConfirmTOTP(ctx.GetInt64("user_id"), body.Code)
+	if err != nil {
+		twoFactorError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"recovery_codes": codes,
+		"message":        "two-factor authentication enabled, store the recovery codes somewhere safe",
+	})
+}
+
+func (uc *UserController) DisableTOTP(ctx *gin.Context) {
+	var body TOTPCodeDTO
+	if err := ctx.ShouldBindJSON(&body); err != nil || body.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if err := uc.userUsecase.DisableTOTP(ctx.GetInt64("user_id"), body.Code, ctx.ClientIP()); err != nil {
+		twoFactorError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+func (uc *UserController) ResetTOTP(ctx *gin.Context) {
+	if err := uc.userUsecase.ResetTOTP(ctx.Param("id")); err != nil {
+		twoFactorError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "two-factor authentication reset"})
+}
+
+func twoFactorError(ctx *gin.Context, err error) {
+	switch err.Error() {
+	case "user not found":
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "invalid code":
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case "too many failed login attempts":
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case "two-factor authentication is already enabled", "two-factor authentication is not enabled", "two-factor setup has not been started":
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 func (uc *UserController) GetProfile(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	userID, err := strconv.ParseInt(idParam, 10, 64)
@@ -114,7 +323,12 @@ func (uc *UserController) GetProfile(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 		return
 	}
-	ctx.JSON(http.StatusOK, user)
+	counts, err := uc.followUsecase.GetFollowCounts(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, UserProfileResponse{User: user, FollowCounts: counts})
 }
 
 func (uc *UserController) Promote(ctx *gin.Context) {
@@ -139,6 +353,59 @@ func (uc *UserController) Demote(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "user demoted to user"})
 }
 
+func (uc *UserController) UnlockAccount(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "token required"})
+		return
+	}
+	if err := uc.userUsecase.UnlockAccount(token); err != nil {
+		if err.Error() == "could not unlock account" {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+func (uc *UserController) UnlockUser(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if err := uc.userUsecase.UnlockUser(id); err != nil {
+		if err.Error() == "user not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "user unlocked"})
+}
+
+func (uc *UserController) ListLockedUsers(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	users, total, err := uc.userUsecase.ListLockedUsers(page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	locked := make([]LockedUserResponse, 0, len(users))
+	for i := range users {
+		locked = append(locked, LockedUserResponse{User: &users[i], FailedLogins: users[i].FailedLogins, LockedUntil: users[i].LockedUntil})
+	}
+	ctx.JSON(http.StatusOK, gin.H{"users": locked, "meta": gin.H{"total": total, "page": page, "limit": limit}})
+}
+
 func (uc *UserController) UpdateProfile(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	userID, err := strconv.ParseInt(idParam, 10, 64)
@@ -153,12 +420,47 @@ func (uc *UserController) UpdateProfile(ctx *gin.Context) {
 	}
 	err = uc.userUsecase.UpdateUserProfile(userID, updates)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch err.Error() {
+		case "this username is already in use", "this email is already in use":
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case "invalid username", "invalid email format":
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			if strings.HasPrefix(err.Error(), "field ") {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if _, ok := updates["Email"]; ok {
+		ctx.JSON(http.StatusOK, gin.H{"message": "profile updated successfully, confirm the new email address with the link sent to it"})
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "profile updated successfully"})
 }
 
+func (uc *UserController) ConfirmEmailChange(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "token required"})
+		return
+	}
+	if err := uc.userUsecase.ConfirmEmailChange(token); err != nil {
+		switch err.Error() {
+		case "this email is already in use":
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case "could not update email":
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "email updated"})
+}
+
 func (uc *UserController) RefreshToken(ctx *gin.Context) {
 	authHeader := ctx.GetHeader("Authorization")
 	access, refresh, err := uc.userUsecase.RefreshToken(authHeader)
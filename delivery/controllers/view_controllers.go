@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// TrackViewDTO is optional. Pages should send document.referrer, as the
+// Referer of the request itself is the page doing the tracking.
+type TrackViewDTO struct {
+	Referrer string `json:"referrer"`
+}
+
+type ViewController struct {
+	viewUsecase domain.IViewUsecase
+}
+
+func NewViewController(vu domain.IViewUsecase) *ViewController {
+	return &ViewController{viewUsecase: vu}
+}
+
+// TrackView answers 200 whether or not the view was counted, so that
+// clients cannot tell how views are deduplicated.
+func (vc *ViewController) TrackView(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid blog id"})
+		return
+	}
+	var body TrackViewDTO
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+	if body.Referrer == "" {
+		body.Referrer = ctx.Request.Referer()
+	}
+	_, err = vc.viewUsecase.TrackView(ctx.Request.Context(), domain.BlogView{
+		BlogID:    id,
+		UserID:    ctx.GetInt64("user_id"),
+		ClientIP:  ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Referrer:  body.Referrer,
+	})
+	if err != nil {
+		if err.Error() == "blog not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to track view"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "view tracked"})
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type FinishPasskeyRegistrationDTO struct {
+	Name       string                             `json:"name"`
+	Credential domain.WebAuthnAttestationResponse `json:"credential"`
+}
+
+type WebAuthnController struct {
+	webAuthnUsecase domain.IWebAuthnUsecase
+}
+
+func NewWebAuthnController(wu domain.IWebAuthnUsecase) *WebAuthnController {
+	return &WebAuthnController{webAuthnUsecase: wu}
+}
+
+func (wc *WebAuthnController) BeginRegistration(ctx *gin.Context) {
+	options, err := wc.webAuthnUsecase.BeginRegistration(ctx.Request.Context(), ctx.GetInt64("user_id"))
+	if err != nil {
+		if err.Error() == "too many passkeys, remove unused ones first" {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"publicKey": options})
+}
+
+func (wc *WebAuthnController) FinishRegistration(ctx *gin.Context) {
+	var body FinishPasskeyRegistrationDTO
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	credential, err := wc.webAuthnUsecase.FinishRegistration(ctx.Request.Context(), ctx.GetInt64("user_id"), body.Name, &body.Credential)
+	if err != nil {
+		switch err.Error() {
+		case "passkey is already registered":
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case "could not save passkey":
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"data": credential})
+}
+
+func (wc *WebAuthnController) ListCredentials(ctx *gin.Context) {
+	credentials, err := wc.webAuthnUsecase.ListCredentials(ctx.Request.Context(), ctx.GetInt64("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch passkeys"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"passkeys": credentials})
+}
+
+func (wc *WebAuthnController) DeleteCredential(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid passkey id"})
+		return
+	}
+	if err := wc.webAuthnUsecase.DeleteCredential(ctx.Request.Context(), ctx.GetInt64("user_id"), id); err != nil {
+		if err.Error() == "passkey not found" {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "passkey removed"})
+}
+
+func (wc *WebAuthnController) BeginLogin(ctx *gin.Context) {
+	options, err := wc.webAuthnUsecase.BeginLogin(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"publicKey": options})
+}
+
+func (wc *WebAuthnController) FinishLogin(ctx *gin.Context) {
+	var body domain.WebAuthnAssertionResponse
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	result, err := wc.webAuthnUsecase.FinishLogin(ctx.Request.Context(), &body, ctx.ClientIP())
+	if err != nil {
+		switch err.Error() {
+		case "passkey login failed", "invalid or expired challenge":
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case "too many failed login attempts":
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	writeLoginResult(ctx, result)
+}
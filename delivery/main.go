@@ -1,10 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/blog-platform/delivery/routers"
+	"github.com/blog-platform/infrastructure"
 	"github.com/blog-platform/repositories"
+	"github.com/blog-platform/usecases"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -16,6 +25,69 @@ func main() {
 
 	repositories.ConnectDB()
 	// infrastructure.ConnectClient()
-	route := routers.Init(gin.Default())
-	route.Run()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	templates, err := infrastructure.NewEmailTemplates()
+	if err != nil {
+		log.Fatal("Failed to load email templates:", err)
+	}
+	transport, err := infrastructure.NewEmailTransport()
+	if err != nil {
+		log.Fatal("Failed to configure email transport:", err)
+	}
+	outbox := usecases.NewEmailOutboxWorker(repositories.NewOutboxRepository(repositories.DB), templates, transport)
+	go outbox.Run(ctx, 10*time.Second)
+
+	// one blog repository for the whole process, so that its cache is
+	// invalidated by every change
+	blogCache := repositories.NewBlogCache()
+	defer blogCache.Close()
+	blogs := repositories.NewBlogRepository(repositories.DB, blogCache)
+
+	// views, likes and comments are counted in memory and written every few seconds
+	views := usecases.NewViewTracker(blogs, repositories.NewAnalyticsRepository(repositories.DB), infrastructure.NewMemoryViewStore())
+	viewsDone := make(chan struct{})
+	go func() {
+		views.Run(ctx, 5*time.Second)
+		close(viewsDone)
+	}()
+
+	// trending scores are recalculated from the daily stats
+	go usecases.NewTrendingRanker(repositories.NewAnalyticsRepository(repositories.DB)).Run(ctx, 5*time.Minute)
+
+	engine := gin.Default()
+	if err := infrastructure.TrustProxies(engine); err != nil {
+		log.Fatal(err)
+	}
+	server := &http.Server{Addr: address(), Handler: routers.Init(engine, views, blogs)}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server failed:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop() // a second signal kills the process
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown: %v", err)
+	}
+	// write the views counted since the last flush, including those of the
+	// requests that just finished
+	<-viewsDone
+	if err := views.Flush(shutdownCtx); err != nil {
+		log.Printf("view tracker: %v", err)
+	}
+}
+
+// address listens on PORT like gin's Run, defaulting to 8080.
+func address() string {
+	if port := os.Getenv("PORT"); port != "" {
+		return ":" + port
+	}
+	return ":8080"
 }
@@ -0,0 +1,33 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/blog-platform/delivery/controllers"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/repositories"
+	"github.com/blog-platform/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+func AIUsageRoutes(group *gin.RouterGroup, blogs domain.IBlogRepository) {
+	DB := repositories.DB
+	ur := repositories.NewUserRepository(DB)
+	tr := repositories.NewTokenRepository(DB)
+	js := infrastructure.NewJWTInfrastructure([]byte(os.Getenv("JWT_ACCESS_SECRET")), []byte(os.Getenv("JWT_REFRESH_SECRET")), tr)
+	pu := usecases.NewPersonalAccessTokenUsecase(repositories.NewPersonalAccessTokenRepository(DB), ur)
+	ao := infrastructure.NewMiddleware(js, blogs, pu)
+	au := usecases.NewAIUsageUsecase(repositories.NewAIUsageRepository(DB), ur)
+	ac := controllers.NewAIUsageController(au)
+
+	adminRoutes := group.Group("/admin/ai")
+	adminRoutes.Use(ao.AuthMiddleware(), ao.AdminMiddleware())
+	{
+		adminRoutes.GET("/usage", ac.UsageByUser)
+		adminRoutes.GET("/usage/:user_id", ac.UsageForUser)
+		adminRoutes.GET("/quotas/:user_id", ac.GetQuota)
+		adminRoutes.PUT("/quotas/:user_id", ac.SetQuota)
+		adminRoutes.DELETE("/quotas/:user_id", ac.ClearQuota)
+	}
+}
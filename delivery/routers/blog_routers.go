@@ -1,46 +1,116 @@
 package routers
 
 import (
+	"log"
 	"os"
+	"time"
 
 	"github.com/blog-platform/delivery/controllers"
+	"github.com/blog-platform/domain"
 	"github.com/blog-platform/infrastructure"
 	"github.com/blog-platform/repositories"
 	"github.com/blog-platform/usecases"
 	"github.com/gin-gonic/gin"
 )
 
-func BlogRoutes(router *gin.RouterGroup) {
+func BlogRoutes(router *gin.RouterGroup, views domain.IViewUsecase, blogs domain.IBlogRepository) {
 
 	DB := repositories.DB
-	ur := repositories.NewBlogRepository(DB)
 	tr := repositories.NewTokenRepository(DB)
 	js := infrastructure.NewJWTInfrastructure([]byte(os.Getenv("JWT_ACCESS_SECRET")), []byte(os.Getenv("JWT_REFRESH_SECRET")), tr)
-	ao := infrastructure.NewMiddleware(js, ur)
-	ai := infrastructure.NewChatGPTAIService()
-	uu := usecases.NewBlogUsecase(ur, ai)
+	pu := usecases.NewPersonalAccessTokenUsecase(repositories.NewPersonalAccessTokenRepository(DB), repositories.NewUserRepository(DB))
+	ao := infrastructure.NewMiddleware(js, blogs, pu)
+	var ap domain.IAIProvider
+	if provider, err := infrastructure.NewAIProviderFromEnv(); err != nil {
+		log.Printf("ai features disabled: %v", err)
+	} else {
+		au := usecases.NewAIUsageUsecase(repositories.NewAIUsageRepository(DB), repositories.NewUserRepository(DB))
+		ap = infrastructure.NewMeteredAIProvider(provider, au)
+	}
+	ai := infrastructure.NewAIService(ap)
+	nu := usecases.NewNotificationUsecase(repositories.NewNotificationRepository(DB), repositories.NewFollowRepository(DB))
+	su, indexer, embedder := semanticSearch(blogs)
+	uu := usecases.NewBlogUsecase(blogs, ai, nu, indexer, usecases.NewPromptTemplateUsecase(repositories.NewPromptTemplateRepository(DB)), views)
 	bc := controllers.NewBlogController(uu)
+	sc := controllers.NewSemanticSearchController(su)
+	ac := controllers.NewAskController(usecases.NewAskUsecase(su, embedder, ai))
+	vc := controllers.NewViewController(views)
+	anc := controllers.NewAnalyticsController(usecases.NewAnalyticsUsecase(repositories.NewAnalyticsRepository(DB), blogs))
+
+	// views are counted for anonymous readers too
+	router.POST("/blogs/:id/view", ao.OptionalAuthMiddleware(), viewRateLimit(), vc.TrackView)
 
 	blogRoutes := router.Group("/blogs")
 	blogRoutes.Use(ao.AuthMiddleware())
+	writeLimit := writeRateLimit()
+	aiLimit := aiRateLimit()
 	{
-		blogRoutes.POST("", bc.CreateBlog)
+		blogRoutes.POST("", writeLimit, bc.CreateBlog)
 		blogRoutes.GET("/:id", bc.GetBlogByID)
 		blogRoutes.GET("", bc.GetBlogs)
 		blogRoutes.DELETE("/:id", ao.BlogAuthorMiddleware(), bc.DeleteBlog)
 		blogRoutes.PATCH("/:id", ao.BlogAuthorMiddleware(), bc.UpdateBlog)
 		blogRoutes.GET("/paginated", bc.FetchPaginatedBlogs)
 		blogRoutes.GET("/search", bc.SearchBlogs)
-		blogRoutes.POST("/:id/view", bc.TrackView)
+		blogRoutes.GET("/trending", bc.TrendingBlogs)
+		blogRoutes.GET("/semantic-search", sc.SemanticSearch)
+		blogRoutes.GET("/:id/related", sc.RelatedBlogs)
+		blogRoutes.POST("/ask", aiLimit, ac.Ask)
 		blogRoutes.POST("/:id/like", bc.LikeBlog)
 		blogRoutes.DELETE("/:id/like", bc.UnlikeBlog)
 		blogRoutes.GET("/:id/popularity", bc.GetPopularity)
-		blogRoutes.POST("/ideas", bc.GenerateBlogIdeas)
-		blogRoutes.POST("/improve", bc.SuggestBlogImprovements)
+		blogRoutes.GET("/analytics", anc.AuthorAnalytics)
+		blogRoutes.GET("/:id/analytics", ao.BlogAuthorMiddleware(), anc.BlogAnalytics)
+		blogRoutes.POST("/ideas", aiLimit, bc.GenerateBlogIdeas)
+		blogRoutes.POST("/improve", aiLimit, bc.SuggestBlogImprovements)
+		blogRoutes.POST("/ideas/stream", aiLimit, bc.StreamBlogIdeas)
+		blogRoutes.POST("/improve/stream", aiLimit, bc.StreamBlogImprovements)
+		blogRoutes.POST("/:id/insights", ao.BlogAuthorMiddleware(), aiLimit, bc.RegenerateBlogInsights)
 		blogRoutes.GET("/filter", bc.FilterBlogs)
 		// comments
-		blogRoutes.POST("/:id/comments", bc.AddComment)
+		blogRoutes.POST("/:id/comments", writeLimit, bc.AddComment)
 		blogRoutes.GET("/:id/comments", bc.ListComments)
+		blogRoutes.POST("/:id/comments/:comment_id/replies", writeLimit, bc.ReplyToComment)
+	}
+
+	// comments held by moderation
+	moderationRoutes := router.Group("/admin/comments")
+	moderationRoutes.Use(ao.AuthMiddleware(), ao.AdminMiddleware())
+	{
+		moderationRoutes.GET("/held", bc.ListHeldComments)
+		moderationRoutes.POST("/:comment_id/approve", bc.ApproveComment)
+		moderationRoutes.POST("/:comment_id/reject", bc.RejectComment)
 	}
 
+	adminBlogRoutes := router.Group("/admin/blogs")
+	adminBlogRoutes.Use(ao.AuthMiddleware(), ao.AdminMiddleware())
+	adminBlogRoutes.POST("/reindex", sc.Reindex)
+}
+
+// semanticSearch builds the embeddings pipeline from EMBEDDING_PROVIDER and
+// VECTOR_INDEX (memory or pgvector, default memory). The indexer and embedder
+// are nil when semantic search is disabled, so posts are not embedded.
+// Embeddings are cached for a day, as questions and their candidate
+// paragraphs repeat.
+func semanticSearch(br domain.IBlogRepository) (domain.ISemanticSearchUsecase, domain.IBlogIndexer, domain.IEmbeddingProvider) {
+	provider, err := infrastructure.NewEmbeddingProviderFromEnv()
+	if err != nil {
+		log.Printf("semantic search disabled: %v", err)
+		return usecases.NewSemanticSearchUsecase(br, nil, nil), nil, nil
+	}
+	embedder := infrastructure.NewCachedEmbeddingProvider(provider, 24*time.Hour)
+
+	var index domain.IVectorIndex
+	switch value := os.Getenv("VECTOR_INDEX"); value {
+	case "", "memory":
+		index = infrastructure.NewMemoryVectorIndex(repositories.NewEmbeddingRepository(repositories.DB))
+	case "pgvector":
+		if index, err = repositories.NewPgvectorIndex(repositories.DB); err != nil {
+			log.Fatalf("VECTOR_INDEX: %v", err)
+		}
+	default:
+		log.Fatalf("VECTOR_INDEX: unknown index '%s'", value)
+	}
+	su := usecases.NewSemanticSearchUsecase(br, embedder, index)
+	return su, su, embedder
 }
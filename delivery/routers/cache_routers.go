@@ -0,0 +1,28 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/blog-platform/delivery/controllers"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/repositories"
+	"github.com/blog-platform/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+func CacheRoutes(group *gin.RouterGroup, blogs domain.IBlogRepository) {
+	DB := repositories.DB
+	ur := repositories.NewUserRepository(DB)
+	tr := repositories.NewTokenRepository(DB)
+	js := infrastructure.NewJWTInfrastructure([]byte(os.Getenv("JWT_ACCESS_SECRET")), []byte(os.Getenv("JWT_REFRESH_SECRET")), tr)
+	pu := usecases.NewPersonalAccessTokenUsecase(repositories.NewPersonalAccessTokenRepository(DB), ur)
+	ao := infrastructure.NewMiddleware(js, blogs, pu)
+	cc := controllers.NewCacheController(infrastructure.CacheStats)
+
+	adminRoutes := group.Group("/admin/cache")
+	adminRoutes.Use(ao.AuthMiddleware(), ao.AdminMiddleware())
+	{
+		adminRoutes.GET("", cc.Stats)
+	}
+}
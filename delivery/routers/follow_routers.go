@@ -0,0 +1,36 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/blog-platform/delivery/controllers"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/repositories"
+	"github.com/blog-platform/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+func FollowRoutes(group *gin.RouterGroup, blogs domain.IBlogRepository) {
+	DB := repositories.DB
+	fr := repositories.NewFollowRepository(DB)
+	ur := repositories.NewUserRepository(DB)
+	tr := repositories.NewTokenRepository(DB)
+	js := infrastructure.NewJWTInfrastructure([]byte(os.Getenv("JWT_ACCESS_SECRET")), []byte(os.Getenv("JWT_REFRESH_SECRET")), tr)
+	pu := usecases.NewPersonalAccessTokenUsecase(repositories.NewPersonalAccessTokenRepository(DB), ur)
+	ao := infrastructure.NewMiddleware(js, blogs, pu)
+	nu := usecases.NewNotificationUsecase(repositories.NewNotificationRepository(DB), fr)
+	fu := usecases.NewFollowUsecase(fr, ur, nu)
+	fc := controllers.NewFollowController(fu)
+
+	group.GET("/feed", ao.AuthMiddleware(), fc.GetFeed)
+
+	followRoutes := group.Group("/users")
+	followRoutes.Use(ao.AuthMiddleware())
+	{
+		followRoutes.POST("/:id/follow", fc.Follow)
+		followRoutes.DELETE("/:id/follow", fc.Unfollow)
+		followRoutes.GET("/:id/followers", fc.ListFollowers)
+		followRoutes.GET("/:id/following", fc.ListFollowing)
+	}
+}
@@ -0,0 +1,35 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/blog-platform/delivery/controllers"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/repositories"
+	"github.com/blog-platform/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+func NotificationRoutes(group *gin.RouterGroup, blogs domain.IBlogRepository) {
+	DB := repositories.DB
+	nr := repositories.NewNotificationRepository(DB)
+	fr := repositories.NewFollowRepository(DB)
+	tr := repositories.NewTokenRepository(DB)
+	js := infrastructure.NewJWTInfrastructure([]byte(os.Getenv("JWT_ACCESS_SECRET")), []byte(os.Getenv("JWT_REFRESH_SECRET")), tr)
+	pu := usecases.NewPersonalAccessTokenUsecase(repositories.NewPersonalAccessTokenRepository(DB), repositories.NewUserRepository(DB))
+	ao := infrastructure.NewMiddleware(js, blogs, pu)
+	nu := usecases.NewNotificationUsecase(nr, fr)
+	nc := controllers.NewNotificationController(nu)
+
+	notificationRoutes := group.Group("/notifications")
+	notificationRoutes.Use(ao.AuthMiddleware())
+	{
+		notificationRoutes.GET("", nc.ListNotifications)
+		notificationRoutes.GET("/unread-count", nc.UnreadCount)
+		notificationRoutes.PATCH("/:id/read", nc.MarkRead)
+		notificationRoutes.POST("/read-all", nc.MarkAllRead)
+		notificationRoutes.GET("/preferences", nc.GetPreferences)
+		notificationRoutes.PUT("/preferences", nc.UpdatePreferences)
+	}
+}
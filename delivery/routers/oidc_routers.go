@@ -0,0 +1,41 @@
+package routers
+
+import (
+	"log"
+	"os"
+
+	"github.com/blog-platform/delivery/controllers"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/repositories"
+	"github.com/blog-platform/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+func OIDCRoutes(group *gin.RouterGroup, blogs domain.IBlogRepository) {
+	providers, err := infrastructure.NewOIDCProvidersFromEnv()
+	if err != nil {
+		log.Printf("oidc login disabled: %v", err)
+		return
+	}
+	if len(providers) == 0 {
+		return
+	}
+
+	DB := repositories.DB
+	ur := repositories.NewUserRepository(DB)
+	tr := repositories.NewTokenRepository(DB)
+	js := infrastructure.NewJWTInfrastructure([]byte(os.Getenv("JWT_ACCESS_SECRET")), []byte(os.Getenv("JWT_REFRESH_SECRET")), tr)
+	la := repositories.NewLoginAttemptRepository(DB)
+	uu := usecases.NewUserUsecase(ur, repositories.NewOutboxRepository(DB), la, infrastructure.NewPasswordInfrastructure(), js, tr, infrastructure.NewTOTPService(), passwordPolicy())
+	ou := usecases.NewOIDCUsecase(providers, repositories.NewOIDCRepository(DB), ur, uu)
+	oc := controllers.NewOIDCController(ou)
+	pu := usecases.NewPersonalAccessTokenUsecase(repositories.NewPersonalAccessTokenRepository(DB), ur)
+	ao := infrastructure.NewMiddleware(js, blogs, pu)
+
+	authLimit := authRateLimit()
+
+	group.GET("/auth/oidc/:provider/login", authLimit, oc.Login)
+	group.GET("/auth/oidc/:provider/callback", authLimit, oc.Callback)
+	group.POST("/auth/oidc/:provider/link", authLimit, ao.AuthMiddleware(), ao.SessionOnlyMiddleware(), oc.Link)
+}
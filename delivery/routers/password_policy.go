@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"log"
+	"sync"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+)
+
+// passwordPolicy is read once, as the deny-list can be large, and shared by
+// every route group that builds a UserUsecase.
+var passwordPolicy = sync.OnceValue(func() domain.IPasswordPolicy {
+	policy, err := infrastructure.NewPasswordPolicyFromEnv()
+	if err != nil {
+		log.Fatalf("password policy: %v", err)
+	}
+	return policy
+})
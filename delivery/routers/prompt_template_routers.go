@@ -0,0 +1,30 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/blog-platform/delivery/controllers"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/repositories"
+	"github.com/blog-platform/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+func PromptTemplateRoutes(group *gin.RouterGroup, blogs domain.IBlogRepository) {
+	DB := repositories.DB
+	tr := repositories.NewTokenRepository(DB)
+	js := infrastructure.NewJWTInfrastructure([]byte(os.Getenv("JWT_ACCESS_SECRET")), []byte(os.Getenv("JWT_REFRESH_SECRET")), tr)
+	pu := usecases.NewPersonalAccessTokenUsecase(repositories.NewPersonalAccessTokenRepository(DB), repositories.NewUserRepository(DB))
+	ao := infrastructure.NewMiddleware(js, blogs, pu)
+	pc := controllers.NewPromptTemplateController(usecases.NewPromptTemplateUsecase(repositories.NewPromptTemplateRepository(DB)))
+
+	adminRoutes := group.Group("/admin/ai/prompts")
+	adminRoutes.Use(ao.AuthMiddleware(), ao.AdminMiddleware())
+	{
+		adminRoutes.GET("", pc.ListTemplates)
+		adminRoutes.GET("/:name", pc.ListVersions)
+		adminRoutes.POST("/:name/versions", pc.SaveVersion)
+		adminRoutes.POST("/:name/versions/:version/activate", pc.ActivateVersion)
+	}
+}
@@ -0,0 +1,58 @@
+package routers
+
+import (
+	"log"
+	"os"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/gin-gonic/gin"
+)
+
+// limiter is shared by every route group so buckets survive across groups.
+// The in-memory store only limits a single instance.
+var limiter = infrastructure.NewRateLimiter(infrastructure.NewMemoryRateLimitStore())
+
+// rateLimit returns a middleware for the policy, reading its limit from env
+// (e.g. RATE_LIMIT_AUTH=5/1m) and falling back to def.
+func rateLimit(name, env, def, keyBy string) gin.HandlerFunc {
+	spec := os.Getenv(env)
+	if spec == "" {
+		spec = def
+	}
+	limit, err := infrastructure.ParseRateLimit(spec)
+	if err != nil {
+		log.Printf("%s: %v, using %s", env, err, def)
+		limit = mustParseRateLimit(def)
+	}
+	return limiter.Limit(infrastructure.RateLimitPolicy{Name: name, Limit: limit, KeyBy: keyBy})
+}
+
+func mustParseRateLimit(spec string) domain.RateLimit {
+	limit, err := infrastructure.ParseRateLimit(spec)
+	if err != nil {
+		panic(err)
+	}
+	return limit
+}
+
+// authRateLimit guards unauthenticated credential endpoints per client IP.
+func authRateLimit() gin.HandlerFunc {
+	return rateLimit("auth", "RATE_LIMIT_AUTH", "10/1m", infrastructure.RateLimitByIP)
+}
+
+// writeRateLimit guards content creation per user.
+func writeRateLimit() gin.HandlerFunc {
+	return rateLimit("write", "RATE_LIMIT_WRITE", "30/1m", infrastructure.RateLimitByUser)
+}
+
+// viewRateLimit guards view tracking, which is open to anonymous readers, per
+// client IP.
+func viewRateLimit() gin.HandlerFunc {
+	return rateLimit("view", "RATE_LIMIT_VIEW", "60/1m", infrastructure.RateLimitByIP)
+}
+
+// aiRateLimit guards the paid AI endpoints per user.
+func aiRateLimit() gin.HandlerFunc {
+	return rateLimit("ai", "RATE_LIMIT_AI", "10/1h", infrastructure.RateLimitByUser)
+}
@@ -1,11 +1,23 @@
 package routers
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
 
-func Init(gin *gin.Engine) *gin.Engine {
+// Init registers every route. views is shared with main, which flushes it,
+// and blogs is shared by every route so that they use one cache.
+func Init(gin *gin.Engine, views domain.IViewUsecase, blogs domain.IBlogRepository) *gin.Engine {
 	freeRoutes := gin.Group("")
 
-	AuthRoutes(freeRoutes)
-	BlogRoutes(freeRoutes)
+	AuthRoutes(freeRoutes, blogs)
+	BlogRoutes(freeRoutes, views, blogs)
+	FollowRoutes(freeRoutes, blogs)
+	NotificationRoutes(freeRoutes, blogs)
+	OIDCRoutes(freeRoutes, blogs)
+	WebAuthnRoutes(freeRoutes, blogs)
+	AIUsageRoutes(freeRoutes, blogs)
+	PromptTemplateRoutes(freeRoutes, blogs)
+	CacheRoutes(freeRoutes, blogs)
 	return gin
 }
@@ -4,30 +4,42 @@ import (
 	"os"
 
 	"github.com/blog-platform/delivery/controllers"
+	"github.com/blog-platform/domain"
 	"github.com/blog-platform/infrastructure"
 	"github.com/blog-platform/repositories"
 	"github.com/blog-platform/usecases"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRoutes(group *gin.RouterGroup) {
+func AuthRoutes(group *gin.RouterGroup, blogs domain.IBlogRepository) {
 	DB := repositories.DB
 	ur := repositories.NewUserRepository(DB)
-	ei := infrastructure.NewSMTPEmailService()
+	or := repositories.NewOutboxRepository(DB)
 	pi := infrastructure.NewPasswordInfrastructure()
 	tr := repositories.NewTokenRepository(DB)
 	js := infrastructure.NewJWTInfrastructure([]byte(os.Getenv("JWT_ACCESS_SECRET")), []byte(os.Getenv("JWT_REFRESH_SECRET")), tr)
-	uu := usecases.NewUserUsecase(ur, ei, pi, js, tr)
-	uc := controllers.NewUserController(uu)
-	ao := infrastructure.NewMiddleware(js, repositories.NewBlogRepository(DB))
+	la := repositories.NewLoginAttemptRepository(DB)
+	uu := usecases.NewUserUsecase(ur, or, la, pi, js, tr, infrastructure.NewTOTPService(), passwordPolicy())
+	fr := repositories.NewFollowRepository(DB)
+	fu := usecases.NewFollowUsecase(fr, ur, usecases.NewNotificationUsecase(repositories.NewNotificationRepository(DB), fr))
+	uc := controllers.NewUserController(uu, fu)
+	pu := usecases.NewPersonalAccessTokenUsecase(repositories.NewPersonalAccessTokenRepository(DB), ur)
+	ao := infrastructure.NewMiddleware(js, blogs, pu)
+	ac := controllers.NewAccessTokenController(pu)
 
-	group.POST("/register", uc.Register)
-	group.POST("/login", uc.Login)
+	authLimit := authRateLimit()
+
+	group.POST("/register", authLimit, uc.Register)
+	group.POST("/login", authLimit, uc.Login)
+	group.POST("/login/mfa", authLimit, uc.VerifyMFA)
+	group.POST("/login/magic", authLimit, uc.RequestMagicLink)
+	group.GET("/login/magic/verify", uc.MagicLinkPage)
+	group.POST("/login/magic/verify", authLimit, uc.VerifyMagicLink)
 	group.POST("/token/refresh", uc.RefreshToken)
-	group.POST("/logout", ao.AuthMiddleware(), uc.Logout)
-	group.POST("/reset-password", ao.AuthMiddleware(), uc.ResetPassword)
-	group.POST("/forgot-password", uc.ForgotPassword)
-	group.POST("/password/:id/update", ao.AuthMiddleware(), uc.UpdatePasswordDirect)
+	group.POST("/logout", ao.AuthMiddleware(), ao.SessionOnlyMiddleware(), uc.Logout)
+	group.POST("/reset-password", ao.AuthMiddleware(), ao.SessionOnlyMiddleware(), uc.ResetPassword)
+	group.POST("/forgot-password", authLimit, uc.ForgotPassword)
+	group.POST("/password/:id/update", ao.AuthMiddleware(), ao.SessionOnlyMiddleware(), uc.UpdatePasswordDirect)
 	group.GET("/users/:id", ao.AuthMiddleware(), ao.AccountOwnerMiddleware(), uc.GetProfile)
 
 	adminRoutes := group.Group("/users")
@@ -35,7 +47,28 @@ func AuthRoutes(group *gin.RouterGroup) {
 	{
 		adminRoutes.PUT("/:id/promote", uc.Promote)
 		adminRoutes.PUT("/:id/demote", uc.Demote)
+		adminRoutes.GET("/locked", uc.ListLockedUsers)
+		adminRoutes.PUT("/:id/unlock", uc.UnlockUser)
+		adminRoutes.DELETE("/:id/2fa", uc.ResetTOTP)
 	}
 
-	group.PATCH("/users/:id", ao.AuthMiddleware(), uc.UpdateProfile)
+	group.PATCH("/users/:id", ao.AuthMiddleware(), ao.SessionOnlyMiddleware(), ao.AccountOwnerMiddleware(), uc.UpdateProfile)
+	group.GET("/email/confirm", uc.ConfirmEmailChange)
+	group.GET("/unlock", authLimit, uc.UnlockAccount)
+
+	twoFactorRoutes := group.Group("/2fa/totp")
+	twoFactorRoutes.Use(ao.AuthMiddleware(), ao.SessionOnlyMiddleware())
+	{
+		twoFactorRoutes.POST("/setup", uc.SetupTOTP)
+		twoFactorRoutes.POST("/confirm", uc.ConfirmTOTP)
+		twoFactorRoutes.POST("/disable", authLimit, uc.DisableTOTP)
+	}
+
+	tokenRoutes := group.Group("/tokens")
+	tokenRoutes.Use(ao.AuthMiddleware(), ao.SessionOnlyMiddleware())
+	{
+		tokenRoutes.POST("", ac.Create)
+		tokenRoutes.GET("", ac.List)
+		tokenRoutes.DELETE("/:id", ac.Revoke)
+	}
 }
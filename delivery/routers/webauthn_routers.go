@@ -0,0 +1,39 @@
+package routers
+
+import (
+	"os"
+
+	"github.com/blog-platform/delivery/controllers"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/repositories"
+	"github.com/blog-platform/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+func WebAuthnRoutes(group *gin.RouterGroup, blogs domain.IBlogRepository) {
+	DB := repositories.DB
+	ur := repositories.NewUserRepository(DB)
+	tr := repositories.NewTokenRepository(DB)
+	la := repositories.NewLoginAttemptRepository(DB)
+	js := infrastructure.NewJWTInfrastructure([]byte(os.Getenv("JWT_ACCESS_SECRET")), []byte(os.Getenv("JWT_REFRESH_SECRET")), tr)
+	uu := usecases.NewUserUsecase(ur, repositories.NewOutboxRepository(DB), la, infrastructure.NewPasswordInfrastructure(), js, tr, infrastructure.NewTOTPService(), passwordPolicy())
+	pu := usecases.NewPersonalAccessTokenUsecase(repositories.NewPersonalAccessTokenRepository(DB), ur)
+	ao := infrastructure.NewMiddleware(js, blogs, pu)
+	wu := usecases.NewWebAuthnUsecase(repositories.NewWebAuthnRepository(DB), ur, la, infrastructure.NewWebAuthnService(), uu)
+	wc := controllers.NewWebAuthnController(wu)
+
+	authLimit := authRateLimit()
+
+	group.POST("/login/passkey/begin", authLimit, wc.BeginLogin)
+	group.POST("/login/passkey/finish", authLimit, wc.FinishLogin)
+
+	passkeyRoutes := group.Group("/passkeys")
+	passkeyRoutes.Use(ao.AuthMiddleware(), ao.SessionOnlyMiddleware())
+	{
+		passkeyRoutes.POST("/register/begin", wc.BeginRegistration)
+		passkeyRoutes.POST("/register/finish", wc.FinishRegistration)
+		passkeyRoutes.GET("", wc.ListCredentials)
+		passkeyRoutes.DELETE("/:id", wc.DeleteCredential)
+	}
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+type AIMessage struct {
+	Role    string `json:"role"` // system, user or assistant
+	Content string `json:"content"`
+}
+
+type AICompletionRequest struct {
+	Messages []AIMessage
+	// Model overrides the provider's configured model when set
+	Model     string
+	MaxTokens int
+	// ResponseSchema asks for a JSON answer matching a JSON Schema
+	ResponseSchema *AIResponseSchema
+	// Feature names the helper making the request in the usage ledger
+	Feature string
+	// NoCache asks for a fresh answer even if the prompt was seen before
+	NoCache bool
+}
+
+type AIResponseSchema struct {
+	Name   string
+	Schema json.RawMessage
+}
+
+// ErrAIQuotaExceeded is returned for callers over their daily token quota.
+var ErrAIQuotaExceeded = errors.New("AI daily quota exceeded")
+
+type AICompletion struct {
+	Text             string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// BlogInsights are the structured AI helpers stored on a post.
+type BlogInsights struct {
+	Summary string   `json:"summary"`
+	Excerpt string   `json:"excerpt"`
+	Tags    []string `json:"tags"`
+}
+
+// BlogChunk is a run of paragraphs of a post, retrieved as context for
+// answering a question. Paragraphs are numbered from 1.
+type BlogChunk struct {
+	BlogID         int64
+	Title          string
+	ParagraphStart int
+	ParagraphEnd   int
+	Text           string
+}
+
+// BlogAnswer answers a reader's question from the blog's own posts.
+type BlogAnswer struct {
+	Answer string `json:"answer"`
+	// Answered is false when the posts do not cover the question
+	Answered  bool           `json:"answered"`
+	Citations []BlogCitation `json:"citations"`
+}
+
+// BlogCitation points to the paragraphs an answer is based on.
+type BlogCitation struct {
+	BlogID         int64  `json:"blog_id"`
+	Title          string `json:"title"`
+	ParagraphStart int    `json:"paragraph_start"`
+	ParagraphEnd   int    `json:"paragraph_end"`
+	Quote          string `json:"quote"` // the start of the cited text
+}
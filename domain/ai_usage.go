@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AI features recorded in the usage ledger
+const (
+	AIFeatureIdeas      = "ideas"
+	AIFeatureImprove    = "improve"
+	AIFeatureInsights   = "insights"
+	AIFeatureModeration = "moderation"
+	AIFeatureAsk        = "ask"
+)
+
+// AIUnlimited as a daily token quota lifts the limit.
+const AIUnlimited = -1
+
+// AIUsage is one ledger entry per AI request. Cached answers are recorded
+// with their token counts but no cost and do not count against quotas.
+type AIUsage struct {
+	ID               int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID           int64     `gorm:"index:idx_ai_usages_user_created,priority:1" json:"user_id"` // 0 for system requests
+	Feature          string    `gorm:"type:varchar(50)" json:"feature"`
+	Provider         string    `gorm:"type:varchar(50)" json:"provider"`
+	Model            string    `gorm:"type:varchar(100)" json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"` // estimated from AI_PRICING
+	Cached           bool      `json:"cached"`
+	CreatedAt        time.Time `gorm:"index:idx_ai_usages_user_created,priority:2;index" json:"created_at"` // auto set on insert
+}
+
+// AIQuota overrides the daily token quota of a user's role.
+type AIQuota struct {
+	UserID      int64     `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	DailyTokens int64     `json:"daily_tokens"` // AIUnlimited for no limit
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName keeps the plural, which GORM does not form for "quota".
+func (AIQuota) TableName() string {
+	return "ai_quotas"
+}
+
+// AIUsageSummary aggregates the ledger for a user, a day or both.
+type AIUsageSummary struct {
+	UserID           int64   `json:"user_id,omitempty"`
+	Day              string  `json:"day,omitempty"` // YYYY-MM-DD in UTC
+	Requests         int64   `json:"requests"`
+	CachedRequests   int64   `json:"cached_requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// AIQuotaStatus is a user's quota for the current UTC day.
+type AIQuotaStatus struct {
+	UserID      int64     `json:"user_id"`
+	DailyTokens int64     `json:"daily_tokens"` // AIUnlimited for no limit
+	Override    bool      `json:"override"`     // set per user rather than by role
+	UsedTokens  int64     `json:"used_tokens"`
+	ResetsAt    time.Time `json:"resets_at"`
+}
+
+// AICaller is who an AI request is made for, used for quotas and the
+// usage ledger.
+type AICaller struct {
+	UserID int64
+	Role   string
+}
+
+type aiCallerKey struct{}
+
+// WithAICaller attaches the caller to ctx for the AI provider.
+func WithAICaller(ctx context.Context, caller AICaller) context.Context {
+	return context.WithValue(ctx, aiCallerKey{}, caller)
+}
+
+// AICallerFrom returns the caller attached by WithAICaller, if any.
+func AICallerFrom(ctx context.Context) (AICaller, bool) {
+	caller, ok := ctx.Value(aiCallerKey{}).(AICaller)
+	return caller, ok
+}
@@ -0,0 +1,93 @@
+package domain
+
+import "time"
+
+// DirectReferrer stands for views that came without a Referer header.
+const DirectReferrer = "direct"
+
+// BlogDailyStats is the activity of a post on one UTC day. Rows are written
+// in increments by the view tracker.
+type BlogDailyStats struct {
+	BlogID        int64     `gorm:"primaryKey;autoIncrement:false"`
+	Day           time.Time `gorm:"primaryKey;type:date"`
+	Views         int
+	UniqueViewers int
+	Likes         int // likes minus unlikes
+	Comments      int // published comments and replies
+}
+
+func (BlogDailyStats) TableName() string {
+	return "blog_daily_stats"
+}
+
+// BlogReferrerStats counts the views of a post per referring site and day.
+type BlogReferrerStats struct {
+	BlogID   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Day      time.Time `gorm:"primaryKey;type:date"`
+	Referrer string    `gorm:"primaryKey;type:varchar(100)"` // host, or DirectReferrer
+	Views    int
+}
+
+func (BlogReferrerStats) TableName() string {
+	return "blog_referrer_stats"
+}
+
+// ActivityCounts are the totals of a post, an author or a day.
+type ActivityCounts struct {
+	Views         int `json:"views"`
+	UniqueViewers int `json:"unique_viewers"`
+	Likes         int `json:"likes"`
+	Comments      int `json:"comments"`
+}
+
+// Add adds the counts of a day.
+func (a *ActivityCounts) Add(s *BlogDailyStats) {
+	a.Views += s.Views
+	a.UniqueViewers += s.UniqueViewers
+	a.Likes += s.Likes
+	a.Comments += s.Comments
+}
+
+type ReferrerCount struct {
+	Referrer string `json:"referrer"`
+	Views    int    `json:"views"`
+}
+
+type DayStats struct {
+	Day string `json:"day"` // YYYY-MM-DD in UTC
+	ActivityCounts
+	Referrers []ReferrerCount `json:"referrers,omitempty"` // most views first
+}
+
+// BlogAnalytics is the activity of a post over a period, with every day of
+// the period listed.
+type BlogAnalytics struct {
+	BlogID    int64           `json:"blog_id"`
+	Title     string          `json:"title"`
+	From      string          `json:"from"`
+	To        string          `json:"to"` // inclusive
+	Totals    ActivityCounts  `json:"totals"`
+	Days      []DayStats      `json:"days"`
+	Referrers []ReferrerCount `json:"referrers"`
+}
+
+// PostStats is the activity of one of an author's posts. Only days with
+// activity are listed.
+type PostStats struct {
+	BlogID int64  `json:"blog_id"`
+	Title  string `json:"title"`
+	ActivityCounts
+	Days []DayStats `json:"days"`
+}
+
+// AuthorAnalytics sums the activity of an author's posts over a period.
+// Unique viewers are summed per post, so a reader of two posts counts twice.
+type AuthorAnalytics struct {
+	AuthorID  int64           `json:"author_id"`
+	From      string          `json:"from"`
+	To        string          `json:"to"` // inclusive
+	Totals    ActivityCounts  `json:"totals"`
+	Days      []DayStats      `json:"days"`
+	Posts     []PostStats     `json:"posts"` // most views first
+	Referrers []ReferrerCount `json:"referrers"`
+}
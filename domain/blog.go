@@ -13,11 +13,28 @@ type Blog struct {
 	ViewCount int       `json:"view_count"`
 	Likes     int       `json:"likes"`
 	Dislikes  int       `json:"dislikes"`
-	UserID    int64     `json:"user_id"`                                        // Foreign key column
-	User      User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // GORM relation
+	UserID    int64     `gorm:"index:idx_blogs_user_created,priority:1" json:"user_id"` // Foreign key column
+	User      User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`         // GORM relation
 	Tags      []Tag     `gorm:"many2many:tag_blogs;" json:"tags"`
+	CreatedAt time.Time `gorm:"index:idx_blogs_user_created,priority:2,sort:desc" json:"created_at"` // auto set on insert
+	UpdatedAt time.Time `json:"updated_at"`                                                          // auto set on update
+
+	// AI generated; see BlogInsights
+	Summary             string     `gorm:"type:text" json:"summary,omitempty"`
+	Excerpt             string     `gorm:"type:varchar(300)" json:"excerpt,omitempty"`
+	SuggestedTags       []string   `gorm:"type:text;serializer:json" json:"suggested_tags,omitempty"`
+	InsightsGeneratedAt *time.Time `json:"insights_generated_at,omitempty"`
+}
+
+// BlogLike is one user's like of a post. Blog.Likes is kept in step with the
+// rows, and the unique (blog, user) index makes liking twice a no-op.
+type BlogLike struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	BlogID    int64     `gorm:"uniqueIndex:idx_blog_likes_pair,priority:1;not null" json:"blog_id"`
+	Blog      Blog      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	UserID    int64     `gorm:"uniqueIndex:idx_blog_likes_pair,priority:2;index;not null" json:"user_id"`
+	User      User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 	CreatedAt time.Time `json:"created_at"` // auto set on insert
-	UpdatedAt time.Time `json:"updated_at"` // auto set on update
 }
 
 type BlogFilter struct {
@@ -25,4 +42,6 @@ type BlogFilter struct {
 	UserID        *int64
 	Limit         int
 	Offset        int
+	Sort          string // a BlogSort constant, or "" for insertion order
+	Window        string // trending window for BlogSortTrending
 }
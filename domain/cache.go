@@ -0,0 +1,14 @@
+package domain
+
+// CacheStats are the counters of the in-memory caches sharing a name, since
+// the process started.
+type CacheStats struct {
+	Name          string `json:"name"`
+	Entries       int    `json:"entries"`
+	Capacity      int    `json:"capacity"`
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Evictions     uint64 `json:"evictions"`     // least recently used entries dropped for room
+	Expirations   uint64 `json:"expirations"`   // entries dropped after their TTL
+	Invalidations uint64 `json:"invalidations"` // entries deleted by key, tag or Clear
+}
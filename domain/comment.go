@@ -13,6 +13,36 @@ type Comment struct {
 	User      User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // GORM relation
 	BlogID    int64     `json:"blog_id"`                                        // Foreign key column
 	Blog      Blog      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // GORM relation
+	ParentID  *int64    `gorm:"index" json:"parent_id,omitempty"`               // set on replies
 	CreatedAt time.Time `json:"created_at"`                                     // auto set on insert
 	UpdatedAt time.Time `json:"updated_at"`                                     // auto set on update
+
+	Status           string     `gorm:"type:varchar(20);default:published;index" json:"status"`
+	ModerationLabel  string     `gorm:"type:varchar(20)" json:"moderation_label,omitempty"`
+	ModerationScore  float64    `json:"moderation_score,omitempty"`
+	ModerationReason string     `gorm:"type:varchar(500)" json:"moderation_reason,omitempty"`
+	ReviewedBy       *int64     `json:"reviewed_by,omitempty"`
+	ReviewedAt       *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// Comment statuses; only published comments are listed and notified about.
+const (
+	CommentPublished = "published"
+	CommentHeld      = "held" // waiting for review
+	CommentRejected  = "rejected"
+)
+
+// Moderation labels
+const (
+	ModerationOK         = "ok"
+	ModerationSpam       = "spam"
+	ModerationHarassment = "harassment"
+	ModerationOffTopic   = "off_topic"
+)
+
+// CommentModeration is the AI classification of a comment.
+type CommentModeration struct {
+	Label      string  `json:"label"`
+	Confidence float64 `json:"confidence"` // 0 to 1
+	Reason     string  `json:"reason"`
 }
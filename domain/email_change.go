@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"time"
+)
+
+// PendingEmailChange holds a requested email address until it is confirmed
+// through the link sent to it. A user has at most one pending change.
+type PendingEmailChange struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64     `gorm:"uniqueIndex" json:"user_id"`
+	NewEmail  string    `gorm:"type:varchar(500)" json:"new_email"`
+	TokenHash string    `gorm:"type:varchar(64);uniqueIndex" json:"-"` // sha256 of the emailed token
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"` // auto set on insert
+}
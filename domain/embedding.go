@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// BlogEmbedding is the vector of a post's title and content, stored by the
+// in-process vector index so that it survives restarts.
+type BlogEmbedding struct {
+	BlogID    int64     `gorm:"primaryKey;autoIncrement:false" json:"blog_id"`
+	Model     string    `gorm:"type:varchar(100);index" json:"model"` // vectors of different models cannot be compared
+	Vector    []float32 `gorm:"type:text;serializer:json" json:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// VectorMatch is a nearest neighbour found by a vector index, scored by
+// cosine similarity from -1 to 1.
+type VectorMatch struct {
+	BlogID int64
+	Score  float64
+}
+
+// ScoredBlog is a post found by semantic search or as a related post.
+type ScoredBlog struct {
+	Blog  *Blog   `json:"blog"`
+	Score float64 `json:"score"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"time"
+)
+
+type Follow struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	FollowerID int64     `gorm:"uniqueIndex:idx_follows_pair,priority:1;not null" json:"follower_id"` // user who follows
+	Follower   User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	FolloweeID int64     `gorm:"uniqueIndex:idx_follows_pair,priority:2;index;not null" json:"followee_id"` // user being followed
+	Followee   User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	CreatedAt  time.Time `json:"created_at"` // auto set on insert
+}
+
+type FollowCounts struct {
+	Followers int64 `json:"followers_count"`
+	Following int64 `json:"following_count"`
+}
+
+// FeedCursor marks the last post a client has seen in the feed. Posts are
+// ordered by (created_at, id) descending so the pair is a stable keyset.
+type FeedCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
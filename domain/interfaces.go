@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 )
 
 type IBlogRepository interface {
@@ -11,23 +12,51 @@ type IBlogRepository interface {
 	FetchByID(ctx context.Context, id int64) (*Blog, error)
 	FetchAll(ctx context.Context) ([]*Blog, error)
 	GetBlogAuthorID(ctx context.Context, id int64) (int64, error)
-	IncrementView(ctx context.Context, blogID int64) error
-	AddLike(ctx context.Context, blogID int64, userID int64) error // userID optional, ignored
-	RemoveLike(ctx context.Context, blogID int64, userID int64) error
+	// AddViews adds buffered view counts, keyed by blog ID
+	AddViews(ctx context.Context, views map[int64]int) error
+	AddLike(ctx context.Context, blogID int64, userID int64) (bool, error)    // false if the user already liked it
+	RemoveLike(ctx context.Context, blogID int64, userID int64) (bool, error) // false if the user had not liked it
 	GetPopularity(ctx context.Context, blogID int64) (views int, likes int, err error)
 	SearchBlogs(ctx context.Context, query string, page, limit int) ([]*Blog, int64, error)
 	FetchPaginatedBlogs(ctx context.Context, page int, limit int) ([]*Blog, int64, error)
 	DeleteByID(ctx context.Context, ID int64, userID string) error
 	UpdateByID(ctx context.Context, id int64, userID string, updates map[string]interface{}) error
 	FetchByFilter(ctx context.Context, filter BlogFilter) ([]*Blog, error)
+	// FetchTrending lists posts with a score in the window, highest first
+	FetchTrending(ctx context.Context, window string, page, limit int) ([]*TrendingBlog, int64, error)
+	UpdateInsights(ctx context.Context, blogID int64, insights *BlogInsights) error
 	// comments
-	AddComment(ctx context.Context, blogID, userID int64, content string) (*Comment, error)
+	CreateComment(ctx context.Context, c *Comment) (*Comment, error)
+	FetchCommentByID(ctx context.Context, id int64) (*Comment, error)
+	// ListComments returns published comments only
 	ListComments(ctx context.Context, blogID int64, page, limit int) ([]*Comment, int64, error)
+	ListHeldComments(ctx context.Context, page, limit int) ([]*Comment, int64, error)
+	// ReviewComment sets the status of a held comment, returning nil if the
+	// comment is not held
+	ReviewComment(ctx context.Context, id int64, status string, reviewerID int64, at time.Time) (*Comment, error)
+}
+
+// IAIProvider is a chat completion backend such as OpenAI or Ollama.
+type IAIProvider interface {
+	Name() string
+	Complete(ctx context.Context, req AICompletionRequest) (*AICompletion, error)
+	// Stream calls onDelta with each piece of text as it is generated and
+	// returns the whole completion; an error from onDelta aborts the request.
+	// A stream that fails once started returns what was generated with the
+	// error, without token counts unless the provider sent them.
+	Stream(ctx context.Context, req AICompletionRequest, onDelta func(string) error) (*AICompletion, error)
 }
 
 type IAIService interface {
-	GenerateBlogIdeas(topic string) (string, error)
-	SuggestBlogImprovements(content string) (string, error)
+	GenerateBlogIdeas(ctx context.Context, prompt Prompt) (string, error)
+	SuggestBlogImprovements(ctx context.Context, prompt Prompt) (string, error)
+	StreamBlogIdeas(ctx context.Context, prompt Prompt, onDelta func(string) error) (string, error)
+	StreamBlogImprovements(ctx context.Context, prompt Prompt, onDelta func(string) error) (string, error)
+	GenerateBlogInsights(ctx context.Context, title, content string) (*BlogInsights, error)
+	// ModerateComment classifies a comment in the context of its post
+	ModerateComment(ctx context.Context, post *Blog, comment string) (*CommentModeration, error)
+	// AnswerQuestion answers from chunks only, citing the ones it used
+	AnswerQuestion(ctx context.Context, question string, chunks []BlogChunk) (*BlogAnswer, error)
 }
 
 type IBlogUsecase interface {
@@ -36,18 +65,24 @@ type IBlogUsecase interface {
 	FetchAllBlogs(ctx context.Context) ([]*Blog, error)
 	DeleteBlog(ctx context.Context, ID int64, userID string) error
 	FetchPaginatedBlogs(ctx context.Context, page int, limit int) ([]*Blog, int64, error)
-	TrackView(ctx context.Context, blogID int64) error
 	LikeBlog(ctx context.Context, blogID, userID int64) error
 	UnlikeBlog(ctx context.Context, blogID, userID int64) error
 	GetPopularity(ctx context.Context, blogID int64) (views int, likes int, err error)
 	SearchBlogs(ctx context.Context, query string, page, limit int) ([]*Blog, int64, error)
-	GenerateBlogIdeas(topic string) (string, error)
-	SuggestBlogImprovements(content string) (string, error)
+	FetchTrendingBlogs(ctx context.Context, window string, page, limit int) ([]*TrendingBlog, int64, error)
+	GenerateBlogIdeas(ctx context.Context, topic string, opts PromptOptions) (string, error)
+	SuggestBlogImprovements(ctx context.Context, content string, opts PromptOptions) (string, error)
+	StreamBlogIdeas(ctx context.Context, topic string, opts PromptOptions, onDelta func(string) error) (string, error)
+	StreamBlogImprovements(ctx context.Context, content string, opts PromptOptions, onDelta func(string) error) (string, error)
+	RegenerateBlogInsights(ctx context.Context, blogID int64) (*BlogInsights, error)
 	UpdateBlog(ctx context.Context, id int64, userID string, updates map[string]interface{}) error
 	FetchBlogsByFilter(ctx context.Context, filter BlogFilter) ([]*Blog, error)
 	// comments
 	AddComment(ctx context.Context, blogID, userID int64, content string) (*Comment, error)
+	ReplyToComment(ctx context.Context, blogID, parentID, userID int64, content string) (*Comment, error)
 	GetComments(ctx context.Context, blogID int64, page, limit int) ([]*Comment, int64, error)
+	ListHeldComments(ctx context.Context, page, limit int) ([]*Comment, int64, error)
+	ReviewComment(ctx context.Context, commentID, reviewerID int64, approve bool) (*Comment, error)
 }
 
 type IJWTInfrastructure interface {
@@ -55,6 +90,16 @@ type IJWTInfrastructure interface {
 	GenerateRefreshToken(userID string, userRole string) (string, error)
 	ValidateAccessToken(authHeader string) (*TokenClaims, error)
 	ValidateRefreshToken(token string) (*TokenClaims, error)
+	GenerateMFAToken(userID string) (string, error)
+	// ValidateMFAToken returns the user ID of a valid challenge token
+	ValidateMFAToken(token string) (string, error)
+}
+
+type ITOTPInfrastructure interface {
+	GenerateSecret() (string, error)
+	ProvisioningURI(secret string, account string) string
+	// Validate returns the time step matched by code
+	Validate(secret string, code string, now time.Time) (int64, bool)
 }
 
 type ITokenRepository interface {
@@ -66,20 +111,64 @@ type ITokenRepository interface {
 type IPasswordInfrastructure interface {
 	HashPassword(password string) (string, error)
 	ComparePassword(correctPassword []byte, inputPassword []byte) error
+	// NeedsRehash reports whether a stored hash is weaker than new hashes
+	NeedsRehash(hash string) bool
+}
+
+type IPasswordPolicy interface {
+	// Validate returns an error describing why a new password is not allowed
+	Validate(password string) error
 }
 
 type IEmailInfrastructure interface {
+	// SendEmail sends a single HTML body
 	SendEmail(to []string, subject string, body string) error
+	Send(msg *EmailMessage) error
+}
+
+type IEmailTemplateRenderer interface {
+	Render(name string, data map[string]string) (*RenderedEmail, error)
+}
+
+type IOutboxRepository interface {
+	Enqueue(ctx context.Context, email *OutboxEmail) error
+	// ClaimDue locks up to limit due emails and pushes their next attempt
+	// back by lease so that concurrent workers skip them
+	ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*OutboxEmail, error)
+	MarkSent(ctx context.Context, id int64, sentAt time.Time) error
+	MarkFailed(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time, lastError string, dead bool) error
 }
 
 type IUserUsecase interface {
 	Register(user *User) (User, error)
 	ActivateAccount(id string) error
-	Login(identifier string, password string) (string, string, error)
+	// Login authenticates with a username or email and password. ip is the
+	// client address used to throttle repeated failures.
+	Login(identifier string, password string, ip string) (*LoginResult, error)
+	// VerifyMFA completes a login with a TOTP or recovery code
+	VerifyMFA(mfaToken string, code string, ip string) (*LoginResult, error)
+	SetupTOTP(userID int64) (*TOTPSetup, error)
+	// ConfirmTOTP enables TOTP and returns the recovery codes
+	ConfirmTOTP(userID int64, code string) ([]string, error)
+	DisableTOTP(userID int64, code string, ip string) error
+	ResetTOTP(id string) error
+	// CompleteLogin issues tokens, or a TOTP challenge, for a user who was
+	// authenticated without a password
+	CompleteLogin(user User, ip string) (*LoginResult, error)
+	// CompleteVerifiedLogin issues tokens for a login that already proved two
+	// factors, such as a passkey with user verification
+	CompleteVerifiedLogin(user User, ip string) (*LoginResult, error)
+	// RequestMagicLink emails a sign-in link if the email belongs to a user
+	RequestMagicLink(email string, ip string) error
+	ConsumeMagicLink(token string, ip string) (*LoginResult, error)
 	GetUserProfile(userID int64) (*User, error)
 	Promote(id string) error
 	Demote(id string) error
 	UpdateUserProfile(userID int64, updates map[string]interface{}) error
+	ConfirmEmailChange(token string) error
+	UnlockAccount(token string) error
+	UnlockUser(id string) error
+	ListLockedUsers(page, limit int) ([]User, int64, error)
 	RefreshToken(authHeader string) (string, string, error)
 	ResetPassword(userID string, oldPassword string, newPassword string) error
 	ForgotPassword(email string) error
@@ -89,6 +178,8 @@ type IUserUsecase interface {
 
 type IUserRepository interface {
 	Register(user *User) (User, error)
+	// RegisterWithEmail creates the user and the email built from it in one transaction
+	RegisterWithEmail(user *User, email func(User) (*OutboxEmail, error)) (User, error)
 	FetchByUsername(username string) (User, error)
 	FetchByEmail(email string) (User, error)
 	ActivateAccount(idStr string) error
@@ -97,10 +188,51 @@ type IUserRepository interface {
 	Promote(idStr string) error
 	Demote(idStr string) error
 	UpdateUserProfile(userID int64, updates map[string]interface{}) error
+	// CreateEmailChange replaces the user's pending email change and queues
+	// the emails about it in one transaction
+	CreateEmailChange(change *PendingEmailChange, emails []*OutboxEmail) error
+	FetchEmailChange(tokenHash string) (PendingEmailChange, error)
+	// ConfirmEmailChange sets the user's email and removes the pending change
+	ConfirmEmailChange(change PendingEmailChange) error
+	// RecordLoginFailure increments the user's failed login counter and
+	// returns its new value
+	RecordLoginFailure(userID int64) (int, error)
+	// LockAccount locks the user until the given time. When email is set the
+	// unlock token hash is stored and the email queued in one transaction.
+	LockAccount(userID int64, until time.Time, unlockTokenHash string, email *OutboxEmail) error
+	// ResetLoginFailures clears the failed login counter and any lock
+	ResetLoginFailures(userID int64) error
+	FetchByUnlockToken(tokenHash string) (User, error)
+	ListLocked(now time.Time, page, limit int) ([]User, int64, error)
+	// SetTOTPSecret stores a secret for enrolment, leaving TOTP disabled
+	SetTOTPSecret(userID int64, secret string) error
+	// EnableTOTP enables TOTP and replaces the recovery codes in one transaction
+	EnableTOTP(userID int64, step int64, codeHashes []string) error
+	// DisableTOTP clears the secret and removes the recovery codes
+	DisableTOTP(userID int64) error
+	// UseTOTPStep records step as used, returning false if it is not newer
+	// than the last accepted one
+	UseTOTPStep(userID int64, step int64) (bool, error)
+	// UseRecoveryCode marks an unused code as used, returning false if there
+	// was none
+	UseRecoveryCode(userID int64, codeHash string, now time.Time) (bool, error)
+	// CreateMagicLink stores the link token and queues its email in one transaction
+	CreateMagicLink(link *MagicLinkToken, email *OutboxEmail) error
+	CountMagicLinks(userID int64, since time.Time) (int64, error)
+	// UseMagicLink marks an unused, unexpired token as used and returns it
+	UseMagicLink(tokenHash string, now time.Time) (MagicLinkToken, error)
 	ResetPassword(idStr string, newPassword string) error
+	// UpdatePasswordHash replaces the hash only if it is still oldHash, so a
+	// concurrent password change is not overwritten
+	UpdatePasswordHash(userID int64, oldHash, newHash string) error
 	CountUsers() (int64, error)
 }
 
+type ILoginAttemptRepository interface {
+	Record(attempt *LoginAttempt) error
+	CountFailuresByIP(ip string, since time.Time) (int64, error)
+}
+
 type IUserController interface {
 	Register(ctx *context.Context)
 	ActivateAccount(ctx *context.Context)
@@ -115,3 +247,272 @@ type IUserController interface {
 	UpdatePasswordDirect(ctx *context.Context)
 	Logout(ctx *context.Context)
 }
+
+type IFollowRepository interface {
+	Follow(ctx context.Context, followerID, followeeID int64) error
+	Unfollow(ctx context.Context, followerID, followeeID int64) error
+	IsFollowing(ctx context.Context, followerID, followeeID int64) (bool, error)
+	ListFollowers(ctx context.Context, userID int64, page, limit int) ([]*PublicUser, int64, error)
+	ListFollowing(ctx context.Context, userID int64, page, limit int) ([]*PublicUser, int64, error)
+	ListFollowerIDs(ctx context.Context, userID int64) ([]int64, error)
+	CountFollows(ctx context.Context, userID int64) (FollowCounts, error)
+	FetchFeed(ctx context.Context, userID int64, cursor *FeedCursor, limit int) ([]*Blog, error)
+}
+
+type IFollowUsecase interface {
+	Follow(ctx context.Context, followerID, followeeID int64) error
+	Unfollow(ctx context.Context, followerID, followeeID int64) error
+	GetFollowers(ctx context.Context, userID int64, page, limit int) ([]*PublicUser, int64, error)
+	GetFollowing(ctx context.Context, userID int64, page, limit int) ([]*PublicUser, int64, error)
+	GetFollowCounts(ctx context.Context, userID int64) (FollowCounts, error)
+	GetFeed(ctx context.Context, userID int64, cursor string, limit int) ([]*Blog, string, error)
+}
+
+type INotificationRepository interface {
+	CreateMany(ctx context.Context, notifications []*Notification) error
+	List(ctx context.Context, userID int64, unreadOnly bool, page, limit int) ([]*Notification, int64, error)
+	MarkRead(ctx context.Context, userID, id int64) error
+	MarkAllRead(ctx context.Context, userID int64) error
+	CountUnread(ctx context.Context, userID int64) (int64, error)
+	GetPreferences(ctx context.Context, userID int64) ([]NotificationPreference, error)
+	SetPreference(ctx context.Context, userID int64, kind string, enabled bool) error
+	// DisabledRecipients returns the subset of userIDs that opted out of kind
+	DisabledRecipients(ctx context.Context, kind string, userIDs []int64) ([]int64, error)
+}
+
+// INotifier receives events from the other usecases
+type INotifier interface {
+	Notify(ctx context.Context, event NotificationEvent) error
+}
+
+type INotificationUsecase interface {
+	INotifier
+	ListNotifications(ctx context.Context, userID int64, unreadOnly bool, page, limit int) ([]*Notification, int64, error)
+	MarkRead(ctx context.Context, userID, id int64) error
+	MarkAllRead(ctx context.Context, userID int64) error
+	UnreadCount(ctx context.Context, userID int64) (int64, error)
+	GetPreferences(ctx context.Context, userID int64) (map[string]bool, error)
+	UpdatePreferences(ctx context.Context, userID int64, prefs map[string]bool) error
+}
+
+// IRateLimitStore keeps token buckets. The in-memory store only limits a
+// single instance; deployments running several instances plug in a store
+// backed by shared storage.
+type IRateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+}
+
+// IViewStore remembers recent views for deduplication. The in-memory store
+// only deduplicates within a single instance.
+type IViewStore interface {
+	// MarkViewed records key and reports whether it was already recorded
+	// within window
+	MarkViewed(ctx context.Context, key string, window time.Duration, now time.Time) (bool, error)
+}
+
+// IActivityRecorder adds likes and comments to the daily stats of a post.
+type IActivityRecorder interface {
+	RecordActivity(blogID int64, likes, comments int)
+}
+
+type IViewUsecase interface {
+	IActivityRecorder
+	// TrackView reports whether the view was counted; repeated views and
+	// views by bots are not
+	TrackView(ctx context.Context, view BlogView) (bool, error)
+}
+
+type IAnalyticsRepository interface {
+	// AddDailyStats adds the counts to the stored rows, creating them as
+	// needed
+	AddDailyStats(ctx context.Context, stats []*BlogDailyStats, referrers []*BlogReferrerStats) error
+	FetchDailyStats(ctx context.Context, blogIDs []int64, from, to time.Time) ([]*BlogDailyStats, error)
+	FetchReferrerStats(ctx context.Context, blogIDs []int64, from, to time.Time) ([]*BlogReferrerStats, error)
+	// FetchStatsSince returns the stats of every post from since on
+	FetchStatsSince(ctx context.Context, since time.Time) ([]*BlogDailyStats, error)
+	// ReplaceTrendingScores replaces every score of the period
+	ReplaceTrendingScores(ctx context.Context, period string, scores []*BlogTrendingScore) error
+}
+
+type IAnalyticsUsecase interface {
+	// periods are [from, to) in whole UTC days
+	BlogAnalytics(ctx context.Context, blogID int64, from, to time.Time) (*BlogAnalytics, error)
+	AuthorAnalytics(ctx context.Context, authorID int64, from, to time.Time) (*AuthorAnalytics, error)
+}
+
+// IOIDCProvider is an OpenID Connect client for one identity provider.
+type IOIDCProvider interface {
+	// AuthCodeURL returns the provider's authorization URL for the
+	// authorization code flow with PKCE (S256)
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange redeems the code and returns the identity from the validated ID token
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*OIDCIdentity, error)
+}
+
+type IOIDCRepository interface {
+	SaveLoginState(ctx context.Context, state *OIDCLoginState) error
+	// ConsumeLoginState deletes and returns the state, or nil if there is none
+	ConsumeLoginState(ctx context.Context, stateHash string) (*OIDCLoginState, error)
+	// FindIdentity returns nil when the identity is not linked
+	FindIdentity(ctx context.Context, provider, subject string) (*ExternalIdentity, error)
+	CreateIdentity(ctx context.Context, identity *ExternalIdentity) error
+	// CreateUserWithIdentity provisions a new user and its identity in one transaction
+	CreateUserWithIdentity(ctx context.Context, user *User, identity *ExternalIdentity) error
+}
+
+type IOIDCUsecase interface {
+	// StartLogin returns the URL to redirect the browser to
+	StartLogin(ctx context.Context, provider string) (string, error)
+	// StartLink is like StartLogin, but the callback links the identity to the
+	// signed in user instead of looking it up
+	StartLink(ctx context.Context, provider string, userID int64) (string, error)
+	// FinishLogin handles the provider's callback
+	FinishLogin(ctx context.Context, provider, state, code, ip string) (*LoginResult, error)
+}
+
+type IPersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *PersonalAccessToken) error
+	// ListActive returns the user's tokens that are not revoked, newest first
+	ListActive(ctx context.Context, userID int64) ([]PersonalAccessToken, error)
+	CountActive(ctx context.Context, userID int64) (int64, error)
+	// FetchByHash returns nil when there is no token with the hash
+	FetchByHash(ctx context.Context, tokenHash string) (*PersonalAccessToken, error)
+	// Revoke returns false if the user has no active token with the ID
+	Revoke(ctx context.Context, userID, id int64, now time.Time) (bool, error)
+	TouchLastUsed(ctx context.Context, id int64, now time.Time, ip string) error
+}
+
+type IPersonalAccessTokenUsecase interface {
+	// Create returns the stored token and the secret, which is not kept
+	Create(ctx context.Context, userID int64, name string, scopes []string, expiresAt *time.Time) (*PersonalAccessToken, string, error)
+	List(ctx context.Context, userID int64) ([]PersonalAccessToken, error)
+	Revoke(ctx context.Context, userID, id int64) error
+	// Authenticate returns the claims of the token's owner and the token's scopes
+	Authenticate(ctx context.Context, token, ip string) (*TokenClaims, []string, error)
+}
+
+type IWebAuthnInfrastructure interface {
+	RelyingParty() WebAuthnRelyingParty
+	// Challenge returns the challenge echoed in the client data
+	Challenge(clientDataJSON string) (string, error)
+	VerifyRegistration(challenge string, resp *WebAuthnAttestationResponse) (*WebAuthnCredentialData, error)
+	// VerifyAssertion returns the authenticator's new signature counter
+	VerifyAssertion(challenge string, publicKey []byte, resp *WebAuthnAssertionResponse) (uint32, error)
+}
+
+type IWebAuthnRepository interface {
+	SaveChallenge(ctx context.Context, challenge *WebAuthnChallenge) error
+	// ConsumeChallenge deletes and returns the challenge, or nil if there is none
+	ConsumeChallenge(ctx context.Context, challengeHash string) (*WebAuthnChallenge, error)
+	CreateCredential(ctx context.Context, credential *WebAuthnCredential) error
+	ListCredentials(ctx context.Context, userID int64) ([]WebAuthnCredential, error)
+	// FetchCredential returns nil when no credential has the ID
+	FetchCredential(ctx context.Context, credentialID string) (*WebAuthnCredential, error)
+	// UpdateSignCount stores the new counter if the stored one is still old,
+	// returning false when another login raced it
+	UpdateSignCount(ctx context.Context, id int64, old, new int64, usedAt time.Time) (bool, error)
+	// DeleteCredential returns false if the user has no credential with the ID
+	DeleteCredential(ctx context.Context, userID, id int64) (bool, error)
+}
+
+type IWebAuthnUsecase interface {
+	BeginRegistration(ctx context.Context, userID int64) (*WebAuthnCreationOptions, error)
+	FinishRegistration(ctx context.Context, userID int64, name string, resp *WebAuthnAttestationResponse) (*WebAuthnCredential, error)
+	BeginLogin(ctx context.Context) (*WebAuthnRequestOptions, error)
+	FinishLogin(ctx context.Context, resp *WebAuthnAssertionResponse, ip string) (*LoginResult, error)
+	ListCredentials(ctx context.Context, userID int64) ([]WebAuthnCredential, error)
+	DeleteCredential(ctx context.Context, userID, id int64) error
+}
+
+type IAIUsageRepository interface {
+	Record(ctx context.Context, usage *AIUsage) error
+	// SumTokens adds up the uncached tokens used by a user since a time
+	SumTokens(ctx context.Context, userID int64, since time.Time) (int64, error)
+	SummarizeByUser(ctx context.Context, from, to time.Time) ([]AIUsageSummary, error)
+	SummarizeByDay(ctx context.Context, userID int64, from, to time.Time) ([]AIUsageSummary, error)
+	// FetchQuota returns nil when the user has no override
+	FetchQuota(ctx context.Context, userID int64) (*AIQuota, error)
+	SaveQuota(ctx context.Context, quota *AIQuota) error
+	DeleteQuota(ctx context.Context, userID int64) (bool, error)
+}
+
+type IAIUsageUsecase interface {
+	// CheckQuota fails once the caller has used up today's tokens
+	CheckQuota(ctx context.Context, caller AICaller) error
+	// Record adds a ledger entry; failures are only logged
+	Record(ctx context.Context, usage *AIUsage)
+	UsageByUser(ctx context.Context, from, to time.Time) ([]AIUsageSummary, error)
+	UsageForUser(ctx context.Context, userID int64, from, to time.Time) ([]AIUsageSummary, error)
+	GetQuota(ctx context.Context, userID int64) (*AIQuotaStatus, error)
+	SetQuota(ctx context.Context, userID int64, dailyTokens int64) (*AIQuotaStatus, error)
+	ClearQuota(ctx context.Context, userID int64) error
+}
+
+type IEmbeddingProvider interface {
+	Name() string
+	// Model names the vectors produced, which are only comparable with
+	// vectors of the same model
+	Model() string
+	// Embed returns one vector per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+type IVectorIndex interface {
+	Upsert(ctx context.Context, blogID int64, model string, vector []float32) error
+	Delete(ctx context.Context, blogID int64) error
+	// Fetch returns nil when the post is not indexed
+	Fetch(ctx context.Context, blogID int64) (*BlogEmbedding, error)
+	// Search returns the posts closest to vector among those embedded with
+	// model, best first, leaving out excludeID
+	Search(ctx context.Context, model string, vector []float32, limit int, excludeID int64) ([]VectorMatch, error)
+}
+
+type IEmbeddingRepository interface {
+	SaveEmbedding(ctx context.Context, embedding *BlogEmbedding) error
+	DeleteEmbedding(ctx context.Context, blogID int64) error
+	FetchEmbeddings(ctx context.Context) ([]*BlogEmbedding, error)
+}
+
+// IBlogIndexer keeps the vector index in step with posts.
+type IBlogIndexer interface {
+	IndexBlog(ctx context.Context, blogID int64) error
+	RemoveBlog(ctx context.Context, blogID int64) error
+}
+
+type ISemanticSearchUsecase interface {
+	IBlogIndexer
+	SemanticSearch(ctx context.Context, query string, limit int) ([]ScoredBlog, error)
+	RelatedBlogs(ctx context.Context, blogID int64, limit int) ([]ScoredBlog, error)
+	// ReindexAll embeds every post, returning how many were indexed
+	ReindexAll(ctx context.Context) (int, error)
+}
+
+type IAskUsecase interface {
+	// Ask answers a question from the posts most relevant to it
+	Ask(ctx context.Context, question string) (*BlogAnswer, error)
+}
+
+type IPromptTemplateRepository interface {
+	// CreateVersion saves t as the next version of its name and activates it
+	CreateVersion(ctx context.Context, t *PromptTemplate) error
+	// FetchTemplate returns the given version of a template, or the active
+	// one for version 0; nil when there is none
+	FetchTemplate(ctx context.Context, name string, version int) (*PromptTemplate, error)
+	ListActive(ctx context.Context) ([]*PromptTemplate, error)
+	// ListVersions lists every version of a template, newest first
+	ListVersions(ctx context.Context, name string) ([]*PromptTemplate, error)
+	// Activate returns false when the version does not exist
+	Activate(ctx context.Context, name string, version int) (bool, error)
+}
+
+type IPromptTemplateUsecase interface {
+	// Render renders the template opts select for feature, filling the
+	// tone, audience and language of vars from opts
+	Render(ctx context.Context, feature string, opts PromptOptions, vars PromptVariables) (*Prompt, error)
+	SaveTemplate(ctx context.Context, t *PromptTemplate, adminID int64) (*PromptTemplate, error)
+	// ListTemplates lists the active version of every template, including
+	// the built-in prompts that have not been replaced
+	ListTemplates(ctx context.Context) ([]*PromptTemplate, error)
+	ListVersions(ctx context.Context, name string) ([]*PromptTemplate, error)
+	ActivateVersion(ctx context.Context, name string, version int) (*PromptTemplate, error)
+}
@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"time"
+)
+
+// LoginAttempt records a single password login. UserID is nil when the
+// identifier did not match an account.
+type LoginAttempt struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    *int64    `gorm:"index" json:"user_id"`
+	IP        string    `gorm:"type:varchar(64);index:idx_login_attempts_ip,priority:1" json:"ip"`
+	Success   bool      `json:"success"`
+	CreatedAt time.Time `gorm:"index:idx_login_attempts_ip,priority:2" json:"created_at"` // auto set on insert
+}
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"time"
+)
+
+// MagicLinkToken is a single-use token emailed for passwordless login. Only
+// its hash is stored.
+type MagicLinkToken struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64      `gorm:"index" json:"user_id"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	RequestIP string     `gorm:"type:varchar(64)" json:"request_ip"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"` // auto set on insert
+}
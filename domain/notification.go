@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"time"
+)
+
+// Notification kinds. They double as the keys of the per-user preferences.
+const (
+	NotificationComment = "comment"
+	NotificationReply   = "reply"
+	NotificationLike    = "like"
+	NotificationFollow  = "follow"
+	NotificationNewPost = "new_post"
+)
+
+var NotificationTypes = []string{
+	NotificationComment,
+	NotificationReply,
+	NotificationLike,
+	NotificationFollow,
+	NotificationNewPost,
+}
+
+type Notification struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64      `gorm:"index:idx_notifications_user_created,priority:1;not null" json:"user_id"` // recipient
+	User      User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	ActorID   int64      `json:"actor_id"` // user who triggered the notification
+	Actor     User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Type      string     `gorm:"type:varchar(50)" json:"type"`
+	BlogID    *int64     `json:"blog_id,omitempty"`
+	CommentID *int64     `json:"comment_id,omitempty"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `gorm:"index:idx_notifications_user_created,priority:2,sort:desc" json:"created_at"` // auto set on insert
+
+	// ActorProfile is what the recipient sees of the actor, set when listing
+	ActorProfile *PublicUser `gorm:"-" json:"actor,omitempty"`
+}
+
+// NotificationPreference stores an explicit opt-out (or opt-back-in) for one
+// notification kind. Kinds without a row are enabled.
+type NotificationPreference struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64     `gorm:"uniqueIndex:idx_notification_prefs_user_type,priority:1;not null" json:"user_id"`
+	User      User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Type      string    `gorm:"type:varchar(50);uniqueIndex:idx_notification_prefs_user_type,priority:2" json:"type"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"` // auto set on update
+}
+
+// NotificationEvent is what the usecases emit when something noteworthy
+// happens. RecipientID is left zero for fan-out events such as NewPost, where
+// the recipients are the actor's followers.
+type NotificationEvent struct {
+	Type        string
+	ActorID     int64
+	RecipientID int64
+	BlogID      int64
+	CommentID   int64
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+)
+
+// ExternalIdentity links an account at an OpenID Connect provider to a user.
+type ExternalIdentity struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64     `gorm:"index" json:"user_id"`
+	Provider  string    `gorm:"type:varchar(100);uniqueIndex:idx_external_identities_subject,priority:1" json:"provider"`
+	Subject   string    `gorm:"type:varchar(255);uniqueIndex:idx_external_identities_subject,priority:2" json:"subject"`
+	Email     string    `gorm:"type:varchar(500)" json:"email"`
+	CreatedAt time.Time `json:"created_at"` // auto set on insert
+}
+
+// OIDCLoginState is kept between the redirect to a provider and its callback.
+// It is looked up by the hash of the state parameter and used once. UserID is
+// set when a signed in user links the provider to their account.
+type OIDCLoginState struct {
+	StateHash    string    `gorm:"type:varchar(64);primaryKey" json:"-"`
+	Provider     string    `gorm:"type:varchar(100)" json:"provider"`
+	UserID       int64     `json:"-"`
+	Nonce        string    `gorm:"type:varchar(100)" json:"-"`
+	CodeVerifier string    `gorm:"type:varchar(128)" json:"-"`
+	ExpiresAt    time.Time `gorm:"index" json:"expires_at"`
+}
+
+// TableName keeps GORM from splitting the acronym into o_id_c_login_states.
+func (OIDCLoginState) TableName() string {
+	return "oidc_login_states"
+}
+
+// OIDCIdentity holds the claims of a validated ID token.
+type OIDCIdentity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
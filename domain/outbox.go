@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"time"
+)
+
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxDead    = "dead"
+)
+
+// Email template names
+const (
+	EmailActivation    = "activation"
+	EmailPasswordReset = "password_reset"
+	EmailNotification  = "notification"
+	EmailChange        = "email_change"        // sent to the new address
+	EmailChangeNotice  = "email_change_notice" // sent to the old address
+	EmailAccountLocked = "account_locked"
+	EmailMagicLink     = "magic_link"
+)
+
+// OutboxEmail is an email waiting to be delivered by the outbox worker. It is
+// written in the same transaction as the change that triggers it, so an email
+// is queued if and only if that change is committed.
+type OutboxEmail struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Recipients    string     `gorm:"type:text" json:"recipients"` // comma separated
+	Template      string     `gorm:"type:varchar(100)" json:"template"`
+	Data          string     `gorm:"type:text" json:"data"` // JSON encoded template variables
+	Status        string     `gorm:"type:varchar(20);index:idx_outbox_due,priority:1" json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index:idx_outbox_due,priority:2" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"` // auto set on insert
+	UpdatedAt     time.Time  `json:"updated_at"` // auto set on update
+}
+
+// EmailMessage is a message handed to an email transport. Either body may be
+// empty; when both are set they are sent as multipart/alternative.
+type EmailMessage struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// RenderedEmail is the result of rendering a named template.
+type RenderedEmail struct {
+	Subject string
+	HTML    string
+	Text    string
+}
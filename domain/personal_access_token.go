@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token so that it can
+// be told apart from a JWT and recognised by secret scanners.
+const PersonalAccessTokenPrefix = "bpat_"
+
+// Personal access token scopes
+const (
+	ScopeRead  = "read"  // GET requests
+	ScopeWrite = "write" // every other method
+	ScopeAdmin = "admin" // admin routes, for admins only
+)
+
+// PersonalAccessToken is a long-lived API token created by a user for
+// scripts. Only the hash of the token is stored; it is shown once on creation.
+type PersonalAccessToken struct {
+	ID         int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     int64      `gorm:"index" json:"user_id"`
+	Name       string     `gorm:"type:varchar(100)" json:"name"`
+	TokenHash  string     `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	Prefix     string     `gorm:"type:varchar(20)" json:"prefix"`  // start of the token, to recognise it in listings
+	Scopes     string     `gorm:"type:varchar(255)" json:"scopes"` // comma separated
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `gorm:"type:varchar(64)" json:"last_used_ip"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"` // auto set on insert
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// PromptTemplate is one version of a prompt for a free-text AI feature
+// (ideas or improve). Prompts are Go text/template templates rendered with
+// PromptVariables. Saving a template adds a version; one version of each
+// name is active and used when the name is selected without a version.
+// A template named after its feature replaces the built-in prompt.
+type PromptTemplate struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name         string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_prompt_templates_name_version,priority:1" json:"name"`
+	Version      int       `gorm:"not null;uniqueIndex:idx_prompt_templates_name_version,priority:2" json:"version"` // 0 for built-in prompts
+	Feature      string    `gorm:"type:varchar(20);not null" json:"feature"`
+	SystemPrompt string    `gorm:"type:text;not null" json:"system_prompt"`
+	UserPrompt   string    `gorm:"type:text;not null" json:"user_prompt"`
+	Active       bool      `gorm:"not null;default:false" json:"active"`
+	CreatedBy    int64     `json:"created_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// PromptVariables are the values prompt templates are rendered with, e.g.
+// {{.Topic}} or {{with .Tone}}Write in a {{.}} tone.{{end}}.
+type PromptVariables struct {
+	Topic    string
+	Content  string
+	Tone     string
+	Audience string
+	Language string
+}
+
+// PromptOptions select the template of a request and fill its optional
+// variables.
+type PromptOptions struct {
+	Template        string `json:"template"`         // empty for the feature's default
+	TemplateVersion int    `json:"template_version"` // 0 for the active version
+	Tone            string `json:"tone"`
+	Audience        string `json:"audience"`
+	Language        string `json:"language"`
+}
+
+// Prompt is a rendered template, ready for the AI provider.
+type Prompt struct {
+	System string
+	User   string
+}
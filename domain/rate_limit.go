@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+)
+
+// RateLimit describes a token bucket holding up to Burst tokens that refills
+// Burst tokens every Period.
+type RateLimit struct {
+	Burst  int
+	Period time.Duration
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // until the next token, zero when allowed
+	Reset      time.Duration // until the bucket is full again
+}
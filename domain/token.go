@@ -23,3 +23,11 @@ type TokenClaims struct {
 	UserRole string `json:"user_role"`
 	jwt.RegisteredClaims
 }
+
+// LoginResult holds either the issued tokens or, when the account has
+// two-factor authentication enabled, the challenge token for the second step.
+type LoginResult struct {
+	AccessToken  string
+	RefreshToken string
+	MFAToken     string
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// Trending windows
+const (
+	TrendingDay  = "24h"
+	TrendingWeek = "7d"
+)
+
+// Sort orders of filtered listings; the default is insertion order.
+const (
+	BlogSortNewest   = "newest"
+	BlogSortOldest   = "oldest"
+	BlogSortTrending = "trending"
+)
+
+// BlogTrendingScore ranks a post within a trending window. Scores are
+// recalculated periodically from the daily stats; posts without recent
+// activity have no score.
+type BlogTrendingScore struct {
+	BlogID    int64   `gorm:"primaryKey;autoIncrement:false"`
+	Period    string  `gorm:"primaryKey;type:varchar(10);index:idx_trending_period_score,priority:1"` // a trending window
+	Score     float64 `gorm:"index:idx_trending_period_score,priority:2,sort:desc"`
+	UpdatedAt time.Time
+}
+
+// TrendingBlog is a post with its score in a trending window.
+type TrendingBlog struct {
+	*Blog
+	Score float64 `json:"trending_score"`
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"time"
+)
+
+// RecoveryCode is a one-time code that replaces a TOTP code when the
+// authenticator is lost. Only the hash is stored.
+type RecoveryCode struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64      `gorm:"index:idx_recovery_codes_user_code,priority:1" json:"user_id"`
+	CodeHash  string     `gorm:"type:varchar(64);index:idx_recovery_codes_user_code,priority:2" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"` // auto set on insert
+}
+
+// TOTPSetup is returned when enrolment starts. The secret is shown once so
+// it can be typed in when the QR code cannot be scanned.
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
@@ -7,15 +7,37 @@ import (
 
 type User struct {
 	//gorm.Model
-	ID             int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	Username       string    `gorm:"type:varchar(255)" json:"username"`
-	Email          string    `gorm:"type:varchar(500)" json:"email"`
-	Password       string    `gorm:"type:varchar(255)" json:"-"`
-	Role           string    `gorm:"type:varchar(255)" json:"role"`
-	Bio            string    `json:"bio"`
-	ProfilePicture string    `gorm:"type:varchar(500)" json:"profile_picture"`
-	Phone          string    `gorm:"type:varchar(255)" json:"phone"`
-	Status         string    `gorm:"type:varchar(255)" json:"status"`
-	CreatedAt      time.Time `json:"created_at"` // auto set on insert
-	UpdatedAt      time.Time `json:"updated_at"` // auto set on update
+	ID             int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	Username       string `gorm:"type:varchar(255)" json:"username"`
+	Email          string `gorm:"type:varchar(500)" json:"email"`
+	Password       string `gorm:"type:varchar(255)" json:"-"`
+	Role           string `gorm:"type:varchar(255)" json:"role"`
+	Bio            string `json:"bio"`
+	ProfilePicture string `gorm:"type:varchar(500)" json:"profile_picture"`
+	Phone          string `gorm:"type:varchar(255)" json:"phone"`
+	Status         string `gorm:"type:varchar(255)" json:"status"`
+	// consecutive failed logins, reset by a successful login or an unlock;
+	// only admins see them, through the locked users list
+	FailedLogins    int        `json:"-"`
+	LockedUntil     *time.Time `gorm:"index" json:"-"`
+	UnlockTokenHash string     `gorm:"type:varchar(64);index" json:"-"`
+	// TOTPSecret is set during enrolment and only used once TOTPEnabled
+	TOTPSecret   string    `gorm:"type:varchar(64)" json:"-"`
+	TOTPEnabled  bool      `json:"totp_enabled"`
+	TOTPLastStep int64     `json:"-"`          // last accepted time step, to refuse replayed codes
+	CreatedAt    time.Time `json:"created_at"` // auto set on insert
+	UpdatedAt    time.Time `json:"updated_at"` // auto set on update
+}
+
+// PublicUser is what any user may see of another: no contact details or
+// account state.
+type PublicUser struct {
+	ID             int64  `json:"id"`
+	Username       string `json:"username"`
+	Bio            string `json:"bio"`
+	ProfilePicture string `json:"profile_picture"`
+}
+
+func (u *User) Public() *PublicUser {
+	return &PublicUser{ID: u.ID, Username: u.Username, Bio: u.Bio, ProfilePicture: u.ProfilePicture}
 }
@@ -0,0 +1,11 @@
+package domain
+
+// BlogView is a request to count a view of a post. Views are deduplicated by
+// user, or by client IP and user agent for anonymous readers.
+type BlogView struct {
+	BlogID    int64
+	UserID    int64 // 0 for anonymous readers
+	ClientIP  string
+	UserAgent string
+	Referrer  string // Referer header, if any
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"time"
+)
+
+// WebAuthn ceremony types
+const (
+	WebAuthnRegistration = "registration"
+	WebAuthnLogin        = "login"
+)
+
+// COSE algorithm identifiers accepted for passkeys
+const (
+	COSEAlgES256 = -7
+	COSEAlgEdDSA = -8
+	COSEAlgRS256 = -257
+)
+
+// WebAuthnCredential is a passkey registered by a user.
+type WebAuthnCredential struct {
+	ID           int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       int64      `gorm:"index" json:"user_id"`
+	Name         string     `gorm:"type:varchar(100)" json:"name"`
+	CredentialID string     `gorm:"type:varchar(1400);uniqueIndex" json:"credential_id"` // base64url
+	PublicKey    []byte     `json:"-"`                                                   // COSE encoded
+	SignCount    int64      `json:"sign_count"`
+	AAGUID       string     `gorm:"type:varchar(36)" json:"aaguid"`
+	Transports   string     `gorm:"type:varchar(255)" json:"transports"` // comma separated
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	CreatedAt    time.Time  `json:"created_at"` // auto set on insert
+}
+
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+// WebAuthnChallenge is kept for the duration of a ceremony. It is looked up
+// by the hash of the challenge echoed in the client data and used once.
+type WebAuthnChallenge struct {
+	ChallengeHash string    `gorm:"type:varchar(64);primaryKey"`
+	Type          string    `gorm:"type:varchar(20)"`
+	UserID        int64     // the registering user; 0 for logins
+	ExpiresAt     time.Time `gorm:"index"`
+}
+
+func (WebAuthnChallenge) TableName() string {
+	return "webauthn_challenges"
+}
+
+// WebAuthnCredentialData is what a verified registration yields.
+type WebAuthnCredentialData struct {
+	CredentialID string // base64url
+	PublicKey    []byte
+	SignCount    uint32
+	AAGUID       string
+}
+
+// The types below follow the JSON form of the WebAuthn browser API, with
+// binary fields encoded as base64url.
+
+type WebAuthnRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type WebAuthnUserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type WebAuthnCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type WebAuthnCredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+type WebAuthnCreationOptions struct {
+	RP                     WebAuthnRelyingParty           `json:"rp"`
+	User                   WebAuthnUserEntity             `json:"user"`
+	Challenge              string                         `json:"challenge"`
+	PubKeyCredParams       []WebAuthnCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"`
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                         `json:"attestation"`
+}
+
+type WebAuthnRequestOptions struct {
+	Challenge        string                         `json:"challenge"`
+	Timeout          int64                          `json:"timeout"`
+	RPID             string                         `json:"rpId"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                         `json:"userVerification"`
+}
+
+type WebAuthnAttestationResponse struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+type WebAuthnAssertionResponse struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
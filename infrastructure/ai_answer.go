@@ -0,0 +1,78 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blog-platform/domain"
+)
+
+const maxQuoteLength = 200
+
+var blogAnswerSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"answer": {"type": "string", "description": "the answer, citing excerpts like [1]"},
+		"answerable": {"type": "boolean", "description": "false if the excerpts do not answer the question"},
+		"sources": {"type": "array", "items": {"type": "integer"}, "description": "numbers of the excerpts the answer uses"}
+	},
+	"required": ["answer", "answerable", "sources"],
+	"additionalProperties": false
+}`)
+
+type blogAnswerJSON struct {
+	Answer     string `json:"answer"`
+	Answerable bool   `json:"answerable"`
+	Sources    []int  `json:"sources"`
+}
+
+// AnswerQuestion numbers the chunks and asks for an answer grounded in them.
+// Cited numbers that do not match a chunk are dropped, and an answer that
+// cites nothing is not counted as answered.
+func (s *AIService) AnswerQuestion(ctx context.Context, question string, chunks []domain.BlogChunk) (*domain.BlogAnswer, error) {
+	var excerpts strings.Builder
+	for i, chunk := range chunks {
+		fmt.Fprintf(&excerpts, "[%d] From \"%s\", paragraphs %d-%d:\n%s\n\n", i+1, chunk.Title, chunk.ParagraphStart, chunk.ParagraphEnd, chunk.Text)
+	}
+	req := chat(fmt.Sprintf("You answer readers' questions about a blog using only the numbered excerpts from its posts. Cite the excerpts you use by number, like [2]. If the excerpts do not answer the question, say so briefly and set answerable to false; never answer from general knowledge. Answer only with a JSON object matching this JSON Schema: %s", blogAnswerSchema),
+		fmt.Sprintf("Excerpts:\n\n%sQuestion: %s", excerpts.String(), question))
+	req.ResponseSchema = &domain.AIResponseSchema{Name: "blog_answer", Schema: blogAnswerSchema}
+	req.Feature = domain.AIFeatureAsk
+
+	text, err := s.complete(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	var parsed blogAnswerJSON
+	if err := decodeAnswer(text, &parsed); err != nil || strings.TrimSpace(parsed.Answer) == "" {
+		log.Printf("ai: %s: invalid answer JSON: %v", s.provider.Name(), err)
+		return nil, errAIInvalidResponse
+	}
+
+	answer := &domain.BlogAnswer{Answer: strings.TrimSpace(parsed.Answer), Citations: []domain.BlogCitation{}}
+	seen := map[int]bool{}
+	for _, source := range parsed.Sources {
+		if source < 1 || source > len(chunks) || seen[source] {
+			continue
+		}
+		seen[source] = true
+		chunk := chunks[source-1]
+		quote := chunk.Text
+		if utf8.RuneCountInString(quote) > maxQuoteLength {
+			quote = string([]rune(quote)[:maxQuoteLength]) + "…"
+		}
+		answer.Citations = append(answer.Citations, domain.BlogCitation{
+			BlogID:         chunk.BlogID,
+			Title:          chunk.Title,
+			ParagraphStart: chunk.ParagraphStart,
+			ParagraphEnd:   chunk.ParagraphEnd,
+			Quote:          quote,
+		})
+	}
+	answer.Answered = parsed.Answerable && len(answer.Citations) > 0
+	return answer, nil
+}
@@ -0,0 +1,130 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blog-platform/domain"
+)
+
+var errAIInvalidResponse = errors.New("AI provider returned an invalid response")
+
+// limits enforced on generated insights
+const (
+	maxSummaryLength = 1000
+	maxExcerptLength = 300
+	maxSuggestedTags = 5
+	maxTagLength     = 30
+)
+
+// blogInsightsSchema is sent to providers that support structured output.
+// Length limits are checked in validateBlogInsights, as not every provider
+// accepts them in a schema.
+var blogInsightsSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"summary": {"type": "string", "description": "two or three sentences summarising the post"},
+		"excerpt": {"type": "string", "description": "a teaser of at most 300 characters"},
+		"tags": {"type": "array", "items": {"type": "string"}, "description": "up to 5 short lowercase topic tags"}
+	},
+	"required": ["summary", "excerpt", "tags"],
+	"additionalProperties": false
+}`)
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// GenerateBlogInsights asks for a summary, an excerpt and tags as JSON and
+// validates the answer, failing with "AI provider returned an invalid
+// response" if it does not fit the schema.
+func (s *AIService) GenerateBlogInsights(ctx context.Context, title, content string) (*domain.BlogInsights, error) {
+	req := chat(fmt.Sprintf("You are an editor for a blog platform. Answer only with a JSON object matching this JSON Schema: %s", blogInsightsSchema),
+		fmt.Sprintf("Title: %s\n\n%s", title, content))
+	req.ResponseSchema = &domain.AIResponseSchema{Name: "blog_insights", Schema: blogInsightsSchema}
+	req.Feature = domain.AIFeatureInsights
+	// a new answer is the point of regenerating
+	req.NoCache = true
+
+	text, err := s.complete(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	insights, err := parseBlogInsights(text)
+	if err != nil {
+		log.Printf("ai: %s: %v", s.provider.Name(), err)
+		return nil, errAIInvalidResponse
+	}
+	return insights, nil
+}
+
+// decodeAnswer decodes a model answer into v, rejecting unknown fields and
+// trailing text. Models that do not support structured output tend to wrap
+// JSON in a code fence, which is removed first.
+func decodeAnswer(text string, v interface{}) error {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(text, "```")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("trailing text after JSON")
+	}
+	return nil
+}
+
+// parseBlogInsights decodes and validates a model answer.
+func parseBlogInsights(text string) (*domain.BlogInsights, error) {
+	var insights domain.BlogInsights
+	if err := decodeAnswer(text, &insights); err != nil {
+		return nil, fmt.Errorf("invalid insights JSON: %w", err)
+	}
+	if err := validateBlogInsights(&insights); err != nil {
+		return nil, err
+	}
+	return &insights, nil
+}
+
+// validateBlogInsights checks the lengths and normalises the tags, dropping
+// duplicates and tags that cannot be made into a slug.
+func validateBlogInsights(insights *domain.BlogInsights) error {
+	insights.Summary = strings.TrimSpace(insights.Summary)
+	insights.Excerpt = strings.TrimSpace(insights.Excerpt)
+	if insights.Summary == "" || utf8.RuneCountInString(insights.Summary) > maxSummaryLength {
+		return fmt.Errorf("summary must be 1 to %d characters", maxSummaryLength)
+	}
+	if insights.Excerpt == "" || utf8.RuneCountInString(insights.Excerpt) > maxExcerptLength {
+		return fmt.Errorf("excerpt must be 1 to %d characters", maxExcerptLength)
+	}
+
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, tag := range insights.Tags {
+		tag = strings.Join(strings.Fields(strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))), "-")
+		if !tagPattern.MatchString(tag) || len(tag) > maxTagLength || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+		if len(tags) == maxSuggestedTags {
+			break
+		}
+	}
+	if len(tags) == 0 {
+		return errors.New("no usable tags")
+	}
+	insights.Tags = tags
+	return nil
+}
@@ -0,0 +1,220 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/blog-platform/domain"
+)
+
+// AIPrice is the price in USD per million prompt and completion tokens.
+type AIPrice struct {
+	Input  float64
+	Output float64
+}
+
+// DefaultAIPricing covers common hosted models; unknown models, such as
+// local Ollama ones, cost nothing.
+var DefaultAIPricing = map[string]AIPrice{
+	"gpt-3.5-turbo": {Input: 0.5, Output: 1.5},
+	"gpt-4o":        {Input: 2.5, Output: 10},
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.6},
+	"gpt-4.1":       {Input: 2, Output: 8},
+	"gpt-4.1-mini":  {Input: 0.4, Output: 1.6},
+}
+
+// MeteredAIProvider wraps a provider with per-user daily quotas, a usage
+// ledger and a cache of answers to identical prompts. The caller is taken
+// from domain.AICallerFrom; requests without one are recorded as user 0.
+type MeteredAIProvider struct {
+	provider domain.IAIProvider
+	usage    domain.IAIUsageUsecase
+	cache    *Cache
+	// CacheTTL is how long answers are reused; 0 disables the cache
+	CacheTTL time.Duration
+	// Pricing is matched against the longest model name prefix
+	Pricing map[string]AIPrice
+}
+
+// NewMeteredAIProvider reads AI_CACHE_TTL (default 1h, 0 to disable) and
+// AI_PRICING, a comma separated list of model=input:output prices in USD per
+// million tokens added to DefaultAIPricing.
+func NewMeteredAIProvider(provider domain.IAIProvider, usage domain.IAIUsageUsecase) *MeteredAIProvider {
+	p := &MeteredAIProvider{
+		provider: provider,
+		usage:    usage,
+		cache:    NewCache("ai_answers", 1000),
+		CacheTTL: time.Hour,
+		Pricing:  make(map[string]AIPrice, len(DefaultAIPricing)),
+	}
+	for model, price := range DefaultAIPricing {
+		p.Pricing[model] = price
+	}
+	if value := os.Getenv("AI_CACHE_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl < 0 {
+			log.Printf("AI_CACHE_TTL: invalid duration '%s', using %s", value, p.CacheTTL)
+		} else {
+			p.CacheTTL = ttl
+		}
+	}
+	for _, entry := range strings.Split(os.Getenv("AI_PRICING"), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		model, price, err := parseAIPrice(entry)
+		if err != nil {
+			log.Printf("AI_PRICING: %v", err)
+			continue
+		}
+		p.Pricing[model] = price
+	}
+	return p
+}
+
+func parseAIPrice(entry string) (string, AIPrice, error) {
+	model, prices, ok := strings.Cut(strings.TrimSpace(entry), "=")
+	input, output, ok2 := strings.Cut(prices, ":")
+	if !ok || !ok2 || model == "" {
+		return "", AIPrice{}, fmt.Errorf("invalid entry '%s', want model=input:output", entry)
+	}
+	in, err := strconv.ParseFloat(input, 64)
+	out, err2 := strconv.ParseFloat(output, 64)
+	if err != nil || err2 != nil || in < 0 || out < 0 {
+		return "", AIPrice{}, fmt.Errorf("invalid prices in '%s'", entry)
+	}
+	return model, AIPrice{Input: in, Output: out}, nil
+}
+
+func (p *MeteredAIProvider) Name() string { return p.provider.Name() }
+
+// cost estimates the price of a completion; dated model versions such as
+// gpt-4o-mini-2024-07-18 match their base name.
+func (p *MeteredAIProvider) cost(completion *domain.AICompletion) float64 {
+	var best string
+	for model := range p.Pricing {
+		if strings.HasPrefix(completion.Model, model) && len(model) > len(best) {
+			best = model
+		}
+	}
+	if best == "" {
+		return 0
+	}
+	price := p.Pricing[best]
+	return (float64(completion.PromptTokens)*price.Input + float64(completion.CompletionTokens)*price.Output) / 1e6
+}
+
+// cacheKey identifies a prompt; the feature and caller are left out so that
+// identical prompts share an answer.
+func (p *MeteredAIProvider) cacheKey(req domain.AICompletionRequest) string {
+	payload, _ := json.Marshal(struct {
+		Provider  string
+		Model     string
+		Messages  []domain.AIMessage
+		MaxTokens int
+		Schema    *domain.AIResponseSchema
+	}{p.provider.Name(), req.Model, req.Messages, req.MaxTokens, req.ResponseSchema})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func (p *MeteredAIProvider) cached(req domain.AICompletionRequest) (*domain.AICompletion, string) {
+	if p.CacheTTL <= 0 || req.NoCache {
+		return nil, ""
+	}
+	key := p.cacheKey(req)
+	if v, ok := p.cache.Get(key); ok {
+		completion := *v.(*domain.AICompletion)
+		return &completion, key
+	}
+	return nil, key
+}
+
+// record writes the ledger entry even if the client has gone away.
+func (p *MeteredAIProvider) record(ctx context.Context, caller domain.AICaller, req domain.AICompletionRequest, completion *domain.AICompletion, cached bool) {
+	usage := &domain.AIUsage{
+		UserID:           caller.UserID,
+		Feature:          req.Feature,
+		Provider:         p.provider.Name(),
+		Model:            completion.Model,
+		PromptTokens:     completion.PromptTokens,
+		CompletionTokens: completion.CompletionTokens,
+		Cached:           cached,
+	}
+	if !cached {
+		usage.CostUSD = p.cost(completion)
+	}
+	p.usage.Record(context.WithoutCancel(ctx), usage)
+}
+
+// estimateUsage fills in the token counts a failed stream did not report,
+// at about four characters a token.
+func estimateUsage(req domain.AICompletionRequest, completion *domain.AICompletion) {
+	if completion.PromptTokens == 0 {
+		chars := 0
+		for _, message := range req.Messages {
+			chars += utf8.RuneCountInString(message.Content)
+		}
+		completion.PromptTokens = (chars + 3) / 4
+	}
+	if completion.CompletionTokens == 0 {
+		completion.CompletionTokens = (utf8.RuneCountInString(completion.Text) + 3) / 4
+	}
+}
+
+func (p *MeteredAIProvider) Complete(ctx context.Context, req domain.AICompletionRequest) (*domain.AICompletion, error) {
+	return p.run(ctx, req, nil)
+}
+
+// Stream sends a cached answer as a single delta.
+func (p *MeteredAIProvider) Stream(ctx context.Context, req domain.AICompletionRequest, onDelta func(string) error) (*domain.AICompletion, error) {
+	return p.run(ctx, req, onDelta)
+}
+
+func (p *MeteredAIProvider) run(ctx context.Context, req domain.AICompletionRequest, onDelta func(string) error) (*domain.AICompletion, error) {
+	caller, _ := domain.AICallerFrom(ctx)
+	if err := p.usage.CheckQuota(ctx, caller); err != nil {
+		return nil, domain.ErrAIQuotaExceeded
+	}
+
+	completion, key := p.cached(req)
+	if completion != nil {
+		if onDelta != nil && completion.Text != "" {
+			if err := onDelta(completion.Text); err != nil {
+				return nil, err
+			}
+		}
+		p.record(ctx, caller, req, completion, true)
+		return completion, nil
+	}
+
+	var err error
+	if onDelta == nil {
+		completion, err = p.provider.Complete(ctx, req)
+	} else {
+		completion, err = p.provider.Stream(ctx, req, onDelta)
+	}
+	if err != nil {
+		// a stream cut short was generated, and billed, up to that point
+		if completion != nil && completion.Text != "" {
+			estimateUsage(req, completion)
+			p.record(ctx, caller, req, completion, false)
+		}
+		return completion, err
+	}
+	p.record(ctx, caller, req, completion, false)
+	if key != "" {
+		stored := *completion
+		p.cache.Set(key, &stored, p.CacheTTL)
+	}
+	return completion, nil
+}
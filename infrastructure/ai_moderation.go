@@ -0,0 +1,79 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blog-platform/domain"
+)
+
+// how much of the post is sent as context for a comment
+const moderationPostContext = 1000
+
+const maxModerationReason = 500
+
+var commentModerationSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"label": {"type": "string", "enum": ["ok", "spam", "harassment", "off_topic"]},
+		"confidence": {"type": "number", "description": "how sure you are of the label, from 0 to 1"},
+		"reason": {"type": "string", "description": "one short sentence for a human reviewer"}
+	},
+	"required": ["label", "confidence", "reason"],
+	"additionalProperties": false
+}`)
+
+var moderationLabels = map[string]bool{
+	domain.ModerationOK:         true,
+	domain.ModerationSpam:       true,
+	domain.ModerationHarassment: true,
+	domain.ModerationOffTopic:   true,
+}
+
+// ModerateComment classifies a comment as ok, spam, harassment or off_topic.
+// Identical comments on the same post share a cached answer, which keeps
+// repeated spam cheap.
+func (s *AIService) ModerateComment(ctx context.Context, post *domain.Blog, comment string) (*domain.CommentModeration, error) {
+	excerpt := post.Content
+	if utf8.RuneCountInString(excerpt) > moderationPostContext {
+		excerpt = string([]rune(excerpt)[:moderationPostContext])
+	}
+	req := chat(fmt.Sprintf("You moderate comments on a blog platform. Label a comment spam if it advertises or links to unrelated things, harassment if it insults or threatens someone, off_topic if it has nothing to do with the post and ok otherwise. Disagreement and criticism are ok. Answer only with a JSON object matching this JSON Schema: %s", commentModerationSchema),
+		fmt.Sprintf("Post title: %s\n\nPost: %s\n\nComment: %s", post.Title, excerpt, comment))
+	req.ResponseSchema = &domain.AIResponseSchema{Name: "comment_moderation", Schema: commentModerationSchema}
+	req.Feature = domain.AIFeatureModeration
+
+	text, err := s.complete(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	moderation, err := parseCommentModeration(text)
+	if err != nil {
+		log.Printf("ai: %s: %v", s.provider.Name(), err)
+		return nil, errAIInvalidResponse
+	}
+	return moderation, nil
+}
+
+func parseCommentModeration(text string) (*domain.CommentModeration, error) {
+	var moderation domain.CommentModeration
+	if err := decodeAnswer(text, &moderation); err != nil {
+		return nil, fmt.Errorf("invalid moderation JSON: %w", err)
+	}
+	moderation.Label = strings.ToLower(strings.TrimSpace(moderation.Label))
+	if !moderationLabels[moderation.Label] {
+		return nil, fmt.Errorf("unknown moderation label '%s'", moderation.Label)
+	}
+	if moderation.Confidence < 0 || moderation.Confidence > 1 {
+		return nil, fmt.Errorf("confidence %v is not between 0 and 1", moderation.Confidence)
+	}
+	moderation.Reason = strings.TrimSpace(moderation.Reason)
+	if utf8.RuneCountInString(moderation.Reason) > maxModerationReason {
+		moderation.Reason = string([]rune(moderation.Reason)[:maxModerationReason])
+	}
+	return &moderation, nil
+}
@@ -0,0 +1,561 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+const (
+	AIProviderOpenAI = "openai"
+	AIProviderOllama = "ollama"
+)
+
+var (
+	errAINotConfigured = errors.New("AI provider is not configured")
+	errAITimeout       = errors.New("AI provider timed out")
+	errAIUnavailable   = errors.New("AI provider request failed")
+)
+
+// maxAIResponseSize bounds how much of a provider response is read.
+const maxAIResponseSize = 4 << 20
+
+// errStreamDone is returned by line handlers once the stream is complete.
+var errStreamDone = errors.New("stream done")
+
+// AIProviderConfig configures the HTTP side of a provider. Zero values use
+// the provider's defaults.
+type AIProviderConfig struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	// Timeout bounds each attempt, not the retries together. For streams it
+	// bounds the wait for the response and for each following chunk.
+	Timeout time.Duration
+	// MaxRetries is the number of retries after the first attempt for 429,
+	// 5xx and network errors
+	MaxRetries int
+	// RetryBackoff is the first retry delay; it doubles with every retry
+	RetryBackoff time.Duration
+	HTTPClient   *http.Client
+}
+
+// NewAIProviderFromEnv builds the provider named by AI_PROVIDER (openai or
+// ollama, default openai) from AI_BASE_URL, AI_MODEL, AI_API_KEY (falling
+// back to OPENAI_API_KEY), AI_TIMEOUT and AI_MAX_RETRIES.
+func NewAIProviderFromEnv() (domain.IAIProvider, error) {
+	cfg, err := aiConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	cfg.BaseURL = os.Getenv("AI_BASE_URL")
+	cfg.Model = os.Getenv("AI_MODEL")
+
+	switch provider := strings.ToLower(os.Getenv("AI_PROVIDER")); provider {
+	case "", AIProviderOpenAI:
+		return NewOpenAIProvider(cfg), nil
+	case AIProviderOllama:
+		return NewOllamaProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("AI_PROVIDER: unknown provider '%s'", provider)
+	}
+}
+
+// aiConfigFromEnv reads the settings shared by chat and embedding
+// providers: the API key, AI_TIMEOUT and AI_MAX_RETRIES.
+func aiConfigFromEnv() (AIProviderConfig, error) {
+	cfg := AIProviderConfig{
+		APIKey:     os.Getenv("AI_API_KEY"),
+		MaxRetries: 2,
+	}
+	if cfg.APIKey == "" {
+		cfg.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if value := os.Getenv("AI_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return cfg, fmt.Errorf("AI_TIMEOUT: invalid duration '%s'", value)
+		}
+		cfg.Timeout = timeout
+	}
+	if value := os.Getenv("AI_MAX_RETRIES"); value != "" {
+		retries, err := strconv.Atoi(value)
+		if err != nil || retries < 0 || retries > 10 {
+			return cfg, fmt.Errorf("AI_MAX_RETRIES: must be between 0 and 10")
+		}
+		cfg.MaxRetries = retries
+	}
+	return cfg, nil
+}
+
+func (cfg AIProviderConfig) withDefaults(baseURL, model string) AIProviderConfig {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = baseURL
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	if cfg.Model == "" {
+		cfg.Model = model
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 60 * time.Second
+	}
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{}
+	}
+	return cfg
+}
+
+// retryableError marks failures worth another attempt; after is the delay
+// the server asked for, if any.
+type retryableError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// postJSON sends body to path and decodes the response into out, retrying
+// with exponential backoff while the failure is transient.
+func (cfg AIProviderConfig) postJSON(ctx context.Context, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	return cfg.retry(ctx, func() error {
+		return cfg.attempt(ctx, path, payload, out)
+	})
+}
+
+// retry calls fn until it succeeds, fails with an error that is not a
+// retryableError or has been retried MaxRetries times. It waits between
+// attempts with exponential backoff, unless the server asked for a delay.
+func (cfg AIProviderConfig) retry(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		var retryable *retryableError
+		if err == nil || !errors.As(err, &retryable) || attempt >= cfg.MaxRetries {
+			return err
+		}
+
+		delay := retryable.after
+		if delay == 0 {
+			// exponential backoff with jitter so that clients do not retry in step
+			backoff := cfg.RetryBackoff << attempt
+			delay = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (cfg AIProviderConfig) attempt(ctx context.Context, path string, payload []byte, out interface{}) error {
+	attemptCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(attemptCtx, http.MethodPost, cfg.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+	}
+
+	resp, err := cfg.HTTPClient.Do(req)
+	if err == nil {
+		defer resp.Body.Close()
+		var respBody []byte
+		respBody, err = io.ReadAll(io.LimitReader(resp.Body, maxAIResponseSize))
+		if err == nil {
+			return decodeAIResponse(resp, respBody, out)
+		}
+	}
+	// the caller gave up, e.g. the client disconnected
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s", errAITimeout, cfg.Timeout)
+	}
+	return &retryableError{err: fmt.Errorf("%w: %v", errAIUnavailable, err)}
+}
+
+// streamLines sends body to path and calls onLine with every non-empty line
+// of the response until it ends or onLine returns errStreamDone. Only
+// failures before the response arrives are retried, as the lines already
+// handled cannot be taken back.
+func (cfg AIProviderConfig) streamLines(ctx context.Context, path string, body interface{}, onLine func([]byte) error) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	return cfg.retry(ctx, func() error {
+		return cfg.streamAttempt(ctx, path, payload, onLine)
+	})
+}
+
+func (cfg AIProviderConfig) streamAttempt(ctx context.Context, path string, payload []byte, onLine func([]byte) error) error {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// an idle timer instead of a deadline: long answers may take minutes
+	// in total but a stalled stream should not hang forever
+	var timedOut atomic.Bool
+	idle := time.AfterFunc(cfg.Timeout, func() {
+		timedOut.Store(true)
+		cancel()
+	})
+	defer idle.Stop()
+
+	failed := func(err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if timedOut.Load() {
+			return fmt.Errorf("%w after %s", errAITimeout, cfg.Timeout)
+		}
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(attemptCtx, http.MethodPost, cfg.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+	}
+
+	resp, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		return failed(&retryableError{err: fmt.Errorf("%w: %v", errAIUnavailable, err)})
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxAIResponseSize))
+		if err != nil {
+			return failed(&retryableError{err: fmt.Errorf("%w: %v", errAIUnavailable, err)})
+		}
+		return decodeAIResponse(resp, respBody, nil)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		idle.Reset(cfg.Timeout)
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := onLine(line); err != nil {
+			if errors.Is(err, errStreamDone) {
+				return nil
+			}
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return failed(fmt.Errorf("%w: %v", errAIUnavailable, err))
+	}
+	return nil
+}
+
+func decodeAIResponse(resp *http.Response, body []byte, out interface{}) error {
+	if resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("%w: invalid response: %v", errAIUnavailable, err)
+		}
+		return nil
+	}
+
+	snippet := string(body)
+	if len(snippet) > 200 {
+		snippet = snippet[:200]
+	}
+	err := fmt.Errorf("%w: status %d: %s", errAIUnavailable, resp.StatusCode, snippet)
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: status %d", errAINotConfigured, resp.StatusCode)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		retryable := &retryableError{err: err}
+		if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && seconds > 0 && seconds <= 30 {
+			retryable.after = time.Duration(seconds) * time.Second
+		}
+		return retryable
+	}
+	return err
+}
+
+// OpenAIProvider talks to the OpenAI chat completions API or any server
+// compatible with it (Azure OpenAI proxies, vLLM, LM Studio...).
+type OpenAIProvider struct {
+	cfg AIProviderConfig
+}
+
+func NewOpenAIProvider(cfg AIProviderConfig) *OpenAIProvider {
+	return &OpenAIProvider{cfg: cfg.withDefaults("https://api.openai.com/v1", "gpt-3.5-turbo")}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return AIProviderOpenAI
+}
+
+type openAIChatRequest struct {
+	Model          string                `json:"model"`
+	Messages       []domain.AIMessage    `json:"messages"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	Stream         bool                  `json:"stream,omitempty"`
+	StreamOptions  *openAIStreamOptions  `json:"stream_options,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+type openAIResponseFormat struct {
+	Type       string `json:"type"`
+	JSONSchema struct {
+		Name   string          `json:"name"`
+		Schema json.RawMessage `json:"schema"`
+		Strict bool            `json:"strict"`
+	} `json:"json_schema"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIStreamChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta domain.AIMessage `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+type openAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message domain.AIMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func (p *OpenAIProvider) request(req domain.AICompletionRequest) (openAIChatRequest, error) {
+	// the hosted API always needs a key; compatible servers may not
+	if p.cfg.APIKey == "" && strings.HasPrefix(p.cfg.BaseURL, "https://api.openai.com") {
+		return openAIChatRequest{}, errAINotConfigured
+	}
+	model := req.Model
+	if model == "" {
+		model = p.cfg.Model
+	}
+	body := openAIChatRequest{Model: model, Messages: req.Messages, MaxTokens: req.MaxTokens}
+	if req.ResponseSchema != nil {
+		body.ResponseFormat = &openAIResponseFormat{Type: "json_schema"}
+		body.ResponseFormat.JSONSchema.Name = req.ResponseSchema.Name
+		body.ResponseFormat.JSONSchema.Schema = req.ResponseSchema.Schema
+		body.ResponseFormat.JSONSchema.Strict = true
+	}
+	return body, nil
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req domain.AICompletionRequest) (*domain.AICompletion, error) {
+	body, err := p.request(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp openAIChatResponse
+	err = p.cfg.postJSON(ctx, "/chat/completions", body, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("%w: no choices returned", errAIUnavailable)
+	}
+	return &domain.AICompletion{
+		Text:             resp.Choices[0].Message.Content,
+		Model:            resp.Model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}, nil
+}
+
+// Stream reads the server-sent events of a streamed chat completion, which
+// end with "data: [DONE]".
+func (p *OpenAIProvider) Stream(ctx context.Context, req domain.AICompletionRequest, onDelta func(string) error) (*domain.AICompletion, error) {
+	body, err := p.request(req)
+	if err != nil {
+		return nil, err
+	}
+	body.Stream = true
+	body.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+
+	completion := &domain.AICompletion{Model: body.Model}
+	var text strings.Builder
+	done := false
+	err = p.cfg.streamLines(ctx, "/chat/completions", body, func(line []byte) error {
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			return nil // comments and other SSE fields
+		}
+		data = bytes.TrimSpace(data)
+		if string(data) == "[DONE]" {
+			done = true
+			return errStreamDone
+		}
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("%w: invalid stream chunk: %v", errAIUnavailable, err)
+		}
+		if chunk.Model != "" {
+			completion.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			completion.PromptTokens = chunk.Usage.PromptTokens
+			completion.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			text.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	completion.Text = text.String()
+	if err != nil {
+		return completion, err
+	}
+	if !done {
+		return completion, fmt.Errorf("%w: stream ended early", errAIUnavailable)
+	}
+	return completion, nil
+}
+
+// OllamaProvider talks to a local Ollama server's chat API.
+type OllamaProvider struct {
+	cfg AIProviderConfig
+}
+
+func NewOllamaProvider(cfg AIProviderConfig) *OllamaProvider {
+	return &OllamaProvider{cfg: cfg.withDefaults("http://localhost:11434", "llama3.2")}
+}
+
+func (p *OllamaProvider) Name() string {
+	return AIProviderOllama
+}
+
+type ollamaChatRequest struct {
+	Model    string             `json:"model"`
+	Messages []domain.AIMessage `json:"messages"`
+	Stream   bool               `json:"stream"`
+	Options  map[string]int     `json:"options,omitempty"`
+	// Format constrains the answer to a JSON Schema
+	Format json.RawMessage `json:"format,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Model           string           `json:"model"`
+	Message         domain.AIMessage `json:"message"`
+	Done            bool             `json:"done"`
+	Error           string           `json:"error"`
+	PromptEvalCount int              `json:"prompt_eval_count"`
+	EvalCount       int              `json:"eval_count"`
+}
+
+func (p *OllamaProvider) request(req domain.AICompletionRequest) ollamaChatRequest {
+	body := ollamaChatRequest{Model: req.Model, Messages: req.Messages}
+	if body.Model == "" {
+		body.Model = p.cfg.Model
+	}
+	if req.MaxTokens > 0 {
+		body.Options = map[string]int{"num_predict": req.MaxTokens}
+	}
+	if req.ResponseSchema != nil {
+		body.Format = req.ResponseSchema.Schema
+	}
+	return body
+}
+
+func (p *OllamaProvider) Complete(ctx context.Context, req domain.AICompletionRequest) (*domain.AICompletion, error) {
+	var resp ollamaChatResponse
+	if err := p.cfg.postJSON(ctx, "/api/chat", p.request(req), &resp); err != nil {
+		return nil, err
+	}
+	return &domain.AICompletion{
+		Text:             resp.Message.Content,
+		Model:            resp.Model,
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+	}, nil
+}
+
+// Stream reads Ollama's newline-delimited JSON objects; the last one has
+// "done" set and carries the token counts.
+func (p *OllamaProvider) Stream(ctx context.Context, req domain.AICompletionRequest, onDelta func(string) error) (*domain.AICompletion, error) {
+	body := p.request(req)
+	body.Stream = true
+
+	completion := &domain.AICompletion{Model: body.Model}
+	var text strings.Builder
+	done := false
+	err := p.cfg.streamLines(ctx, "/api/chat", body, func(line []byte) error {
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("%w: invalid stream chunk: %v", errAIUnavailable, err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("%w: %s", errAIUnavailable, chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			text.WriteString(chunk.Message.Content)
+			if err := onDelta(chunk.Message.Content); err != nil {
+				return err
+			}
+		}
+		if chunk.Done {
+			done = true
+			completion.PromptTokens = chunk.PromptEvalCount
+			completion.CompletionTokens = chunk.EvalCount
+			return errStreamDone
+		}
+		return nil
+	})
+	completion.Text = text.String()
+	if err != nil {
+		return completion, err
+	}
+	if !done {
+		return completion, fmt.Errorf("%w: stream ended early", errAIUnavailable)
+	}
+	return completion, nil
+}
@@ -1,123 +1,122 @@
 package infrastructure
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-	"os"
-)
+	"log"
+	"strings"
 
-type ChatGPTAIService struct {
-	APIKey string
-}
+	"github.com/blog-platform/domain"
+)
 
-func NewChatGPTAIService() *ChatGPTAIService {
-	return &ChatGPTAIService{
-		APIKey: os.Getenv("OPENAI_API_KEY"),
-	}
+// AIService implements the blog writing helpers on top of any provider.
+type AIService struct {
+	provider domain.IAIProvider
 }
 
-type chatGPTRequest struct {
-	Model    string        `json:"model"`
-	Messages []chatMessage `json:"messages"`
+// NewAIService returns a service using provider; a nil provider makes every
+// call fail with "AI provider is not configured".
+func NewAIService(provider domain.IAIProvider) *AIService {
+	return &AIService{provider: provider}
 }
 
-type chatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+func ideasChat(prompt domain.Prompt) domain.AICompletionRequest {
+	req := chat(prompt.System, prompt.User)
+	req.Feature = domain.AIFeatureIdeas
+	return req
 }
 
-type chatGPTResponse struct {
-	Choices []struct {
-		Message chatMessage `json:"message"`
-	} `json:"choices"`
+func improvementsChat(prompt domain.Prompt) domain.AICompletionRequest {
+	req := chat(prompt.System, prompt.User)
+	req.Feature = domain.AIFeatureImprove
+	return req
 }
 
-func (s *ChatGPTAIService) GenerateBlogIdeas(topic string) (string, error) {
-	if s.APIKey == "" {
-		return "", errors.New("OPENAI_API_KEY not set")
-	}
-
-	reqBody := chatGPTRequest{
-		Model: "gpt-3.5-turbo",
-		Messages: []chatMessage{
-			{Role: "system", Content: "You are a helpful assistant that generates blog ideas."},
-			{Role: "user", Content: fmt.Sprintf("Generate blog ideas about: %s", topic)},
-		},
-	}
-
-	bodyBytes, _ := json.Marshal(reqBody)
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(bodyBytes))
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Authorization", "Bearer "+s.APIKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
+// GenerateBlogIdeas sends a prompt rendered from the ideas templates.
+func (s *AIService) GenerateBlogIdeas(ctx context.Context, prompt domain.Prompt) (string, error) {
+	text, err := s.complete(ctx, ideasChat(prompt), nil)
+	if err == nil && text == "" {
+		return "", errors.New("no ideas generated")
 	}
-	defer resp.Body.Close()
+	return text, err
+}
 
-	respBody, _ := ioutil.ReadAll(resp.Body)
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("OpenAI API error: %s", respBody)
+// SuggestBlogImprovements sends a prompt rendered from the improve
+// templates.
+func (s *AIService) SuggestBlogImprovements(ctx context.Context, prompt domain.Prompt) (string, error) {
+	text, err := s.complete(ctx, improvementsChat(prompt), nil)
+	if err == nil && text == "" {
+		return "", errors.New("no suggestions generated")
 	}
+	return text, err
+}
 
-	var chatResp chatGPTResponse
-	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return "", err
-	}
-	if len(chatResp.Choices) == 0 {
+// StreamBlogIdeas is GenerateBlogIdeas passing each piece of the answer to
+// onDelta as it arrives; the full text is returned at the end.
+func (s *AIService) StreamBlogIdeas(ctx context.Context, prompt domain.Prompt, onDelta func(string) error) (string, error) {
+	text, err := s.complete(ctx, ideasChat(prompt), onDelta)
+	if err == nil && text == "" {
 		return "", errors.New("no ideas generated")
 	}
-	return chatResp.Choices[0].Message.Content, nil
+	return text, err
 }
 
-func (s *ChatGPTAIService) SuggestBlogImprovements(content string) (string, error) {
-	if s.APIKey == "" {
-		return "", errors.New("OPENAI_API_KEY not set")
+// StreamBlogImprovements is SuggestBlogImprovements passing each piece of
+// the answer to onDelta as it arrives.
+func (s *AIService) StreamBlogImprovements(ctx context.Context, prompt domain.Prompt, onDelta func(string) error) (string, error) {
+	text, err := s.complete(ctx, improvementsChat(prompt), onDelta)
+	if err == nil && text == "" {
+		return "", errors.New("no suggestions generated")
 	}
+	return text, err
+}
 
-	reqBody := chatGPTRequest{
-		Model: "gpt-3.5-turbo",
-		Messages: []chatMessage{
-			{Role: "system", Content: "You are a helpful assistant that suggests improvements for blog content."},
-			{Role: "user", Content: fmt.Sprintf("Suggest improvements for this blog content: %s", content)},
+func chat(system, user string) domain.AICompletionRequest {
+	return domain.AICompletionRequest{
+		Messages: []domain.AIMessage{
+			{Role: "system", Content: system},
+			{Role: "user", Content: user},
 		},
 	}
+}
 
-	bodyBytes, _ := json.Marshal(reqBody)
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(bodyBytes))
-	if err != nil {
-		return "", err
+// complete runs a single-turn chat, streamed when onDelta is set, and
+// reduces provider failures to the errors callers handle; the details are
+// logged. Errors returned by onDelta are passed through unchanged.
+func (s *AIService) complete(ctx context.Context, req domain.AICompletionRequest, onDelta func(string) error) (string, error) {
+	if s.provider == nil {
+		return "", errAINotConfigured
 	}
-	req.Header.Set("Authorization", "Bearer "+s.APIKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
+	var completion *domain.AICompletion
+	var err error
+	if onDelta == nil {
+		completion, err = s.provider.Complete(ctx, req)
+	} else {
+		var deltaErr error
+		completion, err = s.provider.Stream(ctx, req, func(delta string) error {
+			deltaErr = onDelta(delta)
+			return deltaErr
+		})
+		if deltaErr != nil {
+			return "", deltaErr
+		}
 	}
-	defer resp.Body.Close()
-
-	respBody, _ := ioutil.ReadAll(resp.Body)
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("OpenAI API error: %s", respBody)
+	if err != nil {
+		return "", aiError(s.provider.Name(), err)
 	}
+	return strings.TrimSpace(completion.Text), nil
+}
 
-	var chatResp chatGPTResponse
-	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return "", err
+func aiError(provider string, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, domain.ErrAIQuotaExceeded) {
+		return err
 	}
-	if len(chatResp.Choices) == 0 {
-		return "", errors.New("no suggestions generated")
+	log.Printf("ai: %s: %v", provider, err)
+	switch {
+	case errors.Is(err, errAINotConfigured):
+		return errAINotConfigured
+	case errors.Is(err, errAITimeout):
+		return errAITimeout
 	}
-	return chatResp.Choices[0].Message.Content, nil
+	return errAIUnavailable
 }
@@ -3,24 +3,35 @@ package infrastructure
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/blog-platform/domain"
 	"github.com/gin-gonic/gin"
 )
 
 type Middleware struct {
-	tokenInfra domain.IJWTInfrastructure
-	blogRepo   domain.IBlogRepository
+	tokenInfra   domain.IJWTInfrastructure
+	blogRepo     domain.IBlogRepository
+	accessTokens domain.IPersonalAccessTokenUsecase
 }
 
-func NewMiddleware(tokenInfra domain.IJWTInfrastructure, blogRepo domain.IBlogRepository) *Middleware {
-	return &Middleware{tokenInfra: tokenInfra, blogRepo: blogRepo}
+func NewMiddleware(tokenInfra domain.IJWTInfrastructure, blogRepo domain.IBlogRepository, accessTokens domain.IPersonalAccessTokenUsecase) *Middleware {
+	return &Middleware{tokenInfra: tokenInfra, blogRepo: blogRepo, accessTokens: accessTokens}
 }
 
+// AuthMiddleware accepts JWT access tokens and personal access tokens. For
+// the latter the token's scopes are stored as "token_scopes" and must allow
+// the request method.
 func (m *Middleware) AuthMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authHeader := ctx.GetHeader("Authorization")
 
+		raw := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+		if strings.HasPrefix(raw, domain.PersonalAccessTokenPrefix) {
+			m.personalAccessToken(ctx, raw)
+			return
+		}
+
 		claims, err := m.tokenInfra.ValidateAccessToken(authHeader)
 		if err != nil {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
@@ -37,6 +48,61 @@ func (m *Middleware) AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// OptionalAuthMiddleware authenticates requests that carry a token like
+// AuthMiddleware and lets requests without one through anonymously.
+func (m *Middleware) OptionalAuthMiddleware() gin.HandlerFunc {
+	auth := m.AuthMiddleware()
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") == "" {
+			ctx.Next()
+			return
+		}
+		auth(ctx)
+	}
+}
+
+func (m *Middleware) personalAccessToken(ctx *gin.Context, token string) {
+	if m.accessTokens == nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		ctx.Abort()
+		return
+	}
+	claims, scopes, err := m.accessTokens.Authenticate(ctx.Request.Context(), token, ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		ctx.Abort()
+		return
+	}
+
+	required := domain.ScopeWrite
+	if ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead {
+		required = domain.ScopeRead
+	}
+	// write implies read
+	if !hasScope(scopes, required) && !(required == domain.ScopeRead && hasScope(scopes, domain.ScopeWrite)) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "token scope does not allow this request"})
+		ctx.Abort()
+		return
+	}
+
+	userID, _ := strconv.ParseInt(claims.UserID, 10, 64)
+	ctx.Set("user_id", userID)
+	ctx.Set("role", claims.UserRole)
+	ctx.Set("token_scopes", scopes)
+	ctx.Next()
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminMiddleware requires the admin role, and the admin scope when the
+// request uses a personal access token.
 func (m *Middleware) AdminMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		role, ok := ctx.Get("role")
@@ -45,11 +111,29 @@ func (m *Middleware) AdminMiddleware() gin.HandlerFunc {
 			ctx.Abort()
 			return
 		}
+		if scopes, ok := ctx.Get("token_scopes"); ok && !hasScope(scopes.([]string), domain.ScopeAdmin) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "token scope does not allow this request"})
+			ctx.Abort()
+			return
+		}
 
 		ctx.Next()
 	}
 }
 
+// SessionOnlyMiddleware rejects personal access tokens on routes that manage
+// credentials, so that a leaked token cannot be used to take over the account.
+func (m *Middleware) SessionOnlyMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, ok := ctx.Get("token_scopes"); ok {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "this route requires a login session"})
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
 func (m *Middleware) AccountOwnerMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
@@ -1,58 +1,247 @@
 package infrastructure
 
 import (
+	"container/list"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+const (
+	defaultCacheCapacity = 1000
+	cacheSweepInterval   = time.Minute
 )
 
 type cacheItem struct {
+	key       string
 	value     interface{}
 	expiresAt time.Time
 	hasExpiry bool
+	tags      []string
 }
 
+// Cache is a size-bounded LRU cache. Entries may expire after a TTL and may
+// carry tags, so that everything derived from a record can be dropped with
+// InvalidateTag. Expired entries are removed when read and by a background
+// sweep; Close stops the sweep.
 type Cache struct {
-	mu   sync.RWMutex
-	data map[string]cacheItem
+	name     string
+	capacity int
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List // most recently used first
+	tags  map[string]map[string]struct{}
+	stats domain.CacheStats
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+var (
+	cachesMu sync.Mutex
+	caches   []*Cache
+)
+
+// NewCache returns a cache of at most capacity entries, 1000 if capacity is
+// not positive. Its stats are reported under name by CacheStats.
+func NewCache(name string, capacity int) *Cache {
+	if capacity <= 0 {
+		capacity = defaultCacheCapacity
+	}
+	c := &Cache{
+		name:     name,
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		tags:     make(map[string]map[string]struct{}),
+		stop:     make(chan struct{}),
+	}
+	go c.sweepEvery(cacheSweepInterval)
+
+	cachesMu.Lock()
+	caches = append(caches, c)
+	cachesMu.Unlock()
+	return c
 }
 
-func NewCache() *Cache {
-	return &Cache{data: make(map[string]cacheItem)}
+// CacheStats sums the stats of the open caches by name.
+func CacheStats() []domain.CacheStats {
+	cachesMu.Lock()
+	open := append([]*Cache(nil), caches...)
+	cachesMu.Unlock()
+
+	byName := make(map[string]*domain.CacheStats)
+	for _, c := range open {
+		s := c.Stats()
+		total, ok := byName[s.Name]
+		if !ok {
+			total = &domain.CacheStats{Name: s.Name}
+			byName[s.Name] = total
+		}
+		total.Entries += s.Entries
+		total.Capacity += s.Capacity
+		total.Hits += s.Hits
+		total.Misses += s.Misses
+		total.Evictions += s.Evictions
+		total.Expirations += s.Expirations
+		total.Invalidations += s.Invalidations
+	}
+	stats := make([]domain.CacheStats, 0, len(byName))
+	for _, s := range byName {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
 }
 
-func (c *Cache) Set(key string, value interface{}, ttl time.Duration) {
+// Set stores value under key, replacing any previous value and tags. A ttl
+// of 0 keeps the entry until it is evicted or invalidated.
+func (c *Cache) Set(key string, value interface{}, ttl time.Duration, tags ...string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	item := cacheItem{value: value}
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	item := &cacheItem{key: key, value: value, tags: tags}
 	if ttl > 0 {
 		item.hasExpiry = true
 		item.expiresAt = time.Now().Add(ttl)
 	}
-	c.data[key] = item
+	c.items[key] = c.order.PushFront(item)
+	for _, tag := range tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
 }
 
 func (c *Cache) Get(key string) (interface{}, bool) {
-	c.mu.RLock()
-	item, ok := c.data[key]
-	c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
 	if !ok {
+		c.stats.Misses++
 		return nil, false
 	}
+	item := el.Value.(*cacheItem)
 	if item.hasExpiry && time.Now().After(item.expiresAt) {
-		c.Delete(key)
+		c.remove(el)
+		c.stats.Expirations++
+		c.stats.Misses++
 		return nil, false
 	}
+	c.order.MoveToFront(el)
+	c.stats.Hits++
 	return item.value, true
 }
 
 func (c *Cache) Delete(key string) {
 	c.mu.Lock()
-	delete(c.data, key)
-	c.mu.Unlock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+		c.stats.Invalidations++
+	}
+}
+
+// InvalidateTag deletes every entry tagged with tag and returns how many
+// there were.
+func (c *Cache) InvalidateTag(tag string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := c.tags[tag]
+	n := len(keys)
+	for key := range keys {
+		c.remove(c.items[key])
+	}
+	c.stats.Invalidations += uint64(n)
+	return n
 }
 
 func (c *Cache) Clear() {
 	c.mu.Lock()
-	c.data = make(map[string]cacheItem)
-	c.mu.Unlock()
+	defer c.mu.Unlock()
+	c.stats.Invalidations += uint64(c.order.Len())
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	c.tags = make(map[string]map[string]struct{})
+}
+
+func (c *Cache) Stats() domain.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Name = c.name
+	stats.Entries = c.order.Len()
+	stats.Capacity = c.capacity
+	return stats
+}
+
+// Close stops the background sweep and drops the cache from CacheStats.
+func (c *Cache) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		cachesMu.Lock()
+		defer cachesMu.Unlock()
+		for i, open := range caches {
+			if open == c {
+				caches = append(caches[:i], caches[i+1:]...)
+				break
+			}
+		}
+	})
+}
+
+// remove unlinks an entry from the list and the tag index. c.mu must be
+// held.
+func (c *Cache) remove(el *list.Element) {
+	item := c.order.Remove(el).(*cacheItem)
+	delete(c.items, item.key)
+	for _, tag := range item.tags {
+		if keys, ok := c.tags[tag]; ok {
+			delete(keys, item.key)
+			if len(keys) == 0 {
+				delete(c.tags, tag)
+			}
+		}
+	}
+}
+
+func (c *Cache) sweepEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			c.sweep(now)
+		}
+	}
+}
+
+// sweep removes expired entries.
+func (c *Cache) sweep(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for el := c.order.Back(); el != nil; {
+		prev := el.Prev()
+		if item := el.Value.(*cacheItem); item.hasExpiry && now.After(item.expiresAt) {
+			c.remove(el)
+			c.stats.Expirations++
+		}
+		el = prev
+	}
 }
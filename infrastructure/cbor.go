@@ -0,0 +1,151 @@
+package infrastructure
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// cborMaxDepth bounds nesting so that hostile input cannot exhaust the stack.
+const cborMaxDepth = 16
+
+// decodeCBOR decodes the first CBOR item in data and returns it with the
+// remaining bytes. It covers the subset used by WebAuthn (CTAP2 canonical
+// encoding): integers become int64, byte strings []byte, text strings string,
+// arrays []interface{} and maps map[interface{}]interface{}. Indefinite
+// lengths and tags other than their content are not supported.
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > cborMaxDepth {
+		return nil, nil, errors.New("cbor: nesting too deep")
+	}
+	if len(data) == 0 {
+		return nil, nil, errors.New("cbor: unexpected end of data")
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+
+	if major == 7 {
+		return decodeCBORSimple(data, info)
+	}
+
+	arg, rest, err := cborArgument(data, info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return int64(arg), rest, nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return -1 - int64(arg), rest, nil
+	case 2, 3:
+		if arg > uint64(len(rest)) {
+			return nil, nil, errors.New("cbor: unexpected end of data")
+		}
+		if major == 2 {
+			b := make([]byte, arg)
+			copy(b, rest[:arg])
+			return b, rest[arg:], nil
+		}
+		return string(rest[:arg]), rest[arg:], nil
+	case 4:
+		// every item takes at least one byte
+		if arg > uint64(len(rest)) {
+			return nil, nil, errors.New("cbor: unexpected end of data")
+		}
+		items := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item interface{}
+			item, rest, err = decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rest, nil
+	case 5:
+		if arg > uint64(len(rest))/2 {
+			return nil, nil, errors.New("cbor: unexpected end of data")
+		}
+		m := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value interface{}
+			key, rest, err = decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errors.New("cbor: unsupported map key")
+			}
+			value, rest, err = decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, dup := m[key]; dup {
+				return nil, nil, errors.New("cbor: duplicate map key")
+			}
+			m[key] = value
+		}
+		return m, rest, nil
+	case 6:
+		// tags carry no meaning for WebAuthn; return the tagged item
+		return decodeCBORItem(rest, depth+1)
+	}
+	return nil, nil, fmt.Errorf("cbor: unsupported major type %d", major)
+}
+
+func cborArgument(data []byte, info byte) (uint64, []byte, error) {
+	rest := data[1:]
+	switch {
+	case info < 24:
+		return uint64(info), rest, nil
+	case info == 24 && len(rest) >= 1:
+		return uint64(rest[0]), rest[1:], nil
+	case info == 25 && len(rest) >= 2:
+		return uint64(binary.BigEndian.Uint16(rest)), rest[2:], nil
+	case info == 26 && len(rest) >= 4:
+		return uint64(binary.BigEndian.Uint32(rest)), rest[4:], nil
+	case info == 27 && len(rest) >= 8:
+		return binary.BigEndian.Uint64(rest), rest[8:], nil
+	case info == 31:
+		return 0, nil, errors.New("cbor: indefinite length is not supported")
+	case info >= 28:
+		return 0, nil, errors.New("cbor: invalid additional information")
+	}
+	return 0, nil, errors.New("cbor: unexpected end of data")
+}
+
+func decodeCBORSimple(data []byte, info byte) (interface{}, []byte, error) {
+	rest := data[1:]
+	switch info {
+	case 20:
+		return false, rest, nil
+	case 21:
+		return true, rest, nil
+	case 22, 23:
+		return nil, rest, nil
+	case 26:
+		if len(rest) < 4 {
+			return nil, nil, errors.New("cbor: unexpected end of data")
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(rest))), rest[4:], nil
+	case 27:
+		if len(rest) < 8 {
+			return nil, nil, errors.New("cbor: unexpected end of data")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(rest)), rest[8:], nil
+	}
+	return nil, nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+}
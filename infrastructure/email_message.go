@@ -0,0 +1,131 @@
+package infrastructure
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+// BuildMessage renders msg as an RFC 5322 message with MIME bodies. Header
+// values are validated so that user supplied input such as subjects and
+// recipients cannot inject extra headers.
+func BuildMessage(from string, msg *domain.EmailMessage, now time.Time) ([]byte, error) {
+	if msg.Text == "" && msg.HTML == "" {
+		return nil, errors.New("email has no body")
+	}
+
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+	recipients, err := parseRecipients(msg.To)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, errors.New("invalid subject")
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", sender.String())
+	writeHeader(&buf, "To", strings.Join(recipients, ", "))
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader(&buf, "Date", now.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", newMessageID(sender.Address))
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	// a single body needs no multipart wrapper
+	if msg.Text == "" || msg.HTML == "" {
+		contentType, body := "text/plain; charset=UTF-8", msg.Text
+		if msg.HTML != "" {
+			contentType, body = "text/html; charset=UTF-8", msg.HTML
+		}
+		writeHeader(&buf, "Content-Type", contentType)
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	writeHeader(&buf, "Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+
+	// least preferred part first, as required for multipart/alternative
+	parts := []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	}
+	for _, p := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(pw, p.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func parseRecipients(to []string) ([]string, error) {
+	if len(to) == 0 {
+		return nil, errors.New("email has no recipients")
+	}
+	recipients := make([]string, 0, len(to))
+	for _, addr := range to {
+		parsed, err := mail.ParseAddress(strings.TrimSpace(addr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient address '%s'", addr)
+		}
+		recipients = append(recipients, parsed.String())
+	}
+	return recipients, nil
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	// normalise line endings so the encoder emits CRLF
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func newMessageID(senderAddress string) string {
+	domainPart := "localhost"
+	if at := strings.LastIndex(senderAddress, "@"); at >= 0 {
+		domainPart = senderAddress[at+1:]
+	}
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domainPart)
+}
@@ -1,10 +1,32 @@
 package infrastructure
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
+	"net/mail"
 	"net/smtp"
 	"os"
 	"strings"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+// SMTP connection security modes
+const (
+	SMTPSecurityNone     = "none"
+	SMTPSecuritySTARTTLS = "starttls"
+	SMTPSecurityTLS      = "tls" // implicit TLS, usually port 465
+)
+
+// SMTP authentication mechanisms
+const (
+	SMTPAuthNone    = "none"
+	SMTPAuthPlain   = "plain"
+	SMTPAuthLogin   = "login"
+	SMTPAuthCRAMMD5 = "cram-md5"
 )
 
 type SendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
@@ -15,28 +37,178 @@ type SMTPEmailService struct {
 	Username   string
 	Password   string
 	From       string
+	Security   string
+	AuthMethod string
+	Timeout    time.Duration
 	SendMailFn SendMailFunc
 }
 
-func NewSMTPEmailService() *SMTPEmailService {
-	return &SMTPEmailService{
+// NewSMTPEmailService reads the SMTP_* settings. Unknown SMTP_SECURITY and
+// SMTP_AUTH values are rejected rather than risking a plaintext connection.
+func NewSMTPEmailService() (*SMTPEmailService, error) {
+	s := &SMTPEmailService{
 		Host:       os.Getenv("SMTP_HOST"),
 		Port:       os.Getenv("SMTP_PORT"),
 		Username:   os.Getenv("SMTP_USERNAME"),
 		Password:   os.Getenv("SMTP_PASSWORD"),
 		From:       os.Getenv("SMTP_FROM"),
-		SendMailFn: smtp.SendMail,
+		Security:   strings.ToLower(strings.TrimSpace(os.Getenv("SMTP_SECURITY"))),
+		AuthMethod: strings.ToLower(strings.TrimSpace(os.Getenv("SMTP_AUTH"))),
+		Timeout:    30 * time.Second,
+	}
+	switch s.Security {
+	case "":
+		s.Security = SMTPSecuritySTARTTLS
+	case SMTPSecuritySTARTTLS, SMTPSecurityTLS, SMTPSecurityNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP_SECURITY '%s', want starttls, tls or none", os.Getenv("SMTP_SECURITY"))
 	}
+	switch s.AuthMethod {
+	case "", SMTPAuthPlain, SMTPAuthLogin, SMTPAuthCRAMMD5, SMTPAuthNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP_AUTH '%s', want plain, login, cram-md5 or none", os.Getenv("SMTP_AUTH"))
+	}
+	s.SendMailFn = s.sendMail
+	return s, nil
 }
 
 func (s *SMTPEmailService) SendEmail(to []string, subject string, body string) error {
-	auth := smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	return s.Send(&domain.EmailMessage{To: to, Subject: subject, HTML: body})
+}
+
+func (s *SMTPEmailService) Send(msg *domain.EmailMessage) error {
+	raw, err := BuildMessage(s.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	sender, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	recipients := make([]string, 0, len(msg.To))
+	for _, to := range msg.To {
+		addr, err := mail.ParseAddress(strings.TrimSpace(to))
+		if err != nil {
+			return fmt.Errorf("invalid recipient address '%s'", to)
+		}
+		recipients = append(recipients, addr.Address)
+	}
+
+	addr := net.JoinHostPort(s.Host, s.Port)
+	return s.SendMailFn(addr, s.auth(), sender.Address, recipients, raw)
+}
+
+func (s *SMTPEmailService) auth() smtp.Auth {
+	switch s.AuthMethod {
+	case SMTPAuthNone:
+		return nil
+	case SMTPAuthLogin:
+		return &loginAuth{username: s.Username, password: s.Password, host: s.Host}
+	case SMTPAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(s.Username, s.Password)
+	default:
+		if s.Username == "" {
+			return nil
+		}
+		return smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+}
 
-	msg := []byte("To: " + strings.Join(to, ",") + "\r\n" +
-		"Subject: " + subject + "\r\n" +
-		"MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n" +
-		body)
+// sendMail is the default SendMailFn. Unlike smtp.SendMail it supports
+// implicit TLS and refuses to continue without encryption when STARTTLS was
+// requested but is not offered by the server.
+func (s *SMTPEmailService) sendMail(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	tlsConfig := &tls.Config{ServerName: s.Host, MinVersion: tls.VersionTLS12}
+	dialer := &net.Dialer{Timeout: s.Timeout}
 
-	addr := fmt.Sprintf("%s:%s", s.Host, s.Port)
-	return s.SendMailFn(addr, auth, s.From, to, msg)
-}
\ No newline at end of file
+	var (
+		conn net.Conn
+		err  error
+	)
+	if s.Security == SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if s.Timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(s.Timeout))
+	}
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	// STARTTLS is the default mode; only "none" may send in plaintext
+	if s.Security != SMTPSecurityNone && s.Security != SMTPSecurityTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if a != nil {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err := c.Auth(a); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// loginAuth implements the LOGIN mechanism still required by some providers.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && server.Name != "localhost" && server.Name != "127.0.0.1" && server.Name != "::1" {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+}
@@ -0,0 +1,72 @@
+package infrastructure
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/blog-platform/domain"
+)
+
+//go:embed templates/email/*
+var emailTemplateFS embed.FS
+
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// EmailTemplates renders the named HTML and plain text email templates
+// embedded under templates/email. Each name has a <name>.html and <name>.txt
+// file defining a "subject" and a "body" template; HTML bodies are wrapped in
+// layout.html.
+type EmailTemplates struct {
+	templates map[string]emailTemplate
+}
+
+func NewEmailTemplates() (*EmailTemplates, error) {
+	names := []string{domain.EmailActivation, domain.EmailPasswordReset, domain.EmailNotification, domain.EmailChange, domain.EmailChangeNotice, domain.EmailAccountLocked, domain.EmailMagicLink}
+	t := &EmailTemplates{templates: make(map[string]emailTemplate, len(names))}
+
+	for _, name := range names {
+		h, err := htmltemplate.New(name).Option("missingkey=zero").
+			ParseFS(emailTemplateFS, "templates/email/layout.html", "templates/email/"+name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("parse %s html template: %w", name, err)
+		}
+		txt, err := texttemplate.New(name).Option("missingkey=zero").
+			ParseFS(emailTemplateFS, "templates/email/"+name+".txt")
+		if err != nil {
+			return nil, fmt.Errorf("parse %s text template: %w", name, err)
+		}
+		t.templates[name] = emailTemplate{html: h, text: txt}
+	}
+	return t, nil
+}
+
+func (t *EmailTemplates) Render(name string, data map[string]string) (*domain.RenderedEmail, error) {
+	tmpl, ok := t.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template '%s'", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "body", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return nil, err
+	}
+
+	return &domain.RenderedEmail{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
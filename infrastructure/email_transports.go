@@ -0,0 +1,108 @@
+package infrastructure
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+// Email transports selectable through EMAIL_TRANSPORT
+const (
+	EmailTransportSMTP    = "smtp"
+	EmailTransportMaildir = "maildir"
+	EmailTransportLog     = "log"
+)
+
+// NewEmailTransport returns the transport configured by EMAIL_TRANSPORT,
+// defaulting to SMTP. The maildir and log transports are meant for
+// development and never send anything over the network.
+func NewEmailTransport() (domain.IEmailInfrastructure, error) {
+	from := os.Getenv("SMTP_FROM")
+	switch strings.ToLower(os.Getenv("EMAIL_TRANSPORT")) {
+	case "", EmailTransportSMTP:
+		service, err := NewSMTPEmailService()
+		if err != nil {
+			return nil, err
+		}
+		return service, nil
+	case EmailTransportMaildir:
+		dir := os.Getenv("EMAIL_MAILDIR")
+		if dir == "" {
+			dir = "maildir"
+		}
+		return NewMaildirEmailService(dir, from)
+	case EmailTransportLog:
+		return NewLogEmailService(from, log.Default()), nil
+	default:
+		return nil, fmt.Errorf("unknown email transport '%s'", os.Getenv("EMAIL_TRANSPORT"))
+	}
+}
+
+// MaildirEmailService writes every message as a file into a Maildir so it can
+// be opened with a regular mail client.
+type MaildirEmailService struct {
+	Dir  string
+	From string
+}
+
+func NewMaildirEmailService(dir, from string) (*MaildirEmailService, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, err
+		}
+	}
+	return &MaildirEmailService{Dir: dir, From: from}, nil
+}
+
+func (s *MaildirEmailService) SendEmail(to []string, subject string, body string) error {
+	return s.Send(&domain.EmailMessage{To: to, Subject: subject, HTML: body})
+}
+
+func (s *MaildirEmailService) Send(msg *domain.EmailMessage) error {
+	raw, err := BuildMessage(s.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	host, _ := os.Hostname()
+	name := fmt.Sprintf("%d.%s.%s.eml", time.Now().UnixNano(), hex.EncodeToString(b), strings.ReplaceAll(host, "/", "_"))
+
+	// deliver to tmp first and rename so readers never see partial files
+	tmp := filepath.Join(s.Dir, "tmp", name)
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.Dir, "new", name))
+}
+
+// LogEmailService prints messages to a logger instead of sending them.
+type LogEmailService struct {
+	From   string
+	Logger *log.Logger
+}
+
+func NewLogEmailService(from string, logger *log.Logger) *LogEmailService {
+	return &LogEmailService{From: from, Logger: logger}
+}
+
+func (s *LogEmailService) SendEmail(to []string, subject string, body string) error {
+	return s.Send(&domain.EmailMessage{To: to, Subject: subject, HTML: body})
+}
+
+func (s *LogEmailService) Send(msg *domain.EmailMessage) error {
+	raw, err := BuildMessage(s.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	s.Logger.Printf("email to %s:\n%s", strings.Join(msg.To, ", "), raw)
+	return nil
+}
@@ -0,0 +1,235 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/blog-platform/domain"
+)
+
+// EmbeddingProviderLocal hashes words into a vector without calling out; it
+// is deterministic, which makes it the stand-in for tests and offline use.
+const EmbeddingProviderLocal = "local"
+
+// LocalEmbeddingDimensions is the size of vectors from the local provider.
+const LocalEmbeddingDimensions = 256
+
+// NewEmbeddingProviderFromEnv builds the provider named by EMBEDDING_PROVIDER
+// (openai, ollama or local) from EMBEDDING_BASE_URL and EMBEDDING_MODEL. The
+// API key, timeout and retries are shared with the chat provider.
+func NewEmbeddingProviderFromEnv() (domain.IEmbeddingProvider, error) {
+	provider := strings.ToLower(os.Getenv("EMBEDDING_PROVIDER"))
+	if provider == "" {
+		return nil, fmt.Errorf("EMBEDDING_PROVIDER is not set")
+	}
+	if provider == EmbeddingProviderLocal {
+		return NewLocalEmbeddingProvider(LocalEmbeddingDimensions), nil
+	}
+
+	cfg, err := aiConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	cfg.BaseURL = os.Getenv("EMBEDDING_BASE_URL")
+	cfg.Model = os.Getenv("EMBEDDING_MODEL")
+	switch provider {
+	case AIProviderOpenAI:
+		return NewOpenAIEmbeddingProvider(cfg), nil
+	case AIProviderOllama:
+		return NewOllamaEmbeddingProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("EMBEDDING_PROVIDER: unknown provider '%s'", provider)
+	}
+}
+
+// OpenAIEmbeddingProvider calls the OpenAI embeddings API or a compatible
+// server.
+type OpenAIEmbeddingProvider struct {
+	cfg AIProviderConfig
+}
+
+func NewOpenAIEmbeddingProvider(cfg AIProviderConfig) *OpenAIEmbeddingProvider {
+	return &OpenAIEmbeddingProvider{cfg: cfg.withDefaults("https://api.openai.com/v1", "text-embedding-3-small")}
+}
+
+func (p *OpenAIEmbeddingProvider) Name() string  { return AIProviderOpenAI }
+func (p *OpenAIEmbeddingProvider) Model() string { return p.cfg.Model }
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (p *OpenAIEmbeddingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	var resp openAIEmbeddingResponse
+	body := map[string]interface{}{"model": p.cfg.Model, "input": texts}
+	if err := p.cfg.postJSON(ctx, "/embeddings", body, &resp); err != nil {
+		return nil, aiError(p.Name(), err)
+	}
+	vectors := make([][]float32, len(texts))
+	for _, item := range resp.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, aiError(p.Name(), fmt.Errorf("%w: embedding index %d out of range", errAIUnavailable, item.Index))
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	if err := checkEmbeddings(vectors); err != nil {
+		return nil, aiError(p.Name(), err)
+	}
+	return vectors, nil
+}
+
+// OllamaEmbeddingProvider calls a local Ollama server.
+type OllamaEmbeddingProvider struct {
+	cfg AIProviderConfig
+}
+
+func NewOllamaEmbeddingProvider(cfg AIProviderConfig) *OllamaEmbeddingProvider {
+	return &OllamaEmbeddingProvider{cfg: cfg.withDefaults("http://localhost:11434", "nomic-embed-text")}
+}
+
+func (p *OllamaEmbeddingProvider) Name() string  { return AIProviderOllama }
+func (p *OllamaEmbeddingProvider) Model() string { return p.cfg.Model }
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+func (p *OllamaEmbeddingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	var resp ollamaEmbedResponse
+	body := map[string]interface{}{"model": p.cfg.Model, "input": texts}
+	if err := p.cfg.postJSON(ctx, "/api/embed", body, &resp); err != nil {
+		return nil, aiError(p.Name(), err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, aiError(p.Name(), fmt.Errorf("%w: got %d embeddings for %d texts", errAIUnavailable, len(resp.Embeddings), len(texts)))
+	}
+	if err := checkEmbeddings(resp.Embeddings); err != nil {
+		return nil, aiError(p.Name(), err)
+	}
+	return resp.Embeddings, nil
+}
+
+// checkEmbeddings makes sure every text got a vector of the same size.
+func checkEmbeddings(vectors [][]float32) error {
+	for i, vector := range vectors {
+		if len(vector) == 0 || len(vector) != len(vectors[0]) {
+			return fmt.Errorf("%w: missing or mismatched embedding %d", errAIUnavailable, i)
+		}
+	}
+	return nil
+}
+
+// LocalEmbeddingProvider hashes words and pairs of neighbouring words into a
+// fixed number of buckets. It only matches shared vocabulary, but needs no
+// model and always gives the same vector for the same text.
+type LocalEmbeddingProvider struct {
+	dimensions int
+}
+
+func NewLocalEmbeddingProvider(dimensions int) *LocalEmbeddingProvider {
+	return &LocalEmbeddingProvider{dimensions: dimensions}
+}
+
+func (p *LocalEmbeddingProvider) Name() string { return EmbeddingProviderLocal }
+func (p *LocalEmbeddingProvider) Model() string {
+	return fmt.Sprintf("local-hash-%d", p.dimensions)
+}
+
+func (p *LocalEmbeddingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = p.embed(text)
+	}
+	return vectors, nil
+}
+
+func (p *LocalEmbeddingProvider) embed(text string) []float32 {
+	vector := make([]float32, p.dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		p.add(vector, word, 1)
+		if i > 0 {
+			p.add(vector, words[i-1]+" "+word, 0.5)
+		}
+	}
+	return normalize(vector)
+}
+
+// add puts weight into the bucket of feature, with a sign taken from the hash
+// so that collisions tend to cancel out.
+func (p *LocalEmbeddingProvider) add(vector []float32, feature string, weight float32) {
+	h := fnv.New32a()
+	h.Write([]byte(feature))
+	sum := h.Sum32()
+	if sum&(1<<31) != 0 {
+		weight = -weight
+	}
+	vector[int(sum%uint32(p.dimensions))] += weight
+}
+
+// CachedEmbeddingProvider remembers the vectors of texts it has embedded, so
+// that chunks of the same posts are not embedded again for every question.
+// The 5000 most recently used vectors are kept.
+type CachedEmbeddingProvider struct {
+	provider domain.IEmbeddingProvider
+	cache    *Cache
+	ttl      time.Duration
+}
+
+func NewCachedEmbeddingProvider(provider domain.IEmbeddingProvider, ttl time.Duration) *CachedEmbeddingProvider {
+	return &CachedEmbeddingProvider{provider: provider, cache: NewCache("embeddings", 5000), ttl: ttl}
+}
+
+func (p *CachedEmbeddingProvider) Name() string  { return p.provider.Name() }
+func (p *CachedEmbeddingProvider) Model() string { return p.provider.Model() }
+
+func (p *CachedEmbeddingProvider) key(text string) string {
+	sum := sha256.Sum256([]byte(p.provider.Model() + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Embed only sends the texts it has no vector for.
+func (p *CachedEmbeddingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	var missing []string
+	var missingAt []int
+	for i, text := range texts {
+		if v, ok := p.cache.Get(p.key(text)); ok {
+			vectors[i] = v.([]float32)
+			continue
+		}
+		missing = append(missing, text)
+		missingAt = append(missingAt, i)
+	}
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	embedded, err := p.provider.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, vector := range embedded {
+		vectors[missingAt[j]] = vector
+		p.cache.Set(p.key(missing[j]), vector, p.ttl)
+	}
+	return vectors, nil
+}
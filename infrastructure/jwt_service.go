@@ -114,4 +114,40 @@ func (infra *JWTInfrastructure) ValidateAccessToken(authHeader string) (*domain.
 
 func (infra *JWTInfrastructure) ValidateRefreshToken(authHeader string) (*domain.TokenClaims, error) {
 	return infra.validateToken(authHeader, infra.RefreshSecret)
-}
\ No newline at end of file
+}
+
+// mfaAudience marks challenge tokens so they are never accepted in place of
+// an access token.
+const mfaAudience = "mfa"
+
+// GenerateMFAToken issues the short-lived token that carries a user from the
+// password step of a login to the second factor.
+func (infra *JWTInfrastructure) GenerateMFAToken(userID string) (string, error) {
+	if userID == "" {
+		return "", errors.New("userID cannot be empty")
+	}
+
+	claims := jwt.RegisteredClaims{
+		Subject:   userID,
+		Audience:  jwt.ClaimStrings{mfaAudience},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(infra.AccessSecret)
+}
+
+func (infra *JWTInfrastructure) ValidateMFAToken(tokenString string) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return infra.AccessSecret, nil
+	}, jwt.WithAudience(mfaAudience), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid || claims.Subject == "" {
+		return "", errors.New("invalid or expired mfa token")
+	}
+	return claims.Subject, nil
+}
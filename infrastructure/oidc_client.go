@@ -0,0 +1,355 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minimum time between JWKS refreshes triggered by an unknown key ID
+const jwksRefreshInterval = time.Minute
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCClient is an OpenID Connect relying party for a single provider. The
+// provider metadata is discovered from the issuer on first use and ID tokens
+// are verified against the provider's published keys.
+type OIDCClient struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	HTTPClient   *http.Client
+
+	mu          sync.Mutex
+	discovery   *oidcDiscovery
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+func NewOIDCClient(issuer, clientID, clientSecret, redirectURL string, scopes []string) *OIDCClient {
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	return &OIDCClient{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewOIDCProvidersFromEnv builds the providers listed in OIDC_PROVIDERS, a
+// comma separated list of names. Each name is configured with
+// OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET and optionally _REDIRECT_URL
+// and _SCOPES.
+func NewOIDCProvidersFromEnv() (map[string]domain.IOIDCProvider, error) {
+	providers := make(map[string]domain.IOIDCProvider)
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		issuer := os.Getenv(prefix + "ISSUER")
+		clientID := os.Getenv(prefix + "CLIENT_ID")
+		if issuer == "" || clientID == "" {
+			return nil, fmt.Errorf("oidc provider '%s' needs %sISSUER and %sCLIENT_ID", name, prefix, prefix)
+		}
+		redirectURL := os.Getenv(prefix + "REDIRECT_URL")
+		if redirectURL == "" {
+			redirectURL = fmt.Sprintf("%v://%v:%v/auth/oidc/%v/callback", os.Getenv("PROTOCOL"), os.Getenv("DOMAIN"), os.Getenv("PORT"), name)
+		}
+		providers[name] = NewOIDCClient(issuer, clientID, os.Getenv(prefix+"CLIENT_SECRET"), redirectURL, strings.Fields(os.Getenv(prefix+"SCOPES")))
+	}
+	return providers, nil
+}
+
+func (c *OIDCClient) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	d, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", c.ClientID)
+	q.Set("redirect_uri", c.RedirectURL)
+	q.Set("scope", strings.Join(c.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+func (c *OIDCClient) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*domain.OIDCIdentity, error) {
+	d, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", c.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := c.doJSON(req, &tokens)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	if status != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf("token request failed: %s %s", tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	return c.verifyIDToken(ctx, d, tokens.IDToken, nonce)
+}
+
+type idTokenClaims struct {
+	Nonce             string      `json:"nonce"`
+	AuthorizedParty   string      `json:"azp"`
+	Email             string      `json:"email"`
+	EmailVerified     interface{} `json:"email_verified"` // some providers send a string
+	Name              string      `json:"name"`
+	PreferredUsername string      `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+func (c *OIDCClient) verifyIDToken(ctx context.Context, d *oidcDiscovery, raw, nonce string) (*domain.OIDCIdentity, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return c.key(ctx, d, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384"}),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(c.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid id token: missing subject")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != c.ClientID {
+		return nil, errors.New("invalid id token: unexpected authorized party")
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("invalid id token: nonce mismatch")
+	}
+
+	verified := false
+	switch v := claims.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true"
+	}
+	return &domain.OIDCIdentity{
+		Issuer:            claims.Issuer,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     verified,
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+func (c *OIDCClient) discover(ctx context.Context) (*oidcDiscovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var d oidcDiscovery
+	status, err := c.doJSON(req, &d)
+	if err != nil || status != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery failed for %s: status %d %v", c.Issuer, status, err)
+	}
+	// the issuer must match exactly, otherwise tokens from another issuer could be accepted
+	if strings.TrimSuffix(d.Issuer, "/") != c.Issuer {
+		return nil, fmt.Errorf("oidc discovery returned issuer '%s', expected '%s'", d.Issuer, c.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document is incomplete")
+	}
+	c.discovery = &d
+	return c.discovery, nil
+}
+
+// key returns the signing key with the given ID, refreshing the key set when
+// the ID is unknown so that provider key rotation is picked up.
+func (c *OIDCClient) key(ctx context.Context, d *oidcDiscovery, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if k, ok := c.lookupKey(kid); ok {
+		return k, nil
+	}
+	if time.Since(c.keysFetched) < jwksRefreshInterval && c.keys != nil {
+		return nil, fmt.Errorf("unknown signing key '%s'", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	status, err := c.doJSON(req, &set)
+	if err != nil || status != http.StatusOK {
+		return nil, fmt.Errorf("fetching jwks failed: status %d %v", status, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if k, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = k
+		}
+	}
+	c.keys = keys
+	c.keysFetched = time.Now()
+
+	if k, ok := c.lookupKey(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown signing key '%s'", kid)
+}
+
+// lookupKey finds a key by ID. A token without a key ID is accepted only when
+// the set has exactly one key.
+func (c *OIDCClient) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, k := range c.keys {
+			return k, true
+		}
+	}
+	k, ok := c.keys[kid]
+	return k, ok
+}
+
+func (c *OIDCClient) doJSON(req *http.Request, out interface{}) (int, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve '%s'", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("invalid ec key")
+		}
+		return pub, nil
+	}
+	return nil, fmt.Errorf("unsupported key type '%s'", k.Kty)
+}
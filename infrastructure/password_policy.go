@@ -0,0 +1,151 @@
+package infrastructure
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// character classes a password policy can require
+const (
+	PasswordClassUpper  = "upper"
+	PasswordClassLower  = "lower"
+	PasswordClassNumber = "number"
+	PasswordClassSymbol = "symbol"
+)
+
+var passwordClassNames = map[string]string{
+	PasswordClassUpper:  "an uppercase letter",
+	PasswordClassLower:  "a lowercase letter",
+	PasswordClassNumber: "a number",
+	PasswordClassSymbol: "a symbol",
+}
+
+// PasswordPolicy checks new passwords against length limits, required
+// character classes and a deny-list of common or breached passwords.
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	Classes   []string
+	denied    map[string]struct{}
+}
+
+// DefaultPasswordPolicy requires 8 to 128 characters with every class.
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength: 8,
+		MaxLength: 128,
+		Classes:   []string{PasswordClassUpper, PasswordClassLower, PasswordClassNumber, PasswordClassSymbol},
+	}
+}
+
+// NewPasswordPolicyFromEnv starts from the default policy and reads
+// PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH, PASSWORD_REQUIRED_CLASSES (comma
+// separated, or "none") and PASSWORD_DENYLIST_FILE. A deny-list that cannot
+// be read is an error rather than silently allowing every password.
+func NewPasswordPolicyFromEnv() (*PasswordPolicy, error) {
+	policy := DefaultPasswordPolicy()
+	policy.MinLength = int(envUint("PASSWORD_MIN_LENGTH", uint64(policy.MinLength), 1, 1024))
+	policy.MaxLength = int(envUint("PASSWORD_MAX_LENGTH", uint64(policy.MaxLength), uint64(policy.MinLength), 1024))
+
+	if spec := strings.TrimSpace(os.Getenv("PASSWORD_REQUIRED_CLASSES")); spec != "" {
+		var classes []string
+		for _, class := range strings.Split(spec, ",") {
+			class = strings.ToLower(strings.TrimSpace(class))
+			if class == "" || class == "none" {
+				continue
+			}
+			if _, ok := passwordClassNames[class]; !ok {
+				return nil, fmt.Errorf("PASSWORD_REQUIRED_CLASSES: unknown class '%s'", class)
+			}
+			classes = append(classes, class)
+		}
+		policy.Classes = classes
+	}
+
+	if path := os.Getenv("PASSWORD_DENYLIST_FILE"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("PASSWORD_DENYLIST_FILE: %w", err)
+		}
+		defer file.Close()
+		if err := policy.LoadDenyList(file); err != nil {
+			return nil, fmt.Errorf("PASSWORD_DENYLIST_FILE: %w", err)
+		}
+		log.Printf("password policy: loaded %d denied passwords", len(policy.denied))
+	}
+	return policy, nil
+}
+
+// LoadDenyList adds one password per line; blank lines and lines starting
+// with # are skipped. Entries are matched case-insensitively.
+func (p *PasswordPolicy) LoadDenyList(r io.Reader) error {
+	if p.denied == nil {
+		p.denied = make(map[string]struct{})
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.denied[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// requirement describes the length and class rules in one sentence.
+func (p *PasswordPolicy) requirement() string {
+	msg := fmt.Sprintf("password must be at least %d characters long", p.MinLength)
+	if len(p.Classes) == 0 {
+		return msg
+	}
+	names := make([]string, len(p.Classes))
+	for i, class := range p.Classes {
+		names[i] = passwordClassNames[class]
+	}
+	if len(names) == 1 {
+		return msg + " and contain " + names[0]
+	}
+	return msg + " and contain " + strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+func (p *PasswordPolicy) Validate(password string) error {
+	length := utf8.RuneCountInString(password)
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("password must be at most %d characters long", p.MaxLength)
+	}
+	if length < p.MinLength {
+		return errors.New(p.requirement())
+	}
+
+	found := map[string]bool{}
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			found[PasswordClassUpper] = true
+		case unicode.IsLower(c):
+			found[PasswordClassLower] = true
+		case unicode.IsNumber(c):
+			found[PasswordClassNumber] = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			found[PasswordClassSymbol] = true
+		}
+	}
+	for _, class := range p.Classes {
+		if !found[class] {
+			return errors.New(p.requirement())
+		}
+	}
+
+	if _, denied := p.denied[strings.ToLower(password)]; denied {
+		return errors.New("password is too common, choose a different one")
+	}
+	return nil
+}
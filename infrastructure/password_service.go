@@ -1,28 +1,200 @@
 package infrastructure
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-type PasswordInfrastructure struct {}
+const (
+	PasswordArgon2id = "argon2id"
+	PasswordBcrypt   = "bcrypt"
+)
+
+// bcryptMaxPasswordBytes is the longest input bcrypt hashes in full.
+const bcryptMaxPasswordBytes = 72
+
+// Argon2Params are the argon2id cost parameters; Memory is in KiB.
+type Argon2Params struct {
+	Memory     uint32
+	Time       uint32
+	Threads    uint8
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// DefaultArgon2Params follow the OWASP recommendation for argon2id.
+var DefaultArgon2Params = Argon2Params{Memory: 19 * 1024, Time: 2, Threads: 1, SaltLength: 16, KeyLength: 32}
 
+// PasswordInfrastructure hashes new passwords with Algorithm and verifies
+// hashes of every supported algorithm. Hashes are self-describing: argon2id
+// uses the PHC string format ($argon2id$v=19$m=...,t=...,p=...$salt$key) and
+// bcrypt its usual $2a$ form, so existing hashes keep working when the
+// configuration changes. The zero value hashes with argon2id and defaults.
+type PasswordInfrastructure struct {
+	Algorithm  string
+	Argon2     Argon2Params
+	BcryptCost int
+}
+
+// NewPasswordInfrastructure reads PASSWORD_HASH_ALGORITHM (argon2id or
+// bcrypt), PASSWORD_ARGON2_MEMORY (KiB), PASSWORD_ARGON2_TIME,
+// PASSWORD_ARGON2_THREADS and PASSWORD_BCRYPT_COST, using the defaults for
+// unset or invalid values.
 func NewPasswordInfrastructure() *PasswordInfrastructure {
-	return &PasswordInfrastructure{}
+	infra := &PasswordInfrastructure{
+		Algorithm:  PasswordArgon2id,
+		Argon2:     DefaultArgon2Params,
+		BcryptCost: bcrypt.DefaultCost,
+	}
+	switch algorithm := os.Getenv("PASSWORD_HASH_ALGORITHM"); algorithm {
+	case "", PasswordArgon2id:
+	case PasswordBcrypt:
+		infra.Algorithm = PasswordBcrypt
+	default:
+		log.Printf("PASSWORD_HASH_ALGORITHM: unknown algorithm '%s', using %s", algorithm, PasswordArgon2id)
+	}
+	infra.Argon2.Memory = uint32(envUint("PASSWORD_ARGON2_MEMORY", uint64(infra.Argon2.Memory), 8, 4*1024*1024))
+	infra.Argon2.Time = uint32(envUint("PASSWORD_ARGON2_TIME", uint64(infra.Argon2.Time), 1, 100))
+	infra.Argon2.Threads = uint8(envUint("PASSWORD_ARGON2_THREADS", uint64(infra.Argon2.Threads), 1, 255))
+	infra.BcryptCost = int(envUint("PASSWORD_BCRYPT_COST", uint64(infra.BcryptCost), uint64(bcrypt.MinCost), uint64(bcrypt.MaxCost)))
+	return infra
+}
+
+func envUint(name string, def, min, max uint64) uint64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || n < min || n > max {
+		log.Printf("%s: must be between %d and %d, using %d", name, min, max, def)
+		return def
+	}
+	return n
+}
+
+func (infra *PasswordInfrastructure) algorithm() string {
+	if infra.Algorithm == "" {
+		return PasswordArgon2id
+	}
+	return infra.Algorithm
+}
+
+func (infra *PasswordInfrastructure) argon2Params() Argon2Params {
+	if infra.Argon2 == (Argon2Params{}) {
+		return DefaultArgon2Params
+	}
+	return infra.Argon2
+}
+
+func (infra *PasswordInfrastructure) bcryptCost() int {
+	if infra.BcryptCost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return infra.BcryptCost
 }
 
 func (infra *PasswordInfrastructure) HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+	if infra.algorithm() == PasswordBcrypt {
+		// bcrypt ignores everything past 72 bytes; refuse instead of truncating
+		if len(password) > bcryptMaxPasswordBytes {
+			return "", fmt.Errorf("password must be at most %d bytes long", bcryptMaxPasswordBytes)
+		}
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), infra.bcryptCost())
+		if err != nil {
+			return "", errors.New("unable to hash password")
+		}
+		return string(hashedPassword), nil
+	}
+
+	p := infra.argon2Params()
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return "", errors.New("unable to hash password")
 	}
-	return string(hashedPassword), nil
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
 }
 
 func (infra *PasswordInfrastructure) ComparePassword(correctPassword []byte, inputPassword []byte) error {
+	hash := string(correctPassword)
+	if strings.HasPrefix(hash, "$argon2id$") {
+		p, salt, key, err := parseArgon2Hash(hash)
+		if err != nil {
+			return errors.New("invalid credentials")
+		}
+		computed := argon2.IDKey(inputPassword, salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return errors.New("invalid credentials")
+		}
+		return nil
+	}
 	if bcrypt.CompareHashAndPassword(correctPassword, inputPassword) != nil {
 		return errors.New("invalid credentials")
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// NeedsRehash reports whether hash was made with another algorithm or other
+// parameters than new hashes would be, so that it can be replaced after the
+// next successful login.
+func (infra *PasswordInfrastructure) NeedsRehash(hash string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		if infra.algorithm() != PasswordArgon2id {
+			return true
+		}
+		p, salt, key, err := parseArgon2Hash(hash)
+		if err != nil {
+			return true
+		}
+		want := infra.argon2Params()
+		return p.Memory != want.Memory || p.Time != want.Time || p.Threads != want.Threads ||
+			uint32(len(salt)) != want.SaltLength || uint32(len(key)) != want.KeyLength
+	}
+	if infra.algorithm() != PasswordBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != infra.bcryptCost()
+}
+
+// parseArgon2Hash decodes a PHC argon2id string, bounding the parameters so
+// that a tampered hash cannot make verification arbitrarily expensive.
+func parseArgon2Hash(hash string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, errors.New("invalid argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, errors.New("invalid argon2id parameters")
+	}
+	if p.Threads < 1 || p.Memory < 8*uint32(p.Threads) || p.Memory > 4*1024*1024 || p.Time < 1 || p.Time > 100 {
+		return p, nil, nil, errors.New("invalid argon2id parameters")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) < 8 {
+		return p, nil, nil, errors.New("invalid argon2id salt")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) < 16 || len(key) > 128 {
+		return p, nil, nil, errors.New("invalid argon2id key")
+	}
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
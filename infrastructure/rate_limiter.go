@@ -0,0 +1,199 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// Rate limit keys
+const (
+	RateLimitByIP    = "ip"
+	RateLimitByUser  = "user"  // falls back to the IP for anonymous requests
+	RateLimitByRoute = "route" // one bucket shared by every caller of the route
+)
+
+// RateLimitPolicy is applied to a route group. Name separates the buckets of
+// different groups that use the same key.
+type RateLimitPolicy struct {
+	Name  string
+	Limit domain.RateLimit
+	KeyBy string
+}
+
+// ParseRateLimit parses limits written as "<requests>/<period>", e.g. "5/1m".
+func ParseRateLimit(spec string) (domain.RateLimit, error) {
+	parts := strings.SplitN(strings.TrimSpace(spec), "/", 2)
+	if len(parts) != 2 {
+		return domain.RateLimit{}, fmt.Errorf("invalid rate limit '%s'", spec)
+	}
+	burst, err := strconv.Atoi(parts[0])
+	if err != nil || burst <= 0 {
+		return domain.RateLimit{}, fmt.Errorf("invalid rate limit '%s'", spec)
+	}
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period <= 0 {
+		return domain.RateLimit{}, fmt.Errorf("invalid rate limit '%s'", spec)
+	}
+	return domain.RateLimit{Burst: burst, Period: period}, nil
+}
+
+type RateLimiter struct {
+	store domain.IRateLimitStore
+	now   func() time.Time
+}
+
+func NewRateLimiter(store domain.IRateLimitStore) *RateLimiter {
+	return &RateLimiter{store: store, now: time.Now}
+}
+
+// Limit rejects requests over the policy with 429 and sets the RateLimit-*
+// headers on every response. When the store fails requests are let through.
+func (rl *RateLimiter) Limit(policy RateLimitPolicy) gin.HandlerFunc {
+	limitHeader := strconv.Itoa(policy.Limit.Burst)
+	policyHeader := fmt.Sprintf("%d;w=%d", policy.Limit.Burst, int(policy.Limit.Period.Seconds()))
+
+	return func(ctx *gin.Context) {
+		key := policy.Name + ":" + rateLimitKey(ctx, policy.KeyBy)
+		res, err := rl.store.Take(ctx.Request.Context(), key, policy.Limit, rl.now())
+		if err != nil {
+			log.Printf("rate limiter: %v", err)
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("RateLimit-Limit", limitHeader)
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		ctx.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		ctx.Header("RateLimit-Policy", policyHeader)
+
+		if !res.Allowed {
+			ctx.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func rateLimitKey(ctx *gin.Context, keyBy string) string {
+	switch keyBy {
+	case RateLimitByRoute:
+		return ctx.FullPath()
+	case RateLimitByUser:
+		if userID, ok := ctx.Get("user_id"); ok {
+			if id, ok := userID.(int64); ok && id > 0 {
+				return "user:" + strconv.FormatInt(id, 10)
+			}
+		}
+	}
+	return "ip:" + ctx.ClientIP()
+}
+
+// TrustProxies sets the proxies whose X-Forwarded-For ClientIP believes, read
+// from TRUSTED_PROXIES as comma separated IPs or CIDRs. With none set the peer
+// address is used, so clients cannot pick the IP that IP rate limits, login
+// lockouts and view dedup are keyed by.
+func TrustProxies(engine *gin.Engine) error {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	if err := engine.SetTrustedProxies(proxies); err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	return nil
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+type tokenBucket struct {
+	tokens   float64
+	updated  time.Time
+	capacity float64
+	refill   float64 // tokens per second
+}
+
+// fullAt returns when the bucket will be full if nothing else is taken.
+func (b *tokenBucket) fullAt() time.Time {
+	missing := b.capacity - b.tokens
+	return b.updated.Add(time.Duration(missing / b.refill * float64(time.Second)))
+}
+
+// MemoryRateLimitStore keeps buckets in process memory. Buckets that have
+// refilled completely carry no state and are dropped by a periodic sweep.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+const rateLimitSweepInterval = time.Minute
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitResult, error) {
+	if limit.Burst <= 0 || limit.Period <= 0 {
+		return domain.RateLimitResult{}, fmt.Errorf("invalid rate limit %+v", limit)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	capacity := float64(limit.Burst)
+	refill := capacity / limit.Period.Seconds()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+	b.capacity, b.refill = capacity, refill
+
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*refill)
+		b.updated = now
+	}
+
+	res := domain.RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) / refill * float64(time.Second))
+	}
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = b.fullAt().Sub(now)
+	return res, nil
+}
+
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt()) {
+			delete(s.buckets, key)
+		}
+	}
+}
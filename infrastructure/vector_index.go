@@ -0,0 +1,148 @@
+package infrastructure
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/blog-platform/domain"
+)
+
+// MemoryVectorIndex keeps every post's vector in memory and compares a query
+// with all of them, which is fast enough for tens of thousands of posts.
+// Vectors are persisted through the repository and loaded on first use, so
+// an instance only sees posts indexed by other instances after a restart;
+// deployments with several instances should use the pgvector index.
+type MemoryVectorIndex struct {
+	repo    domain.IEmbeddingRepository
+	loadMu  sync.Mutex
+	mu      sync.RWMutex
+	loaded  bool
+	vectors map[int64]*domain.BlogEmbedding // normalised to unit length
+}
+
+func NewMemoryVectorIndex(repo domain.IEmbeddingRepository) *MemoryVectorIndex {
+	return &MemoryVectorIndex{repo: repo, vectors: map[int64]*domain.BlogEmbedding{}}
+}
+
+// load reads the stored vectors once. Writes load first too, so that a
+// vector written or deleted before the first search is not overwritten by
+// the stored state.
+func (ix *MemoryVectorIndex) load(ctx context.Context) error {
+	ix.loadMu.Lock()
+	defer ix.loadMu.Unlock()
+	if ix.loaded {
+		return nil
+	}
+
+	embeddings, err := ix.repo.FetchEmbeddings(ctx)
+	if err != nil {
+		return err
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for _, embedding := range embeddings {
+		embedding.Vector = normalize(embedding.Vector)
+		ix.vectors[embedding.BlogID] = embedding
+	}
+	ix.loaded = true
+	return nil
+}
+
+func (ix *MemoryVectorIndex) Upsert(ctx context.Context, blogID int64, model string, vector []float32) error {
+	if err := ix.load(ctx); err != nil {
+		return err
+	}
+	embedding := &domain.BlogEmbedding{BlogID: blogID, Model: model, Vector: vector}
+	if err := ix.repo.SaveEmbedding(ctx, embedding); err != nil {
+		return err
+	}
+	stored := *embedding
+	stored.Vector = normalize(vector)
+	ix.mu.Lock()
+	ix.vectors[blogID] = &stored
+	ix.mu.Unlock()
+	return nil
+}
+
+func (ix *MemoryVectorIndex) Delete(ctx context.Context, blogID int64) error {
+	if err := ix.load(ctx); err != nil {
+		return err
+	}
+	if err := ix.repo.DeleteEmbedding(ctx, blogID); err != nil {
+		return err
+	}
+	ix.mu.Lock()
+	delete(ix.vectors, blogID)
+	ix.mu.Unlock()
+	return nil
+}
+
+func (ix *MemoryVectorIndex) Fetch(ctx context.Context, blogID int64) (*domain.BlogEmbedding, error) {
+	if err := ix.load(ctx); err != nil {
+		return nil, err
+	}
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	embedding, ok := ix.vectors[blogID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *embedding
+	return &copied, nil
+}
+
+func (ix *MemoryVectorIndex) Search(ctx context.Context, model string, vector []float32, limit int, excludeID int64) ([]domain.VectorMatch, error) {
+	if err := ix.load(ctx); err != nil {
+		return nil, err
+	}
+	query := normalize(vector)
+
+	ix.mu.RLock()
+	matches := make([]domain.VectorMatch, 0, len(ix.vectors))
+	for blogID, embedding := range ix.vectors {
+		if blogID == excludeID || embedding.Model != model || len(embedding.Vector) != len(query) {
+			continue
+		}
+		matches = append(matches, domain.VectorMatch{BlogID: blogID, Score: dot(query, embedding.Vector)})
+	}
+	ix.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].BlogID > matches[j].BlogID // newer posts first on ties
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// normalize returns vector scaled to unit length, so that the dot product of
+// two normalised vectors is their cosine similarity.
+func normalize(vector []float32) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	normalized := make([]float32, len(vector))
+	if norm == 0 {
+		return normalized
+	}
+	norm = math.Sqrt(norm)
+	for i, v := range vector {
+		normalized[i] = float32(float64(v) / norm)
+	}
+	return normalized
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
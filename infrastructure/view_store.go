@@ -0,0 +1,48 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const viewStoreSweepInterval = time.Minute
+
+// MemoryViewStore remembers when each key was last counted. Keys whose window
+// has passed are dropped by a periodic sweep.
+type MemoryViewStore struct {
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryViewStore() *MemoryViewStore {
+	return &MemoryViewStore{expires: make(map[string]time.Time)}
+}
+
+// MarkViewed starts a new window only when the previous one has passed, so a
+// reader refreshing a page is counted once per window rather than once after
+// they stop.
+func (s *MemoryViewStore) MarkViewed(_ context.Context, key string, window time.Duration, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	if expires, ok := s.expires[key]; ok && now.Before(expires) {
+		return true, nil
+	}
+	s.expires[key] = now.Add(window)
+	return false, nil
+}
+
+func (s *MemoryViewStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < viewStoreSweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, expires := range s.expires {
+		if !now.Before(expires) {
+			delete(s.expires, key)
+		}
+	}
+}
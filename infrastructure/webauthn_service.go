@@ -0,0 +1,344 @@
+package infrastructure
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/blog-platform/domain"
+)
+
+// authenticator data flags
+const (
+	authDataUserPresent  = 0x01
+	authDataUserVerified = 0x04
+	authDataAttested     = 0x40
+)
+
+// WebAuthnService verifies the responses of the WebAuthn registration and
+// authentication ceremonies for one relying party. Attestation statements are
+// not verified: options ask for "none", which is what passkeys provide.
+type WebAuthnService struct {
+	RPID    string
+	RPName  string
+	Origins []string
+}
+
+// NewWebAuthnService reads WEBAUTHN_RP_ID (default DOMAIN), WEBAUTHN_RP_NAME
+// and WEBAUTHN_ORIGINS, a comma separated list defaulting to the site URL.
+func NewWebAuthnService() *WebAuthnService {
+	rpID := os.Getenv("WEBAUTHN_RP_ID")
+	if rpID == "" {
+		rpID = os.Getenv("DOMAIN")
+	}
+	rpName := os.Getenv("WEBAUTHN_RP_NAME")
+	if rpName == "" {
+		rpName = "Blog Platform"
+	}
+
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("WEBAUTHN_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+	if len(origins) == 0 {
+		origins = []string{fmt.Sprintf("%v://%v:%v", os.Getenv("PROTOCOL"), os.Getenv("DOMAIN"), os.Getenv("PORT"))}
+	}
+	return &WebAuthnService{RPID: rpID, RPName: rpName, Origins: origins}
+}
+
+func (s *WebAuthnService) RelyingParty() domain.WebAuthnRelyingParty {
+	return domain.WebAuthnRelyingParty{ID: s.RPID, Name: s.RPName}
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+func parseClientData(encoded string) (*clientData, []byte, error) {
+	raw, err := decodeBase64URL(encoded)
+	if err != nil {
+		return nil, nil, errors.New("invalid client data")
+	}
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return nil, nil, errors.New("invalid client data")
+	}
+	return &cd, raw, nil
+}
+
+// Challenge returns the challenge echoed in the client data, which
+// identifies the ceremony the response belongs to.
+func (s *WebAuthnService) Challenge(clientDataJSON string) (string, error) {
+	cd, _, err := parseClientData(clientDataJSON)
+	if err != nil {
+		return "", err
+	}
+	if cd.Challenge == "" {
+		return "", errors.New("invalid client data")
+	}
+	return cd.Challenge, nil
+}
+
+func (s *WebAuthnService) checkClientData(cd *clientData, ceremony, challenge string) error {
+	if cd.Type != ceremony {
+		return errors.New("unexpected client data type")
+	}
+	if cd.Challenge != challenge {
+		return errors.New("challenge mismatch")
+	}
+	if cd.CrossOrigin {
+		return errors.New("cross-origin requests are not allowed")
+	}
+	for _, origin := range s.Origins {
+		if cd.Origin == origin {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin '%s' is not allowed", cd.Origin)
+}
+
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("authenticator data too short")
+	}
+	ad := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if ad.flags&authDataAttested == 0 {
+		return ad, nil
+	}
+
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, errors.New("attested credential data too short")
+	}
+	ad.aaguid = rest[:16]
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+		return nil, errors.New("invalid credential id")
+	}
+	ad.credentialID = rest[:idLen]
+	rest = rest[idLen:]
+
+	// the public key is followed by extensions, so its length is only known
+	// once it has been decoded
+	_, after, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential public key: %w", err)
+	}
+	ad.publicKey = rest[:len(rest)-len(after)]
+	return ad, nil
+}
+
+func (s *WebAuthnService) checkAuthenticatorData(ad *authenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(s.RPID))
+	if !bytes.Equal(ad.rpIDHash, rpIDHash[:]) {
+		return errors.New("relying party mismatch")
+	}
+	if ad.flags&authDataUserPresent == 0 {
+		return errors.New("user presence is required")
+	}
+	if ad.flags&authDataUserVerified == 0 {
+		return errors.New("user verification is required")
+	}
+	return nil
+}
+
+func (s *WebAuthnService) VerifyRegistration(challenge string, resp *domain.WebAuthnAttestationResponse) (*domain.WebAuthnCredentialData, error) {
+	if resp.Type != "public-key" {
+		return nil, errors.New("unexpected credential type")
+	}
+	cd, _, err := parseClientData(resp.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkClientData(cd, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, err := decodeBase64URL(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, errors.New("invalid attestation object")
+	}
+	decoded, _, err := decodeCBOR(rawAttestation)
+	if err != nil {
+		return nil, errors.New("invalid attestation object")
+	}
+	attestation, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("invalid attestation object")
+	}
+	authDataBytes, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("invalid attestation object")
+	}
+
+	ad, err := parseAuthenticatorData(authDataBytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAuthenticatorData(ad); err != nil {
+		return nil, err
+	}
+	if ad.credentialID == nil {
+		return nil, errors.New("attested credential data missing")
+	}
+	credentialID := base64.RawURLEncoding.EncodeToString(ad.credentialID)
+	if resp.ID != credentialID {
+		return nil, errors.New("credential id mismatch")
+	}
+	if _, _, err := parseCOSEKey(ad.publicKey); err != nil {
+		return nil, err
+	}
+
+	return &domain.WebAuthnCredentialData{
+		CredentialID: credentialID,
+		PublicKey:    ad.publicKey,
+		SignCount:    ad.signCount,
+		AAGUID:       formatAAGUID(ad.aaguid),
+	}, nil
+}
+
+// VerifyAssertion checks an authentication response against the stored
+// public key and returns the authenticator's new signature counter.
+func (s *WebAuthnService) VerifyAssertion(challenge string, publicKey []byte, resp *domain.WebAuthnAssertionResponse) (uint32, error) {
+	if resp.Type != "public-key" {
+		return 0, errors.New("unexpected credential type")
+	}
+	cd, rawClientData, err := parseClientData(resp.Response.ClientDataJSON)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.checkClientData(cd, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	authDataBytes, err := decodeBase64URL(resp.Response.AuthenticatorData)
+	if err != nil {
+		return 0, errors.New("invalid authenticator data")
+	}
+	ad, err := parseAuthenticatorData(authDataBytes)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.checkAuthenticatorData(ad); err != nil {
+		return 0, err
+	}
+
+	signature, err := decodeBase64URL(resp.Response.Signature)
+	if err != nil {
+		return 0, errors.New("invalid signature")
+	}
+	key, alg, err := parseCOSEKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte{}, authDataBytes...), clientDataHash[:]...)
+	if !verifyCOSESignature(key, alg, signed, signature) {
+		return 0, errors.New("invalid signature")
+	}
+	return ad.signCount, nil
+}
+
+// parseCOSEKey decodes an EC2 (P-256), RSA or OKP (Ed25519) COSE key.
+func parseCOSEKey(data []byte) (crypto.PublicKey, int64, error) {
+	decoded, rest, err := decodeCBOR(data)
+	if err != nil || len(rest) != 0 {
+		return nil, 0, errors.New("invalid credential public key")
+	}
+	m, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, errors.New("invalid credential public key")
+	}
+	kty, _ := m[int64(1)].(int64)
+	alg, _ := m[int64(3)].(int64)
+
+	switch {
+	case kty == 2 && alg == domain.COSEAlgES256:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		y, _ := m[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errors.New("invalid EC2 key")
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, 0, errors.New("invalid EC2 key")
+		}
+		return pub, alg, nil
+	case kty == 3 && alg == domain.COSEAlgRS256:
+		n, _ := m[int64(-1)].([]byte)
+		e, _ := m[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, alg, nil
+	case kty == 1 && alg == domain.COSEAlgEdDSA:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		if crv != 6 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, errors.New("invalid OKP key")
+		}
+		return ed25519.PublicKey(x), alg, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported key type %d with algorithm %d", kty, alg)
+}
+
+func verifyCOSESignature(key crypto.PublicKey, alg int64, data, signature []byte) bool {
+	switch alg {
+	case domain.COSEAlgES256:
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(key.(*ecdsa.PublicKey), digest[:], signature)
+	case domain.COSEAlgRS256:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	case domain.COSEAlgEdDSA:
+		return ed25519.Verify(key.(ed25519.PublicKey), data, signature)
+	}
+	return false
+}
+
+func formatAAGUID(b []byte) string {
+	if len(b) != 16 {
+		return ""
+	}
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// decodeBase64URL accepts base64url with or without padding, as browsers
+// and libraries differ.
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
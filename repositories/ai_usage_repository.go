@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const aiUsageTotals = "COUNT(*) AS requests, " +
+	"COALESCE(SUM(CASE WHEN cached THEN 1 ELSE 0 END), 0) AS cached_requests, " +
+	"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, " +
+	"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, " +
+	"COALESCE(SUM(cost_usd), 0) AS cost_usd"
+
+type AIUsageRepository struct {
+	db *gorm.DB
+}
+
+func NewAIUsageRepository(db *gorm.DB) domain.IAIUsageRepository {
+	return &AIUsageRepository{db: db}
+}
+
+func (r *AIUsageRepository) Record(ctx context.Context, usage *domain.AIUsage) error {
+	return r.db.WithContext(ctx).Create(usage).Error
+}
+
+func (r *AIUsageRepository) SumTokens(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&domain.AIUsage{}).
+		Select("COALESCE(SUM(prompt_tokens + completion_tokens), 0)").
+		Where("user_id = ? AND cached = ? AND created_at >= ?", userID, false, since).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *AIUsageRepository) SummarizeByUser(ctx context.Context, from, to time.Time) ([]domain.AIUsageSummary, error) {
+	summaries := []domain.AIUsageSummary{}
+	err := r.db.WithContext(ctx).Model(&domain.AIUsage{}).
+		Select("user_id, "+aiUsageTotals).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("user_id").
+		Order("cost_usd DESC, user_id").
+		Scan(&summaries).Error
+	return summaries, err
+}
+
+func (r *AIUsageRepository) SummarizeByDay(ctx context.Context, userID int64, from, to time.Time) ([]domain.AIUsageSummary, error) {
+	summaries := []domain.AIUsageSummary{}
+	err := r.db.WithContext(ctx).Model(&domain.AIUsage{}).
+		Select("user_id, to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, "+aiUsageTotals).
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to).
+		Group("user_id, day").
+		Order("day").
+		Scan(&summaries).Error
+	return summaries, err
+}
+
+func (r *AIUsageRepository) FetchQuota(ctx context.Context, userID int64) (*domain.AIQuota, error) {
+	var quota domain.AIQuota
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&quota).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+func (r *AIUsageRepository) SaveQuota(ctx context.Context, quota *domain.AIQuota) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"daily_tokens", "updated_at"}),
+	}).Create(quota).Error
+}
+
+func (r *AIUsageRepository) DeleteQuota(ctx context.Context, userID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&domain.AIQuota{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AnalyticsRepository struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsRepository(db *gorm.DB) domain.IAnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+// AddDailyStats upserts in one transaction, so that a failed flush can be
+// retried whole. Rows are written in key order so that concurrent flushes
+// lock them in the same order.
+func (r *AnalyticsRepository) AddDailyStats(ctx context.Context, stats []*domain.BlogDailyStats, referrers []*domain.BlogReferrerStats) error {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].BlogID != stats[j].BlogID {
+			return stats[i].BlogID < stats[j].BlogID
+		}
+		return stats[i].Day.Before(stats[j].Day)
+	})
+	sort.Slice(referrers, func(i, j int) bool {
+		a, b := referrers[i], referrers[j]
+		if a.BlogID != b.BlogID {
+			return a.BlogID < b.BlogID
+		}
+		if !a.Day.Equal(b.Day) {
+			return a.Day.Before(b.Day)
+		}
+		return a.Referrer < b.Referrer
+	})
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(stats) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "blog_id"}, {Name: "day"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"views":          gorm.Expr("blog_daily_stats.views + excluded.views"),
+					"unique_viewers": gorm.Expr("blog_daily_stats.unique_viewers + excluded.unique_viewers"),
+					"likes":          gorm.Expr("blog_daily_stats.likes + excluded.likes"),
+					"comments":       gorm.Expr("blog_daily_stats.comments + excluded.comments"),
+				}),
+			}).CreateInBatches(stats, viewBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(referrers) > 0 {
+			return tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "blog_id"}, {Name: "day"}, {Name: "referrer"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("blog_referrer_stats.views + excluded.views")}),
+			}).CreateInBatches(referrers, viewBatchSize).Error
+		}
+		return nil
+	})
+}
+
+func (r *AnalyticsRepository) FetchDailyStats(ctx context.Context, blogIDs []int64, from, to time.Time) ([]*domain.BlogDailyStats, error) {
+	stats := []*domain.BlogDailyStats{}
+	if len(blogIDs) == 0 {
+		return stats, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("blog_id IN ? AND day >= ? AND day < ?", blogIDs, from, to).
+		Order("day, blog_id").
+		Find(&stats).Error
+	return stats, err
+}
+
+func (r *AnalyticsRepository) FetchReferrerStats(ctx context.Context, blogIDs []int64, from, to time.Time) ([]*domain.BlogReferrerStats, error) {
+	referrers := []*domain.BlogReferrerStats{}
+	if len(blogIDs) == 0 {
+		return referrers, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("blog_id IN ? AND day >= ? AND day < ?", blogIDs, from, to).
+		Order("day, views DESC, referrer").
+		Find(&referrers).Error
+	return referrers, err
+}
+
+func (r *AnalyticsRepository) FetchStatsSince(ctx context.Context, since time.Time) ([]*domain.BlogDailyStats, error) {
+	stats := []*domain.BlogDailyStats{}
+	err := r.db.WithContext(ctx).
+		Where("day >= ?", since).
+		Order("blog_id, day").
+		Find(&stats).Error
+	return stats, err
+}
+
+// ReplaceTrendingScores swaps the scores in one transaction, so listings
+// never see a partial ranking.
+func (r *AnalyticsRepository) ReplaceTrendingScores(ctx context.Context, period string, scores []*domain.BlogTrendingScore) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("period = ?", period).Delete(&domain.BlogTrendingScore{}).Error; err != nil {
+			return err
+		}
+		if len(scores) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(scores, viewBatchSize).Error
+	})
+}
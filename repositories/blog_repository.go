@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,8 +12,33 @@ import (
 	"github.com/blog-platform/infrastructure"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// posts updated per statement when adding views
+const viewBatchSize = 500
+
+// blogCacheSize bounds the cached posts and pages.
+const blogCacheSize = 1000
+
+// Cached posts are tagged with blogTag and cached lists with blogListTag and
+// the blogTag of every post they hold, so that a change to a post only drops
+// the entries showing it.
+const blogListTag = "blogs:list"
+
+func blogTag(id int64) string {
+	return fmt.Sprintf("blog:%d", id)
+}
+
+func listTags(blogs []*domain.Blog) []string {
+	tags := make([]string, 0, len(blogs)+1)
+	tags = append(tags, blogListTag)
+	for _, b := range blogs {
+		tags = append(tags, blogTag(b.ID))
+	}
+	return tags
+}
+
 type BlogRepository struct {
 	db *gorm.DB
 	c  *infrastructure.Cache
@@ -24,8 +50,14 @@ type pagedBlogs struct {
 	Total int64
 }
 
-func NewBlogRepository(db *gorm.DB) domain.IBlogRepository {
-	return &BlogRepository{db: db, c: infrastructure.NewCache()}
+// NewBlogCache returns the cache for NewBlogRepository. Repositories that
+// share it see each other's invalidations, so a process should use one.
+func NewBlogCache() *infrastructure.Cache {
+	return infrastructure.NewCache("blogs", blogCacheSize)
+}
+
+func NewBlogRepository(db *gorm.DB, c *infrastructure.Cache) domain.IBlogRepository {
+	return &BlogRepository{db: db, c: c}
 }
 
 func (r *BlogRepository) Create(ctx context.Context, blog *domain.Blog) error {
@@ -36,8 +68,8 @@ func (r *BlogRepository) Create(ctx context.Context, blog *domain.Blog) error {
 	if err := r.db.WithContext(ctx).Preload("User").First(blog, blog.ID).Error; err != nil {
 		return err
 	}
-	// invalidate caches
-	r.c.Clear()
+	// every list gains a post
+	r.c.InvalidateTag(blogListTag)
 	return nil
 }
 
@@ -64,7 +96,7 @@ func (r *BlogRepository) LinkTagToBlog(ctx context.Context, blogID int64, tagID
 	if err := r.db.WithContext(ctx).Create(&tagBlog).Error; err != nil {
 		return err
 	}
-	r.c.Clear()
+	r.c.InvalidateTag(blogTag(blogID))
 	return nil
 }
 func (r *BlogRepository) FetchByID(ctx context.Context, id int64) (*domain.Blog, error) {
@@ -78,7 +110,7 @@ func (r *BlogRepository) FetchByID(ctx context.Context, id int64) (*domain.Blog,
 	if err := r.db.WithContext(ctx).Preload("User").Preload("Tags").First(&blog, id).Error; err != nil {
 		return nil, err
 	}
-	r.c.Set(key, &blog, 5*time.Minute)
+	r.c.Set(key, &blog, 5*time.Minute, blogTag(id))
 	return &blog, nil
 }
 
@@ -93,7 +125,7 @@ func (r *BlogRepository) FetchAll(ctx context.Context) ([]*domain.Blog, error) {
 	if err := r.db.WithContext(ctx).Preload("User").Preload("Tags").Find(&blogs).Error; err != nil {
 		return nil, err
 	}
-	r.c.Set(key, blogs, 2*time.Minute)
+	r.c.Set(key, blogs, 2*time.Minute, listTags(blogs)...)
 	return blogs, nil
 }
 
@@ -115,7 +147,8 @@ func (r *BlogRepository) DeleteByID(ctx context.Context, ID int64, userID string
 	if result.RowsAffected == 0 {
 		return errors.New("blog not found")
 	}
-	r.c.Clear()
+	r.c.InvalidateTag(blogTag(ID))
+	r.c.InvalidateTag(blogListTag)
 	return result.Error
 }
 
@@ -133,7 +166,28 @@ func (r *BlogRepository) UpdateByID(ctx context.Context, id int64, userID string
 		return errors.New("blog not found")
 	}
 	// invalidate caches after successful update
-	r.c.Clear()
+	r.c.InvalidateTag(blogTag(id))
+	return nil
+}
+
+// UpdateInsights stores generated insights without touching the content.
+func (r *BlogRepository) UpdateInsights(ctx context.Context, blogID int64, insights *domain.BlogInsights) error {
+	now := time.Now()
+	res := r.db.WithContext(ctx).Model(&domain.Blog{ID: blogID}).
+		Select("Summary", "Excerpt", "SuggestedTags", "InsightsGeneratedAt").
+		Updates(&domain.Blog{
+			Summary:             insights.Summary,
+			Excerpt:             insights.Excerpt,
+			SuggestedTags:       insights.Tags,
+			InsightsGeneratedAt: &now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("blog not found")
+	}
+	r.c.InvalidateTag(blogTag(blogID))
 	return nil
 }
 
@@ -167,7 +221,7 @@ func (r *BlogRepository) FetchPaginatedBlogs(ctx context.Context, page, limit in
 	if err := g.Wait(); err != nil {
 		return nil, 0, err
 	}
-	r.c.Set(key, &pagedBlogs{Blogs: blogs, Total: total}, 1*time.Minute)
+	r.c.Set(key, &pagedBlogs{Blogs: blogs, Total: total}, 1*time.Minute, listTags(blogs)...)
 	return blogs, total, nil
 
 }
@@ -184,6 +238,18 @@ func (r *BlogRepository) FetchByFilter(ctx context.Context, filter domain.BlogFi
 		query = query.Where("user_id = ?", *filter.UserID)
 	}
 
+	switch filter.Sort {
+	case domain.BlogSortNewest:
+		query = query.Order("blogs.created_at DESC, blogs.id DESC")
+	case domain.BlogSortOldest:
+		query = query.Order("blogs.created_at, blogs.id")
+	case domain.BlogSortTrending:
+		// posts without a score follow, newest first
+		query = query.
+			Joins("LEFT JOIN blog_trending_scores ON blog_trending_scores.blog_id = blogs.id AND blog_trending_scores.period = ?", filter.Window).
+			Order("COALESCE(blog_trending_scores.score, 0) DESC, blogs.created_at DESC, blogs.id DESC")
+	}
+
 	if filter.Limit > 0 {
 		query = query.Limit(filter.Limit)
 	}
@@ -200,32 +266,127 @@ func (r *BlogRepository) FetchByFilter(ctx context.Context, filter domain.BlogFi
 	return blogs, nil
 }
 
-func (r *BlogRepository) IncrementView(ctx context.Context, blogID int64) error {
-	return r.db.WithContext(ctx).
-		Model(&domain.Blog{}).
-		Where("id = ?", blogID).
-		UpdateColumn("view_count", gorm.Expr("view_count + 1")).Error
+func (r *BlogRepository) FetchTrending(ctx context.Context, window string, page, limit int) ([]*domain.TrendingBlog, int64, error) {
+	ranked := r.db.WithContext(ctx).Model(&domain.BlogTrendingScore{}).
+		Joins("JOIN blogs ON blogs.id = blog_trending_scores.blog_id").
+		Where("blog_trending_scores.period = ?", window)
+
+	var total int64
+	if err := ranked.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var scores []*domain.BlogTrendingScore
+	err := ranked.
+		Select("blog_trending_scores.blog_id, blog_trending_scores.score").
+		Order("blog_trending_scores.score DESC, blog_trending_scores.blog_id").
+		Scopes(Paginate(page, limit)).
+		Find(&scores).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(scores) == 0 {
+		return []*domain.TrendingBlog{}, total, nil
+	}
+
+	ids := make([]int64, 0, len(scores))
+	for _, s := range scores {
+		ids = append(ids, s.BlogID)
+	}
+	var blogs []*domain.Blog
+	if err := r.db.WithContext(ctx).Preload("User").Preload("Tags").Where("id IN ?", ids).Find(&blogs).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[int64]*domain.Blog, len(blogs))
+	for _, b := range blogs {
+		byID[b.ID] = b
+	}
+	trending := make([]*domain.TrendingBlog, 0, len(scores))
+	for _, s := range scores {
+		// skip posts deleted between the queries
+		if b, ok := byID[s.BlogID]; ok {
+			trending = append(trending, &domain.TrendingBlog{Blog: b, Score: s.Score})
+		}
+	}
+	return trending, total, nil
 }
 
-func (r *BlogRepository) AddLike(ctx context.Context, blogID int64, _ int64) error {
-	return r.db.WithContext(ctx).
-		Model(&domain.Blog{}).
-		Where("id = ?", blogID).
-		UpdateColumn("likes", gorm.Expr("likes + 1")).Error
+// AddViews updates up to viewBatchSize posts per statement, in one
+// transaction so that a failed flush can be retried whole. Posts are updated
+// in ID order so that concurrent flushes lock rows in the same order.
+func (r *BlogRepository) AddViews(ctx context.Context, views map[int64]int) error {
+	ids := make([]int64, 0, len(views))
+	for id := range views {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(ids); start += viewBatchSize {
+			batch := ids[start:min(start+viewBatchSize, len(ids))]
+			var increments strings.Builder
+			args := make([]interface{}, 0, 2*len(batch))
+			increments.WriteString("view_count + CASE id")
+			for _, id := range batch {
+				increments.WriteString(" WHEN ? THEN ?")
+				args = append(args, id, views[id])
+			}
+			increments.WriteString(" ELSE 0 END")
+			err := tx.Model(&domain.Blog{}).
+				Where("id IN ?", batch).
+				UpdateColumn("view_count", gorm.Expr(increments.String(), args...)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		r.c.InvalidateTag(blogTag(id))
+	}
+	return nil
 }
 
-func (r *BlogRepository) RemoveLike(ctx context.Context, blogID int64, _ int64) error {
-	// Portable clamp to zero
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var b domain.Blog
-		if err := tx.Select("id, likes").First(&b, blogID).Error; err != nil {
-			return err
+// AddLike stores the user's like and counts it, unless the user already
+// liked the post.
+func (r *BlogRepository) AddLike(ctx context.Context, blogID int64, userID int64) (bool, error) {
+	added := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&domain.BlogLike{BlogID: blogID, UserID: userID})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
 		}
-		if b.Likes > 0 {
-			b.Likes--
+		added = true
+		return tx.Model(&domain.Blog{}).
+			Where("id = ?", blogID).
+			UpdateColumn("likes", gorm.Expr("likes + 1")).Error
+	})
+	if added && err == nil {
+		r.c.InvalidateTag(blogTag(blogID))
+	}
+	return added, err
+}
+
+// RemoveLike deletes the user's like and uncounts it, if there was one.
+func (r *BlogRepository) RemoveLike(ctx context.Context, blogID int64, userID int64) (bool, error) {
+	removed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("blog_id = ? AND user_id = ?", blogID, userID).Delete(&domain.BlogLike{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
 		}
-		return tx.Model(&domain.Blog{}).Where("id = ?", blogID).Update("likes", b.Likes).Error
+		removed = true
+		return tx.Model(&domain.Blog{}).
+			Where("id = ? AND likes > 0", blogID).
+			UpdateColumn("likes", gorm.Expr("likes - 1")).Error
 	})
+	if removed && err == nil {
+		r.c.InvalidateTag(blogTag(blogID))
+	}
+	return removed, err
 }
 
 func (r *BlogRepository) GetPopularity(ctx context.Context, blogID int64) (int, int, error) {
@@ -271,13 +432,11 @@ func (r *BlogRepository) SearchBlogs(ctx context.Context, query string, page, li
 
 }
 
-func (r *BlogRepository) AddComment(ctx context.Context, blogID, userID int64, content string) (*domain.Comment, error) {
-	c := &domain.Comment{
-		BlogID:  blogID,
-		UserID:  userID,
-		Content: content,
+// CreateComment inserts a comment or reply, published unless a status is set.
+func (r *BlogRepository) CreateComment(ctx context.Context, c *domain.Comment) (*domain.Comment, error) {
+	if c.Status == "" {
+		c.Status = domain.CommentPublished
 	}
-
 	// Create the comment
 	if err := r.db.WithContext(ctx).Create(c).Error; err != nil {
 		return nil, err
@@ -295,13 +454,21 @@ func (r *BlogRepository) AddComment(ctx context.Context, blogID, userID int64, c
 	return c, nil
 }
 
+func (r *BlogRepository) FetchCommentByID(ctx context.Context, id int64) (*domain.Comment, error) {
+	var c domain.Comment
+	if err := r.db.WithContext(ctx).First(&c, id).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
 func (r *BlogRepository) ListComments(ctx context.Context, blogID int64, page, limit int) ([]*domain.Comment, int64, error) {
 	var (
 		comments []*domain.Comment
 		total    int64
 	)
 
-	q := r.db.WithContext(ctx).Model(&domain.Comment{}).Where("blog_id = ?", blogID)
+	q := r.db.WithContext(ctx).Model(&domain.Comment{}).Where("blog_id = ? AND status = ?", blogID, domain.CommentPublished)
 
 	// Count total
 	if err := q.Count(&total).Error; err != nil {
@@ -331,3 +498,51 @@ func (r *BlogRepository) ListComments(ctx context.Context, blogID int64, page, l
 
 	return comments, total, nil
 }
+
+// ListHeldComments returns comments waiting for review, oldest first.
+func (r *BlogRepository) ListHeldComments(ctx context.Context, page, limit int) ([]*domain.Comment, int64, error) {
+	var (
+		comments []*domain.Comment
+		total    int64
+	)
+
+	q := r.db.WithContext(ctx).Model(&domain.Comment{}).Where("status = ?", domain.CommentHeld)
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := q.
+		Preload("User").
+		Preload("Blog").
+		Order("created_at ASC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&comments).Error; err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+// ReviewComment only changes held comments, so concurrent reviews of the
+// same comment cannot both succeed.
+func (r *BlogRepository) ReviewComment(ctx context.Context, id int64, status string, reviewerID int64, at time.Time) (*domain.Comment, error) {
+	res := r.db.WithContext(ctx).Model(&domain.Comment{}).
+		Where("id = ? AND status = ?", id, domain.CommentHeld).
+		Updates(map[string]interface{}{"status": status, "reviewed_by": reviewerID, "reviewed_at": at})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	var c domain.Comment
+	if err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Blog").
+		Preload("Blog.User").
+		First(&c, id).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -22,7 +22,7 @@ func ConnectDB() {
 
     DB = db
 
-	err = DB.AutoMigrate(&domain.User{}, &domain.Blog{}, &domain.Comment{}, &domain.Tag{}, &domain.Tag_Blog{}, &domain.Token{})
+	err = DB.AutoMigrate(&domain.User{}, &domain.Blog{}, &domain.Comment{}, &domain.Tag{}, &domain.Tag_Blog{}, &domain.Token{}, &domain.Follow{}, &domain.BlogLike{}, &domain.Notification{}, &domain.NotificationPreference{}, &domain.OutboxEmail{}, &domain.PendingEmailChange{}, &domain.LoginAttempt{}, &domain.RecoveryCode{}, &domain.ExternalIdentity{}, &domain.OIDCLoginState{}, &domain.PersonalAccessToken{}, &domain.MagicLinkToken{}, &domain.WebAuthnCredential{}, &domain.WebAuthnChallenge{}, &domain.AIUsage{}, &domain.AIQuota{}, &domain.BlogEmbedding{}, &domain.PromptTemplate{}, &domain.BlogDailyStats{}, &domain.BlogReferrerStats{}, &domain.BlogTrendingScore{})
     if err != nil {
         log.Fatal("Failed to migrate database:", err)
     }
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/blog-platform/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmbeddingRepository struct {
+	db *gorm.DB
+}
+
+func NewEmbeddingRepository(db *gorm.DB) domain.IEmbeddingRepository {
+	return &EmbeddingRepository{db: db}
+}
+
+func (r *EmbeddingRepository) SaveEmbedding(ctx context.Context, embedding *domain.BlogEmbedding) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "blog_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"model", "vector", "updated_at"}),
+	}).Create(embedding).Error
+}
+
+func (r *EmbeddingRepository) DeleteEmbedding(ctx context.Context, blogID int64) error {
+	return r.db.WithContext(ctx).Where("blog_id = ?", blogID).Delete(&domain.BlogEmbedding{}).Error
+}
+
+func (r *EmbeddingRepository) FetchEmbeddings(ctx context.Context) ([]*domain.BlogEmbedding, error) {
+	var embeddings []*domain.BlogEmbedding
+	err := r.db.WithContext(ctx).Find(&embeddings).Error
+	return embeddings, err
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/blog-platform/domain"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FollowRepository struct {
+	db *gorm.DB
+}
+
+func NewFollowRepository(db *gorm.DB) domain.IFollowRepository {
+	return &FollowRepository{db: db}
+}
+
+func (r *FollowRepository) Follow(ctx context.Context, followerID, followeeID int64) error {
+	f := domain.Follow{FollowerID: followerID, FolloweeID: followeeID}
+	// following twice is a no-op thanks to the unique (follower, followee) index
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&f).Error
+}
+
+func (r *FollowRepository) Unfollow(ctx context.Context, followerID, followeeID int64) error {
+	return r.db.WithContext(ctx).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&domain.Follow{}).Error
+}
+
+func (r *FollowRepository) IsFollowing(ctx context.Context, followerID, followeeID int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Follow{}).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// listUsers pages through the users on one side of the follows table,
+// matchCol selects which side we filter on and joinCol which side we return.
+// Only public columns are read, so nothing private can reach the response.
+func (r *FollowRepository) listUsers(ctx context.Context, matchCol, joinCol string, userID int64, page, limit int) ([]*domain.PublicUser, int64, error) {
+	var (
+		users []*domain.PublicUser
+		total int64
+	)
+
+	if err := r.db.WithContext(ctx).Model(&domain.Follow{}).
+		Where(matchCol+" = ?", userID).
+		Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	if err := r.db.WithContext(ctx).Model(&domain.User{}).
+		Select("users.id, users.username, users.bio, users.profile_picture").
+		Joins("JOIN follows ON follows."+joinCol+" = users.id").
+		Where("follows."+matchCol+" = ?", userID).
+		Order("follows.created_at DESC").
+		Scopes(Paginate(page, limit)).
+		Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *FollowRepository) ListFollowers(ctx context.Context, userID int64, page, limit int) ([]*domain.PublicUser, int64, error) {
+	return r.listUsers(ctx, "followee_id", "follower_id", userID, page, limit)
+}
+
+func (r *FollowRepository) ListFollowing(ctx context.Context, userID int64, page, limit int) ([]*domain.PublicUser, int64, error) {
+	return r.listUsers(ctx, "follower_id", "followee_id", userID, page, limit)
+}
+
+func (r *FollowRepository) ListFollowerIDs(ctx context.Context, userID int64) ([]int64, error) {
+	var ids []int64
+	if err := r.db.WithContext(ctx).Model(&domain.Follow{}).
+		Where("followee_id = ?", userID).
+		Pluck("follower_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *FollowRepository) CountFollows(ctx context.Context, userID int64) (domain.FollowCounts, error) {
+	var counts domain.FollowCounts
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return r.db.WithContext(gctx).Model(&domain.Follow{}).
+			Where("followee_id = ?", userID).Count(&counts.Followers).Error
+	})
+	g.Go(func() error {
+		return r.db.WithContext(gctx).Model(&domain.Follow{}).
+			Where("follower_id = ?", userID).Count(&counts.Following).Error
+	})
+	if err := g.Wait(); err != nil {
+		return domain.FollowCounts{}, err
+	}
+	return counts, nil
+}
+
+// FetchFeed returns the newest posts written by authors userID follows.
+// It uses keyset pagination on (created_at, id) so that each page is an
+// index range scan on idx_blogs_user_created per followed author instead of
+// an OFFSET over the whole result, which stays cheap for users following
+// thousands of authors.
+func (r *FollowRepository) FetchFeed(ctx context.Context, userID int64, cursor *domain.FeedCursor, limit int) ([]*domain.Blog, error) {
+	var blogs []*domain.Blog
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	followees := r.db.Model(&domain.Follow{}).
+		Select("followee_id").
+		Where("follower_id = ?", userID)
+
+	q := r.db.WithContext(ctx).Model(&domain.Blog{}).
+		Where("blogs.user_id IN (?)", followees)
+	if cursor != nil {
+		q = q.Where("(blogs.created_at, blogs.id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	if err := q.
+		Preload("User").
+		Preload("Tags").
+		Order("blogs.created_at DESC, blogs.id DESC").
+		Limit(limit).
+		Find(&blogs).Error; err != nil {
+		return nil, err
+	}
+	return blogs, nil
+}
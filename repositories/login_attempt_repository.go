@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/blog-platform/domain"
+	"gorm.io/gorm"
+)
+
+type LoginAttemptRepository struct {
+	DB *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{DB: db}
+}
+
+func (r *LoginAttemptRepository) Record(attempt *domain.LoginAttempt) error {
+	return r.DB.Create(attempt).Error
+}
+
+func (r *LoginAttemptRepository) CountFailuresByIP(ip string, since time.Time) (int64, error) {
+	var count int64
+	err := r.DB.Model(&domain.LoginAttempt{}).
+		Where("ip = ? AND success = ? AND created_at >= ?", ip, false, since).
+		Count(&count).Error
+	return count, err
+}
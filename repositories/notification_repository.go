@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) domain.INotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) CreateMany(ctx context.Context, notifications []*domain.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(notifications, 500).Error
+}
+
+func (r *NotificationRepository) List(ctx context.Context, userID int64, unreadOnly bool, page, limit int) ([]*domain.Notification, int64, error) {
+	var (
+		notifications []*domain.Notification
+		total         int64
+	)
+
+	q := r.db.WithContext(ctx).Model(&domain.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		q = q.Where("read_at IS NULL")
+	}
+
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	if err := q.
+		Preload("Actor", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, username, bio, profile_picture")
+		}).
+		Order("created_at DESC").
+		Scopes(Paginate(page, limit)).
+		Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+	for _, n := range notifications {
+		n.ActorProfile = n.Actor.Public()
+	}
+	return notifications, total, nil
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id int64) error {
+	res := r.db.WithContext(ctx).Model(&domain.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		UpdateColumn("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("notification not found")
+	}
+	return nil
+}
+
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID int64) error {
+	return r.db.WithContext(ctx).Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		UpdateColumn("read_at", time.Now()).Error
+}
+
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *NotificationRepository) GetPreferences(ctx context.Context, userID int64) ([]domain.NotificationPreference, error) {
+	var prefs []domain.NotificationPreference
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&prefs).Error; err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func (r *NotificationRepository) SetPreference(ctx context.Context, userID int64, kind string, enabled bool) error {
+	pref := domain.NotificationPreference{UserID: userID, Type: kind, Enabled: enabled}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&pref).Error
+}
+
+func (r *NotificationRepository) DisabledRecipients(ctx context.Context, kind string, userIDs []int64) ([]int64, error) {
+	var ids []int64
+	if len(userIDs) == 0 {
+		return ids, nil
+	}
+	if err := r.db.WithContext(ctx).Model(&domain.NotificationPreference{}).
+		Where("type = ? AND enabled = ? AND user_id IN ?", kind, false, userIDs).
+		Pluck("user_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/blog-platform/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OIDCRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCRepository(db *gorm.DB) domain.IOIDCRepository {
+	return &OIDCRepository{db: db}
+}
+
+func (r *OIDCRepository) SaveLoginState(ctx context.Context, state *domain.OIDCLoginState) error {
+	return r.db.WithContext(ctx).Create(state).Error
+}
+
+func (r *OIDCRepository) ConsumeLoginState(ctx context.Context, stateHash string) (*domain.OIDCLoginState, error) {
+	var states []domain.OIDCLoginState
+	// deleting with RETURNING makes the state single use even under concurrent callbacks
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("state_hash = ?", stateHash).
+		Delete(&states).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, nil
+	}
+	return &states[0], nil
+}
+
+func (r *OIDCRepository) FindIdentity(ctx context.Context, provider, subject string) (*domain.ExternalIdentity, error) {
+	var identity domain.ExternalIdentity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *OIDCRepository) CreateIdentity(ctx context.Context, identity *domain.ExternalIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *OIDCRepository) CreateUserWithIdentity(ctx context.Context, user *domain.User, identity *domain.ExternalIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
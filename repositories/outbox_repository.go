@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) domain.IOutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+func (r *OutboxRepository) Enqueue(ctx context.Context, email *domain.OutboxEmail) error {
+	return r.db.WithContext(ctx).Create(email).Error
+}
+
+func (r *OutboxRepository) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*domain.OutboxEmail, error) {
+	var emails []*domain.OutboxEmail
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", domain.OutboxPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&emails).Error; err != nil {
+			return err
+		}
+		if len(emails) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(emails))
+		for i, e := range emails {
+			ids[i] = e.ID
+		}
+		return tx.Model(&domain.OutboxEmail{}).
+			Where("id IN ?", ids).
+			UpdateColumn("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
+
+func (r *OutboxRepository) MarkSent(ctx context.Context, id int64, sentAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.OutboxEmail{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     domain.OutboxSent,
+			"sent_at":    sentAt,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": "",
+		}).Error
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time, lastError string, dead bool) error {
+	status := domain.OutboxPending
+	if dead {
+		status = domain.OutboxDead
+	}
+	return r.db.WithContext(ctx).Model(&domain.OutboxEmail{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		}).Error
+}
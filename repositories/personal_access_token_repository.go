@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"gorm.io/gorm"
+)
+
+type PersonalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalAccessTokenRepository(db *gorm.DB) domain.IPersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{db: db}
+}
+
+func (r *PersonalAccessTokenRepository) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *PersonalAccessTokenRepository) ListActive(ctx context.Context, userID int64) ([]domain.PersonalAccessToken, error) {
+	var tokens []domain.PersonalAccessToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *PersonalAccessTokenRepository) CountActive(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *PersonalAccessTokenRepository) FetchByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error) {
+	var token domain.PersonalAccessToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *PersonalAccessTokenRepository) Revoke(ctx context.Context, userID, id int64, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		UpdateColumn("revoked_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *PersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id int64, now time.Time, ip string) error {
+	return r.db.WithContext(ctx).Model(&domain.PersonalAccessToken{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"last_used_at": now, "last_used_ip": ip}).Error
+}
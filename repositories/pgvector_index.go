@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blog-platform/domain"
+	"gorm.io/gorm"
+)
+
+// PgvectorIndex stores vectors in Postgres with the pgvector extension and
+// lets the database find the nearest ones, so every instance sees the same
+// index. The column has no fixed size, as it depends on the model; for large
+// tables add an HNSW index on a fixed-size expression by hand.
+type PgvectorIndex struct {
+	db *gorm.DB
+}
+
+// NewPgvectorIndex creates the extension and the blog_vectors table if they
+// do not exist; creating the extension needs sufficient privileges.
+func NewPgvectorIndex(db *gorm.DB) (domain.IVectorIndex, error) {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		`CREATE TABLE IF NOT EXISTS blog_vectors (
+			blog_id bigint PRIMARY KEY REFERENCES blogs(id) ON DELETE CASCADE,
+			model varchar(100) NOT NULL,
+			embedding vector NOT NULL,
+			updated_at timestamptz NOT NULL DEFAULT now()
+		)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return nil, fmt.Errorf("pgvector: %w", err)
+		}
+	}
+	return &PgvectorIndex{db: db}, nil
+}
+
+// vectorLiteral formats a vector as pgvector's text form, e.g. [1,2.5,3].
+func vectorLiteral(vector []float32) string {
+	parts := make([]string, len(vector))
+	for i, v := range vector {
+		parts[i] = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+func parseVectorLiteral(literal string) ([]float32, error) {
+	literal = strings.TrimSuffix(strings.TrimPrefix(literal, "["), "]")
+	if literal == "" {
+		return nil, nil
+	}
+	parts := strings.Split(literal, ",")
+	vector := make([]float32, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 32)
+		if err != nil {
+			return nil, err
+		}
+		vector[i] = float32(v)
+	}
+	return vector, nil
+}
+
+func (ix *PgvectorIndex) Upsert(ctx context.Context, blogID int64, model string, vector []float32) error {
+	return ix.db.WithContext(ctx).Exec(
+		`INSERT INTO blog_vectors (blog_id, model, embedding, updated_at) VALUES (?, ?, ?::vector, now())
+		ON CONFLICT (blog_id) DO UPDATE SET model = EXCLUDED.model, embedding = EXCLUDED.embedding, updated_at = EXCLUDED.updated_at`,
+		blogID, model, vectorLiteral(vector)).Error
+}
+
+func (ix *PgvectorIndex) Delete(ctx context.Context, blogID int64) error {
+	return ix.db.WithContext(ctx).Exec(`DELETE FROM blog_vectors WHERE blog_id = ?`, blogID).Error
+}
+
+func (ix *PgvectorIndex) Fetch(ctx context.Context, blogID int64) (*domain.BlogEmbedding, error) {
+	var row struct {
+		BlogID    int64
+		Model     string
+		Embedding string
+	}
+	err := ix.db.WithContext(ctx).
+		Raw(`SELECT blog_id, model, embedding::text AS embedding FROM blog_vectors WHERE blog_id = ?`, blogID).
+		Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	vector, err := parseVectorLiteral(row.Embedding)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.BlogEmbedding{BlogID: row.BlogID, Model: row.Model, Vector: vector}, nil
+}
+
+// Search orders by cosine distance (<=>); the score is 1 minus the distance.
+func (ix *PgvectorIndex) Search(ctx context.Context, model string, vector []float32, limit int, excludeID int64) ([]domain.VectorMatch, error) {
+	literal := vectorLiteral(vector)
+	matches := []domain.VectorMatch{}
+	err := ix.db.WithContext(ctx).Raw(
+		`SELECT blog_id, 1 - (embedding <=> ?::vector) AS score FROM blog_vectors
+		WHERE model = ? AND blog_id <> ? AND vector_dims(embedding) = ?
+		ORDER BY embedding <=> ?::vector, blog_id DESC LIMIT ?`,
+		literal, model, excludeID, len(vector), literal, limit).
+		Scan(&matches).Error
+	return matches, err
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/blog-platform/domain"
+	"gorm.io/gorm"
+)
+
+type PromptTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewPromptTemplateRepository(db *gorm.DB) domain.IPromptTemplateRepository {
+	return &PromptTemplateRepository{db: db}
+}
+
+// CreateVersion numbers t after the latest version of its name. Two versions
+// saved at once get the same number, and the unique index rejects the second.
+func (r *PromptTemplateRepository) CreateVersion(ctx context.Context, t *domain.PromptTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&domain.PromptTemplate{}).Select("COALESCE(MAX(version), 0)").Where("name = ?", t.Name).Scan(&latest).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.PromptTemplate{}).Where("name = ? AND active = ?", t.Name, true).Update("active", false).Error; err != nil {
+			return err
+		}
+		t.Version = latest + 1
+		t.Active = true
+		return tx.Create(t).Error
+	})
+}
+
+func (r *PromptTemplateRepository) FetchTemplate(ctx context.Context, name string, version int) (*domain.PromptTemplate, error) {
+	query := r.db.WithContext(ctx).Where("name = ?", name)
+	if version == 0 {
+		query = query.Where("active = ?", true)
+	} else {
+		query = query.Where("version = ?", version)
+	}
+	var t domain.PromptTemplate
+	err := query.First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *PromptTemplateRepository) ListActive(ctx context.Context) ([]*domain.PromptTemplate, error) {
+	templates := []*domain.PromptTemplate{}
+	err := r.db.WithContext(ctx).Where("active = ?", true).Order("name").Find(&templates).Error
+	return templates, err
+}
+
+func (r *PromptTemplateRepository) ListVersions(ctx context.Context, name string) ([]*domain.PromptTemplate, error) {
+	templates := []*domain.PromptTemplate{}
+	err := r.db.WithContext(ctx).Where("name = ?", name).Order("version DESC").Find(&templates).Error
+	return templates, err
+}
+
+func (r *PromptTemplateRepository) Activate(ctx context.Context, name string, version int) (bool, error) {
+	found := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&domain.PromptTemplate{}).Where("name = ? AND version = ?", name, version).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		found = true
+		if err := tx.Model(&domain.PromptTemplate{}).Where("name = ? AND active = ?", name, true).Update("active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.PromptTemplate{}).Where("name = ? AND version = ?", name, version).Update("active", true).Error
+	})
+	return found, err
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"errors"
 	"strconv"
+	"time"
 
 	"github.com/blog-platform/domain"
 	"gorm.io/gorm"
@@ -26,6 +27,23 @@ func (ur *UserRepository) Register(user *domain.User) (domain.User, error) {
 	return *user, nil
 }
 
+func (ur *UserRepository) RegisterWithEmail(user *domain.User, email func(domain.User) (*domain.OutboxEmail, error)) (domain.User, error) {
+	err := ur.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		e, err := email(*user)
+		if err != nil {
+			return err
+		}
+		return tx.Create(e).Error
+	})
+	if err != nil {
+		return domain.User{}, errors.New(err.Error())
+	}
+	return *user, nil
+}
+
 func (ur *UserRepository) FetchByEmail(email string) (domain.User, error) {
 	var user domain.User
 	err := ur.DB.Where("email = ?", email).First(&user).Error
@@ -118,11 +136,9 @@ func (ur *UserRepository) Demote(idStr string) error {
 func (ur *UserRepository) UpdateUserProfile(userID int64, updates map[string]interface{}) error {
 	allowedFields := map[string]bool{
 		"Username":       true,
-		"Email":          true,
 		"Bio":            true,
 		"ProfilePicture": true,
 		"Phone":          true,
-		"Status":         true,
 	}
 	filteredUpdates := make(map[string]interface{})
 	for k, v := range updates {
@@ -136,6 +152,193 @@ func (ur *UserRepository) UpdateUserProfile(userID int64, updates map[string]int
 	return ur.DB.Model(&domain.User{}).Where("id = ?", userID).Updates(filteredUpdates).Error
 }
 
+func (ur *UserRepository) CreateEmailChange(change *domain.PendingEmailChange, emails []*domain.OutboxEmail) error {
+	return ur.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", change.UserID).Delete(&domain.PendingEmailChange{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+		for _, e := range emails {
+			if err := tx.Create(e).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (ur *UserRepository) FetchEmailChange(tokenHash string) (domain.PendingEmailChange, error) {
+	var change domain.PendingEmailChange
+	err := ur.DB.Where("token_hash = ?", tokenHash).First(&change).Error
+	if err != nil {
+		return domain.PendingEmailChange{}, errors.New(err.Error())
+	}
+	return change, nil
+}
+
+func (ur *UserRepository) ConfirmEmailChange(change domain.PendingEmailChange) error {
+	return ur.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.User{}).Where("id = ?", change.UserID).Update("email", change.NewEmail)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("user not found")
+		}
+		return tx.Delete(&domain.PendingEmailChange{}, change.ID).Error
+	})
+}
+
+func (ur *UserRepository) RecordLoginFailure(userID int64) (int, error) {
+	var failures int
+	err := ur.DB.Raw(`UPDATE users SET failed_logins = failed_logins + 1 WHERE id = ? RETURNING failed_logins`, userID).
+		Scan(&failures).Error
+	if err != nil {
+		return 0, err
+	}
+	return failures, nil
+}
+
+func (ur *UserRepository) LockAccount(userID int64, until time.Time, unlockTokenHash string, email *domain.OutboxEmail) error {
+	return ur.DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"locked_until": until}
+		if unlockTokenHash != "" {
+			updates["unlock_token_hash"] = unlockTokenHash
+		}
+		if err := tx.Model(&domain.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if email == nil {
+			return nil
+		}
+		return tx.Create(email).Error
+	})
+}
+
+func (ur *UserRepository) ResetLoginFailures(userID int64) error {
+	return ur.DB.Model(&domain.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"failed_logins":     0,
+		"locked_until":      nil,
+		"unlock_token_hash": "",
+	}).Error
+}
+
+func (ur *UserRepository) FetchByUnlockToken(tokenHash string) (domain.User, error) {
+	var user domain.User
+	err := ur.DB.Where("unlock_token_hash = ?", tokenHash).First(&user).Error
+	if err != nil {
+		return domain.User{}, errors.New(err.Error())
+	}
+	return user, nil
+}
+
+func (ur *UserRepository) ListLocked(now time.Time, page, limit int) ([]domain.User, int64, error) {
+	var users []domain.User
+	var total int64
+	q := ur.DB.Model(&domain.User{}).Where("locked_until > ?", now)
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.Order("locked_until desc").Scopes(Paginate(page, limit)).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (ur *UserRepository) SetTOTPSecret(userID int64, secret string) error {
+	return ur.DB.Model(&domain.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"totp_secret":  secret,
+		"totp_enabled": false,
+	}).Error
+}
+
+func (ur *UserRepository) EnableTOTP(userID int64, step int64, codeHashes []string) error {
+	return ur.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]domain.RecoveryCode, len(codeHashes))
+		for i, h := range codeHashes {
+			codes[i] = domain.RecoveryCode{UserID: userID, CodeHash: h}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (ur *UserRepository) DisableTOTP(userID int64) error {
+	return ur.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"totp_secret":    "",
+			"totp_enabled":   false,
+			"totp_last_step": 0,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error
+	})
+}
+
+func (ur *UserRepository) UseTOTPStep(userID int64, step int64) (bool, error) {
+	result := ur.DB.Model(&domain.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (ur *UserRepository) UseRecoveryCode(userID int64, codeHash string, now time.Time) (bool, error) {
+	result := ur.DB.Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (ur *UserRepository) CreateMagicLink(link *domain.MagicLinkToken, email *domain.OutboxEmail) error {
+	return ur.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(link).Error; err != nil {
+			return err
+		}
+		return tx.Create(email).Error
+	})
+}
+
+func (ur *UserRepository) CountMagicLinks(userID int64, since time.Time) (int64, error) {
+	var count int64
+	err := ur.DB.Model(&domain.MagicLinkToken{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (ur *UserRepository) UseMagicLink(tokenHash string, now time.Time) (domain.MagicLinkToken, error) {
+	var links []domain.MagicLinkToken
+	// a single conditional update so that a link cannot be used twice concurrently
+	err := ur.DB.Raw(`UPDATE magic_link_tokens SET used_at = ? WHERE token_hash = ? AND used_at IS NULL AND expires_at > ? RETURNING *`, now, tokenHash, now).
+		Scan(&links).Error
+	if err != nil {
+		return domain.MagicLinkToken{}, errors.New(err.Error())
+	}
+	if len(links) == 0 {
+		return domain.MagicLinkToken{}, errors.New("magic link not found")
+	}
+	return links[0], nil
+}
+
 func (ur *UserRepository) ResetPassword(idStr string, newPassword string) error {
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -154,6 +357,12 @@ func (ur *UserRepository) ResetPassword(idStr string, newPassword string) error
 	return nil
 }
 
+func (ur *UserRepository) UpdatePasswordHash(userID int64, oldHash, newHash string) error {
+	return ur.DB.Model(&domain.User{}).
+		Where("id = ? AND password = ?", userID, oldHash).
+		Update("password", newHash).Error
+}
+
 func (ur *UserRepository) CountUsers() (int64, error) {
 	var count int64
 	err := ur.DB.Model(&domain.User{}).Count(&count).Error
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebAuthnRepository struct {
+	db *gorm.DB
+}
+
+func NewWebAuthnRepository(db *gorm.DB) domain.IWebAuthnRepository {
+	return &WebAuthnRepository{db: db}
+}
+
+func (r *WebAuthnRepository) SaveChallenge(ctx context.Context, challenge *domain.WebAuthnChallenge) error {
+	return r.db.WithContext(ctx).Create(challenge).Error
+}
+
+func (r *WebAuthnRepository) ConsumeChallenge(ctx context.Context, challengeHash string) (*domain.WebAuthnChallenge, error) {
+	var challenges []domain.WebAuthnChallenge
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("challenge_hash = ?", challengeHash).
+		Delete(&challenges).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(challenges) == 0 {
+		return nil, nil
+	}
+	return &challenges[0], nil
+}
+
+func (r *WebAuthnRepository) CreateCredential(ctx context.Context, credential *domain.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Create(credential).Error
+}
+
+func (r *WebAuthnRepository) ListCredentials(ctx context.Context, userID int64) ([]domain.WebAuthnCredential, error) {
+	var credentials []domain.WebAuthnCredential
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *WebAuthnRepository) FetchCredential(ctx context.Context, credentialID string) (*domain.WebAuthnCredential, error) {
+	var credential domain.WebAuthnCredential
+	err := r.db.WithContext(ctx).Where("credential_id = ?", credentialID).First(&credential).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *WebAuthnRepository) UpdateSignCount(ctx context.Context, id int64, old, new int64, usedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.WebAuthnCredential{}).
+		Where("id = ? AND sign_count = ?", id, old).
+		UpdateColumns(map[string]interface{}{"sign_count": new, "last_used_at": usedAt})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *WebAuthnRepository) DeleteCredential(ctx context.Context, userID, id int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&domain.WebAuthnCredential{})
+	return result.RowsAffected > 0, result.Error
+}
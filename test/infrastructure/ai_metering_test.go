@@ -0,0 +1,193 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/test/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeAIProvider answers every request with the same completion. With
+// streamErr set, streams send the text and then fail without token counts.
+type fakeAIProvider struct {
+	completion domain.AICompletion
+	err        error
+	streamErr  error
+	calls      int
+}
+
+func (f *fakeAIProvider) Name() string { return "fake" }
+
+func (f *fakeAIProvider) Complete(ctx context.Context, req domain.AICompletionRequest) (*domain.AICompletion, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	completion := f.completion
+	return &completion, nil
+}
+
+func (f *fakeAIProvider) Stream(ctx context.Context, req domain.AICompletionRequest, onDelta func(string) error) (*domain.AICompletion, error) {
+	completion, err := f.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := onDelta(completion.Text); err != nil {
+		return completion, err
+	}
+	if f.streamErr != nil {
+		return &domain.AICompletion{Text: completion.Text, Model: completion.Model}, f.streamErr
+	}
+	return completion, nil
+}
+
+type AIMeteringTestSuite struct {
+	suite.Suite
+	provider *fakeAIProvider
+	usage    *mocks.MockAIUsageUsecase
+	metered  *infrastructure.MeteredAIProvider
+	ctx      context.Context
+}
+
+func (suite *AIMeteringTestSuite) SetupTest() {
+	suite.provider = &fakeAIProvider{completion: domain.AICompletion{
+		Text: "ideas", Model: "gpt-4o-mini-2024-07-18", PromptTokens: 1000000, CompletionTokens: 500000,
+	}}
+	suite.usage = new(mocks.MockAIUsageUsecase)
+	suite.metered = infrastructure.NewMeteredAIProvider(suite.provider, suite.usage)
+	suite.ctx = domain.WithAICaller(context.Background(), domain.AICaller{UserID: 7, Role: "user"})
+}
+
+var ideasRequest = domain.AICompletionRequest{
+	Messages: []domain.AIMessage{{Role: "user", Content: "ideas about go"}},
+	Feature:  domain.AIFeatureIdeas,
+}
+
+func (suite *AIMeteringTestSuite) TestRecordsUsageWithCost() {
+	suite.usage.On("CheckQuota", suite.ctx, domain.AICaller{UserID: 7, Role: "user"}).Return(nil)
+	var recorded *domain.AIUsage
+	suite.usage.On("Record", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(1).(*domain.AIUsage)
+	})
+
+	completion, err := suite.metered.Complete(suite.ctx, ideasRequest)
+	suite.NoError(err)
+	suite.Equal("ideas", completion.Text)
+	suite.Require().NotNil(recorded)
+	suite.Equal(int64(7), recorded.UserID)
+	suite.Equal(domain.AIFeatureIdeas, recorded.Feature)
+	suite.Equal("fake", recorded.Provider)
+	suite.False(recorded.Cached)
+	// 1M prompt tokens at 0.15 and 0.5M completion tokens at 0.6 per million
+	suite.InDelta(0.45, recorded.CostUSD, 1e-9)
+}
+
+func (suite *AIMeteringTestSuite) TestCachesIdenticalPrompts() {
+	suite.usage.On("CheckQuota", mock.Anything, mock.Anything).Return(nil)
+	var recorded []*domain.AIUsage
+	suite.usage.On("Record", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		recorded = append(recorded, args.Get(1).(*domain.AIUsage))
+	})
+
+	_, err := suite.metered.Complete(suite.ctx, ideasRequest)
+	suite.NoError(err)
+	var deltas []string
+	completion, err := suite.metered.Stream(suite.ctx, ideasRequest, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	suite.NoError(err)
+	suite.Equal("ideas", completion.Text)
+	suite.Equal([]string{"ideas"}, deltas)
+	suite.Equal(1, suite.provider.calls)
+	suite.Require().Len(recorded, 2)
+	suite.True(recorded[1].Cached)
+	suite.Zero(recorded[1].CostUSD)
+
+	other := ideasRequest
+	other.NoCache = true
+	_, err = suite.metered.Complete(suite.ctx, other)
+	suite.NoError(err)
+	suite.Equal(2, suite.provider.calls)
+}
+
+func (suite *AIMeteringTestSuite) TestCacheExpires() {
+	suite.usage.On("CheckQuota", mock.Anything, mock.Anything).Return(nil)
+	suite.usage.On("Record", mock.Anything, mock.Anything)
+	suite.metered.CacheTTL = 10 * time.Millisecond
+
+	_, err := suite.metered.Complete(suite.ctx, ideasRequest)
+	suite.NoError(err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = suite.metered.Complete(suite.ctx, ideasRequest)
+	suite.NoError(err)
+	suite.Equal(2, suite.provider.calls)
+}
+
+func (suite *AIMeteringTestSuite) TestQuotaExceeded() {
+	suite.usage.On("CheckQuota", mock.Anything, mock.Anything).Return(errors.New("AI daily quota exceeded"))
+
+	service := infrastructure.NewAIService(suite.metered)
+	_, err := service.GenerateBlogIdeas(suite.ctx, ideasPrompt)
+	suite.EqualError(err, "AI daily quota exceeded")
+	suite.Zero(suite.provider.calls)
+	suite.usage.AssertNotCalled(suite.T(), "Record", mock.Anything, mock.Anything)
+}
+
+func (suite *AIMeteringTestSuite) TestFailuresAreNotRecordedOrCached() {
+	suite.usage.On("CheckQuota", mock.Anything, mock.Anything).Return(nil)
+	suite.provider.err = errors.New("boom")
+
+	_, err := suite.metered.Complete(suite.ctx, ideasRequest)
+	suite.Error(err)
+	suite.provider.err = nil
+	suite.usage.On("Record", mock.Anything, mock.Anything)
+	_, err = suite.metered.Complete(suite.ctx, ideasRequest)
+	suite.NoError(err)
+	suite.Equal(2, suite.provider.calls)
+	suite.usage.AssertNumberOfCalls(suite.T(), "Record", 1)
+}
+
+func (suite *AIMeteringTestSuite) TestFailedStreamRecordsWhatWasStreamed() {
+	suite.usage.On("CheckQuota", mock.Anything, mock.Anything).Return(nil)
+	var recorded []*domain.AIUsage
+	suite.usage.On("Record", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		recorded = append(recorded, args.Get(1).(*domain.AIUsage))
+	})
+	suite.provider.streamErr = errors.New("connection reset")
+
+	completion, err := suite.metered.Stream(suite.ctx, ideasRequest, func(string) error { return nil })
+	suite.EqualError(err, "connection reset")
+	suite.Equal("ideas", completion.Text)
+	suite.Require().Len(recorded, 1)
+	// estimated at four characters a token: "ideas about go" and "ideas"
+	suite.Equal(4, recorded[0].PromptTokens)
+	suite.Equal(2, recorded[0].CompletionTokens)
+	suite.Positive(recorded[0].CostUSD)
+	suite.False(recorded[0].Cached)
+
+	// the partial answer is not cached
+	suite.provider.streamErr = nil
+	_, err = suite.metered.Complete(suite.ctx, ideasRequest)
+	suite.NoError(err)
+	suite.Equal(2, suite.provider.calls)
+}
+
+func (suite *AIMeteringTestSuite) TestPricingFromEnv() {
+	suite.T().Setenv("AI_PRICING", "llama3.2=1:2, broken")
+	suite.T().Setenv("AI_CACHE_TTL", "0")
+	metered := infrastructure.NewMeteredAIProvider(suite.provider, suite.usage)
+	suite.Equal(infrastructure.AIPrice{Input: 1, Output: 2}, metered.Pricing["llama3.2"])
+	suite.Equal(infrastructure.DefaultAIPricing["gpt-4o"], metered.Pricing["gpt-4o"])
+	suite.Zero(metered.CacheTTL)
+}
+
+func TestAIMeteringTestSuite(t *testing.T) {
+	suite.Run(t, new(AIMeteringTestSuite))
+}
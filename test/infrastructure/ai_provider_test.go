@@ -0,0 +1,546 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/stretchr/testify/suite"
+)
+
+type AIProviderTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	handler  http.HandlerFunc
+	requests atomic.Int32
+	ctx      context.Context
+}
+
+func (suite *AIProviderTestSuite) SetupTest() {
+	suite.requests.Store(0)
+	suite.ctx = context.Background()
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.requests.Add(1)
+		// consume the body up front so that the server notices when the
+		// client goes away
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		suite.handler(w, r)
+	}))
+}
+
+func (suite *AIProviderTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *AIProviderTestSuite) config() infrastructure.AIProviderConfig {
+	return infrastructure.AIProviderConfig{
+		BaseURL:      suite.server.URL,
+		APIKey:       "test-key",
+		Model:        "test-model",
+		Timeout:      time.Second,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+	}
+}
+
+func openAIReply(w http.ResponseWriter, text string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"model":   "test-model-0613",
+		"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": text}}},
+		"usage":   map[string]int{"prompt_tokens": 12, "completion_tokens": 5},
+	})
+}
+
+var aiChat = domain.AICompletionRequest{Messages: []domain.AIMessage{{Role: "user", Content: "hello"}}, MaxTokens: 50}
+
+var ideasPrompt = domain.Prompt{System: "You generate blog ideas.", User: "Generate blog ideas about: go"}
+
+func (suite *AIProviderTestSuite) TestOpenAI_Complete() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("/chat/completions", r.URL.Path)
+		suite.Equal("Bearer test-key", r.Header.Get("Authorization"))
+		var body map[string]interface{}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		suite.Equal("test-model", body["model"])
+		suite.Equal(float64(50), body["max_tokens"])
+		openAIReply(w, "hi there")
+	}
+
+	completion, err := infrastructure.NewOpenAIProvider(suite.config()).Complete(suite.ctx, aiChat)
+	suite.NoError(err)
+	suite.Equal("hi there", completion.Text)
+	suite.Equal("test-model-0613", completion.Model)
+	suite.Equal(12, completion.PromptTokens)
+	suite.Equal(5, completion.CompletionTokens)
+}
+
+func (suite *AIProviderTestSuite) TestOllama_Complete() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("/api/chat", r.URL.Path)
+		var body map[string]interface{}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		suite.Equal(false, body["stream"])
+		suite.Equal(map[string]interface{}{"num_predict": float64(50)}, body["options"])
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"model":             "test-model",
+			"message":           map[string]string{"role": "assistant", "content": "hello from ollama"},
+			"prompt_eval_count": 7,
+			"eval_count":        3,
+			"done":              true,
+		})
+	}
+
+	completion, err := infrastructure.NewOllamaProvider(suite.config()).Complete(suite.ctx, aiChat)
+	suite.NoError(err)
+	suite.Equal("hello from ollama", completion.Text)
+	suite.Equal(7, completion.PromptTokens)
+	suite.Equal(3, completion.CompletionTokens)
+}
+
+func (suite *AIProviderTestSuite) TestRetriesTransientFailures() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		switch suite.requests.Load() {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			openAIReply(w, "finally")
+		}
+	}
+
+	completion, err := infrastructure.NewOpenAIProvider(suite.config()).Complete(suite.ctx, aiChat)
+	suite.NoError(err)
+	suite.Equal("finally", completion.Text)
+	suite.Equal(int32(3), suite.requests.Load())
+}
+
+func (suite *AIProviderTestSuite) TestGivesUpAfterMaxRetries() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(suite.config()))
+	_, err := service.GenerateBlogIdeas(suite.ctx, ideasPrompt)
+	suite.EqualError(err, "AI provider request failed")
+	suite.Equal(int32(3), suite.requests.Load())
+}
+
+func (suite *AIProviderTestSuite) TestDoesNotRetryClientErrors() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	_, err := infrastructure.NewOpenAIProvider(suite.config()).Complete(suite.ctx, aiChat)
+	suite.ErrorContains(err, "status 400")
+	suite.Equal(int32(1), suite.requests.Load())
+}
+
+func (suite *AIProviderTestSuite) TestRejectedKey() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(suite.config()))
+	_, err := service.SuggestBlogImprovements(suite.ctx, domain.Prompt{User: "text"})
+	suite.EqualError(err, "AI provider is not configured")
+	suite.Equal(int32(1), suite.requests.Load())
+}
+
+func (suite *AIProviderTestSuite) TestTimeout() {
+	release := make(chan struct{})
+	defer close(release)
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}
+	cfg := suite.config()
+	cfg.Timeout = 20 * time.Millisecond
+
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(cfg))
+	_, err := service.GenerateBlogIdeas(suite.ctx, ideasPrompt)
+	suite.EqualError(err, "AI provider timed out")
+	suite.Equal(int32(1), suite.requests.Load(), "timeouts are not retried")
+}
+
+func (suite *AIProviderTestSuite) TestCancellation() {
+	started := make(chan struct{})
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}
+	ctx, cancel := context.WithCancel(suite.ctx)
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	_, err := infrastructure.NewOpenAIProvider(suite.config()).Complete(ctx, aiChat)
+	suite.True(errors.Is(err, context.Canceled), err)
+}
+
+func (suite *AIProviderTestSuite) TestOpenAI_Stream() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		suite.Equal(true, body["stream"])
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, piece := range []string{"hi", " there"} {
+			fmt.Fprintf(w, "data: {\"model\":\"test-model-0613\",\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", piece)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":2}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}
+
+	var deltas []string
+	completion, err := infrastructure.NewOpenAIProvider(suite.config()).Stream(suite.ctx, aiChat, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	suite.NoError(err)
+	suite.Equal([]string{"hi", " there"}, deltas)
+	suite.Equal("hi there", completion.Text)
+	suite.Equal("test-model-0613", completion.Model)
+	suite.Equal(12, completion.PromptTokens)
+	suite.Equal(2, completion.CompletionTokens)
+}
+
+func (suite *AIProviderTestSuite) TestOllama_Stream() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		suite.Equal(true, body["stream"])
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"hello"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":" world"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":7,"eval_count":2}`)
+	}
+
+	var deltas []string
+	completion, err := infrastructure.NewOllamaProvider(suite.config()).Stream(suite.ctx, aiChat, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	suite.NoError(err)
+	suite.Equal([]string{"hello", " world"}, deltas)
+	suite.Equal("hello world", completion.Text)
+	suite.Equal(7, completion.PromptTokens)
+	suite.Equal(2, completion.CompletionTokens)
+}
+
+func (suite *AIProviderTestSuite) TestStream_EndsEarly() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\n")
+	}
+
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(suite.config()))
+	_, err := service.StreamBlogIdeas(suite.ctx, ideasPrompt, func(string) error { return nil })
+	suite.EqualError(err, "AI provider request failed")
+	suite.Equal(int32(1), suite.requests.Load(), "streams are not retried once started")
+}
+
+func (suite *AIProviderTestSuite) TestOpenAI_StreamEndsEarlyReturnsPartial() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"model\":\"test-model\",\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\n")
+	}
+
+	completion, err := infrastructure.NewOpenAIProvider(suite.config()).Stream(suite.ctx, aiChat, func(string) error { return nil })
+	suite.Error(err)
+	suite.Require().NotNil(completion)
+	suite.Equal("partial", completion.Text)
+	suite.Equal("test-model", completion.Model)
+	suite.Zero(completion.CompletionTokens)
+}
+
+func (suite *AIProviderTestSuite) TestStream_RetriesBeforeStart() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		if suite.requests.Load() == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ideas\"}}]}\n\ndata: [DONE]\n\n")
+	}
+
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(suite.config()))
+	text, err := service.StreamBlogIdeas(suite.ctx, ideasPrompt, func(string) error { return nil })
+	suite.NoError(err)
+	suite.Equal("ideas", text)
+	suite.Equal(int32(2), suite.requests.Load())
+}
+
+func (suite *AIProviderTestSuite) TestStream_DeltaErrorStopsUpstream() {
+	upstreamDone := make(chan struct{})
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		defer close(upstreamDone)
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"one\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}
+	stop := errors.New("client went away")
+
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(suite.config()))
+	_, err := service.StreamBlogImprovements(suite.ctx, domain.Prompt{User: "text"}, func(string) error { return stop })
+	suite.Equal(stop, err)
+	select {
+	case <-upstreamDone:
+	case <-time.After(time.Second):
+		suite.Fail("upstream request was not cancelled")
+	}
+}
+
+func (suite *AIProviderTestSuite) TestStream_IdleTimeout() {
+	release := make(chan struct{})
+	defer close(release)
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"one\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}
+	cfg := suite.config()
+	cfg.Timeout = 50 * time.Millisecond
+
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(cfg))
+	_, err := service.StreamBlogIdeas(suite.ctx, ideasPrompt, func(string) error { return nil })
+	suite.EqualError(err, "AI provider timed out")
+}
+
+func (suite *AIProviderTestSuite) TestGenerateBlogInsights() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		format := body["response_format"].(map[string]interface{})
+		suite.Equal("json_schema", format["type"])
+		suite.Equal("blog_insights", format["json_schema"].(map[string]interface{})["name"])
+		openAIReply(w, "```json\n"+`{"summary": " A tour of Go. ", "excerpt": "Why Go?", "tags": ["Go", "#web dev", "go", "c++"]}`+"\n```")
+	}
+
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(suite.config()))
+	insights, err := service.GenerateBlogInsights(suite.ctx, "Go", "content")
+	suite.NoError(err)
+	suite.Equal(&domain.BlogInsights{Summary: "A tour of Go.", Excerpt: "Why Go?", Tags: []string{"go", "web-dev"}}, insights)
+}
+
+func (suite *AIProviderTestSuite) TestGenerateBlogInsights_Ollama() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		suite.Equal("object", body["format"].(map[string]interface{})["type"])
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]string{"role": "assistant", "content": `{"summary": "s", "excerpt": "e", "tags": ["go"]}`},
+			"done":    true,
+		})
+	}
+
+	service := infrastructure.NewAIService(infrastructure.NewOllamaProvider(suite.config()))
+	insights, err := service.GenerateBlogInsights(suite.ctx, "Go", "content")
+	suite.NoError(err)
+	suite.Equal([]string{"go"}, insights.Tags)
+}
+
+func (suite *AIProviderTestSuite) TestGenerateBlogInsights_Invalid() {
+	replies := []string{
+		"Here are some insights!",
+		`{"summary": "s", "excerpt": "e", "tags": ["go"], "title": "extra"}`,
+		`{"summary": "", "excerpt": "e", "tags": ["go"]}`,
+		fmt.Sprintf(`{"summary": "s", "excerpt": %q, "tags": ["go"]}`, bytes.Repeat([]byte("x"), 301)),
+		`{"summary": "s", "excerpt": "e", "tags": ["!!!"]}`,
+	}
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(suite.config()))
+	for _, reply := range replies {
+		suite.handler = func(w http.ResponseWriter, r *http.Request) { openAIReply(w, reply) }
+		_, err := service.GenerateBlogInsights(suite.ctx, "Go", "content")
+		suite.EqualError(err, "AI provider returned an invalid response", reply)
+	}
+}
+
+func (suite *AIProviderTestSuite) TestModerateComment() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		suite.Equal("comment_moderation", body["response_format"].(map[string]interface{})["json_schema"].(map[string]interface{})["name"])
+		openAIReply(w, `{"label": "Spam", "confidence": 0.92, "reason": "Links to a shop."}`)
+	}
+
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(suite.config()))
+	moderation, err := service.ModerateComment(suite.ctx, &domain.Blog{Title: "Go", Content: "content"}, "Cheap watches!")
+	suite.NoError(err)
+	suite.Equal(&domain.CommentModeration{Label: domain.ModerationSpam, Confidence: 0.92, Reason: "Links to a shop."}, moderation)
+}
+
+func (suite *AIProviderTestSuite) TestModerateComment_Invalid() {
+	replies := []string{
+		`{"label": "rude", "confidence": 0.5, "reason": "r"}`,
+		`{"label": "ok", "confidence": 1.5, "reason": "r"}`,
+		`{"label": "ok", "confidence": 0.5}` + "trailing",
+	}
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(suite.config()))
+	for _, reply := range replies {
+		suite.handler = func(w http.ResponseWriter, r *http.Request) { openAIReply(w, reply) }
+		_, err := service.ModerateComment(suite.ctx, &domain.Blog{Title: "Go"}, "comment")
+		suite.EqualError(err, "AI provider returned an invalid response", reply)
+	}
+}
+
+func (suite *AIProviderTestSuite) TestAnswerQuestion() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		suite.Equal("blog_answer", body["response_format"].(map[string]interface{})["json_schema"].(map[string]interface{})["name"])
+		openAIReply(w, `{"answer": "Use select [2].", "answerable": true, "sources": [2, 2, 7]}`)
+	}
+	chunks := []domain.BlogChunk{
+		{BlogID: 1, Title: "Bread", ParagraphStart: 1, ParagraphEnd: 1, Text: "Flour and water."},
+		{BlogID: 2, Title: "Go", ParagraphStart: 3, ParagraphEnd: 4, Text: strings.Repeat("a", 250)},
+	}
+
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(suite.config()))
+	answer, err := service.AnswerQuestion(suite.ctx, "How do I wait on channels?", chunks)
+	suite.NoError(err)
+	suite.True(answer.Answered)
+	suite.Equal("Use select [2].", answer.Answer)
+	// repeated and unknown sources are dropped
+	suite.Equal([]domain.BlogCitation{{BlogID: 2, Title: "Go", ParagraphStart: 3, ParagraphEnd: 4, Quote: strings.Repeat("a", 200) + "…"}}, answer.Citations)
+}
+
+func (suite *AIProviderTestSuite) TestAnswerQuestion_Unanswered() {
+	chunks := []domain.BlogChunk{{BlogID: 1, Title: "Bread", ParagraphStart: 1, ParagraphEnd: 1, Text: "Flour and water."}}
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(suite.config()))
+	replies := []string{
+		`{"answer": "The posts do not say.", "answerable": false, "sources": []}`,
+		// claims an answer but cites nothing it was given
+		`{"answer": "Use select.", "answerable": true, "sources": [3]}`,
+	}
+	for _, reply := range replies {
+		suite.handler = func(w http.ResponseWriter, r *http.Request) { openAIReply(w, reply) }
+		answer, err := service.AnswerQuestion(suite.ctx, "How do I wait on channels?", chunks)
+		suite.NoError(err)
+		suite.False(answer.Answered, reply)
+		suite.Empty(answer.Citations, reply)
+	}
+
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		openAIReply(w, `{"answer": " ", "answerable": true, "sources": [1]}`)
+	}
+	_, err := service.AnswerQuestion(suite.ctx, "How do I wait on channels?", chunks)
+	suite.EqualError(err, "AI provider returned an invalid response")
+}
+
+func (suite *AIProviderTestSuite) TestOpenAIEmbed() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("/embeddings", r.URL.Path)
+		var body struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		suite.Equal([]string{"first", "second"}, body.Input)
+		// the API does not promise to keep the order
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": []map[string]interface{}{
+			{"index": 1, "embedding": []float32{0, 1}},
+			{"index": 0, "embedding": []float32{1, 0}},
+		}})
+	}
+
+	provider := infrastructure.NewOpenAIEmbeddingProvider(suite.config())
+	vectors, err := provider.Embed(suite.ctx, []string{"first", "second"})
+	suite.NoError(err)
+	suite.Equal([][]float32{{1, 0}, {0, 1}}, vectors)
+	suite.Equal("test-model", provider.Model())
+}
+
+func (suite *AIProviderTestSuite) TestOllamaEmbed() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("/api/embed", r.URL.Path)
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": [][]float32{{0.5, 0.5}}})
+	}
+
+	vectors, err := infrastructure.NewOllamaEmbeddingProvider(suite.config()).Embed(suite.ctx, []string{"text"})
+	suite.NoError(err)
+	suite.Equal([][]float32{{0.5, 0.5}}, vectors)
+}
+
+func (suite *AIProviderTestSuite) TestEmbed_Invalid() {
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": [][]float32{{0.5, 0.5}, {1}}})
+	}
+
+	_, err := infrastructure.NewOllamaEmbeddingProvider(suite.config()).Embed(suite.ctx, []string{"a", "b"})
+	suite.EqualError(err, "AI provider request failed")
+}
+
+func (suite *AIProviderTestSuite) TestCachedEmbed() {
+	var inputs [][]string
+	suite.handler = func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input []string `json:"input"`
+		}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		inputs = append(inputs, body.Input)
+		embeddings := make([][]float32, len(body.Input))
+		for i, text := range body.Input {
+			embeddings[i] = []float32{float32(len(text)), 1}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": embeddings})
+	}
+
+	provider := infrastructure.NewCachedEmbeddingProvider(infrastructure.NewOllamaEmbeddingProvider(suite.config()), time.Hour)
+	_, err := provider.Embed(suite.ctx, []string{"a", "bb"})
+	suite.NoError(err)
+	vectors, err := provider.Embed(suite.ctx, []string{"bb", "ccc", "a"})
+	suite.NoError(err)
+	suite.Equal([][]float32{{2, 1}, {3, 1}, {1, 1}}, vectors)
+	suite.Equal([][]string{{"a", "bb"}, {"ccc"}}, inputs)
+
+	vectors, err = provider.Embed(suite.ctx, []string{"a"})
+	suite.NoError(err)
+	suite.Equal([][]float32{{1, 1}}, vectors)
+	suite.Len(inputs, 2)
+}
+
+func (suite *AIProviderTestSuite) TestNotConfigured() {
+	service := infrastructure.NewAIService(infrastructure.NewOpenAIProvider(infrastructure.AIProviderConfig{}))
+	_, err := service.GenerateBlogIdeas(suite.ctx, ideasPrompt)
+	suite.EqualError(err, "AI provider is not configured")
+
+	_, err = infrastructure.NewAIService(nil).GenerateBlogIdeas(suite.ctx, ideasPrompt)
+	suite.EqualError(err, "AI provider is not configured")
+}
+
+func (suite *AIProviderTestSuite) TestFromEnv() {
+	suite.T().Setenv("AI_PROVIDER", "ollama")
+	suite.T().Setenv("AI_TIMEOUT", "5s")
+	provider, err := infrastructure.NewAIProviderFromEnv()
+	suite.NoError(err)
+	suite.Equal("ollama", provider.Name())
+
+	suite.T().Setenv("AI_PROVIDER", "other")
+	_, err = infrastructure.NewAIProviderFromEnv()
+	suite.Error(err)
+
+	suite.T().Setenv("AI_PROVIDER", "")
+	suite.T().Setenv("AI_TIMEOUT", "soon")
+	_, err = infrastructure.NewAIProviderFromEnv()
+	suite.Error(err)
+}
+
+func TestAIProviderTestSuite(t *testing.T) {
+	suite.Run(t, new(AIProviderTestSuite))
+}
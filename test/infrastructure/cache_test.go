@@ -0,0 +1,106 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/stretchr/testify/suite"
+)
+
+type CacheTestSuite struct {
+	suite.Suite
+	cache *infrastructure.Cache
+}
+
+func (suite *CacheTestSuite) SetupTest() {
+	suite.cache = infrastructure.NewCache("test", 3)
+}
+
+func (suite *CacheTestSuite) TearDownTest() {
+	suite.cache.Close()
+}
+
+func (suite *CacheTestSuite) TestEvictsLeastRecentlyUsed() {
+	suite.cache.Set("a", 1, 0)
+	suite.cache.Set("b", 2, 0)
+	suite.cache.Set("c", 3, 0)
+	_, ok := suite.cache.Get("a") // b is now the oldest
+	suite.True(ok)
+	suite.cache.Set("d", 4, 0)
+
+	_, ok = suite.cache.Get("b")
+	suite.False(ok)
+	for _, key := range []string{"a", "c", "d"} {
+		_, ok := suite.cache.Get(key)
+		suite.True(ok, key)
+	}
+	// replacing a value does not evict
+	suite.cache.Set("d", 5, 0)
+	v, _ := suite.cache.Get("d")
+	suite.Equal(5, v)
+
+	stats := suite.cache.Stats()
+	suite.Equal(domain.CacheStats{Name: "test", Entries: 3, Capacity: 3, Hits: 5, Misses: 1, Evictions: 1}, stats)
+}
+
+func (suite *CacheTestSuite) TestExpires() {
+	suite.cache.Set("a", 1, 10*time.Millisecond)
+	suite.cache.Set("b", 2, 0)
+	time.Sleep(20 * time.Millisecond)
+
+	_, ok := suite.cache.Get("a")
+	suite.False(ok)
+	_, ok = suite.cache.Get("b")
+	suite.True(ok)
+	stats := suite.cache.Stats()
+	suite.Equal(uint64(1), stats.Expirations)
+	suite.Equal(1, stats.Entries)
+}
+
+func (suite *CacheTestSuite) TestInvalidateTag() {
+	suite.cache.Set("blog:1", "post 1", 0, "blog:1")
+	suite.cache.Set("page:1", "posts 1 and 2", 0, "list", "blog:1", "blog:2")
+	suite.cache.Set("page:2", "post 3", 0, "list", "blog:3")
+
+	suite.Equal(2, suite.cache.InvalidateTag("blog:1"))
+	_, ok := suite.cache.Get("page:2")
+	suite.True(ok)
+	suite.Zero(suite.cache.InvalidateTag("blog:2"))
+
+	// tags are replaced with the value
+	suite.cache.Set("page:2", "post 4", 0, "list", "blog:4")
+	suite.Zero(suite.cache.InvalidateTag("blog:3"))
+	suite.Equal(1, suite.cache.InvalidateTag("list"))
+	suite.Zero(suite.cache.Stats().Entries)
+	suite.Equal(uint64(3), suite.cache.Stats().Invalidations)
+}
+
+func (suite *CacheTestSuite) TestCacheStatsByName() {
+	other := infrastructure.NewCache("test", 3)
+	other.Set("a", 1, 0)
+	suite.cache.Set("a", 1, 0)
+	suite.cache.Set("b", 1, 0)
+
+	var found bool
+	for _, stats := range infrastructure.CacheStats() {
+		if stats.Name == "test" {
+			found = true
+			suite.Equal(3, stats.Entries)
+			suite.Equal(6, stats.Capacity)
+		}
+	}
+	suite.True(found)
+
+	other.Close()
+	for _, stats := range infrastructure.CacheStats() {
+		if stats.Name == "test" {
+			suite.Equal(2, stats.Entries)
+		}
+	}
+}
+
+func TestCacheTestSuite(t *testing.T) {
+	suite.Run(t, new(CacheTestSuite))
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/stretchr/testify/suite"
+)
+
+type EmailMessageTestSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+func (suite *EmailMessageTestSuite) SetupTest() {
+	suite.now = time.Date(2025, 8, 12, 10, 0, 0, 0, time.UTC)
+}
+
+func (suite *EmailMessageTestSuite) TestBuildMessage_MultipartAlternative() {
+	raw, err := infrastructure.BuildMessage("Blog <noreply@example.com>", &domain.EmailMessage{
+		To:      []string{"john@example.com"},
+		Subject: "Héllo",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+	}, suite.now)
+	suite.Require().NoError(err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	suite.Require().NoError(err)
+	suite.Equal(`"Blog" <noreply@example.com>`, msg.Header.Get("From"))
+	suite.Equal("<john@example.com>", msg.Header.Get("To"))
+	suite.Equal("1.0", msg.Header.Get("MIME-Version"))
+	suite.Equal("Tue, 12 Aug 2025 10:00:00 +0000", msg.Header.Get("Date"))
+	suite.True(strings.HasSuffix(msg.Header.Get("Message-ID"), "@example.com>"))
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	suite.NoError(err)
+	suite.Equal("Héllo", subject)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	suite.Require().NoError(err)
+	suite.Equal("multipart/alternative", mediaType)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var types, bodies []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		suite.Require().NoError(err)
+		b, _ := io.ReadAll(p)
+		types = append(types, p.Header.Get("Content-Type"))
+		bodies = append(bodies, string(b))
+	}
+	suite.Equal([]string{"text/plain; charset=UTF-8", "text/html; charset=UTF-8"}, types)
+	suite.Equal([]string{"plain body", "<p>html body</p>"}, bodies)
+}
+
+func (suite *EmailMessageTestSuite) TestBuildMessage_RejectsHeaderInjection() {
+	_, err := infrastructure.BuildMessage("noreply@example.com", &domain.EmailMessage{
+		To:      []string{"john@example.com"},
+		Subject: "Hi\r\nBcc: victim@example.com",
+		HTML:    "body",
+	}, suite.now)
+	suite.Error(err)
+
+	_, err = infrastructure.BuildMessage("noreply@example.com", &domain.EmailMessage{
+		To:   []string{"john@example.com\r\nBcc: victim@example.com"},
+		HTML: "body",
+	}, suite.now)
+	suite.Error(err)
+}
+
+func (suite *EmailMessageTestSuite) TestMaildirTransport_WritesMessage() {
+	dir := suite.T().TempDir()
+	svc, err := infrastructure.NewMaildirEmailService(dir, "noreply@example.com")
+	suite.Require().NoError(err)
+
+	err = svc.Send(&domain.EmailMessage{To: []string{"john@example.com"}, Subject: "Hi", Text: "hello"})
+	suite.Require().NoError(err)
+
+	files, err := os.ReadDir(filepath.Join(dir, "new"))
+	suite.Require().NoError(err)
+	suite.Require().Len(files, 1)
+	raw, err := os.ReadFile(filepath.Join(dir, "new", files[0].Name()))
+	suite.Require().NoError(err)
+	suite.Contains(string(raw), "Subject: Hi\r\n")
+
+	tmp, _ := os.ReadDir(filepath.Join(dir, "tmp"))
+	suite.Empty(tmp)
+}
+
+func (suite *EmailMessageTestSuite) TestEmailTransport_SelectedByConfiguration() {
+	suite.T().Setenv("EMAIL_TRANSPORT", "log")
+	transport, err := infrastructure.NewEmailTransport()
+	suite.NoError(err)
+	suite.IsType(&infrastructure.LogEmailService{}, transport)
+
+	suite.T().Setenv("EMAIL_TRANSPORT", "carrier-pigeon")
+	_, err = infrastructure.NewEmailTransport()
+	suite.Error(err)
+}
+
+func TestEmailMessageTestSuite(t *testing.T) {
+	suite.Run(t, new(EmailMessageTestSuite))
+}
@@ -43,6 +43,64 @@ func (suite *EmailServiceTestSuite) TestSendEmail_Failure() {
 	suite.Equal("failed to send email", err.Error())
 }
 
+func (suite *EmailServiceTestSuite) TestSendEmail_BuildsMIMEMessage() {
+	var sent []byte
+	var rcpts []string
+	suite.emailService.SendMailFn = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		suite.Equal("smtp.example.com:587", addr)
+		suite.Equal("from@example.com", from)
+		rcpts = to
+		sent = msg
+		return nil
+	}
+
+	err := suite.emailService.SendEmail([]string{"to@example.com"}, "Test Subject", "<p>Test Body</p>")
+	suite.NoError(err)
+	suite.Equal([]string{"to@example.com"}, rcpts)
+	suite.Contains(string(sent), "MIME-Version: 1.0\r\n")
+	suite.Contains(string(sent), "Content-Type: text/html; charset=UTF-8\r\n")
+	suite.Contains(string(sent), "Message-ID: <")
+	suite.Contains(string(sent), "Date: ")
+}
+
+func (suite *EmailServiceTestSuite) TestSendEmail_InvalidRecipient() {
+	suite.emailService.SendMailFn = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		suite.Fail("should not send")
+		return nil
+	}
+
+	err := suite.emailService.SendEmail([]string{"to@example.com\r\nBcc: x@example.com"}, "Test Subject", "Test Body")
+	suite.Error(err)
+}
+
+func (suite *EmailServiceTestSuite) TestNewSMTPEmailService_Security() {
+	suite.T().Setenv("SMTP_SECURITY", "")
+	service, err := infrastructure.NewSMTPEmailService()
+	suite.Require().NoError(err)
+	suite.Equal(infrastructure.SMTPSecuritySTARTTLS, service.Security)
+
+	suite.T().Setenv("SMTP_SECURITY", " STARTTLS ")
+	service, err = infrastructure.NewSMTPEmailService()
+	suite.Require().NoError(err)
+	suite.Equal(infrastructure.SMTPSecuritySTARTTLS, service.Security)
+}
+
+func (suite *EmailServiceTestSuite) TestNewSMTPEmailService_RejectsUnknownSettings() {
+	suite.T().Setenv("SMTP_SECURITY", "ssl")
+	_, err := infrastructure.NewSMTPEmailService()
+	suite.EqualError(err, "unknown SMTP_SECURITY 'ssl', want starttls, tls or none")
+
+	suite.T().Setenv("SMTP_SECURITY", "tls")
+	suite.T().Setenv("SMTP_AUTH", "xoauth2")
+	_, err = infrastructure.NewSMTPEmailService()
+	suite.EqualError(err, "unknown SMTP_AUTH 'xoauth2', want plain, login, cram-md5 or none")
+
+	suite.T().Setenv("EMAIL_TRANSPORT", "smtp")
+	transport, err := infrastructure.NewEmailTransport()
+	suite.Error(err)
+	suite.Nil(transport)
+}
+
 func TestEmailServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(EmailServiceTestSuite))
 }
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/stretchr/testify/suite"
+)
+
+type EmailTemplatesTestSuite struct {
+	suite.Suite
+	templates *infrastructure.EmailTemplates
+}
+
+func (suite *EmailTemplatesTestSuite) SetupTest() {
+	t, err := infrastructure.NewEmailTemplates()
+	suite.Require().NoError(err)
+	suite.templates = t
+}
+
+func (suite *EmailTemplatesTestSuite) TestRender_Activation() {
+	email, err := suite.templates.Render(domain.EmailActivation, map[string]string{
+		"Username": "john",
+		"Link":     "http://localhost:8080/user/1/activate",
+	})
+	suite.NoError(err)
+	suite.Equal("Activate your account", email.Subject)
+	suite.Contains(email.HTML, `<a href="http://localhost:8080/user/1/activate">`)
+	suite.Contains(email.Text, "Hi john,")
+	suite.Contains(email.Text, "http://localhost:8080/user/1/activate")
+}
+
+func (suite *EmailTemplatesTestSuite) TestRender_EmailChange() {
+	for _, name := range []string{domain.EmailChange, domain.EmailChangeNotice} {
+		email, err := suite.templates.Render(name, map[string]string{
+			"Username": "john",
+			"Email":    "new@example.com",
+			"Link":     "http://localhost:8080/email/confirm?token=abc",
+		})
+		suite.NoError(err, name)
+		suite.NotEmpty(email.Subject, name)
+		suite.Contains(email.Text, "new@example.com", name)
+	}
+}
+
+func (suite *EmailTemplatesTestSuite) TestRender_MagicLink() {
+	email, err := suite.templates.Render(domain.EmailMagicLink, map[string]string{
+		"Username": "john",
+		"Link":     "http://localhost:8080/login/magic/verify?token=abc",
+		"Minutes":  "15",
+	})
+	suite.NoError(err)
+	suite.Equal("Your sign-in link", email.Subject)
+	suite.Contains(email.Text, "expires in 15 minutes")
+	suite.Contains(email.HTML, "/login/magic/verify?token=abc")
+}
+
+func (suite *EmailTemplatesTestSuite) TestRender_EscapesHTML() {
+	email, err := suite.templates.Render(domain.EmailNotification, map[string]string{
+		"Username": "<script>alert(1)</script>",
+		"Title":    "New comment",
+		"Message":  "Someone commented",
+	})
+	suite.NoError(err)
+	suite.NotContains(email.HTML, "<script>")
+	suite.Contains(email.HTML, "&lt;script&gt;")
+	suite.NotContains(email.HTML, "View on Blog Platform")
+}
+
+func (suite *EmailTemplatesTestSuite) TestRender_UnknownTemplate() {
+	_, err := suite.templates.Render("missing", nil)
+	suite.Error(err)
+}
+
+func TestEmailTemplatesTestSuite(t *testing.T) {
+	suite.Run(t, new(EmailTemplatesTestSuite))
+}
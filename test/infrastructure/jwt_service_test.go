@@ -209,6 +209,23 @@ func (suite *JWTInfrastructureTestSuite) TestValidateRefreshToken_ExpiredToken()
 	suite.Contains(err.Error(), "token is expired")
 }
 
+func (suite *JWTInfrastructureTestSuite) TestMFAToken_RoundTrip() {
+	tokenString, err := suite.infra.GenerateMFAToken("42")
+	suite.NoError(err)
+
+	userID, err := suite.infra.ValidateMFAToken(tokenString)
+	suite.NoError(err)
+	suite.Equal("42", userID)
+}
+
+func (suite *JWTInfrastructureTestSuite) TestValidateMFAToken_RejectsAccessToken() {
+	tokenString, err := suite.infra.GenerateAccessToken("42", "user")
+	suite.Require().NoError(err)
+
+	_, err = suite.infra.ValidateMFAToken(tokenString)
+	suite.EqualError(err, "invalid or expired mfa token")
+}
+
 func TestJWTInfrastructureTestSuite(t *testing.T) {
 	suite.Run(t, new(JWTInfrastructureTestSuite))
 }
\ No newline at end of file
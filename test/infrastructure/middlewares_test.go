@@ -11,21 +11,24 @@ import (
 	"github.com/blog-platform/test/mocks"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
 type MiddlewareTestSuite struct {
 	suite.Suite
-	mockJWTService *mocks.MockJWTService
-	mockBlogRepo   *mocks.MockBlogRepo
-	middleware     *infrastructure.Middleware
-	router         *gin.Engine
+	mockJWTService   *mocks.MockJWTService
+	mockBlogRepo     *mocks.MockBlogRepo
+	mockAccessTokens *mocks.MockPersonalAccessTokenUsecase
+	middleware       *infrastructure.Middleware
+	router           *gin.Engine
 }
 
 func (suite *MiddlewareTestSuite) SetupTest() {
 	suite.mockJWTService = new(mocks.MockJWTService)
 	suite.mockBlogRepo = new(mocks.MockBlogRepo)
-	suite.middleware = infrastructure.NewMiddleware(suite.mockJWTService, suite.mockBlogRepo)
+	suite.mockAccessTokens = new(mocks.MockPersonalAccessTokenUsecase)
+	suite.middleware = infrastructure.NewMiddleware(suite.mockJWTService, suite.mockBlogRepo, suite.mockAccessTokens)
 	suite.router = gin.Default()
 }
 
@@ -78,6 +81,108 @@ func (suite *MiddlewareTestSuite) TestAuthMiddleware_InvalidToken() {
 	suite.mockJWTService.AssertExpectations(suite.T())
 }
 
+func (suite *MiddlewareTestSuite) TestOptionalAuthMiddleware_Anonymous() {
+	req, _ := http.NewRequest("POST", "/test", nil)
+	w := httptest.NewRecorder()
+
+	suite.router.POST("/test", suite.middleware.OptionalAuthMiddleware(), func(c *gin.Context) {
+		_, exists := c.Get("user_id")
+		assert.False(suite.T(), exists)
+		c.Status(http.StatusOK)
+	})
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	suite.mockJWTService.AssertNotCalled(suite.T(), "ValidateAccessToken", mock.Anything)
+}
+
+func (suite *MiddlewareTestSuite) TestOptionalAuthMiddleware_WithToken() {
+	req, _ := http.NewRequest("POST", "/test", nil)
+	req.Header.Set("Authorization", "Bearer valid_token")
+	w := httptest.NewRecorder()
+
+	claims := &domain.TokenClaims{UserID: "7", UserRole: "user"}
+	suite.mockJWTService.On("ValidateAccessToken", "Bearer valid_token").Return(claims, nil)
+
+	suite.router.POST("/test", suite.middleware.OptionalAuthMiddleware(), func(c *gin.Context) {
+		assert.Equal(suite.T(), int64(7), c.GetInt64("user_id"))
+		c.Status(http.StatusOK)
+	})
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *MiddlewareTestSuite) TestOptionalAuthMiddleware_InvalidToken() {
+	req, _ := http.NewRequest("POST", "/test", nil)
+	req.Header.Set("Authorization", "Bearer invalid_token")
+	w := httptest.NewRecorder()
+
+	suite.mockJWTService.On("ValidateAccessToken", "Bearer invalid_token").Return(nil, errors.New("invalid token"))
+
+	suite.router.POST("/test", suite.middleware.OptionalAuthMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *MiddlewareTestSuite) servePAT(method string, scopes []string, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	claims := &domain.TokenClaims{UserID: "7", UserRole: "admin"}
+	suite.mockAccessTokens.On("Authenticate", mock.Anything, "bpat_secret", mock.Anything).Return(claims, scopes, nil)
+
+	handlers = append([]gin.HandlerFunc{suite.middleware.AuthMiddleware()}, handlers...)
+	suite.router.Handle(method, "/test", append(handlers, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetInt64("user_id")})
+	})...)
+	req, _ := http.NewRequest(method, "/test", nil)
+	req.Header.Set("Authorization", "Bearer bpat_secret")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *MiddlewareTestSuite) TestAuthMiddleware_PersonalAccessToken() {
+	w := suite.servePAT("GET", []string{"read"})
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.JSONEq(suite.T(), `{"user_id":7}`, w.Body.String())
+	suite.mockJWTService.AssertNotCalled(suite.T(), "ValidateAccessToken", mock.Anything)
+}
+
+func (suite *MiddlewareTestSuite) TestAuthMiddleware_PersonalAccessTokenScope() {
+	assert.Equal(suite.T(), http.StatusForbidden, suite.servePAT("POST", []string{"read"}).Code)
+}
+
+func (suite *MiddlewareTestSuite) TestAuthMiddleware_PersonalAccessTokenWriteImpliesRead() {
+	assert.Equal(suite.T(), http.StatusOK, suite.servePAT("GET", []string{"write"}).Code)
+}
+
+func (suite *MiddlewareTestSuite) TestAuthMiddleware_InvalidPersonalAccessToken() {
+	suite.mockAccessTokens.On("Authenticate", mock.Anything, "bpat_revoked", mock.Anything).Return(nil, nil, errors.New("invalid token"))
+	suite.router.GET("/test", suite.middleware.AuthMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer bpat_revoked")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *MiddlewareTestSuite) TestAdminMiddleware_PersonalAccessTokenNeedsAdminScope() {
+	w := suite.servePAT("GET", []string{"read"}, suite.middleware.AdminMiddleware())
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *MiddlewareTestSuite) TestSessionOnlyMiddleware_RejectsPersonalAccessToken() {
+	w := suite.servePAT("POST", []string{"write"}, suite.middleware.SessionOnlyMiddleware())
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	assert.JSONEq(suite.T(), `{"error":"this route requires a login session"}`, w.Body.String())
+}
+
 func (suite *MiddlewareTestSuite) TestAdminMiddleware_Success() {
 	req, _ := http.NewRequest("GET", "/admin", nil)
 	w := httptest.NewRecorder()
@@ -0,0 +1,195 @@
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/infrastructure"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+)
+
+// stand-in identity provider serving discovery, JWKS and a token endpoint
+type testIdP struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	signKey   *rsa.PrivateKey
+	challenge string
+	claims    jwt.MapClaims
+	jwksHits  int
+}
+
+func newTestIdP() *testIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	idp := &testIdP{key: key, signKey: key}
+	mux := http.NewServeMux()
+	idp.server = httptest.NewServer(mux)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.jwksHits++
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims)
+		token.Header["kid"] = "key-1"
+		signed, _ := token.SignedString(idp.signKey)
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "token_type": "Bearer", "id_token": signed})
+	})
+	return idp
+}
+
+type OIDCClientTestSuite struct {
+	suite.Suite
+	idp    *testIdP
+	client *infrastructure.OIDCClient
+	ctx    context.Context
+}
+
+func (suite *OIDCClientTestSuite) SetupTest() {
+	suite.idp = newTestIdP()
+	suite.client = infrastructure.NewOIDCClient(suite.idp.server.URL, "blog", "secret", "http://localhost:8080/auth/oidc/corp/callback", nil)
+	suite.ctx = context.Background()
+
+	verifier := "verifier-verifier-verifier-verifier-verifier"
+	sum := sha256.Sum256([]byte(verifier))
+	suite.idp.challenge = base64.RawURLEncoding.EncodeToString(sum[:])
+	suite.idp.claims = jwt.MapClaims{
+		"iss":            suite.idp.server.URL,
+		"sub":            "user-1",
+		"aud":            "blog",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          "n-1",
+		"email":          "jane@example.com",
+		"email_verified": true,
+	}
+}
+
+func (suite *OIDCClientTestSuite) TearDownTest() {
+	suite.idp.server.Close()
+}
+
+func (suite *OIDCClientTestSuite) exchange() error {
+	_, err := suite.client.Exchange(suite.ctx, "good-code", "verifier-verifier-verifier-verifier-verifier", "n-1")
+	return err
+}
+
+func (suite *OIDCClientTestSuite) TestAuthCodeURL() {
+	raw, err := suite.client.AuthCodeURL(suite.ctx, "st", "n-1", suite.idp.challenge)
+	suite.NoError(err)
+
+	u, err := url.Parse(raw)
+	suite.NoError(err)
+	suite.Equal("/authorize", u.Path)
+	q := u.Query()
+	suite.Equal("code", q.Get("response_type"))
+	suite.Equal("blog", q.Get("client_id"))
+	suite.Equal("st", q.Get("state"))
+	suite.Equal("n-1", q.Get("nonce"))
+	suite.Equal("S256", q.Get("code_challenge_method"))
+	suite.Equal("openid email profile", q.Get("scope"))
+}
+
+func (suite *OIDCClientTestSuite) TestExchange_Success() {
+	identity, err := suite.client.Exchange(suite.ctx, "good-code", "verifier-verifier-verifier-verifier-verifier", "n-1")
+	suite.Require().NoError(err)
+	suite.Equal("user-1", identity.Subject)
+	suite.Equal("jane@example.com", identity.Email)
+	suite.True(identity.EmailVerified)
+
+	// keys are cached between logins
+	suite.NoError(suite.exchange())
+	suite.Equal(1, suite.idp.jwksHits)
+}
+
+func (suite *OIDCClientTestSuite) TestExchange_EmailVerifiedAsString() {
+	suite.idp.claims["email_verified"] = "true"
+	identity, err := suite.client.Exchange(suite.ctx, "good-code", "verifier-verifier-verifier-verifier-verifier", "n-1")
+	suite.Require().NoError(err)
+	suite.True(identity.EmailVerified)
+}
+
+func (suite *OIDCClientTestSuite) TestExchange_WrongVerifier() {
+	_, err := suite.client.Exchange(suite.ctx, "good-code", "other-verifier", "n-1")
+	suite.Error(err)
+}
+
+func (suite *OIDCClientTestSuite) TestExchange_NonceMismatch() {
+	suite.idp.claims["nonce"] = "other"
+	suite.Error(suite.exchange())
+}
+
+func (suite *OIDCClientTestSuite) TestExchange_WrongAudience() {
+	suite.idp.claims["aud"] = "someone-else"
+	suite.Error(suite.exchange())
+}
+
+func (suite *OIDCClientTestSuite) TestExchange_WrongIssuer() {
+	suite.idp.claims["iss"] = "https://evil.example.com"
+	suite.Error(suite.exchange())
+}
+
+func (suite *OIDCClientTestSuite) TestExchange_Expired() {
+	suite.idp.claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	suite.Error(suite.exchange())
+}
+
+func (suite *OIDCClientTestSuite) TestExchange_MultipleAudiencesRequireAzp() {
+	suite.idp.claims["aud"] = []string{"blog", "other"}
+	suite.Error(suite.exchange())
+
+	suite.idp.claims["azp"] = "blog"
+	suite.NoError(suite.exchange())
+}
+
+func (suite *OIDCClientTestSuite) TestExchange_UntrustedKey() {
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	suite.Require().NoError(err)
+	// the token is signed with a key the JWKS does not publish
+	suite.idp.signKey = other
+	suite.Error(suite.exchange())
+}
+
+func (suite *OIDCClientTestSuite) TestDiscovery_IssuerMismatch() {
+	client := infrastructure.NewOIDCClient(suite.idp.server.URL+"/other", "blog", "", "http://localhost/callback", nil)
+	_, err := client.AuthCodeURL(suite.ctx, "s", "n", "c")
+	suite.Error(err)
+}
+
+func TestOIDCClientTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCClientTestSuite))
+}
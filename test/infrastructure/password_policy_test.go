@@ -0,0 +1,76 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blog-platform/infrastructure"
+	"github.com/stretchr/testify/suite"
+)
+
+type PasswordPolicyTestSuite struct {
+	suite.Suite
+	policy *infrastructure.PasswordPolicy
+}
+
+func (suite *PasswordPolicyTestSuite) SetupTest() {
+	suite.policy = infrastructure.DefaultPasswordPolicy()
+	for _, env := range []string{"PASSWORD_MIN_LENGTH", "PASSWORD_MAX_LENGTH", "PASSWORD_REQUIRED_CLASSES", "PASSWORD_DENYLIST_FILE"} {
+		suite.T().Setenv(env, "")
+	}
+}
+
+func (suite *PasswordPolicyTestSuite) TestValidate_Default() {
+	suite.NoError(suite.policy.Validate("Password123!"))
+	suite.NoError(suite.policy.Validate("Пароль123!"), "letters outside ASCII count")
+
+	for _, password := range []string{"Pa1!", "password123!", "PASSWORD123!", "Password!!!!", "Password1234"} {
+		suite.EqualError(suite.policy.Validate(password),
+			"password must be at least 8 characters long and contain an uppercase letter, a lowercase letter, a number and a symbol", password)
+	}
+	suite.EqualError(suite.policy.Validate("Aa1!"+strings.Repeat("x", 125)), "password must be at most 128 characters long")
+}
+
+func (suite *PasswordPolicyTestSuite) TestValidate_DenyList() {
+	suite.NoError(suite.policy.LoadDenyList(strings.NewReader("# top passwords\n\nPassword123!\n  Welcome1!  \n")))
+
+	suite.EqualError(suite.policy.Validate("pASSWORD123!"), "password is too common, choose a different one")
+	suite.EqualError(suite.policy.Validate("Welcome1!"), "password is too common, choose a different one")
+	suite.NoError(suite.policy.Validate("Password124!"))
+}
+
+func (suite *PasswordPolicyTestSuite) TestFromEnv() {
+	path := filepath.Join(suite.T().TempDir(), "denylist.txt")
+	suite.Require().NoError(os.WriteFile(path, []byte("correcthorse1\n"), 0o600))
+	suite.T().Setenv("PASSWORD_MIN_LENGTH", "12")
+	suite.T().Setenv("PASSWORD_REQUIRED_CLASSES", "lower, number")
+	suite.T().Setenv("PASSWORD_DENYLIST_FILE", path)
+
+	policy, err := infrastructure.NewPasswordPolicyFromEnv()
+	suite.Require().NoError(err)
+	suite.NoError(policy.Validate("long passphrase 1"))
+	suite.EqualError(policy.Validate("short 1"), "password must be at least 12 characters long and contain a lowercase letter and a number")
+	suite.EqualError(policy.Validate("CorrectHorse1"), "password is too common, choose a different one")
+
+	suite.T().Setenv("PASSWORD_REQUIRED_CLASSES", "none")
+	policy, err = infrastructure.NewPasswordPolicyFromEnv()
+	suite.Require().NoError(err)
+	suite.NoError(policy.Validate("aaaaaaaaaaaa"))
+}
+
+func (suite *PasswordPolicyTestSuite) TestFromEnv_Errors() {
+	suite.T().Setenv("PASSWORD_REQUIRED_CLASSES", "upper,emoji")
+	_, err := infrastructure.NewPasswordPolicyFromEnv()
+	suite.Error(err)
+
+	suite.T().Setenv("PASSWORD_REQUIRED_CLASSES", "")
+	suite.T().Setenv("PASSWORD_DENYLIST_FILE", filepath.Join(suite.T().TempDir(), "missing.txt"))
+	_, err = infrastructure.NewPasswordPolicyFromEnv()
+	suite.Error(err)
+}
+
+func TestPasswordPolicyTestSuite(t *testing.T) {
+	suite.Run(t, new(PasswordPolicyTestSuite))
+}
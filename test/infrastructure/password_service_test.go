@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/suite"
 	"github.com/blog-platform/infrastructure"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type PasswordServiceTestSuite struct {
@@ -76,6 +77,76 @@ func (suite *PasswordServiceTestSuite) TestHash_EdgeCases() {
 	suite.NotEmpty(hSpecial)
 }
 
+func (suite *PasswordServiceTestSuite) TestHash_Argon2idFormat() {
+	hash, err := suite.service.HashPassword("secret")
+	suite.NoError(err)
+	suite.True(strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$"), hash)
+	suite.False(suite.service.NeedsRehash(hash))
+}
+
+func (suite *PasswordServiceTestSuite) TestHash_LongPasswordsAreNotTruncated() {
+	long := strings.Repeat("x", 72)
+	hash, err := suite.service.HashPassword(long + "a")
+	suite.NoError(err)
+
+	suite.Error(suite.service.ComparePassword([]byte(hash), []byte(long+"b")))
+	suite.NoError(suite.service.ComparePassword([]byte(hash), []byte(long+"a")))
+}
+
+func (suite *PasswordServiceTestSuite) TestHash_BcryptRefusesPasswordsOver72Bytes() {
+	bcryptService := &infrastructure.PasswordInfrastructure{Algorithm: infrastructure.PasswordBcrypt, BcryptCost: bcrypt.MinCost}
+
+	_, err := bcryptService.HashPassword(strings.Repeat("x", 72))
+	suite.NoError(err)
+	// 71 characters, but 73 bytes
+	_, err = bcryptService.HashPassword(strings.Repeat("x", 69) + "éé")
+	suite.EqualError(err, "password must be at most 72 bytes long")
+}
+
+func (suite *PasswordServiceTestSuite) TestCompare_LegacyBcrypt() {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	suite.Require().NoError(err)
+
+	suite.NoError(suite.service.ComparePassword(legacy, []byte("secret")))
+	suite.Error(suite.service.ComparePassword(legacy, []byte("other")))
+	suite.True(suite.service.NeedsRehash(string(legacy)))
+}
+
+func (suite *PasswordServiceTestSuite) TestNeedsRehash_ParameterChanges() {
+	hash, err := suite.service.HashPassword("secret")
+	suite.Require().NoError(err)
+
+	stronger := &infrastructure.PasswordInfrastructure{Argon2: infrastructure.DefaultArgon2Params}
+	stronger.Argon2.Time = 3
+	suite.True(stronger.NeedsRehash(hash))
+	suite.NoError(stronger.ComparePassword([]byte(hash), []byte("secret")), "old parameters still verify")
+
+	bcryptService := &infrastructure.PasswordInfrastructure{Algorithm: infrastructure.PasswordBcrypt, BcryptCost: bcrypt.MinCost}
+	suite.True(bcryptService.NeedsRehash(hash))
+	bcryptHash, err := bcryptService.HashPassword("secret")
+	suite.Require().NoError(err)
+	suite.False(bcryptService.NeedsRehash(bcryptHash))
+	suite.NoError(bcryptService.ComparePassword([]byte(hash), []byte("secret")))
+}
+
+func (suite *PasswordServiceTestSuite) TestBcrypt_RejectsLongPasswords() {
+	bcryptService := &infrastructure.PasswordInfrastructure{Algorithm: infrastructure.PasswordBcrypt, BcryptCost: bcrypt.MinCost}
+	_, err := bcryptService.HashPassword(strings.Repeat("x", 73))
+	suite.Error(err)
+}
+
+func (suite *PasswordServiceTestSuite) TestCompare_TamperedArgon2Hash() {
+	for _, hash := range []string{
+		"$argon2id$v=19$m=9999999,t=2,p=1$c29tZXNhbHRzb21lc2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5",
+		"$argon2id$v=18$m=19456,t=2,p=1$c29tZXNhbHRzb21lc2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=19456,t=2,p=1$c29tZXNhbHRzb21lc2FsdA",
+		"$argon2id$v=19$m=19456,t=2,p=1$!!$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5",
+	} {
+		suite.Error(suite.service.ComparePassword([]byte(hash), []byte("secret")), hash)
+		suite.True(suite.service.NeedsRehash(hash), hash)
+	}
+}
+
 func TestPasswordServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(PasswordServiceTestSuite))
 }
\ No newline at end of file
@@ -0,0 +1,183 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, domain.RateLimit, time.Time) (domain.RateLimitResult, error) {
+	return domain.RateLimitResult{}, errors.New("store unavailable")
+}
+
+type RateLimiterTestSuite struct {
+	suite.Suite
+	store *infrastructure.MemoryRateLimitStore
+	limit domain.RateLimit
+	now   time.Time
+}
+
+func (suite *RateLimiterTestSuite) SetupTest() {
+	suite.store = infrastructure.NewMemoryRateLimitStore()
+	suite.limit = domain.RateLimit{Burst: 2, Period: time.Minute}
+	suite.now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func (suite *RateLimiterTestSuite) take(key string, at time.Time) domain.RateLimitResult {
+	res, err := suite.store.Take(context.Background(), key, suite.limit, at)
+	suite.Require().NoError(err)
+	return res
+}
+
+func (suite *RateLimiterTestSuite) TestTake_ExhaustsBurst() {
+	suite.True(suite.take("k", suite.now).Allowed)
+	res := suite.take("k", suite.now)
+	suite.True(res.Allowed)
+	suite.Equal(0, res.Remaining)
+
+	res = suite.take("k", suite.now)
+	suite.False(res.Allowed)
+	suite.Equal(30*time.Second, res.RetryAfter)
+	suite.Equal(time.Minute, res.Reset)
+}
+
+func (suite *RateLimiterTestSuite) TestTake_Refills() {
+	suite.take("k", suite.now)
+	suite.take("k", suite.now)
+	suite.False(suite.take("k", suite.now.Add(10*time.Second)).Allowed)
+
+	suite.True(suite.take("k", suite.now.Add(30*time.Second)).Allowed)
+}
+
+func (suite *RateLimiterTestSuite) TestTake_KeysAreIndependent() {
+	suite.take("a", suite.now)
+	suite.take("a", suite.now)
+
+	suite.True(suite.take("b", suite.now).Allowed)
+}
+
+func (suite *RateLimiterTestSuite) TestTake_InvalidLimit() {
+	_, err := suite.store.Take(context.Background(), "k", domain.RateLimit{}, suite.now)
+	suite.Error(err)
+}
+
+func (suite *RateLimiterTestSuite) TestParseRateLimit() {
+	limit, err := infrastructure.ParseRateLimit("5/1m")
+	suite.NoError(err)
+	suite.Equal(domain.RateLimit{Burst: 5, Period: time.Minute}, limit)
+
+	for _, spec := range []string{"", "5", "0/1m", "x/1m", "5/abc", "5/-1s"} {
+		_, err := infrastructure.ParseRateLimit(spec)
+		suite.Error(err, spec)
+	}
+}
+
+func (suite *RateLimiterTestSuite) newRouter(limiter *infrastructure.RateLimiter, keyBy string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/login", func(c *gin.Context) {
+		if id := c.GetHeader("X-User"); id != "" {
+			c.Set("user_id", int64(len(id)))
+		}
+	}, limiter.Limit(infrastructure.RateLimitPolicy{Name: "auth", Limit: suite.limit, KeyBy: keyBy}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func (suite *RateLimiterTestSuite) do(router *gin.Engine, ip, user string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/login", nil)
+	req.RemoteAddr = ip + ":1234"
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *RateLimiterTestSuite) TestMiddleware_RejectsWithHeaders() {
+	router := suite.newRouter(infrastructure.NewRateLimiter(suite.store), infrastructure.RateLimitByIP)
+
+	w := suite.do(router, "10.0.0.1", "")
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal("2", w.Header().Get("RateLimit-Limit"))
+	suite.Equal("1", w.Header().Get("RateLimit-Remaining"))
+	suite.Equal("2;w=60", w.Header().Get("RateLimit-Policy"))
+
+	suite.do(router, "10.0.0.1", "")
+	w = suite.do(router, "10.0.0.1", "")
+	suite.Equal(http.StatusTooManyRequests, w.Code)
+	suite.Equal("0", w.Header().Get("RateLimit-Remaining"))
+	suite.NotEmpty(w.Header().Get("Retry-After"))
+
+	// another client has its own bucket
+	suite.Equal(http.StatusOK, suite.do(router, "10.0.0.2", "").Code)
+}
+
+func (suite *RateLimiterTestSuite) TestMiddleware_KeysByUser() {
+	router := suite.newRouter(infrastructure.NewRateLimiter(suite.store), infrastructure.RateLimitByUser)
+
+	suite.do(router, "10.0.0.1", "a")
+	suite.do(router, "10.0.0.2", "a")
+	suite.Equal(http.StatusTooManyRequests, suite.do(router, "10.0.0.3", "a").Code)
+
+	suite.Equal(http.StatusOK, suite.do(router, "10.0.0.1", "bb").Code)
+}
+
+func (suite *RateLimiterTestSuite) TestMiddleware_FailsOpen() {
+	router := suite.newRouter(infrastructure.NewRateLimiter(failingRateLimitStore{}), infrastructure.RateLimitByIP)
+
+	for i := 0; i < 3; i++ {
+		suite.Equal(http.StatusOK, suite.do(router, "10.0.0.1", "").Code)
+	}
+}
+
+func (suite *RateLimiterTestSuite) doForwarded(router *gin.Engine, ip, forwardedFor string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/login", nil)
+	req.RemoteAddr = ip + ":1234"
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *RateLimiterTestSuite) TestMiddleware_IgnoresSpoofedForwardedFor() {
+	suite.T().Setenv("TRUSTED_PROXIES", "")
+	router := suite.newRouter(infrastructure.NewRateLimiter(suite.store), infrastructure.RateLimitByIP)
+	suite.Require().NoError(infrastructure.TrustProxies(router))
+
+	suite.doForwarded(router, "10.0.0.1", "1.1.1.1")
+	suite.doForwarded(router, "10.0.0.1", "2.2.2.2")
+	suite.Equal(http.StatusTooManyRequests, suite.doForwarded(router, "10.0.0.1", "3.3.3.3").Code)
+}
+
+func (suite *RateLimiterTestSuite) TestMiddleware_TrustedProxyForwardsClientIP() {
+	suite.T().Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.1")
+	router := suite.newRouter(infrastructure.NewRateLimiter(suite.store), infrastructure.RateLimitByIP)
+	suite.Require().NoError(infrastructure.TrustProxies(router))
+
+	suite.doForwarded(router, "10.0.0.1", "1.1.1.1")
+	suite.doForwarded(router, "10.0.0.1", "1.1.1.1")
+	suite.Equal(http.StatusTooManyRequests, suite.doForwarded(router, "10.0.0.1", "1.1.1.1").Code)
+	suite.Equal(http.StatusOK, suite.doForwarded(router, "10.0.0.1", "2.2.2.2").Code)
+}
+
+func (suite *RateLimiterTestSuite) TestTrustProxies_Invalid() {
+	suite.T().Setenv("TRUSTED_PROXIES", "not-an-ip")
+	suite.Error(infrastructure.TrustProxies(gin.New()))
+}
+
+func TestRateLimiterTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimiterTestSuite))
+}
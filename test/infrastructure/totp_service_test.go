@@ -0,0 +1,69 @@
+package test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/infrastructure"
+	"github.com/stretchr/testify/suite"
+)
+
+type TOTPServiceTestSuite struct {
+	suite.Suite
+	service *infrastructure.TOTPService
+}
+
+func (suite *TOTPServiceTestSuite) SetupTest() {
+	suite.service = &infrastructure.TOTPService{Issuer: "Blog Platform"}
+}
+
+// RFC 6238 appendix B, SHA-1, truncated to 6 digits
+func (suite *TOTPServiceTestSuite) TestTOTPCode_RFCVectors() {
+	key := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, code := range vectors {
+		suite.Equal(code, infrastructure.TOTPCode(key, unix/30), unix)
+	}
+}
+
+func (suite *TOTPServiceTestSuite) TestValidate_AllowsOneStepSkew() {
+	key := []byte("12345678901234567890")
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(key)
+	now := time.Unix(1111111109, 0)
+
+	step, ok := suite.service.Validate(secret, "081804", now)
+	suite.True(ok)
+	suite.Equal(int64(1111111109/30), step)
+
+	_, ok = suite.service.Validate(secret, "081804", now.Add(30*time.Second))
+	suite.True(ok)
+	_, ok = suite.service.Validate(secret, "081804", now.Add(90*time.Second))
+	suite.False(ok)
+	_, ok = suite.service.Validate(secret, "000000", now)
+	suite.False(ok)
+}
+
+func (suite *TOTPServiceTestSuite) TestProvisioningURI() {
+	secret, err := suite.service.GenerateSecret()
+	suite.Require().NoError(err)
+	suite.Len(secret, 32)
+
+	u, err := url.Parse(suite.service.ProvisioningURI(secret, "john@example.com"))
+	suite.Require().NoError(err)
+	suite.Equal("otpauth", u.Scheme)
+	suite.Equal("totp", u.Host)
+	suite.Equal("/Blog Platform:john@example.com", u.Path)
+	suite.Equal(secret, u.Query().Get("secret"))
+	suite.Equal("Blog Platform", u.Query().Get("issuer"))
+}
+
+func TestTOTPServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(TOTPServiceTestSuite))
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/test/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type VectorIndexTestSuite struct {
+	suite.Suite
+	repo     *mocks.MockEmbeddingRepository
+	index    *infrastructure.MemoryVectorIndex
+	embedder *infrastructure.LocalEmbeddingProvider
+	ctx      context.Context
+}
+
+func (suite *VectorIndexTestSuite) SetupTest() {
+	suite.repo = new(mocks.MockEmbeddingRepository)
+	suite.index = infrastructure.NewMemoryVectorIndex(suite.repo)
+	suite.embedder = infrastructure.NewLocalEmbeddingProvider(infrastructure.LocalEmbeddingDimensions)
+	suite.ctx = context.Background()
+}
+
+func (suite *VectorIndexTestSuite) embed(text string) []float32 {
+	vectors, err := suite.embedder.Embed(suite.ctx, []string{text})
+	suite.Require().NoError(err)
+	return vectors[0]
+}
+
+func (suite *VectorIndexTestSuite) TestLocalEmbeddingIsDeterministic() {
+	a := suite.embed("Goroutines and channels")
+	suite.Equal(a, suite.embed("goroutines, and CHANNELS!"))
+	suite.Len(a, infrastructure.LocalEmbeddingDimensions)
+	suite.Equal("local-hash-256", suite.embedder.Model())
+}
+
+func (suite *VectorIndexTestSuite) TestSearchRanksBySimilarity() {
+	suite.repo.On("FetchEmbeddings", suite.ctx).Return([]*domain.BlogEmbedding{
+		{BlogID: 1, Model: "local-hash-256", Vector: suite.embed("baking sourdough bread at home")},
+		{BlogID: 2, Model: "local-hash-256", Vector: suite.embed("concurrency in go with goroutines and channels")},
+		{BlogID: 3, Model: "other-model", Vector: suite.embed("goroutines and channels")},
+	}, nil).Once()
+	suite.repo.On("SaveEmbedding", suite.ctx, mock.Anything).Return(nil)
+
+	suite.NoError(suite.index.Upsert(suite.ctx, 4, "local-hash-256", suite.embed("channels and select in go")))
+
+	matches, err := suite.index.Search(suite.ctx, "local-hash-256", suite.embed("goroutines and channels"), 10, 4)
+	suite.NoError(err)
+	suite.Len(matches, 2)
+	suite.Equal(int64(2), matches[0].BlogID)
+	suite.Equal(int64(1), matches[1].BlogID)
+	suite.Greater(matches[0].Score, matches[1].Score)
+
+	matches, err = suite.index.Search(suite.ctx, "local-hash-256", suite.embed("goroutines and channels"), 1, 0)
+	suite.NoError(err)
+	suite.Len(matches, 1)
+
+	// loaded once, before the first write
+	embedding, err := suite.index.Fetch(suite.ctx, 4)
+	suite.NoError(err)
+	suite.NotNil(embedding)
+	suite.repo.AssertNumberOfCalls(suite.T(), "FetchEmbeddings", 1)
+}
+
+func (suite *VectorIndexTestSuite) TestDelete() {
+	suite.repo.On("FetchEmbeddings", suite.ctx).Return([]*domain.BlogEmbedding{
+		{BlogID: 1, Model: "m", Vector: []float32{1, 0}},
+	}, nil)
+	suite.repo.On("DeleteEmbedding", suite.ctx, int64(1)).Return(nil)
+
+	suite.NoError(suite.index.Delete(suite.ctx, 1))
+	embedding, err := suite.index.Fetch(suite.ctx, 1)
+	suite.NoError(err)
+	suite.Nil(embedding)
+}
+
+func TestVectorIndexTestSuite(t *testing.T) {
+	suite.Run(t, new(VectorIndexTestSuite))
+}
@@ -0,0 +1,29 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/infrastructure"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryViewStore_Window(t *testing.T) {
+	store := infrastructure.NewMemoryViewStore()
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	seen, _ := store.MarkViewed(ctx, "view:1:user:7", 30*time.Minute, start)
+	assert.False(t, seen)
+	seen, _ = store.MarkViewed(ctx, "view:1:user:7", 30*time.Minute, start.Add(29*time.Minute))
+	assert.True(t, seen)
+	seen, _ = store.MarkViewed(ctx, "view:2:user:7", 30*time.Minute, start.Add(29*time.Minute))
+	assert.False(t, seen)
+
+	// a refresh inside the window does not extend it
+	seen, _ = store.MarkViewed(ctx, "view:1:user:7", 30*time.Minute, start.Add(30*time.Minute))
+	assert.False(t, seen)
+	seen, _ = store.MarkViewed(ctx, "view:1:user:7", 30*time.Minute, start.Add(45*time.Minute))
+	assert.True(t, seen)
+}
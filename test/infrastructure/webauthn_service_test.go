@@ -0,0 +1,135 @@
+package test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/test/mocks"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebAuthnServiceTestSuite struct {
+	suite.Suite
+	service       *infrastructure.WebAuthnService
+	authenticator *mocks.SoftAuthenticator
+}
+
+func (suite *WebAuthnServiceTestSuite) SetupTest() {
+	suite.service = &infrastructure.WebAuthnService{
+		RPID:    "localhost",
+		RPName:  "Blog Platform",
+		Origins: []string{"http://localhost:8080"},
+	}
+	suite.authenticator = mocks.NewSoftAuthenticator("localhost", "http://localhost:8080")
+}
+
+func (suite *WebAuthnServiceTestSuite) TestVerifyRegistration_Success() {
+	resp := suite.authenticator.Register("challenge-1")
+
+	challenge, err := suite.service.Challenge(resp.Response.ClientDataJSON)
+	suite.NoError(err)
+	suite.Equal("challenge-1", challenge)
+
+	data, err := suite.service.VerifyRegistration("challenge-1", resp)
+	suite.NoError(err)
+	suite.Equal(suite.authenticator.ID(), data.CredentialID)
+	suite.Equal(suite.authenticator.COSEKey(), data.PublicKey)
+	suite.Equal(uint32(0), data.SignCount)
+	suite.Equal("00000000-0000-0000-0000-000000000000", data.AAGUID)
+}
+
+func (suite *WebAuthnServiceTestSuite) TestVerifyRegistration_ChallengeMismatch() {
+	resp := suite.authenticator.Register("challenge-1")
+
+	_, err := suite.service.VerifyRegistration("challenge-2", resp)
+	suite.EqualError(err, "challenge mismatch")
+}
+
+func (suite *WebAuthnServiceTestSuite) TestVerifyRegistration_WrongOrigin() {
+	suite.authenticator.Origin = "http://evil.example"
+	resp := suite.authenticator.Register("challenge-1")
+
+	_, err := suite.service.VerifyRegistration("challenge-1", resp)
+	suite.EqualError(err, "origin 'http://evil.example' is not allowed")
+}
+
+func (suite *WebAuthnServiceTestSuite) TestVerifyRegistration_WrongRelyingParty() {
+	suite.authenticator.RPID = "evil.example"
+	resp := suite.authenticator.Register("challenge-1")
+
+	_, err := suite.service.VerifyRegistration("challenge-1", resp)
+	suite.EqualError(err, "relying party mismatch")
+}
+
+func (suite *WebAuthnServiceTestSuite) TestVerifyRegistration_RequiresUserVerification() {
+	suite.authenticator.Flags = 0x01
+	resp := suite.authenticator.Register("challenge-1")
+
+	_, err := suite.service.VerifyRegistration("challenge-1", resp)
+	suite.EqualError(err, "user verification is required")
+}
+
+func (suite *WebAuthnServiceTestSuite) TestVerifyRegistration_CredentialIDMismatch() {
+	resp := suite.authenticator.Register("challenge-1")
+	resp.ID = base64.RawURLEncoding.EncodeToString([]byte("other"))
+
+	_, err := suite.service.VerifyRegistration("challenge-1", resp)
+	suite.EqualError(err, "credential id mismatch")
+}
+
+func (suite *WebAuthnServiceTestSuite) TestVerifyRegistration_MalformedAttestation() {
+	resp := suite.authenticator.Register("challenge-1")
+	for _, object := range [][]byte{
+		{},
+		{0xbf},                         // indefinite length map
+		{0xa1, 0x01},                   // truncated map
+		{0xa2, 0x01, 0x01, 0x01, 0x02}, // duplicate key
+		mocks.EncodeCBOR(map[interface{}]interface{}{"fmt": "none"}),
+	} {
+		resp.Response.AttestationObject = base64.RawURLEncoding.EncodeToString(object)
+		_, err := suite.service.VerifyRegistration("challenge-1", resp)
+		suite.Error(err, "%x", object)
+	}
+}
+
+func (suite *WebAuthnServiceTestSuite) TestVerifyAssertion_Success() {
+	publicKey := suite.authenticator.COSEKey()
+	resp := suite.authenticator.Login("challenge-1")
+
+	signCount, err := suite.service.VerifyAssertion("challenge-1", publicKey, resp)
+	suite.NoError(err)
+	suite.Equal(uint32(1), signCount)
+}
+
+func (suite *WebAuthnServiceTestSuite) TestVerifyAssertion_WrongKey() {
+	other := mocks.NewSoftAuthenticator("localhost", "http://localhost:8080")
+	resp := suite.authenticator.Login("challenge-1")
+
+	_, err := suite.service.VerifyAssertion("challenge-1", other.COSEKey(), resp)
+	suite.EqualError(err, "invalid signature")
+}
+
+func (suite *WebAuthnServiceTestSuite) TestVerifyAssertion_TamperedData() {
+	resp := suite.authenticator.Login("challenge-1")
+	// the signature covers the client data, so a replay under another
+	// challenge cannot be made to verify
+	other := suite.authenticator.Login("challenge-2")
+	resp.Response.ClientDataJSON = other.Response.ClientDataJSON
+
+	_, err := suite.service.VerifyAssertion("challenge-2", suite.authenticator.COSEKey(), resp)
+	suite.EqualError(err, "invalid signature")
+}
+
+func (suite *WebAuthnServiceTestSuite) TestVerifyAssertion_RegistrationResponseRejected() {
+	resp := suite.authenticator.Register("challenge-1")
+	assertion := suite.authenticator.Login("challenge-1")
+	assertion.Response.ClientDataJSON = resp.Response.ClientDataJSON
+
+	_, err := suite.service.VerifyAssertion("challenge-1", suite.authenticator.COSEKey(), assertion)
+	suite.EqualError(err, "unexpected client data type")
+}
+
+func TestWebAuthnServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(WebAuthnServiceTestSuite))
+}
@@ -0,0 +1,93 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAIUsageRepository struct {
+	mock.Mock
+}
+
+func (m *MockAIUsageRepository) Record(ctx context.Context, usage *domain.AIUsage) error {
+	args := m.Called(ctx, usage)
+	return args.Error(0)
+}
+
+func (m *MockAIUsageRepository) SumTokens(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	args := m.Called(ctx, userID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAIUsageRepository) SummarizeByUser(ctx context.Context, from, to time.Time) ([]domain.AIUsageSummary, error) {
+	args := m.Called(ctx, from, to)
+	summaries, _ := args.Get(0).([]domain.AIUsageSummary)
+	return summaries, args.Error(1)
+}
+
+func (m *MockAIUsageRepository) SummarizeByDay(ctx context.Context, userID int64, from, to time.Time) ([]domain.AIUsageSummary, error) {
+	args := m.Called(ctx, userID, from, to)
+	summaries, _ := args.Get(0).([]domain.AIUsageSummary)
+	return summaries, args.Error(1)
+}
+
+func (m *MockAIUsageRepository) FetchQuota(ctx context.Context, userID int64) (*domain.AIQuota, error) {
+	args := m.Called(ctx, userID)
+	quota, _ := args.Get(0).(*domain.AIQuota)
+	return quota, args.Error(1)
+}
+
+func (m *MockAIUsageRepository) SaveQuota(ctx context.Context, quota *domain.AIQuota) error {
+	args := m.Called(ctx, quota)
+	return args.Error(0)
+}
+
+func (m *MockAIUsageRepository) DeleteQuota(ctx context.Context, userID int64) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockAIUsageUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAIUsageUsecase) CheckQuota(ctx context.Context, caller domain.AICaller) error {
+	args := m.Called(ctx, caller)
+	return args.Error(0)
+}
+
+func (m *MockAIUsageUsecase) Record(ctx context.Context, usage *domain.AIUsage) {
+	m.Called(ctx, usage)
+}
+
+func (m *MockAIUsageUsecase) UsageByUser(ctx context.Context, from, to time.Time) ([]domain.AIUsageSummary, error) {
+	args := m.Called(ctx, from, to)
+	summaries, _ := args.Get(0).([]domain.AIUsageSummary)
+	return summaries, args.Error(1)
+}
+
+func (m *MockAIUsageUsecase) UsageForUser(ctx context.Context, userID int64, from, to time.Time) ([]domain.AIUsageSummary, error) {
+	args := m.Called(ctx, userID, from, to)
+	summaries, _ := args.Get(0).([]domain.AIUsageSummary)
+	return summaries, args.Error(1)
+}
+
+func (m *MockAIUsageUsecase) GetQuota(ctx context.Context, userID int64) (*domain.AIQuotaStatus, error) {
+	args := m.Called(ctx, userID)
+	status, _ := args.Get(0).(*domain.AIQuotaStatus)
+	return status, args.Error(1)
+}
+
+func (m *MockAIUsageUsecase) SetQuota(ctx context.Context, userID int64, dailyTokens int64) (*domain.AIQuotaStatus, error) {
+	args := m.Called(ctx, userID, dailyTokens)
+	status, _ := args.Get(0).(*domain.AIQuotaStatus)
+	return status, args.Error(1)
+}
+
+func (m *MockAIUsageUsecase) ClearQuota(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
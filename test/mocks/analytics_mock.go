@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAnalyticsRepository struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsRepository) AddDailyStats(ctx context.Context, stats []*domain.BlogDailyStats, referrers []*domain.BlogReferrerStats) error {
+	args := m.Called(ctx, stats, referrers)
+	return args.Error(0)
+}
+
+func (m *MockAnalyticsRepository) FetchDailyStats(ctx context.Context, blogIDs []int64, from, to time.Time) ([]*domain.BlogDailyStats, error) {
+	args := m.Called(ctx, blogIDs, from, to)
+	stats, _ := args.Get(0).([]*domain.BlogDailyStats)
+	return stats, args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) FetchReferrerStats(ctx context.Context, blogIDs []int64, from, to time.Time) ([]*domain.BlogReferrerStats, error) {
+	args := m.Called(ctx, blogIDs, from, to)
+	referrers, _ := args.Get(0).([]*domain.BlogReferrerStats)
+	return referrers, args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) FetchStatsSince(ctx context.Context, since time.Time) ([]*domain.BlogDailyStats, error) {
+	args := m.Called(ctx, since)
+	stats, _ := args.Get(0).([]*domain.BlogDailyStats)
+	return stats, args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) ReplaceTrendingScores(ctx context.Context, period string, scores []*domain.BlogTrendingScore) error {
+	args := m.Called(ctx, period, scores)
+	return args.Error(0)
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/blog-platform/domain"
 	"github.com/stretchr/testify/mock"
@@ -49,19 +50,19 @@ func (m *MockBlogRepo) FetchPaginatedBlogs(ctx context.Context, page, limit int)
 	return args.Get(0).([]*domain.Blog), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockBlogRepo) IncrementView(ctx context.Context, blogID int64) error {
-	args := m.Called(ctx, blogID)
+func (m *MockBlogRepo) AddViews(ctx context.Context, views map[int64]int) error {
+	args := m.Called(ctx, views)
 	return args.Error(0)
 }
 
-func (m *MockBlogRepo) AddLike(ctx context.Context, blogID int64, userID int64) error {
+func (m *MockBlogRepo) AddLike(ctx context.Context, blogID int64, userID int64) (bool, error) {
 	args := m.Called(ctx, blogID, userID)
-	return args.Error(0)
+	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockBlogRepo) RemoveLike(ctx context.Context, blogID int64, userID int64) error {
+func (m *MockBlogRepo) RemoveLike(ctx context.Context, blogID int64, userID int64) (bool, error) {
 	args := m.Called(ctx, blogID, userID)
-	return args.Error(0)
+	return args.Bool(0), args.Error(1)
 }
 
 func (m *MockBlogRepo) GetPopularity(ctx context.Context, blogID int64) (int, int, error) {
@@ -89,8 +90,14 @@ func (m *MockBlogRepo) FetchByFilter(ctx context.Context, filter domain.BlogFilt
 	return args.Get(0).([]*domain.Blog), args.Error(1)
 }
 
-func (m *MockBlogRepo) AddComment(ctx context.Context, blogID int64, userID int64, content string) (*domain.Comment, error) {
-	args := m.Called(ctx, blogID, userID, content)
+func (m *MockBlogRepo) FetchTrending(ctx context.Context, window string, page, limit int) ([]*domain.TrendingBlog, int64, error) {
+	args := m.Called(ctx, window, page, limit)
+	blogs, _ := args.Get(0).([]*domain.TrendingBlog)
+	return blogs, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockBlogRepo) CreateComment(ctx context.Context, c *domain.Comment) (*domain.Comment, error) {
+	args := m.Called(ctx, c)
 	if c, ok := args.Get(0).(*domain.Comment); ok {
 		return c, args.Error(1)
 	}
@@ -101,3 +108,29 @@ func (m *MockBlogRepo) ListComments(ctx context.Context, blogID int64, page int,
 	args := m.Called(ctx, blogID, page, limit)
 	return args.Get(0).([]*domain.Comment), args.Get(1).(int64), args.Error(2)
 }
+
+func (m *MockBlogRepo) ListHeldComments(ctx context.Context, page int, limit int) ([]*domain.Comment, int64, error) {
+	args := m.Called(ctx, page, limit)
+	return args.Get(0).([]*domain.Comment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockBlogRepo) ReviewComment(ctx context.Context, id int64, status string, reviewerID int64, at time.Time) (*domain.Comment, error) {
+	args := m.Called(ctx, id, status, reviewerID, at)
+	if c, ok := args.Get(0).(*domain.Comment); ok {
+		return c, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockBlogRepo) FetchCommentByID(ctx context.Context, id int64) (*domain.Comment, error) {
+	args := m.Called(ctx, id)
+	if c, ok := args.Get(0).(*domain.Comment); ok {
+		return c, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockBlogRepo) UpdateInsights(ctx context.Context, blogID int64, insights *domain.BlogInsights) error {
+	args := m.Called(ctx, blogID, insights)
+	return args.Error(0)
+}
@@ -1,6 +1,9 @@
 package mocks
 
-import "github.com/stretchr/testify/mock"
+import (
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
 
 type MockEmailService struct {
 	mock.Mock
@@ -10,3 +13,8 @@ func (m *MockEmailService) SendEmail(to []string, subject string, body string) e
 	args := m.Called(to, subject, body)
 	return args.Error(0)
 }
+
+func (m *MockEmailService) Send(msg *domain.EmailMessage) error {
+	args := m.Called(msg)
+	return args.Error(0)
+}
@@ -0,0 +1,28 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEmbeddingRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmbeddingRepository) SaveEmbedding(ctx context.Context, embedding *domain.BlogEmbedding) error {
+	args := m.Called(ctx, embedding)
+	return args.Error(0)
+}
+
+func (m *MockEmbeddingRepository) DeleteEmbedding(ctx context.Context, blogID int64) error {
+	args := m.Called(ctx, blogID)
+	return args.Error(0)
+}
+
+func (m *MockEmbeddingRepository) FetchEmbeddings(ctx context.Context) ([]*domain.BlogEmbedding, error) {
+	args := m.Called(ctx)
+	embeddings, _ := args.Get(0).([]*domain.BlogEmbedding)
+	return embeddings, args.Error(1)
+}
@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockFollowRepo struct {
+	mock.Mock
+}
+
+func (m *MockFollowRepo) Follow(ctx context.Context, followerID, followeeID int64) error {
+	args := m.Called(ctx, followerID, followeeID)
+	return args.Error(0)
+}
+
+func (m *MockFollowRepo) Unfollow(ctx context.Context, followerID, followeeID int64) error {
+	args := m.Called(ctx, followerID, followeeID)
+	return args.Error(0)
+}
+
+func (m *MockFollowRepo) IsFollowing(ctx context.Context, followerID, followeeID int64) (bool, error) {
+	args := m.Called(ctx, followerID, followeeID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockFollowRepo) ListFollowers(ctx context.Context, userID int64, page, limit int) ([]*domain.PublicUser, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
+	return args.Get(0).([]*domain.PublicUser), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockFollowRepo) ListFollowing(ctx context.Context, userID int64, page, limit int) ([]*domain.PublicUser, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
+	return args.Get(0).([]*domain.PublicUser), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockFollowRepo) CountFollows(ctx context.Context, userID int64) (domain.FollowCounts, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(domain.FollowCounts), args.Error(1)
+}
+
+func (m *MockFollowRepo) FetchFeed(ctx context.Context, userID int64, cursor *domain.FeedCursor, limit int) ([]*domain.Blog, error) {
+	args := m.Called(ctx, userID, cursor, limit)
+	return args.Get(0).([]*domain.Blog), args.Error(1)
+}
+
+func (m *MockFollowRepo) ListFollowerIDs(ctx context.Context, userID int64) ([]int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]int64), args.Error(1)
+}
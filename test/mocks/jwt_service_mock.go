@@ -34,3 +34,13 @@ func (m *MockJWTService) ValidateRefreshToken(authHeader string) (*domain.TokenC
 	}
 	return args.Get(0).(*domain.TokenClaims), args.Error(1)
 }
+
+func (m *MockJWTService) GenerateMFAToken(userID string) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockJWTService) ValidateMFAToken(token string) (string, error) {
+	args := m.Called(token)
+	return args.String(0), args.Error(1)
+}
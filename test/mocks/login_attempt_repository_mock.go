@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLoginAttemptRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptRepository) Record(attempt *domain.LoginAttempt) error {
+	args := m.Called(attempt)
+	return args.Error(0)
+}
+
+func (m *MockLoginAttemptRepository) CountFailuresByIP(ip string, since time.Time) (int64, error) {
+	args := m.Called(ip, since)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,61 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, event domain.NotificationEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+type MockNotificationRepo struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepo) CreateMany(ctx context.Context, notifications []*domain.Notification) error {
+	args := m.Called(ctx, notifications)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepo) List(ctx context.Context, userID int64, unreadOnly bool, page, limit int) ([]*domain.Notification, int64, error) {
+	args := m.Called(ctx, userID, unreadOnly, page, limit)
+	return args.Get(0).([]*domain.Notification), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockNotificationRepo) MarkRead(ctx context.Context, userID, id int64) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepo) MarkAllRead(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepo) CountUnread(ctx context.Context, userID int64) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationRepo) GetPreferences(ctx context.Context, userID int64) ([]domain.NotificationPreference, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.NotificationPreference), args.Error(1)
+}
+
+func (m *MockNotificationRepo) SetPreference(ctx context.Context, userID int64, kind string, enabled bool) error {
+	args := m.Called(ctx, userID, kind, enabled)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepo) DisabledRecipients(ctx context.Context, kind string, userIDs []int64) ([]int64, error) {
+	args := m.Called(ctx, kind, userIDs)
+	return args.Get(0).([]int64), args.Error(1)
+}
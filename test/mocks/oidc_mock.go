@@ -0,0 +1,60 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOIDCProvider struct {
+	mock.Mock
+}
+
+func (m *MockOIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	args := m.Called(ctx, state, nonce, codeChallenge)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*domain.OIDCIdentity, error) {
+	args := m.Called(ctx, code, codeVerifier, nonce)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OIDCIdentity), args.Error(1)
+}
+
+type MockOIDCRepository struct {
+	mock.Mock
+}
+
+func (m *MockOIDCRepository) SaveLoginState(ctx context.Context, state *domain.OIDCLoginState) error {
+	args := m.Called(ctx, state)
+	return args.Error(0)
+}
+
+func (m *MockOIDCRepository) ConsumeLoginState(ctx context.Context, stateHash string) (*domain.OIDCLoginState, error) {
+	args := m.Called(ctx, stateHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OIDCLoginState), args.Error(1)
+}
+
+func (m *MockOIDCRepository) FindIdentity(ctx context.Context, provider, subject string) (*domain.ExternalIdentity, error) {
+	args := m.Called(ctx, provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ExternalIdentity), args.Error(1)
+}
+
+func (m *MockOIDCRepository) CreateIdentity(ctx context.Context, identity *domain.ExternalIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockOIDCRepository) CreateUserWithIdentity(ctx context.Context, user *domain.User, identity *domain.ExternalIdentity) error {
+	args := m.Called(ctx, user, identity)
+	return args.Error(0)
+}
@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) Enqueue(ctx context.Context, email *domain.OutboxEmail) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*domain.OutboxEmail, error) {
+	args := m.Called(ctx, now, limit, lease)
+	return args.Get(0).([]*domain.OutboxEmail), args.Error(1)
+}
+
+func (m *MockOutboxRepository) MarkSent(ctx context.Context, id int64, sentAt time.Time) error {
+	args := m.Called(ctx, id, sentAt)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time, lastError string, dead bool) error {
+	args := m.Called(ctx, id, attempts, nextAttemptAt, lastError, dead)
+	return args.Error(0)
+}
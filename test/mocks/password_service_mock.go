@@ -14,4 +14,9 @@ func (m *MockPasswordService) HashPassword(password string) (string, error) {
 func (m *MockPasswordService) ComparePassword(correctPassword []byte, inputPassword []byte) error {
 	args := m.Called(correctPassword, inputPassword)
 	return args.Error(0)
-}
\ No newline at end of file
+}
+
+func (m *MockPasswordService) NeedsRehash(hash string) bool {
+	args := m.Called(hash)
+	return args.Bool(0)
+}
@@ -0,0 +1,76 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPersonalAccessTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockPersonalAccessTokenRepository) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) ListActive(ctx context.Context, userID int64) ([]domain.PersonalAccessToken, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) CountActive(ctx context.Context, userID int64) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) FetchByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) Revoke(ctx context.Context, userID, id int64, now time.Time) (bool, error) {
+	args := m.Called(ctx, userID, id, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id int64, now time.Time, ip string) error {
+	args := m.Called(ctx, id, now, ip)
+	return args.Error(0)
+}
+
+type MockPersonalAccessTokenUsecase struct {
+	mock.Mock
+}
+
+func (m *MockPersonalAccessTokenUsecase) Create(ctx context.Context, userID int64, name string, scopes []string, expiresAt *time.Time) (*domain.PersonalAccessToken, string, error) {
+	args := m.Called(ctx, userID, name, scopes, expiresAt)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).(*domain.PersonalAccessToken), args.String(1), args.Error(2)
+}
+
+func (m *MockPersonalAccessTokenUsecase) List(ctx context.Context, userID int64) ([]domain.PersonalAccessToken, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenUsecase) Revoke(ctx context.Context, userID, id int64) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenUsecase) Authenticate(ctx context.Context, token, ip string) (*domain.TokenClaims, []string, error) {
+	args := m.Called(ctx, token, ip)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*domain.TokenClaims), args.Get(1).([]string), args.Error(2)
+}
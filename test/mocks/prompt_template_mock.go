@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPromptTemplateRepository struct {
+	mock.Mock
+}
+
+func (m *MockPromptTemplateRepository) CreateVersion(ctx context.Context, t *domain.PromptTemplate) error {
+	args := m.Called(ctx, t)
+	return args.Error(0)
+}
+
+func (m *MockPromptTemplateRepository) FetchTemplate(ctx context.Context, name string, version int) (*domain.PromptTemplate, error) {
+	args := m.Called(ctx, name, version)
+	t, _ := args.Get(0).(*domain.PromptTemplate)
+	return t, args.Error(1)
+}
+
+func (m *MockPromptTemplateRepository) ListActive(ctx context.Context) ([]*domain.PromptTemplate, error) {
+	args := m.Called(ctx)
+	templates, _ := args.Get(0).([]*domain.PromptTemplate)
+	return templates, args.Error(1)
+}
+
+func (m *MockPromptTemplateRepository) ListVersions(ctx context.Context, name string) ([]*domain.PromptTemplate, error) {
+	args := m.Called(ctx, name)
+	templates, _ := args.Get(0).([]*domain.PromptTemplate)
+	return templates, args.Error(1)
+}
+
+func (m *MockPromptTemplateRepository) Activate(ctx context.Context, name string, version int) (bool, error) {
+	args := m.Called(ctx, name, version)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,160 @@
+package mocks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+
+	"github.com/blog-platform/domain"
+)
+
+// SoftAuthenticator is a software WebAuthn authenticator with an ES256 key,
+// producing the responses a browser would send for it.
+type SoftAuthenticator struct {
+	RPID         string
+	Origin       string
+	Key          *ecdsa.PrivateKey
+	CredentialID []byte
+	SignCount    uint32
+	UserHandle   string
+	// Flags are set in the authenticator data; user present and verified by default
+	Flags byte
+}
+
+func NewSoftAuthenticator(rpID, origin string) *SoftAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	return &SoftAuthenticator{RPID: rpID, Origin: origin, Key: key, CredentialID: id, Flags: 0x05}
+}
+
+func (a *SoftAuthenticator) ID() string {
+	return base64.RawURLEncoding.EncodeToString(a.CredentialID)
+}
+
+func (a *SoftAuthenticator) clientData(ceremony, challenge string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+	return data
+}
+
+func (a *SoftAuthenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.SignCount)
+	return append(data, attested...)
+}
+
+// COSEKey returns the credential public key in COSE form.
+func (a *SoftAuthenticator) COSEKey() []byte {
+	pad := func(b []byte) []byte { return append(make([]byte, 32-len(b)), b...) }
+	return EncodeCBOR(map[interface{}]interface{}{
+		int64(1):  int64(2),
+		int64(3):  int64(domain.COSEAlgES256),
+		int64(-1): int64(1),
+		int64(-2): pad(a.Key.X.Bytes()),
+		int64(-3): pad(a.Key.Y.Bytes()),
+	})
+}
+
+// Register answers a navigator.credentials.create() call.
+func (a *SoftAuthenticator) Register(challenge string) *domain.WebAuthnAttestationResponse {
+	attested := make([]byte, 16) // zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.CredentialID)))
+	attested = append(attested, a.CredentialID...)
+	attested = append(attested, a.COSEKey()...)
+
+	attestation := EncodeCBOR(map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": a.authData(a.Flags|0x40, attested),
+	})
+
+	resp := &domain.WebAuthnAttestationResponse{ID: a.ID(), Type: "public-key"}
+	resp.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(a.clientData("webauthn.create", challenge))
+	resp.Response.AttestationObject = base64.RawURLEncoding.EncodeToString(attestation)
+	resp.Response.Transports = []string{"internal"}
+	return resp
+}
+
+// Login answers a navigator.credentials.get() call, incrementing the counter
+// first as authenticators do.
+func (a *SoftAuthenticator) Login(challenge string) *domain.WebAuthnAssertionResponse {
+	a.SignCount++
+	authData := a.authData(a.Flags, nil)
+	clientData := a.clientData("webauthn.get", challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.Key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+
+	resp := &domain.WebAuthnAssertionResponse{ID: a.ID(), Type: "public-key"}
+	resp.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(clientData)
+	resp.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	resp.Response.Signature = base64.RawURLEncoding.EncodeToString(signature)
+	resp.Response.UserHandle = a.UserHandle
+	return resp
+}
+
+// EncodeCBOR encodes int64, []byte, string and map values in CTAP2 canonical form.
+func EncodeCBOR(v interface{}) []byte {
+	switch v := v.(type) {
+	case int64:
+		if v < 0 {
+			return cborHead(1, uint64(-1-v))
+		}
+		return cborHead(0, uint64(v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case map[interface{}]interface{}:
+		type entry struct{ key, value []byte }
+		entries := make([]entry, 0, len(v))
+		for k, val := range v {
+			entries = append(entries, entry{EncodeCBOR(k), EncodeCBOR(val)})
+		}
+		// canonical order: shorter keys first, then bytewise
+		sort.Slice(entries, func(i, j int) bool {
+			if len(entries[i].key) != len(entries[j].key) {
+				return len(entries[i].key) < len(entries[j].key)
+			}
+			return string(entries[i].key) < string(entries[j].key)
+		})
+		out := cborHead(5, uint64(len(entries)))
+		for _, e := range entries {
+			out = append(append(out, e.key...), e.value...)
+		}
+		return out
+	}
+	panic("EncodeCBOR: unsupported type")
+}
+
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+	}
+	return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, n)
+}
@@ -0,0 +1,26 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTOTPService struct {
+	mock.Mock
+}
+
+func (m *MockTOTPService) GenerateSecret() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTOTPService) ProvisioningURI(secret string, account string) string {
+	args := m.Called(secret, account)
+	return args.String(0)
+}
+
+func (m *MockTOTPService) Validate(secret string, code string, now time.Time) (int64, bool) {
+	args := m.Called(secret, code, now)
+	return args.Get(0).(int64), args.Bool(1)
+}
@@ -1,12 +1,17 @@
 package mocks
 
 import (
+	"time"
+
 	"github.com/blog-platform/domain"
 	"github.com/stretchr/testify/mock"
 )
 
 type MockUserRepository struct {
 	mock.Mock
+	// Outbox collects the emails queued through RegisterWithEmail,
+	// CreateEmailChange and LockAccount
+	Outbox []*domain.OutboxEmail
 }
 
 func (m *MockUserRepository) Register(user *domain.User) (domain.User, error) {
@@ -17,6 +22,20 @@ func (m *MockUserRepository) Register(user *domain.User) (domain.User, error) {
 	return args.Get(0).(domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) RegisterWithEmail(user *domain.User, email func(domain.User) (*domain.OutboxEmail, error)) (domain.User, error) {
+	args := m.Called(user)
+	if err := args.Error(1); err != nil {
+		return domain.User{}, err
+	}
+	registered := args.Get(0).(domain.User)
+	e, err := email(registered)
+	if err != nil {
+		return domain.User{}, err
+	}
+	m.Outbox = append(m.Outbox, e)
+	return registered, nil
+}
+
 func (m *MockUserRepository) FetchByUsername(username string) (domain.User, error) {
 	args := m.Called(username)
 	if args.Get(0) == nil {
@@ -72,7 +91,109 @@ func (m *MockUserRepository) ResetPassword(idStr string, newPassword string) err
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) UpdatePasswordHash(userID int64, oldHash, newHash string) error {
+	args := m.Called(userID, oldHash, newHash)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) CountUsers() (int64, error) {
 	args := m.Called()
 	return args.Get(0).(int64), args.Error(1)
 }
+
+func (m *MockUserRepository) CreateEmailChange(change *domain.PendingEmailChange, emails []*domain.OutboxEmail) error {
+	args := m.Called(change)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	m.Outbox = append(m.Outbox, emails...)
+	return nil
+}
+
+func (m *MockUserRepository) FetchEmailChange(tokenHash string) (domain.PendingEmailChange, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return domain.PendingEmailChange{}, args.Error(1)
+	}
+	return args.Get(0).(domain.PendingEmailChange), args.Error(1)
+}
+
+func (m *MockUserRepository) ConfirmEmailChange(change domain.PendingEmailChange) error {
+	args := m.Called(change)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RecordLoginFailure(userID int64) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserRepository) LockAccount(userID int64, until time.Time, unlockTokenHash string, email *domain.OutboxEmail) error {
+	args := m.Called(userID, until, unlockTokenHash, email)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	if email != nil {
+		m.Outbox = append(m.Outbox, email)
+	}
+	return nil
+}
+
+func (m *MockUserRepository) ResetLoginFailures(userID int64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FetchByUnlockToken(tokenHash string) (domain.User, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return domain.User{}, args.Error(1)
+	}
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) ListLocked(now time.Time, page, limit int) ([]domain.User, int64, error) {
+	args := m.Called(now, page, limit)
+	users, _ := args.Get(0).([]domain.User)
+	return users, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) SetTOTPSecret(userID int64, secret string) error {
+	args := m.Called(userID, secret)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) EnableTOTP(userID int64, step int64, codeHashes []string) error {
+	args := m.Called(userID, step, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DisableTOTP(userID int64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UseTOTPStep(userID int64, step int64) (bool, error) {
+	args := m.Called(userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UseRecoveryCode(userID int64, codeHash string, now time.Time) (bool, error) {
+	args := m.Called(userID, codeHash, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) CreateMagicLink(link *domain.MagicLinkToken, email *domain.OutboxEmail) error {
+	args := m.Called(link, email)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CountMagicLinks(userID int64, since time.Time) (int64, error) {
+	args := m.Called(userID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) UseMagicLink(tokenHash string, now time.Time) (domain.MagicLinkToken, error) {
+	args := m.Called(tokenHash, now)
+	return args.Get(0).(domain.MagicLinkToken), args.Error(1)
+}
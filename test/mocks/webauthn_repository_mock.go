@@ -0,0 +1,54 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebAuthnRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebAuthnRepository) SaveChallenge(ctx context.Context, challenge *domain.WebAuthnChallenge) error {
+	args := m.Called(ctx, challenge)
+	return args.Error(0)
+}
+
+func (m *MockWebAuthnRepository) ConsumeChallenge(ctx context.Context, challengeHash string) (*domain.WebAuthnChallenge, error) {
+	args := m.Called(ctx, challengeHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebAuthnChallenge), args.Error(1)
+}
+
+func (m *MockWebAuthnRepository) CreateCredential(ctx context.Context, credential *domain.WebAuthnCredential) error {
+	args := m.Called(ctx, credential)
+	return args.Error(0)
+}
+
+func (m *MockWebAuthnRepository) ListCredentials(ctx context.Context, userID int64) ([]domain.WebAuthnCredential, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.WebAuthnCredential), args.Error(1)
+}
+
+func (m *MockWebAuthnRepository) FetchCredential(ctx context.Context, credentialID string) (*domain.WebAuthnCredential, error) {
+	args := m.Called(ctx, credentialID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebAuthnCredential), args.Error(1)
+}
+
+func (m *MockWebAuthnRepository) UpdateSignCount(ctx context.Context, id int64, old, new int64, usedAt time.Time) (bool, error) {
+	args := m.Called(ctx, id, old, new, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWebAuthnRepository) DeleteCredential(ctx context.Context, userID, id int64) (bool, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Bool(0), args.Error(1)
+}
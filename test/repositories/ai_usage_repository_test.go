@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/repositories"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type AIUsageRepositoryTestSuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo domain.IAIUsageRepository
+}
+
+func (s *AIUsageRepositoryTestSuite) SetupTest() {
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	s.Require().NoError(err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: s.db,
+	}), &gorm.Config{})
+	s.Require().NoError(err)
+
+	s.repo = repositories.NewAIUsageRepository(gormDB)
+}
+
+func (s *AIUsageRepositoryTestSuite) TearDownTest() {
+	s.Require().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *AIUsageRepositoryTestSuite) TestSumTokens_SkipsCachedRequests() {
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(SUM(prompt_tokens + completion_tokens), 0) FROM "ai_usages" WHERE user_id = $1 AND cached = $2 AND created_at >= $3`)).
+		WithArgs(7, false, since).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(1234))
+
+	total, err := s.repo.SumTokens(context.Background(), 7, since)
+	s.NoError(err)
+	s.Equal(int64(1234), total)
+}
+
+func (s *AIUsageRepositoryTestSuite) TestSummarizeByDay() {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	s.mock.ExpectQuery(`SELECT user_id, to_char\(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD'\) AS day, COUNT\(\*\) AS requests, .* FROM "ai_usages" WHERE user_id = \$1 AND created_at >= \$2 AND created_at < \$3 GROUP BY user_id, day ORDER BY day`).
+		WithArgs(7, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "day", "requests", "cached_requests", "prompt_tokens", "completion_tokens", "cost_usd"}).
+			AddRow(7, "2024-05-01", 3, 1, 300, 120, 0.002).
+			AddRow(7, "2024-05-03", 1, 0, 100, 40, 0.001))
+
+	summaries, err := s.repo.SummarizeByDay(context.Background(), 7, from, to)
+	s.NoError(err)
+	s.Require().Len(summaries, 2)
+	s.Equal(domain.AIUsageSummary{UserID: 7, Day: "2024-05-01", Requests: 3, CachedRequests: 1, PromptTokens: 300, CompletionTokens: 120, CostUSD: 0.002}, summaries[0])
+}
+
+func (s *AIUsageRepositoryTestSuite) TestFetchQuota_Missing() {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "ai_quotas" WHERE user_id = $1`)).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "daily_tokens"}))
+
+	quota, err := s.repo.FetchQuota(context.Background(), 7)
+	s.NoError(err)
+	s.Nil(quota)
+}
+
+func (s *AIUsageRepositoryTestSuite) TestSaveQuota_Upserts() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "ai_quotas" ("user_id","daily_tokens","updated_at") VALUES ($1,$2,$3) ON CONFLICT ("user_id") DO UPDATE SET "daily_tokens"="excluded"."daily_tokens","updated_at"="excluded"."updated_at"`)).
+		WithArgs(7, 5000, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	s.NoError(s.repo.SaveQuota(context.Background(), &domain.AIQuota{UserID: 7, DailyTokens: 5000}))
+}
+
+func TestAIUsageRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(AIUsageRepositoryTestSuite))
+}
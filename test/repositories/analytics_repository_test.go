@@ -0,0 +1,98 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/repositories"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type AnalyticsRepositoryTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	mock   sqlmock.Sqlmock
+	gormDB *gorm.DB
+	repo   domain.IAnalyticsRepository
+}
+
+func (s *AnalyticsRepositoryTestSuite) SetupTest() {
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	s.Require().NoError(err)
+
+	s.gormDB, err = gorm.Open(postgres.New(postgres.Config{
+		Conn: s.db,
+	}), &gorm.Config{})
+	s.Require().NoError(err)
+
+	s.repo = repositories.NewAnalyticsRepository(s.gormDB)
+}
+
+func (s *AnalyticsRepositoryTestSuite) TearDownTest() {
+	s.Require().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *AnalyticsRepositoryTestSuite) TestAddDailyStats_AddsToExistingRows() {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "blog_daily_stats" ("blog_id","day","views","unique_viewers","likes","comments") VALUES ($1,$2,$3,$4,$5,$6),($7,$8,$9,$10,$11,$12) ON CONFLICT ("blog_id","day") DO UPDATE SET "comments"=blog_daily_stats.comments + excluded.comments,"likes"=blog_daily_stats.likes + excluded.likes,"unique_viewers"=blog_daily_stats.unique_viewers + excluded.unique_viewers,"views"=blog_daily_stats.views + excluded.views`)).
+		WithArgs(1, day, 2, 1, 0, 0, 3, day, 5, 4, 1, 0).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "blog_referrer_stats" ("blog_id","day","referrer","views") VALUES ($1,$2,$3,$4) ON CONFLICT ("blog_id","day","referrer") DO UPDATE SET "views"=blog_referrer_stats.views + excluded.views`)).
+		WithArgs(1, day, "google.com", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.AddDailyStats(context.Background(), []*domain.BlogDailyStats{
+		{BlogID: 3, Day: day, Views: 5, UniqueViewers: 4, Likes: 1},
+		{BlogID: 1, Day: day, Views: 2, UniqueViewers: 1},
+	}, []*domain.BlogReferrerStats{{BlogID: 1, Day: day, Referrer: "google.com", Views: 2}})
+	s.NoError(err)
+}
+
+func (s *AnalyticsRepositoryTestSuite) TestFetchDailyStats() {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blog_daily_stats" WHERE blog_id IN ($1,$2) AND day >= $3 AND day < $4 ORDER BY day, blog_id`)).
+		WithArgs(1, 2, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"blog_id", "day", "views"}).AddRow(1, from, 3))
+
+	stats, err := s.repo.FetchDailyStats(context.Background(), []int64{1, 2}, from, to)
+	s.NoError(err)
+	s.Require().Len(stats, 1)
+	s.Equal(3, stats[0].Views)
+
+	// no posts, no query
+	stats, err = s.repo.FetchDailyStats(context.Background(), nil, from, to)
+	s.NoError(err)
+	s.Empty(stats)
+}
+
+func (s *AnalyticsRepositoryTestSuite) TestReplaceTrendingScores() {
+	now := time.Now()
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "blog_trending_scores" WHERE period = $1`)).
+		WithArgs(domain.TrendingDay).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "blog_trending_scores" ("blog_id","period","score","updated_at") VALUES ($1,$2,$3,$4)`)).
+		WithArgs(1, domain.TrendingDay, 4.5, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.ReplaceTrendingScores(context.Background(), domain.TrendingDay, []*domain.BlogTrendingScore{
+		{BlogID: 1, Period: domain.TrendingDay, Score: 4.5, UpdatedAt: now},
+	})
+	s.NoError(err)
+}
+
+func TestAnalyticsRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(AnalyticsRepositoryTestSuite))
+}
@@ -3,9 +3,11 @@ package test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
 	"github.com/blog-platform/repositories"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -16,9 +18,10 @@ import (
 
 type BlogRepoTestSuite struct {
 	suite.Suite
-	db   *gorm.DB
-	mock sqlmock.Sqlmock
-	repo domain.IBlogRepository // Change from *BlogRepository to domain.IBlogRepository
+	db    *gorm.DB
+	mock  sqlmock.Sqlmock
+	repo  domain.IBlogRepository // Change from *BlogRepository to domain.IBlogRepository
+	cache *infrastructure.Cache
 }
 
 func (suite *BlogRepoTestSuite) SetupTest() {
@@ -37,7 +40,12 @@ func (suite *BlogRepoTestSuite) SetupTest() {
 
 	suite.db = gormDB
 	suite.mock = mock
-	suite.repo = repositories.NewBlogRepository(gormDB)
+	suite.cache = repositories.NewBlogCache()
+	suite.repo = repositories.NewBlogRepository(gormDB, suite.cache)
+}
+
+func (suite *BlogRepoTestSuite) TearDownTest() {
+	suite.cache.Close()
 }
 
 func (suite *BlogRepoTestSuite) TestCreateBlog() {
@@ -58,6 +66,10 @@ func (suite *BlogRepoTestSuite) TestCreateBlog() {
 			blog.UserID,
 			sqlmock.AnyArg(), // created_at
 			sqlmock.AnyArg(), // updated_at
+			"",               // summary
+			"",               // excerpt
+			sqlmock.AnyArg(), // suggested_tags
+			sqlmock.AnyArg(), // insights_generated_at
 		).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	suite.mock.ExpectCommit()
 
@@ -103,6 +115,170 @@ func (suite *BlogRepoTestSuite) TestUpdateByID_NotFound() {
 	assert.Error(suite.T(), err)
 }
 
+func (suite *BlogRepoTestSuite) TestUpdateInsights() {
+	insights := &domain.BlogInsights{Summary: "About Go.", Excerpt: "Go is fun.", Tags: []string{"go", "testing"}}
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`UPDATE "blogs" SET "updated_at"=\$1,"summary"=\$2,"excerpt"=\$3,"suggested_tags"=\$4,"insights_generated_at"=\$5 WHERE "id" = \$6`).
+		WithArgs(sqlmock.AnyArg(), "About Go.", "Go is fun.", `["go","testing"]`, sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	err := suite.repo.UpdateInsights(context.Background(), 7, insights)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *BlogRepoTestSuite) TestReviewComment_NotHeld() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`UPDATE "comments" SET "reviewed_at"=\$1,"reviewed_by"=\$2,"status"=\$3,"updated_at"=\$4 WHERE id = \$5 AND status = \$6`).
+		WithArgs(sqlmock.AnyArg(), 2, domain.CommentPublished, sqlmock.AnyArg(), 9, domain.CommentHeld).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectCommit()
+
+	c, err := suite.repo.ReviewComment(context.Background(), 9, domain.CommentPublished, 2, time.Now())
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), c)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *BlogRepoTestSuite) TestAddViews() {
+	ctx := context.Background()
+	suite.expectFetchByID(3)
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`UPDATE "blogs" SET "view_count"=view_count \+ CASE id WHEN \$1 THEN \$2 WHEN \$3 THEN \$4 ELSE 0 END WHERE id IN \(\$5,\$6\)`).
+		WithArgs(3, 1, 8, 4, 3, 8).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	suite.mock.ExpectCommit()
+	// the cached post is read again with its new count
+	suite.expectFetchByID(3)
+
+	_, err := suite.repo.FetchByID(ctx, 3)
+	assert.NoError(suite.T(), err)
+	err = suite.repo.AddViews(ctx, map[int64]int{8: 4, 3: 1})
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.FetchByID(ctx, 3)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *BlogRepoTestSuite) TestAddLike_CountsEachUserOnce() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`INSERT INTO "blog_likes" \("blog_id","user_id","created_at"\) VALUES \(\$1,\$2,\$3\) ON CONFLICT DO NOTHING RETURNING "id"`).
+		WithArgs(10, 5, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	suite.mock.ExpectExec(`UPDATE "blogs" SET "likes"=likes \+ 1 WHERE id = \$1`).
+		WithArgs(10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+	// liking again inserts nothing and leaves the count alone
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`INSERT INTO "blog_likes"`).
+		WithArgs(10, 5, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	suite.mock.ExpectCommit()
+
+	liked, err := suite.repo.AddLike(context.Background(), 10, 5)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), liked)
+	liked, err = suite.repo.AddLike(context.Background(), 10, 5)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), liked)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *BlogRepoTestSuite) TestRemoveLike_WithoutLike() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`DELETE FROM "blog_likes" WHERE blog_id = \$1 AND user_id = \$2`).
+		WithArgs(10, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectCommit()
+
+	unliked, err := suite.repo.RemoveLike(context.Background(), 10, 5)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), unliked)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *BlogRepoTestSuite) TestFetchByFilter_Trending() {
+	suite.mock.ExpectQuery(`SELECT "blogs"\."id",.* FROM "blogs" LEFT JOIN blog_trending_scores ON blog_trending_scores\.blog_id = blogs\.id AND blog_trending_scores\.period = \$1 WHERE user_id = \$2 ORDER BY COALESCE\(blog_trending_scores\.score, 0\) DESC, blogs\.created_at DESC, blogs\.id DESC LIMIT \$3`).
+		WithArgs(domain.TrendingWeek, 7, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(3, "Hot").AddRow(1, "Cold"))
+
+	userID := int64(7)
+	blogs, err := suite.repo.FetchByFilter(context.Background(), domain.BlogFilter{UserID: &userID, Limit: 5, Sort: domain.BlogSortTrending, Window: domain.TrendingWeek})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), blogs, 2)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *BlogRepoTestSuite) expectFetchByID(id int64) {
+	suite.mock.ExpectQuery(`SELECT \* FROM "blogs" WHERE "blogs"\."id" = \$1 ORDER BY "blogs"\."id" LIMIT \$2`).
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "user_id"}).AddRow(id, "Post", 0))
+	suite.mock.ExpectQuery(`SELECT \* FROM "tag_blogs" WHERE "tag_blogs"\."blog_id" = \$1`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"blog_id", "tag_id"}))
+}
+
+func (suite *BlogRepoTestSuite) TestUpdateByID_OnlyDropsThatPostFromCache() {
+	ctx := context.Background()
+	suite.expectFetchByID(1)
+	suite.expectFetchByID(2)
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`UPDATE "blogs" SET "title"=\$1,"updated_at"=\$2 WHERE id = \$3 AND user_id = \$4`).
+		WithArgs("New", sqlmock.AnyArg(), 1, "123").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+	suite.expectFetchByID(1)
+
+	for _, id := range []int64{1, 2, 1, 2} {
+		_, err := suite.repo.FetchByID(ctx, id)
+		assert.NoError(suite.T(), err)
+	}
+	assert.NoError(suite.T(), suite.repo.UpdateByID(ctx, 1, "123", map[string]interface{}{"title": "New"}))
+	// post 1 is read again, post 2 is still cached
+	for _, id := range []int64{1, 2} {
+		_, err := suite.repo.FetchByID(ctx, id)
+		assert.NoError(suite.T(), err)
+	}
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *BlogRepoTestSuite) TestLikes_DropThePostFromCache() {
+	ctx := context.Background()
+	suite.expectFetchByID(10)
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`INSERT INTO "blog_likes"`).
+		WithArgs(10, 5, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	suite.mock.ExpectExec(`UPDATE "blogs" SET "likes"=likes \+ 1 WHERE id = \$1`).
+		WithArgs(10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+	suite.expectFetchByID(10)
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`DELETE FROM "blog_likes" WHERE blog_id = \$1 AND user_id = \$2`).
+		WithArgs(10, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec(`UPDATE "blogs" SET "likes"=likes - 1 WHERE id = \$1 AND likes > 0`).
+		WithArgs(10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+	suite.expectFetchByID(10)
+
+	_, err := suite.repo.FetchByID(ctx, 10)
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.AddLike(ctx, 10, 5)
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.FetchByID(ctx, 10)
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.RemoveLike(ctx, 10, 5)
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.FetchByID(ctx, 10)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
 func TestBlogRepoTestSuite(t *testing.T) {
 	suite.Run(t, new(BlogRepoTestSuite))
 }
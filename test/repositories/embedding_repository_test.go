@@ -0,0 +1,85 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/repositories"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type EmbeddingRepositoryTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	mock   sqlmock.Sqlmock
+	gormDB *gorm.DB
+	repo   domain.IEmbeddingRepository
+}
+
+func (s *EmbeddingRepositoryTestSuite) SetupTest() {
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	s.Require().NoError(err)
+
+	s.gormDB, err = gorm.Open(postgres.New(postgres.Config{
+		Conn: s.db,
+	}), &gorm.Config{})
+	s.Require().NoError(err)
+
+	s.repo = repositories.NewEmbeddingRepository(s.gormDB)
+}
+
+func (s *EmbeddingRepositoryTestSuite) TearDownTest() {
+	s.Require().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *EmbeddingRepositoryTestSuite) TestSaveEmbedding_Upserts() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "blog_embeddings" ("blog_id","model","vector","updated_at") VALUES ($1,$2,$3,$4) ON CONFLICT ("blog_id") DO UPDATE SET "model"="excluded"."model","vector"="excluded"."vector","updated_at"="excluded"."updated_at"`)).
+		WithArgs(3, "local-hash-256", "[0.5,1]", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.SaveEmbedding(context.Background(), &domain.BlogEmbedding{BlogID: 3, Model: "local-hash-256", Vector: []float32{0.5, 1}})
+	s.NoError(err)
+}
+
+func (s *EmbeddingRepositoryTestSuite) pgvector() domain.IVectorIndex {
+	s.mock.ExpectExec(`CREATE EXTENSION IF NOT EXISTS vector`).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(`CREATE TABLE IF NOT EXISTS blog_vectors`).WillReturnResult(sqlmock.NewResult(0, 0))
+	index, err := repositories.NewPgvectorIndex(s.gormDB)
+	s.Require().NoError(err)
+	return index
+}
+
+func (s *EmbeddingRepositoryTestSuite) TestPgvector_Search() {
+	index := s.pgvector()
+	s.mock.ExpectQuery(`SELECT blog_id, 1 - \(embedding <=> \$1::vector\) AS score FROM blog_vectors\s+WHERE model = \$2 AND blog_id <> \$3 AND vector_dims\(embedding\) = \$4\s+ORDER BY embedding <=> \$5::vector, blog_id DESC LIMIT \$6`).
+		WithArgs("[0.25,-1]", "m", 7, 2, "[0.25,-1]", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"blog_id", "score"}).AddRow(4, 0.9).AddRow(2, 0.5))
+
+	matches, err := index.Search(context.Background(), "m", []float32{0.25, -1}, 5, 7)
+	s.NoError(err)
+	s.Equal([]domain.VectorMatch{{BlogID: 4, Score: 0.9}, {BlogID: 2, Score: 0.5}}, matches)
+}
+
+func (s *EmbeddingRepositoryTestSuite) TestPgvector_Fetch() {
+	index := s.pgvector()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT blog_id, model, embedding::text AS embedding FROM blog_vectors WHERE blog_id = $1`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"blog_id", "model", "embedding"}).AddRow(4, "m", "[0.25,-1]"))
+
+	embedding, err := index.Fetch(context.Background(), 4)
+	s.NoError(err)
+	s.Equal(&domain.BlogEmbedding{BlogID: 4, Model: "m", Vector: []float32{0.25, -1}}, embedding)
+}
+
+func TestEmbeddingRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(EmbeddingRepositoryTestSuite))
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/repositories"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type FollowRepositoryTestSuite struct {
+	suite.Suite
+	mock sqlmock.Sqlmock
+	repo domain.IFollowRepository
+}
+
+func (s *FollowRepositoryTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	s.Require().NoError(err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	s.Require().NoError(err)
+
+	s.mock = mock
+	s.repo = repositories.NewFollowRepository(gormDB)
+}
+
+func (s *FollowRepositoryTestSuite) TearDownTest() {
+	s.Require().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *FollowRepositoryTestSuite) TestFollow_IgnoresDuplicates() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "follows" ("follower_id","followee_id","created_at") VALUES ($1,$2,$3) ON CONFLICT DO NOTHING RETURNING "id"`)).
+		WithArgs(int64(1), int64(2), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectCommit()
+
+	s.NoError(s.repo.Follow(context.Background(), 1, 2))
+}
+
+func (s *FollowRepositoryTestSuite) TestUnfollow() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "follows" WHERE follower_id = $1 AND followee_id = $2`)).
+		WithArgs(int64(1), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	s.NoError(s.repo.Unfollow(context.Background(), 1, 2))
+}
+
+func (s *FollowRepositoryTestSuite) TestFetchFeed_UsesKeysetCursor() {
+	cursor := &domain.FeedCursor{CreatedAt: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), ID: 40}
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blogs" WHERE blogs.user_id IN (SELECT "followee_id" FROM "follows" WHERE follower_id = $1) AND (blogs.created_at, blogs.id) < ($2, $3) ORDER BY blogs.created_at DESC, blogs.id DESC LIMIT $4`)).
+		WithArgs(int64(1), cursor.CreatedAt, cursor.ID, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "user_id"}))
+
+	blogs, err := s.repo.FetchFeed(context.Background(), 1, cursor, 20)
+	s.NoError(err)
+	s.Empty(blogs)
+}
+
+func (s *FollowRepositoryTestSuite) TestListFollowers_SelectsPublicColumns() {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "follows" WHERE followee_id = $1`)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT users.id, users.username, users.bio, users.profile_picture FROM "users" JOIN follows ON follows.follower_id = users.id WHERE follows.followee_id = $1 ORDER BY follows.created_at DESC LIMIT $2`)).
+		WithArgs(int64(1), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "bio", "profile_picture"}).AddRow(2, "alice", "hi", "a.png"))
+
+	users, total, err := s.repo.ListFollowers(context.Background(), 1, 1, 10)
+	s.NoError(err)
+	s.Equal(int64(1), total)
+	s.Equal([]*domain.PublicUser{{ID: 2, Username: "alice", Bio: "hi", ProfilePicture: "a.png"}}, users)
+}
+
+func TestFollowRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(FollowRepositoryTestSuite))
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/repositories"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type NotificationRepositoryTestSuite struct {
+	suite.Suite
+	mock sqlmock.Sqlmock
+	repo domain.INotificationRepository
+}
+
+func (s *NotificationRepositoryTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	s.Require().NoError(err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	s.Require().NoError(err)
+
+	s.mock = mock
+	s.repo = repositories.NewNotificationRepository(gormDB)
+}
+
+func (s *NotificationRepositoryTestSuite) TearDownTest() {
+	s.Require().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *NotificationRepositoryTestSuite) TestList_ShowsOnlyThePublicProfileOfTheActor() {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "notifications" WHERE user_id = $1`)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "notifications" WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`)).
+		WithArgs(int64(1), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "actor_id", "type"}).AddRow(3, 1, 7, domain.NotificationLike))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, username, bio, profile_picture FROM "users" WHERE "users"."id" = $1`)).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "bio", "profile_picture"}).AddRow(7, "jane", "", ""))
+
+	notifications, total, err := s.repo.List(context.Background(), 1, false, 1, 10)
+	s.Require().NoError(err)
+	s.Equal(int64(1), total)
+	s.Require().Len(notifications, 1)
+	s.Equal(&domain.PublicUser{ID: 7, Username: "jane"}, notifications[0].ActorProfile)
+
+	body, err := json.Marshal(notifications[0])
+	s.Require().NoError(err)
+	s.Contains(string(body), `"actor":{"id":7,"username":"jane","bio":"","profile_picture":""}`)
+}
+
+func TestNotificationRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(NotificationRepositoryTestSuite))
+}
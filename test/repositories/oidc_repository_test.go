@@ -0,0 +1,82 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/repositories"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type OIDCRepositoryTestSuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo domain.IOIDCRepository
+}
+
+func (s *OIDCRepositoryTestSuite) SetupTest() {
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	s.Require().NoError(err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: s.db,
+	}), &gorm.Config{})
+	s.Require().NoError(err)
+
+	s.repo = repositories.NewOIDCRepository(gormDB)
+}
+
+func (s *OIDCRepositoryTestSuite) TearDownTest() {
+	s.Require().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *OIDCRepositoryTestSuite) TestConsumeLoginState_Found() {
+	expires := time.Now().Add(time.Minute)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM "oidc_login_states" WHERE state_hash = $1 RETURNING *`)).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"state_hash", "provider", "nonce", "code_verifier", "expires_at"}).
+			AddRow("hash", "corp", "nonce", "verifier", expires))
+	s.mock.ExpectCommit()
+
+	state, err := s.repo.ConsumeLoginState(context.Background(), "hash")
+	s.NoError(err)
+	s.Require().NotNil(state)
+	s.Equal("corp", state.Provider)
+	s.Equal("verifier", state.CodeVerifier)
+}
+
+func (s *OIDCRepositoryTestSuite) TestConsumeLoginState_Missing() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM "oidc_login_states" WHERE state_hash = $1 RETURNING *`)).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"state_hash"}))
+	s.mock.ExpectCommit()
+
+	state, err := s.repo.ConsumeLoginState(context.Background(), "hash")
+	s.NoError(err)
+	s.Nil(state)
+}
+
+func (s *OIDCRepositoryTestSuite) TestFindIdentity_NotLinked() {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "external_identities" WHERE provider = $1 AND subject = $2 ORDER BY "external_identities"."id" LIMIT $3`)).
+		WithArgs("corp", "sub-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	identity, err := s.repo.FindIdentity(context.Background(), "corp", "sub-1")
+	s.NoError(err)
+	s.Nil(identity)
+}
+
+func TestOIDCRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCRepositoryTestSuite))
+}
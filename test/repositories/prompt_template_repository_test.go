@@ -0,0 +1,86 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/repositories"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type PromptTemplateRepositoryTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	mock   sqlmock.Sqlmock
+	gormDB *gorm.DB
+	repo   domain.IPromptTemplateRepository
+}
+
+func (s *PromptTemplateRepositoryTestSuite) SetupTest() {
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	s.Require().NoError(err)
+
+	s.gormDB, err = gorm.Open(postgres.New(postgres.Config{
+		Conn: s.db,
+	}), &gorm.Config{})
+	s.Require().NoError(err)
+
+	s.repo = repositories.NewPromptTemplateRepository(s.gormDB)
+}
+
+func (s *PromptTemplateRepositoryTestSuite) TearDownTest() {
+	s.Require().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *PromptTemplateRepositoryTestSuite) TestCreateVersion_NumbersAndActivates() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(version), 0) FROM "prompt_templates" WHERE name = $1`)).
+		WithArgs("ideas").
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(2))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "prompt_templates" SET "active"=$1 WHERE name = $2 AND active = $3`)).
+		WithArgs(false, "ideas", true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "prompt_templates"`)).
+		WithArgs("ideas", 3, "ideas", "system", "{{.Topic}}", true, int64(1), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	s.mock.ExpectCommit()
+
+	t := &domain.PromptTemplate{Name: "ideas", Feature: "ideas", SystemPrompt: "system", UserPrompt: "{{.Topic}}", CreatedBy: 1}
+	s.NoError(s.repo.CreateVersion(context.Background(), t))
+	s.Equal(3, t.Version)
+	s.True(t.Active)
+	s.Equal(int64(7), t.ID)
+}
+
+func (s *PromptTemplateRepositoryTestSuite) TestFetchTemplate_ActiveVersion() {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "prompt_templates" WHERE name = $1 AND active = $2 ORDER BY "prompt_templates"."id" LIMIT $3`)).
+		WithArgs("ideas", true, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "version", "active"}))
+
+	t, err := s.repo.FetchTemplate(context.Background(), "ideas", 0)
+	s.NoError(err)
+	s.Nil(t)
+}
+
+func (s *PromptTemplateRepositoryTestSuite) TestActivate_UnknownVersion() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "prompt_templates" WHERE name = $1 AND version = $2`)).
+		WithArgs("ideas", 9).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectCommit()
+
+	found, err := s.repo.Activate(context.Background(), "ideas", 9)
+	s.NoError(err)
+	s.False(found)
+}
+
+func TestPromptTemplateRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(PromptTemplateRepositoryTestSuite))
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/blog-platform/domain"
@@ -50,8 +51,8 @@ func (s *UserRepositoryTestSuite) TestRegister_Success() {
 	}
 
 	s.mock.ExpectBegin()
-	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users" ("username","email","password","role","bio","profile_picture","phone","status","created_at","updated_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) RETURNING "id"`)).
-		WithArgs(user.Username, user.Email, user.Password, "", "", "", "", user.Status, sqlmock.AnyArg(), sqlmock.AnyArg()).
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users" ("username","email","password","role","bio","profile_picture","phone","status","failed_logins","locked_until","unlock_token_hash","totp_secret","totp_enabled","totp_last_step","created_at","updated_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16) RETURNING "id"`)).
+		WithArgs(user.Username, user.Email, user.Password, "", "", "", "", user.Status, 0, nil, "", "", false, 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	s.mock.ExpectCommit()
 
@@ -70,8 +71,8 @@ func (s *UserRepositoryTestSuite) TestRegister_Error() {
 	}
 
 	s.mock.ExpectBegin()
-	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users" ("username","email","password","role","bio","profile_picture","phone","status","created_at","updated_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) RETURNING "id"`)).
-		WithArgs(user.Username, user.Email, user.Password, "", "", "", "", user.Status, sqlmock.AnyArg(), sqlmock.AnyArg()).
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users" ("username","email","password","role","bio","profile_picture","phone","status","failed_logins","locked_until","unlock_token_hash","totp_secret","totp_enabled","totp_last_step","created_at","updated_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16) RETURNING "id"`)).
+		WithArgs(user.Username, user.Email, user.Password, "", "", "", "", user.Status, 0, nil, "", "", false, 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(errors.New("db error"))
 	s.mock.ExpectRollback()
 
@@ -248,6 +249,146 @@ func (s *UserRepositoryTestSuite) TestUpdateUserProfile_NoFields() {
 	err := s.repo.UpdateUserProfile(userID, updates)
 	s.NoError(err)
 }
+func (s *UserRepositoryTestSuite) TestUpdateUserProfile_IgnoresEmailAndStatus() {
+	updates := map[string]interface{}{
+		"Email":  "new@example.com",
+		"Status": "active",
+	}
+	err := s.repo.UpdateUserProfile(1, updates)
+	s.NoError(err)
+}
+
+func (s *UserRepositoryTestSuite) TestCreateEmailChange_ReplacesPending() {
+	change := &domain.PendingEmailChange{UserID: 1, NewEmail: "new@example.com", TokenHash: "hash"}
+	email := &domain.OutboxEmail{Recipients: "new@example.com", Template: domain.EmailChange, Status: domain.OutboxPending}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "pending_email_changes" WHERE user_id = $1`)).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "pending_email_changes"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_emails"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.CreateEmailChange(change, []*domain.OutboxEmail{email})
+	s.NoError(err)
+	s.Equal(int64(1), change.ID)
+}
+
+func (s *UserRepositoryTestSuite) TestConfirmEmailChange_Success() {
+	change := domain.PendingEmailChange{ID: 3, UserID: 1, NewEmail: "new@example.com"}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "email"=$1,"updated_at"=$2 WHERE id = $3`)).
+		WithArgs("new@example.com", sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "pending_email_changes" WHERE "pending_email_changes"."id" = $1`)).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	s.NoError(s.repo.ConfirmEmailChange(change))
+}
+
+func (s *UserRepositoryTestSuite) TestConfirmEmailChange_UserMissing() {
+	change := domain.PendingEmailChange{ID: 3, UserID: 9, NewEmail: "new@example.com"}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "email"=$1,"updated_at"=$2 WHERE id = $3`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectRollback()
+
+	s.EqualError(s.repo.ConfirmEmailChange(change), "user not found")
+}
+
+func (s *UserRepositoryTestSuite) TestRecordLoginFailure() {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`UPDATE users SET failed_logins = failed_logins + 1 WHERE id = $1 RETURNING failed_logins`)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"failed_logins"}).AddRow(4))
+
+	failures, err := s.repo.RecordLoginFailure(1)
+	s.NoError(err)
+	s.Equal(4, failures)
+}
+
+func (s *UserRepositoryTestSuite) TestLockAccount_WithEmail() {
+	until := time.Now().Add(15 * time.Minute)
+	email := &domain.OutboxEmail{Recipients: "a@example.com", Template: domain.EmailAccountLocked, Status: domain.OutboxPending}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "locked_until"=$1,"unlock_token_hash"=$2,"updated_at"=$3 WHERE id = $4`)).
+		WithArgs(until, "hash", sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_emails"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	s.NoError(s.repo.LockAccount(1, until, "hash", email))
+}
+
+func (s *UserRepositoryTestSuite) TestListLocked() {
+	now := time.Now()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE locked_until > $1`)).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE locked_until > $1 ORDER BY locked_until desc LIMIT $2`)).
+		WithArgs(now, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(1, "john"))
+
+	users, total, err := s.repo.ListLocked(now, 1, 10)
+	s.NoError(err)
+	s.Equal(int64(1), total)
+	s.Len(users, 1)
+}
+
+func (s *UserRepositoryTestSuite) TestUseTOTPStep_RejectsOldStep() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "totp_last_step"=$1,"updated_at"=$2 WHERE id = $3 AND totp_last_step < $4`)).
+		WithArgs(int64(100), sqlmock.AnyArg(), int64(1), int64(100)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	used, err := s.repo.UseTOTPStep(1, 100)
+	s.NoError(err)
+	s.False(used)
+}
+
+func (s *UserRepositoryTestSuite) TestUseRecoveryCode() {
+	now := time.Now()
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "recovery_codes" SET "used_at"=$1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`)).
+		WithArgs(now, int64(1), "hash").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	used, err := s.repo.UseRecoveryCode(1, "hash", now)
+	s.NoError(err)
+	s.True(used)
+}
+
+func (s *UserRepositoryTestSuite) TestUseMagicLink() {
+	now := time.Now()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`UPDATE magic_link_tokens SET used_at = $1 WHERE token_hash = $2 AND used_at IS NULL AND expires_at > $3 RETURNING *`)).
+		WithArgs(now, "hash", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash"}).AddRow(1, 5, "hash"))
+
+	link, err := s.repo.UseMagicLink("hash", now)
+	s.NoError(err)
+	s.Equal(int64(5), link.UserID)
+}
+
+func (s *UserRepositoryTestSuite) TestUseMagicLink_AlreadyUsed() {
+	now := time.Now()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`UPDATE magic_link_tokens SET used_at = $1`)).
+		WithArgs(now, "hash", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := s.repo.UseMagicLink("hash", now)
+	s.Error(err)
+}
+
 func (s *UserRepositoryTestSuite) TestResetPassword_Success() {
 	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1 ORDER BY "users"."id" LIMIT $2`)).
 		WithArgs(1, 1).
@@ -262,6 +403,17 @@ func (s *UserRepositoryTestSuite) TestResetPassword_Success() {
 	s.NoError(err)
 }
 
+func (s *UserRepositoryTestSuite) TestUpdatePasswordHash_ComparesOldHash() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "password"=$1,"updated_at"=$2 WHERE id = $3 AND password = $4`)).
+		WithArgs("new_hashed", sqlmock.AnyArg(), int64(1), "old_hashed").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.UpdatePasswordHash(1, "old_hashed", "new_hashed")
+	s.NoError(err)
+}
+
 func (s *UserRepositoryTestSuite) TestResetPassword_InvalidID() {
 	err := s.repo.ResetPassword("abc", "new_hashed")
 	s.Error(err)
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/repositories"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type WebAuthnRepositoryTestSuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo domain.IWebAuthnRepository
+}
+
+func (s *WebAuthnRepositoryTestSuite) SetupTest() {
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	s.Require().NoError(err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: s.db,
+	}), &gorm.Config{})
+	s.Require().NoError(err)
+
+	s.repo = repositories.NewWebAuthnRepository(gormDB)
+}
+
+func (s *WebAuthnRepositoryTestSuite) TearDownTest() {
+	s.Require().NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *WebAuthnRepositoryTestSuite) TestConsumeChallenge_Found() {
+	expires := time.Now().Add(time.Minute)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM "webauthn_challenges" WHERE challenge_hash = $1 RETURNING *`)).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"challenge_hash", "type", "user_id", "expires_at"}).
+			AddRow("hash", domain.WebAuthnRegistration, 7, expires))
+	s.mock.ExpectCommit()
+
+	challenge, err := s.repo.ConsumeChallenge(context.Background(), "hash")
+	s.NoError(err)
+	s.Require().NotNil(challenge)
+	s.Equal(domain.WebAuthnRegistration, challenge.Type)
+	s.Equal(int64(7), challenge.UserID)
+}
+
+func (s *WebAuthnRepositoryTestSuite) TestConsumeChallenge_Missing() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM "webauthn_challenges" WHERE challenge_hash = $1 RETURNING *`)).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"challenge_hash"}))
+	s.mock.ExpectCommit()
+
+	challenge, err := s.repo.ConsumeChallenge(context.Background(), "hash")
+	s.NoError(err)
+	s.Nil(challenge)
+}
+
+func (s *WebAuthnRepositoryTestSuite) TestUpdateSignCount_ComparesOldValue() {
+	usedAt := time.Now()
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "webauthn_credentials" SET "last_used_at"=$1,"sign_count"=$2 WHERE id = $3 AND sign_count = $4`)).
+		WithArgs(usedAt, int64(6), int64(3), int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	updated, err := s.repo.UpdateSignCount(context.Background(), 3, 5, 6, usedAt)
+	s.NoError(err)
+	s.False(updated)
+}
+
+func (s *WebAuthnRepositoryTestSuite) TestDeleteCredential_ScopedToUser() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "webauthn_credentials" WHERE id = $1 AND user_id = $2`)).
+		WithArgs(int64(3), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	deleted, err := s.repo.DeleteCredential(context.Background(), 7, 3)
+	s.NoError(err)
+	s.True(deleted)
+}
+
+func TestWebAuthnRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(WebAuthnRepositoryTestSuite))
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/test/mocks"
+	"github.com/blog-platform/usecases"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AIUsageUsecaseTestSuite struct {
+	suite.Suite
+	usageRepo *mocks.MockAIUsageRepository
+	userRepo  *mocks.MockUserRepository
+	usecase   domain.IAIUsageUsecase
+	ctx       context.Context
+}
+
+func (suite *AIUsageUsecaseTestSuite) SetupTest() {
+	suite.T().Setenv("AI_DAILY_TOKENS_USER", "1000")
+	suite.T().Setenv("AI_DAILY_TOKENS_ADMIN", "unlimited")
+	suite.usageRepo = new(mocks.MockAIUsageRepository)
+	suite.userRepo = new(mocks.MockUserRepository)
+	suite.usecase = usecases.NewAIUsageUsecase(suite.usageRepo, suite.userRepo)
+	suite.ctx = context.Background()
+}
+
+// startOfToday matches the start of the current UTC day, when quotas reset
+var startOfToday = mock.MatchedBy(func(t time.Time) bool {
+	return t.Equal(time.Now().UTC().Truncate(24 * time.Hour))
+})
+
+func (suite *AIUsageUsecaseTestSuite) TestCheckQuota_RoleQuota() {
+	suite.usageRepo.On("FetchQuota", suite.ctx, int64(1)).Return(nil, nil)
+	suite.usageRepo.On("SumTokens", suite.ctx, int64(1), startOfToday).Return(int64(999), nil).Once()
+	suite.NoError(suite.usecase.CheckQuota(suite.ctx, domain.AICaller{UserID: 1, Role: "user"}))
+
+	suite.usageRepo.On("SumTokens", suite.ctx, int64(1), startOfToday).Return(int64(1000), nil).Once()
+	suite.EqualError(suite.usecase.CheckQuota(suite.ctx, domain.AICaller{UserID: 1, Role: "user"}), "AI daily quota exceeded")
+}
+
+func (suite *AIUsageUsecaseTestSuite) TestCheckQuota_AdminUnlimited() {
+	suite.usageRepo.On("FetchQuota", suite.ctx, int64(2)).Return(nil, nil)
+
+	suite.NoError(suite.usecase.CheckQuota(suite.ctx, domain.AICaller{UserID: 2, Role: "admin"}))
+	suite.usageRepo.AssertNotCalled(suite.T(), "SumTokens", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *AIUsageUsecaseTestSuite) TestCheckQuota_UserOverride() {
+	suite.usageRepo.On("FetchQuota", suite.ctx, int64(3)).Return(&domain.AIQuota{UserID: 3, DailyTokens: 0}, nil)
+	suite.usageRepo.On("SumTokens", suite.ctx, int64(3), startOfToday).Return(int64(0), nil)
+
+	err := suite.usecase.CheckQuota(suite.ctx, domain.AICaller{UserID: 3, Role: "admin"})
+	suite.EqualError(err, "AI daily quota exceeded", "a zero override blocks even admins")
+}
+
+func (suite *AIUsageUsecaseTestSuite) TestCheckQuota_StoreFailureLetsRequestsThrough() {
+	suite.usageRepo.On("FetchQuota", suite.ctx, int64(4)).Return(nil, errors.New("connection refused"))
+
+	suite.NoError(suite.usecase.CheckQuota(suite.ctx, domain.AICaller{UserID: 4, Role: "user"}))
+}
+
+func (suite *AIUsageUsecaseTestSuite) TestCheckQuota_SystemRequests() {
+	suite.NoError(suite.usecase.CheckQuota(suite.ctx, domain.AICaller{}))
+	suite.usageRepo.AssertNotCalled(suite.T(), "FetchQuota", mock.Anything, mock.Anything)
+}
+
+func (suite *AIUsageUsecaseTestSuite) TestSetQuota() {
+	suite.userRepo.On("GetUserProfile", int64(5)).Return(&domain.User{ID: 5, Role: "user"}, nil)
+	suite.usageRepo.On("SaveQuota", suite.ctx, &domain.AIQuota{UserID: 5, DailyTokens: 5000}).Return(nil)
+	suite.usageRepo.On("FetchQuota", suite.ctx, int64(5)).Return(&domain.AIQuota{UserID: 5, DailyTokens: 5000}, nil)
+	suite.usageRepo.On("SumTokens", suite.ctx, int64(5), startOfToday).Return(int64(1200), nil)
+
+	status, err := suite.usecase.SetQuota(suite.ctx, 5, 5000)
+	suite.Require().NoError(err)
+	suite.Equal(int64(5000), status.DailyTokens)
+	suite.True(status.Override)
+	suite.Equal(int64(1200), status.UsedTokens)
+	suite.Equal(time.Now().UTC().Truncate(24*time.Hour).Add(24*time.Hour), status.ResetsAt)
+}
+
+func (suite *AIUsageUsecaseTestSuite) TestSetQuota_Invalid() {
+	_, err := suite.usecase.SetQuota(suite.ctx, 5, -5)
+	suite.EqualError(err, "daily_tokens must be at least 0, or -1 for no limit")
+
+	suite.userRepo.On("GetUserProfile", int64(6)).Return(nil, errors.New("record not found"))
+	_, err = suite.usecase.SetQuota(suite.ctx, 6, 10)
+	suite.EqualError(err, "user not found")
+}
+
+func (suite *AIUsageUsecaseTestSuite) TestClearQuota_NotFound() {
+	suite.usageRepo.On("DeleteQuota", suite.ctx, int64(7)).Return(false, nil)
+
+	suite.EqualError(suite.usecase.ClearQuota(suite.ctx, 7), "quota not found")
+}
+
+func (suite *AIUsageUsecaseTestSuite) TestUsage_ValidatesPeriod() {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err := suite.usecase.UsageByUser(suite.ctx, from, from)
+	suite.EqualError(err, "from must be before to")
+	_, err = suite.usecase.UsageForUser(suite.ctx, 1, from, from.AddDate(2, 0, 0))
+	suite.EqualError(err, "period cannot be longer than a year")
+
+	summaries := []domain.AIUsageSummary{{UserID: 1, Requests: 3, PromptTokens: 30, CostUSD: 0.01}}
+	suite.usageRepo.On("SummarizeByUser", suite.ctx, from, from.AddDate(0, 1, 0)).Return(summaries, nil)
+	got, err := suite.usecase.UsageByUser(suite.ctx, from, from.AddDate(0, 1, 0))
+	suite.NoError(err)
+	suite.Equal(summaries, got)
+}
+
+func TestAIUsageUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(AIUsageUsecaseTestSuite))
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/test/mocks"
+	"github.com/blog-platform/usecases"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AnalyticsUsecaseTestSuite struct {
+	suite.Suite
+	analyticsRepo *mocks.MockAnalyticsRepository
+	blogRepo      *mocks.MockBlogRepo
+	usecase       domain.IAnalyticsUsecase
+	ctx           context.Context
+	from, to      time.Time
+}
+
+func (suite *AnalyticsUsecaseTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.analyticsRepo = new(mocks.MockAnalyticsRepository)
+	suite.blogRepo = new(mocks.MockBlogRepo)
+	suite.usecase = usecases.NewAnalyticsUsecase(suite.analyticsRepo, suite.blogRepo)
+	suite.from = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	suite.to = suite.from.AddDate(0, 0, 3)
+}
+
+func (suite *AnalyticsUsecaseTestSuite) day(n int) time.Time {
+	return suite.from.AddDate(0, 0, n)
+}
+
+func (suite *AnalyticsUsecaseTestSuite) TestBlogAnalytics() {
+	suite.blogRepo.On("FetchByID", suite.ctx, int64(1)).Return(&domain.Blog{ID: 1, Title: "Go"}, nil)
+	suite.analyticsRepo.On("FetchDailyStats", suite.ctx, []int64{1}, suite.from, suite.to).Return([]*domain.BlogDailyStats{
+		{BlogID: 1, Day: suite.day(0), Views: 5, UniqueViewers: 3, Likes: 2, Comments: 1},
+		{BlogID: 1, Day: suite.day(2), Views: 2, UniqueViewers: 2, Likes: -1},
+	}, nil)
+	suite.analyticsRepo.On("FetchReferrerStats", suite.ctx, []int64{1}, suite.from, suite.to).Return([]*domain.BlogReferrerStats{
+		{BlogID: 1, Day: suite.day(0), Referrer: "google.com", Views: 3},
+		{BlogID: 1, Day: suite.day(0), Referrer: domain.DirectReferrer, Views: 2},
+		{BlogID: 1, Day: suite.day(2), Referrer: domain.DirectReferrer, Views: 2},
+	}, nil)
+
+	analytics, err := suite.usecase.BlogAnalytics(suite.ctx, 1, suite.from, suite.to)
+	suite.NoError(err)
+	suite.Equal("Go", analytics.Title)
+	suite.Equal("2024-05-01", analytics.From)
+	suite.Equal("2024-05-03", analytics.To)
+	suite.Equal(domain.ActivityCounts{Views: 7, UniqueViewers: 5, Likes: 1, Comments: 1}, analytics.Totals)
+	suite.Equal([]domain.DayStats{
+		{Day: "2024-05-01", ActivityCounts: domain.ActivityCounts{Views: 5, UniqueViewers: 3, Likes: 2, Comments: 1},
+			Referrers: []domain.ReferrerCount{{Referrer: "google.com", Views: 3}, {Referrer: domain.DirectReferrer, Views: 2}}},
+		{Day: "2024-05-02"},
+		{Day: "2024-05-03", ActivityCounts: domain.ActivityCounts{Views: 2, UniqueViewers: 2, Likes: -1},
+			Referrers: []domain.ReferrerCount{{Referrer: domain.DirectReferrer, Views: 2}}},
+	}, analytics.Days)
+	suite.Equal([]domain.ReferrerCount{{Referrer: domain.DirectReferrer, Views: 4}, {Referrer: "google.com", Views: 3}}, analytics.Referrers)
+}
+
+func (suite *AnalyticsUsecaseTestSuite) TestBlogAnalytics_Errors() {
+	_, err := suite.usecase.BlogAnalytics(suite.ctx, 1, suite.to, suite.from)
+	suite.EqualError(err, "from must be before to")
+
+	suite.blogRepo.On("FetchByID", suite.ctx, int64(2)).Return(nil, errors.New("record not found"))
+	_, err = suite.usecase.BlogAnalytics(suite.ctx, 2, suite.from, suite.to)
+	suite.EqualError(err, "blog not found")
+
+	suite.blogRepo.On("FetchByID", suite.ctx, int64(1)).Return(&domain.Blog{ID: 1}, nil)
+	suite.analyticsRepo.On("FetchDailyStats", suite.ctx, []int64{1}, suite.from, suite.to).Return(nil, errors.New("db down"))
+	_, err = suite.usecase.BlogAnalytics(suite.ctx, 1, suite.from, suite.to)
+	suite.EqualError(err, "failed to fetch stats")
+}
+
+func (suite *AnalyticsUsecaseTestSuite) TestAuthorAnalytics() {
+	authorID := int64(7)
+	suite.blogRepo.On("FetchByFilter", suite.ctx, domain.BlogFilter{UserID: &authorID}).Return([]*domain.Blog{
+		{ID: 1, Title: "Go"}, {ID: 2, Title: "Rust"}, {ID: 3, Title: "Quiet"},
+	}, nil)
+	suite.analyticsRepo.On("FetchDailyStats", suite.ctx, []int64{1, 2, 3}, suite.from, suite.to).Return([]*domain.BlogDailyStats{
+		{BlogID: 1, Day: suite.day(0), Views: 2, UniqueViewers: 2},
+		{BlogID: 2, Day: suite.day(0), Views: 4, UniqueViewers: 1, Comments: 1},
+		{BlogID: 1, Day: suite.day(1), Views: 1, UniqueViewers: 1, Likes: 1},
+	}, nil)
+	suite.analyticsRepo.On("FetchReferrerStats", suite.ctx, []int64{1, 2, 3}, suite.from, suite.to).Return([]*domain.BlogReferrerStats{
+		{BlogID: 1, Day: suite.day(0), Referrer: "google.com", Views: 2},
+		{BlogID: 2, Day: suite.day(0), Referrer: "google.com", Views: 4},
+	}, nil)
+
+	analytics, err := suite.usecase.AuthorAnalytics(suite.ctx, authorID, suite.from, suite.to)
+	suite.NoError(err)
+	suite.Equal(domain.ActivityCounts{Views: 7, UniqueViewers: 4, Likes: 1, Comments: 1}, analytics.Totals)
+	suite.Require().Len(analytics.Days, 3)
+	suite.Equal(domain.ActivityCounts{Views: 6, UniqueViewers: 3, Comments: 1}, analytics.Days[0].ActivityCounts)
+	suite.Equal([]domain.ReferrerCount{{Referrer: "google.com", Views: 6}}, analytics.Referrers)
+
+	suite.Require().Len(analytics.Posts, 3)
+	suite.Equal([]int64{2, 1, 3}, []int64{analytics.Posts[0].BlogID, analytics.Posts[1].BlogID, analytics.Posts[2].BlogID})
+	suite.Equal(3, analytics.Posts[1].Views)
+	suite.Equal([]string{"2024-05-01", "2024-05-02"}, []string{analytics.Posts[1].Days[0].Day, analytics.Posts[1].Days[1].Day})
+	suite.Empty(analytics.Posts[2].Days)
+}
+
+func (suite *AnalyticsUsecaseTestSuite) TestAuthorAnalytics_NoPosts() {
+	suite.blogRepo.On("FetchByFilter", suite.ctx, mock.Anything).Return([]*domain.Blog{}, nil)
+	suite.analyticsRepo.On("FetchDailyStats", suite.ctx, []int64{}, suite.from, suite.to).Return([]*domain.BlogDailyStats{}, nil)
+	suite.analyticsRepo.On("FetchReferrerStats", suite.ctx, []int64{}, suite.from, suite.to).Return([]*domain.BlogReferrerStats{}, nil)
+
+	analytics, err := suite.usecase.AuthorAnalytics(suite.ctx, 7, suite.from, suite.to)
+	suite.NoError(err)
+	suite.Empty(analytics.Posts)
+	suite.Len(analytics.Days, 3)
+}
+
+func TestAnalyticsUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(AnalyticsUsecaseTestSuite))
+}
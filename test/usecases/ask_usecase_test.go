@@ -0,0 +1,118 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/test/mocks"
+	"github.com/blog-platform/usecases"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AskUsecaseTestSuite struct {
+	suite.Suite
+	blogRepo *mocks.MockBlogRepo
+	search   domain.ISemanticSearchUsecase
+	embedder domain.IEmbeddingProvider
+	mockAI   *MockAIService
+	usecase  domain.IAskUsecase
+	ctx      context.Context
+}
+
+func (suite *AskUsecaseTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.blogRepo = new(mocks.MockBlogRepo)
+	embeddingRepo := new(mocks.MockEmbeddingRepository)
+	embeddingRepo.On("FetchEmbeddings", mock.Anything).Return([]*domain.BlogEmbedding{}, nil)
+	embeddingRepo.On("SaveEmbedding", mock.Anything, mock.Anything).Return(nil)
+	suite.embedder = infrastructure.NewLocalEmbeddingProvider(infrastructure.LocalEmbeddingDimensions)
+	suite.search = usecases.NewSemanticSearchUsecase(suite.blogRepo, suite.embedder, infrastructure.NewMemoryVectorIndex(embeddingRepo))
+	suite.mockAI = &MockAIService{answer: &domain.BlogAnswer{Answer: "Use select [1].", Answered: true}}
+	suite.usecase = usecases.NewAskUsecase(suite.search, suite.embedder, suite.mockAI)
+}
+
+func (suite *AskUsecaseTestSuite) index(blogs ...*domain.Blog) {
+	for _, blog := range blogs {
+		suite.blogRepo.On("FetchByID", mock.Anything, blog.ID).Return(blog, nil)
+		suite.Require().NoError(suite.search.IndexBlog(suite.ctx, blog.ID))
+	}
+}
+
+func (suite *AskUsecaseTestSuite) TestAsk_SendsBestParagraphs() {
+	suite.index(
+		&domain.Blog{ID: 1, Title: "Sourdough at home", Content: "Feed the starter flour and water.\n\nBake the bread hot."},
+		&domain.Blog{ID: 2, Title: "Go concurrency", Content: "Goroutines are cheap.\n\n\nWait on several channels with select."},
+	)
+
+	answer, err := suite.usecase.Ask(suite.ctx, "  How do I wait on several channels?  ")
+	suite.NoError(err)
+	suite.Equal(suite.mockAI.answer, answer)
+
+	suite.Require().NotEmpty(suite.mockAI.answerChunks)
+	best := suite.mockAI.answerChunks[0]
+	suite.Equal(int64(2), best.BlogID)
+	suite.Equal("Go concurrency", best.Title)
+	suite.Equal(1, best.ParagraphStart)
+	suite.Equal(2, best.ParagraphEnd)
+	suite.Equal("Goroutines are cheap.\n\nWait on several channels with select.", best.Text)
+}
+
+func (suite *AskUsecaseTestSuite) TestAsk_SplitsLongPosts() {
+	long := strings.Repeat("channels ", 200) // 1800 characters
+	suite.index(&domain.Blog{ID: 1, Title: "Channels", Content: "Intro.\n\n" + long + "\n\nOutro."})
+
+	_, err := suite.usecase.Ask(suite.ctx, "channels")
+	suite.NoError(err)
+	chunks := suite.mockAI.answerChunks
+	var ranges [][2]int
+	for _, chunk := range chunks {
+		suite.LessOrEqual(len(chunk.Text), 1200)
+		ranges = append(ranges, [2]int{chunk.ParagraphStart, chunk.ParagraphEnd})
+	}
+	// the long paragraph is cut in two and its tail shares a chunk with the next
+	suite.ElementsMatch([][2]int{{1, 1}, {2, 2}, {2, 3}}, ranges)
+}
+
+func (suite *AskUsecaseTestSuite) TestAsk_NoPosts() {
+	answer, err := suite.usecase.Ask(suite.ctx, "How do I bake bread?")
+	suite.NoError(err)
+	suite.False(answer.Answered)
+	suite.Empty(answer.Citations)
+	suite.Nil(suite.mockAI.answerChunks)
+}
+
+func (suite *AskUsecaseTestSuite) TestAsk_DeletedPostsAreNotSent() {
+	suite.index(&domain.Blog{ID: 1, Title: "Go concurrency", Content: "Wait on several channels with select."})
+	suite.blogRepo.ExpectedCalls = nil
+	suite.blogRepo.On("FetchByID", mock.Anything, int64(1)).Return(nil, errors.New("record not found"))
+
+	answer, err := suite.usecase.Ask(suite.ctx, "How do I wait on channels?")
+	suite.NoError(err)
+	suite.False(answer.Answered)
+	suite.Nil(suite.mockAI.answerChunks)
+}
+
+func (suite *AskUsecaseTestSuite) TestAsk_Errors() {
+	_, err := suite.usecase.Ask(suite.ctx, " ")
+	suite.EqualError(err, "question is required")
+
+	_, err = suite.usecase.Ask(suite.ctx, strings.Repeat("a", 501))
+	suite.EqualError(err, "question cannot be longer than 500 characters")
+
+	_, err = usecases.NewAskUsecase(suite.search, nil, suite.mockAI).Ask(suite.ctx, "go")
+	suite.EqualError(err, "semantic search is not configured")
+
+	suite.index(&domain.Blog{ID: 1, Title: "Go", Content: "Channels."})
+	suite.mockAI.answer, suite.mockAI.answerErr = nil, errors.New("AI provider timed out")
+	_, err = suite.usecase.Ask(suite.ctx, "channels")
+	suite.EqualError(err, "AI provider timed out")
+}
+
+func TestAskUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(AskUsecaseTestSuite))
+}
@@ -2,35 +2,70 @@ package test
 
 import (
 	"context"
+	"errors"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/blog-platform/domain"
 	"github.com/blog-platform/test/mocks"
 	"github.com/blog-platform/usecases"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
-type MockAIService struct{}
+type MockAIService struct {
+	insights      *domain.BlogInsights
+	insightsErr   error
+	moderation    *domain.CommentModeration
+	moderationErr error
+	answer        *domain.BlogAnswer
+	answerErr     error
+	answerChunks  []domain.BlogChunk
+	prompt        domain.Prompt
+}
 
-func (m *MockAIService) GenerateBlogIdeas(topic string) (string, error) {
+func (m *MockAIService) GenerateBlogIdeas(ctx context.Context, prompt domain.Prompt) (string, error) {
+	m.prompt = prompt
 	return "Mocked blog ideas", nil
 }
-func (m *MockAIService) SuggestBlogImprovements(content string) (string, error) {
+func (m *MockAIService) SuggestBlogImprovements(ctx context.Context, prompt domain.Prompt) (string, error) {
+	m.prompt = prompt
 	return "Mocked improvement", nil
 }
+func (m *MockAIService) StreamBlogIdeas(ctx context.Context, prompt domain.Prompt, onDelta func(string) error) (string, error) {
+	m.prompt = prompt
+	return "Mocked blog ideas", onDelta("Mocked blog ideas")
+}
+func (m *MockAIService) StreamBlogImprovements(ctx context.Context, prompt domain.Prompt, onDelta func(string) error) (string, error) {
+	m.prompt = prompt
+	return "Mocked improvement", onDelta("Mocked improvement")
+}
+func (m *MockAIService) GenerateBlogInsights(ctx context.Context, title, content string) (*domain.BlogInsights, error) {
+	return m.insights, m.insightsErr
+}
+func (m *MockAIService) ModerateComment(ctx context.Context, post *domain.Blog, comment string) (*domain.CommentModeration, error) {
+	return m.moderation, m.moderationErr
+}
+func (m *MockAIService) AnswerQuestion(ctx context.Context, question string, chunks []domain.BlogChunk) (*domain.BlogAnswer, error) {
+	m.answerChunks = chunks
+	return m.answer, m.answerErr
+}
 
 type BlogUsecaseTestSuite struct {
 	suite.Suite
-	mockRepo *mocks.MockBlogRepo
-	mockAI   *MockAIService
-	usecase  domain.IBlogUsecase
+	mockRepo     *mocks.MockBlogRepo
+	mockAI       *MockAIService
+	mockNotifier *mocks.MockNotifier
+	usecase      domain.IBlogUsecase
 }
 
 func (suite *BlogUsecaseTestSuite) SetupTest() {
 	suite.mockRepo = new(mocks.MockBlogRepo)
 	suite.mockAI = &MockAIService{}
-	suite.usecase = usecases.NewBlogUsecase(suite.mockRepo, suite.mockAI)
+	suite.mockNotifier = new(mocks.MockNotifier)
+	suite.usecase = usecases.NewBlogUsecase(suite.mockRepo, suite.mockAI, suite.mockNotifier, nil, nil, nil)
 }
 
 func (suite *BlogUsecaseTestSuite) TestCreateBlog_Success() {
@@ -43,11 +78,17 @@ func (suite *BlogUsecaseTestSuite) TestCreateBlog_Success() {
 	}
 
 	suite.mockRepo.On("Create", ctx, blog).Return(nil)
+	suite.mockNotifier.On("Notify", ctx, domain.NotificationEvent{
+		Type:    domain.NotificationNewPost,
+		ActorID: 123,
+		BlogID:  1,
+	}).Return(nil)
 
 	tags := []string{}
 	err := suite.usecase.CreateBlog(ctx, blog, tags)
 	assert.NoError(suite.T(), err)
 	suite.mockRepo.AssertExpectations(suite.T())
+	suite.mockNotifier.AssertExpectations(suite.T())
 }
 
 func (suite *BlogUsecaseTestSuite) TestCreateBlogError() {
@@ -67,6 +108,108 @@ func (suite *BlogUsecaseTestSuite) TestCreateBlogError() {
 	assert.EqualError(suite.T(), err, "failed to create blog")
 	suite.mockRepo.AssertExpectations(suite.T())
 }
+func (suite *BlogUsecaseTestSuite) TestGenerateBlogIdeas_RendersSelectedTemplate() {
+	ctx := context.Background()
+	prompts := new(mocks.MockPromptTemplateRepository)
+	prompts.On("FetchTemplate", ctx, "ideas-short", 0).Return(&domain.PromptTemplate{
+		Name:         "ideas-short",
+		Feature:      domain.AIFeatureIdeas,
+		SystemPrompt: "List three ideas{{with .Language}} in {{.}}{{end}}.",
+		UserPrompt:   "{{.Topic}}",
+	}, nil)
+	suite.usecase = usecases.NewBlogUsecase(suite.mockRepo, suite.mockAI, suite.mockNotifier, nil, usecases.NewPromptTemplateUsecase(prompts), nil)
+
+	ideas, err := suite.usecase.GenerateBlogIdeas(ctx, "gardening", domain.PromptOptions{Template: "ideas-short", Language: "Spanish"})
+	suite.NoError(err)
+	suite.Equal("Mocked blog ideas", ideas)
+	suite.Equal(domain.Prompt{System: "List three ideas in Spanish.", User: "gardening"}, suite.mockAI.prompt)
+
+	prompts.On("FetchTemplate", ctx, "ideas-long", 0).Return(nil, nil)
+	_, err = suite.usecase.StreamBlogIdeas(ctx, "gardening", domain.PromptOptions{Template: "ideas-long"}, func(string) error { return nil })
+	suite.EqualError(err, "prompt template not found")
+}
+
+func (suite *BlogUsecaseTestSuite) TestCreateBlog_AutoInsightsAppliesSuggestedTags() {
+	os.Setenv("AI_AUTO_INSIGHTS", "true")
+	defer os.Unsetenv("AI_AUTO_INSIGHTS")
+	suite.usecase = usecases.NewBlogUsecase(suite.mockRepo, suite.mockAI, suite.mockNotifier, nil, nil, nil)
+	insights := &domain.BlogInsights{Summary: "About Go.", Excerpt: "Go is fun.", Tags: []string{"go"}}
+	suite.mockAI.insights = insights
+
+	ctx := context.Background()
+	blog := &domain.Blog{ID: 3, Title: "Go", Content: "Go is fun.", UserID: 123}
+	suite.mockRepo.On("Create", ctx, blog).Return(nil)
+	suite.mockNotifier.On("Notify", ctx, mock.Anything).Return(nil)
+	suite.mockRepo.On("UpdateInsights", mock.Anything, int64(3), insights).Return(nil)
+	suite.mockRepo.On("FindOrCreateTag", mock.Anything, "go").Return(int64(9), nil)
+	linked := make(chan struct{})
+	suite.mockRepo.On("LinkTagToBlog", mock.Anything, int64(3), int64(9)).Return(nil).Run(func(mock.Arguments) { close(linked) })
+
+	suite.NoError(suite.usecase.CreateBlog(ctx, blog, nil))
+	select {
+	case <-linked:
+	case <-time.After(time.Second):
+		suite.Fail("suggested tags were not applied")
+	}
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *BlogUsecaseTestSuite) TestCreateBlog_AutoInsightsKeepsAuthorTags() {
+	os.Setenv("AI_AUTO_INSIGHTS", "true")
+	defer os.Unsetenv("AI_AUTO_INSIGHTS")
+	suite.usecase = usecases.NewBlogUsecase(suite.mockRepo, suite.mockAI, suite.mockNotifier, nil, nil, nil)
+	insights := &domain.BlogInsights{Summary: "About Go.", Excerpt: "Go is fun.", Tags: []string{"go"}}
+	suite.mockAI.insights = insights
+
+	ctx := context.Background()
+	blog := &domain.Blog{ID: 4, Title: "Go", Content: "Go is fun.", UserID: 123}
+	suite.mockRepo.On("Create", ctx, blog).Return(nil)
+	suite.mockRepo.On("FindOrCreateTag", ctx, "golang").Return(int64(1), nil).Once()
+	suite.mockRepo.On("LinkTagToBlog", ctx, int64(4), int64(1)).Return(nil).Once()
+	suite.mockNotifier.On("Notify", ctx, mock.Anything).Return(nil)
+	stored := make(chan struct{})
+	suite.mockRepo.On("UpdateInsights", mock.Anything, int64(4), insights).Return(nil).Run(func(mock.Arguments) { close(stored) })
+
+	suite.NoError(suite.usecase.CreateBlog(ctx, blog, []string{"golang"}))
+	select {
+	case <-stored:
+	case <-time.After(time.Second):
+		suite.Fail("insights were not stored")
+	}
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *BlogUsecaseTestSuite) TestRegenerateBlogInsights() {
+	ctx := context.Background()
+	insights := &domain.BlogInsights{Summary: "About Go.", Excerpt: "Go is fun.", Tags: []string{"go"}}
+	suite.mockAI.insights = insights
+	suite.mockRepo.On("FetchByID", ctx, int64(5)).Return(&domain.Blog{ID: 5, Title: "Go", Content: "Go is fun."}, nil)
+	suite.mockRepo.On("UpdateInsights", ctx, int64(5), insights).Return(nil)
+
+	got, err := suite.usecase.RegenerateBlogInsights(ctx, 5)
+	suite.NoError(err)
+	suite.Equal(insights, got)
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *BlogUsecaseTestSuite) TestRegenerateBlogInsights_AIFailureKeepsOldInsights() {
+	ctx := context.Background()
+	suite.mockAI.insightsErr = errors.New("AI provider returned an invalid response")
+	suite.mockRepo.On("FetchByID", ctx, int64(5)).Return(&domain.Blog{ID: 5, Title: "Go", Content: "Go is fun."}, nil)
+
+	_, err := suite.usecase.RegenerateBlogInsights(ctx, 5)
+	suite.EqualError(err, "AI provider returned an invalid response")
+	suite.mockRepo.AssertNotCalled(suite.T(), "UpdateInsights", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *BlogUsecaseTestSuite) TestRegenerateBlogInsights_NotFound() {
+	ctx := context.Background()
+	suite.mockRepo.On("FetchByID", ctx, int64(6)).Return(nil, errors.New("record not found"))
+
+	_, err := suite.usecase.RegenerateBlogInsights(ctx, 6)
+	suite.EqualError(err, "blog not found")
+}
+
 func (suite *BlogUsecaseTestSuite) TestFetchBlogsByFilter_Success() {
 	ctx := context.Background()
 	filter := domain.BlogFilter{TitleContains: "Test"}
@@ -94,6 +237,33 @@ func (suite *BlogUsecaseTestSuite) TestFetchBlogsByFilter_Error() {
 	suite.mockRepo.AssertExpectations(suite.T())
 }
 
+func (suite *BlogUsecaseTestSuite) TestFetchBlogsByFilter_Sort() {
+	ctx := context.Background()
+	suite.mockRepo.On("FetchByFilter", ctx, domain.BlogFilter{Sort: domain.BlogSortTrending, Window: domain.TrendingDay}).Return([]*domain.Blog{}, nil)
+	_, err := suite.usecase.FetchBlogsByFilter(ctx, domain.BlogFilter{Sort: domain.BlogSortTrending})
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.usecase.FetchBlogsByFilter(ctx, domain.BlogFilter{Sort: domain.BlogSortTrending, Window: "1h"})
+	assert.EqualError(suite.T(), err, "window must be 24h or 7d")
+	_, err = suite.usecase.FetchBlogsByFilter(ctx, domain.BlogFilter{Sort: "likes"})
+	assert.EqualError(suite.T(), err, "sort must be newest, oldest or trending")
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *BlogUsecaseTestSuite) TestFetchTrendingBlogs() {
+	ctx := context.Background()
+	trending := []*domain.TrendingBlog{{Blog: &domain.Blog{ID: 1}, Score: 4.2}}
+	suite.mockRepo.On("FetchTrending", ctx, domain.TrendingWeek, 1, 10).Return(trending, int64(1), nil)
+
+	blogs, total, err := suite.usecase.FetchTrendingBlogs(ctx, domain.TrendingWeek, 0, 500)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), trending, blogs)
+	assert.Equal(suite.T(), int64(1), total)
+
+	_, _, err = suite.usecase.FetchTrendingBlogs(ctx, "30d", 1, 10)
+	assert.EqualError(suite.T(), err, "window must be 24h or 7d")
+}
+
 func (suite *BlogUsecaseTestSuite) TestUpdateBlog_Success() {
 	ctx := context.Background()
 	updates := map[string]interface{}{"Title": "New", "Content": "Body"}
@@ -102,6 +272,46 @@ func (suite *BlogUsecaseTestSuite) TestUpdateBlog_Success() {
 	assert.NoError(suite.T(), err)
 }
 
+// fakeIndexer reports the posts passed to it.
+type fakeIndexer struct {
+	indexed chan int64
+	removed chan int64
+}
+
+func (f *fakeIndexer) IndexBlog(ctx context.Context, blogID int64) error {
+	f.indexed <- blogID
+	return nil
+}
+func (f *fakeIndexer) RemoveBlog(ctx context.Context, blogID int64) error {
+	f.removed <- blogID
+	return nil
+}
+
+func (suite *BlogUsecaseTestSuite) TestUpdateAndDeleteBlog_UpdateIndex() {
+	ctx := context.Background()
+	indexer := &fakeIndexer{indexed: make(chan int64, 1), removed: make(chan int64, 1)}
+	suite.usecase = usecases.NewBlogUsecase(suite.mockRepo, suite.mockAI, suite.mockNotifier, indexer, nil, nil)
+	updates := map[string]interface{}{"Content": "Body"}
+	suite.mockRepo.On("UpdateByID", ctx, int64(1), "123", updates).Return(nil)
+	suite.mockRepo.On("DeleteByID", ctx, int64(1), "123").Return(nil)
+
+	assert.NoError(suite.T(), suite.usecase.UpdateBlog(ctx, 1, "123", updates))
+	select {
+	case id := <-indexer.indexed:
+		assert.Equal(suite.T(), int64(1), id)
+	case <-time.After(time.Second):
+		suite.T().Fatal("post was not reindexed")
+	}
+
+	assert.NoError(suite.T(), suite.usecase.DeleteBlog(ctx, 1, "123"))
+	select {
+	case id := <-indexer.removed:
+		assert.Equal(suite.T(), int64(1), id)
+	case <-time.After(time.Second):
+		suite.T().Fatal("post was not removed from the index")
+	}
+}
+
 func (suite *BlogUsecaseTestSuite) TestUpdateBlog_InvalidID() {
 	ctx := context.Background()
 	err := suite.usecase.UpdateBlog(ctx, 0, "123", map[string]interface{}{"Title": "X"})
@@ -116,13 +326,213 @@ func (suite *BlogUsecaseTestSuite) TestUpdateBlog_EmptyTitle() {
 
 func (suite *BlogUsecaseTestSuite) TestAddComment_Success() {
 	ctx := context.Background()
-	expected := &domain.Comment{ID: 1, BlogID: 10, UserID: 5, Content: "Nice!"}
-	suite.mockRepo.On("AddComment", ctx, int64(10), int64(5), "Nice!").Return(expected, nil)
+	expected := &domain.Comment{ID: 1, BlogID: 10, UserID: 5, Content: "Nice!", Status: domain.CommentPublished, Blog: domain.Blog{ID: 10, UserID: 8}}
+	suite.mockRepo.On("CreateComment", ctx, &domain.Comment{BlogID: 10, UserID: 5, Content: "Nice!", Status: domain.CommentPublished}).Return(expected, nil)
+	suite.mockNotifier.On("Notify", ctx, domain.NotificationEvent{
+		Type:        domain.NotificationComment,
+		ActorID:     5,
+		RecipientID: 8,
+		BlogID:      10,
+		CommentID:   1,
+	}).Return(nil)
+	c, err := suite.usecase.AddComment(ctx, 10, 5, "Nice!")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), expected, c)
+	suite.mockNotifier.AssertExpectations(suite.T())
+}
+
+func (suite *BlogUsecaseTestSuite) TestAddComment_NotificationFailureIsIgnored() {
+	ctx := context.Background()
+	expected := &domain.Comment{ID: 1, BlogID: 10, UserID: 5, Content: "Nice!", Status: domain.CommentPublished, Blog: domain.Blog{ID: 10, UserID: 8}}
+	suite.mockRepo.On("CreateComment", ctx, &domain.Comment{BlogID: 10, UserID: 5, Content: "Nice!", Status: domain.CommentPublished}).Return(expected, nil)
+	suite.mockNotifier.On("Notify", ctx, mock.Anything).Return(assert.AnError)
+
 	c, err := suite.usecase.AddComment(ctx, 10, 5, "Nice!")
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), expected, c)
 }
 
+func (suite *BlogUsecaseTestSuite) TestReplyToComment_NotifiesParentAndAuthor() {
+	ctx := context.Background()
+	parent := &domain.Comment{ID: 3, BlogID: 10, UserID: 6, Status: domain.CommentPublished}
+	reply := &domain.Comment{ID: 4, BlogID: 10, UserID: 5, Status: domain.CommentPublished, Blog: domain.Blog{ID: 10, UserID: 8}}
+	suite.mockRepo.On("FetchCommentByID", ctx, int64(3)).Return(parent, nil)
+	parentID := int64(3)
+	suite.mockRepo.On("CreateComment", ctx, &domain.Comment{BlogID: 10, UserID: 5, Content: "Agreed", ParentID: &parentID, Status: domain.CommentPublished}).Return(reply, nil)
+	suite.mockNotifier.On("Notify", ctx, domain.NotificationEvent{
+		Type: domain.NotificationReply, ActorID: 5, RecipientID: 6, BlogID: 10, CommentID: 4,
+	}).Return(nil)
+	suite.mockNotifier.On("Notify", ctx, domain.NotificationEvent{
+		Type: domain.NotificationComment, ActorID: 5, RecipientID: 8, BlogID: 10, CommentID: 4,
+	}).Return(nil)
+
+	c, err := suite.usecase.ReplyToComment(ctx, 10, 3, 5, "Agreed")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), reply, c)
+	suite.mockNotifier.AssertExpectations(suite.T())
+}
+
+func (suite *BlogUsecaseTestSuite) TestReplyToComment_ParentOnOtherBlog() {
+	ctx := context.Background()
+	suite.mockRepo.On("FetchCommentByID", ctx, int64(3)).Return(&domain.Comment{ID: 3, BlogID: 11}, nil)
+
+	_, err := suite.usecase.ReplyToComment(ctx, 10, 3, 5, "Agreed")
+	assert.EqualError(suite.T(), err, "comment not found")
+}
+
+func (suite *BlogUsecaseTestSuite) TestReplyToComment_ParentHeld() {
+	ctx := context.Background()
+	suite.mockRepo.On("FetchCommentByID", ctx, int64(3)).Return(&domain.Comment{ID: 3, BlogID: 10, Status: domain.CommentHeld}, nil)
+
+	_, err := suite.usecase.ReplyToComment(ctx, 10, 3, 5, "Agreed")
+	assert.EqualError(suite.T(), err, "comment not found")
+}
+
+// withModeration rebuilds the usecase with AI_MODERATION on and the given
+// fallback.
+func (suite *BlogUsecaseTestSuite) withModeration(fallback string) {
+	os.Setenv("AI_MODERATION", "true")
+	os.Setenv("AI_MODERATION_FALLBACK", fallback)
+	defer os.Unsetenv("AI_MODERATION")
+	defer os.Unsetenv("AI_MODERATION_FALLBACK")
+	suite.usecase = usecases.NewBlogUsecase(suite.mockRepo, suite.mockAI, suite.mockNotifier, nil, nil, nil)
+	suite.mockRepo.On("FetchByID", mock.Anything, int64(10)).Return(&domain.Blog{ID: 10, Title: "Go", Content: "About Go."}, nil)
+}
+
+func (suite *BlogUsecaseTestSuite) TestAddComment_ModerationHoldsFlagged() {
+	ctx := context.Background()
+	suite.withModeration("publish")
+	suite.mockAI.moderation = &domain.CommentModeration{Label: domain.ModerationSpam, Confidence: 0.9, Reason: "Advertises a shop."}
+	held := &domain.Comment{BlogID: 10, UserID: 5, Content: "Buy now!", Status: domain.CommentHeld,
+		ModerationLabel: domain.ModerationSpam, ModerationScore: 0.9, ModerationReason: "Advertises a shop."}
+	suite.mockRepo.On("CreateComment", ctx, held).Return(held, nil)
+
+	c, err := suite.usecase.AddComment(ctx, 10, 5, "Buy now!")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), domain.CommentHeld, c.Status)
+	suite.mockNotifier.AssertNotCalled(suite.T(), "Notify", mock.Anything, mock.Anything)
+}
+
+func (suite *BlogUsecaseTestSuite) TestAddComment_ModerationPublishesBelowThreshold() {
+	ctx := context.Background()
+	suite.withModeration("publish")
+	suite.mockAI.moderation = &domain.CommentModeration{Label: domain.ModerationOffTopic, Confidence: 0.4}
+	suite.mockRepo.On("CreateComment", ctx, mock.MatchedBy(func(c *domain.Comment) bool {
+		return c.Status == domain.CommentPublished && c.ModerationLabel == domain.ModerationOffTopic
+	})).Return(&domain.Comment{ID: 1, BlogID: 10, UserID: 5, Status: domain.CommentPublished, Blog: domain.Blog{ID: 10, UserID: 8}}, nil)
+	suite.mockNotifier.On("Notify", ctx, mock.Anything).Return(nil)
+
+	c, err := suite.usecase.AddComment(ctx, 10, 5, "Anyone watch the game?")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), domain.CommentPublished, c.Status)
+	suite.mockNotifier.AssertNumberOfCalls(suite.T(), "Notify", 1)
+}
+
+func (suite *BlogUsecaseTestSuite) TestAddComment_ModerationFallback() {
+	for fallback, status := range map[string]string{"publish": domain.CommentPublished, "hold": domain.CommentHeld} {
+		suite.Run(fallback, func() {
+			suite.SetupTest()
+			ctx := context.Background()
+			suite.withModeration(fallback)
+			suite.mockAI.moderationErr = errors.New("AI provider is unavailable")
+			suite.mockRepo.On("CreateComment", ctx, mock.MatchedBy(func(c *domain.Comment) bool {
+				return c.Status == status && c.ModerationLabel == ""
+			})).Return(&domain.Comment{ID: 1, BlogID: 10, UserID: 5, Status: status, Blog: domain.Blog{ID: 10, UserID: 8}}, nil)
+			suite.mockNotifier.On("Notify", ctx, mock.Anything).Return(nil)
+
+			c, err := suite.usecase.AddComment(ctx, 10, 5, "Nice!")
+			assert.NoError(suite.T(), err)
+			assert.Equal(suite.T(), status, c.Status)
+		})
+	}
+}
+
+func (suite *BlogUsecaseTestSuite) TestReviewComment_ApproveNotifies() {
+	ctx := context.Background()
+	approved := &domain.Comment{ID: 1, BlogID: 10, UserID: 5, Status: domain.CommentPublished, Blog: domain.Blog{ID: 10, UserID: 8}}
+	suite.mockRepo.On("ReviewComment", ctx, int64(1), domain.CommentPublished, int64(2), mock.Anything).Return(approved, nil)
+	suite.mockNotifier.On("Notify", ctx, domain.NotificationEvent{
+		Type: domain.NotificationComment, ActorID: 5, RecipientID: 8, BlogID: 10, CommentID: 1,
+	}).Return(nil)
+
+	c, err := suite.usecase.ReviewComment(ctx, 1, 2, true)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), approved, c)
+	suite.mockNotifier.AssertExpectations(suite.T())
+}
+
+func (suite *BlogUsecaseTestSuite) TestReviewComment_Reject() {
+	ctx := context.Background()
+	rejected := &domain.Comment{ID: 1, BlogID: 10, UserID: 5, Status: domain.CommentRejected}
+	suite.mockRepo.On("ReviewComment", ctx, int64(1), domain.CommentRejected, int64(2), mock.Anything).Return(rejected, nil)
+
+	_, err := suite.usecase.ReviewComment(ctx, 1, 2, false)
+	assert.NoError(suite.T(), err)
+	suite.mockNotifier.AssertNotCalled(suite.T(), "Notify", mock.Anything, mock.Anything)
+}
+
+func (suite *BlogUsecaseTestSuite) TestReviewComment_NotHeld() {
+	ctx := context.Background()
+	suite.mockRepo.On("ReviewComment", ctx, int64(1), domain.CommentPublished, int64(2), mock.Anything).Return(nil, nil)
+
+	_, err := suite.usecase.ReviewComment(ctx, 1, 2, true)
+	assert.EqualError(suite.T(), err, "held comment not found")
+}
+
+func (suite *BlogUsecaseTestSuite) TestLikeBlog_NotifiesAuthor() {
+	ctx := context.Background()
+	suite.mockRepo.On("AddLike", ctx, int64(10), int64(5)).Return(true, nil)
+	suite.mockRepo.On("GetBlogAuthorID", ctx, int64(10)).Return(int64(8), nil)
+	suite.mockNotifier.On("Notify", ctx, domain.NotificationEvent{
+		Type: domain.NotificationLike, ActorID: 5, RecipientID: 8, BlogID: 10,
+	}).Return(nil)
+
+	err := suite.usecase.LikeBlog(ctx, 10, 5)
+	assert.NoError(suite.T(), err)
+	suite.mockNotifier.AssertExpectations(suite.T())
+}
+
+func (suite *BlogUsecaseTestSuite) TestLikeBlog_AgainDoesNotNotify() {
+	ctx := context.Background()
+	suite.mockRepo.On("AddLike", ctx, int64(10), int64(5)).Return(false, nil)
+
+	assert.NoError(suite.T(), suite.usecase.LikeBlog(ctx, 10, 5))
+	suite.mockRepo.AssertNotCalled(suite.T(), "GetBlogAuthorID", mock.Anything, mock.Anything)
+	suite.mockNotifier.AssertNotCalled(suite.T(), "Notify", mock.Anything, mock.Anything)
+}
+
+// activityLog sums the activity recorded per post.
+type activityLog map[int64][2]int
+
+func (a activityLog) RecordActivity(blogID int64, likes, comments int) {
+	a[blogID] = [2]int{a[blogID][0] + likes, a[blogID][1] + comments}
+}
+
+func (suite *BlogUsecaseTestSuite) TestLikesAndCommentsAreRecorded() {
+	ctx := context.Background()
+	activity := activityLog{}
+	suite.usecase = usecases.NewBlogUsecase(suite.mockRepo, suite.mockAI, suite.mockNotifier, nil, nil, activity)
+	suite.mockRepo.On("AddLike", ctx, int64(10), int64(5)).Return(true, nil).Once()
+	suite.mockRepo.On("AddLike", ctx, int64(10), int64(5)).Return(false, nil).Once()
+	suite.mockRepo.On("RemoveLike", ctx, int64(10), int64(6)).Return(true, nil)
+	suite.mockRepo.On("RemoveLike", ctx, int64(10), int64(7)).Return(false, errors.New("db down"))
+	suite.mockRepo.On("RemoveLike", ctx, int64(10), int64(9)).Return(false, nil)
+	suite.mockRepo.On("GetBlogAuthorID", ctx, int64(10)).Return(int64(8), nil)
+	suite.mockNotifier.On("Notify", ctx, mock.Anything).Return(nil)
+	suite.mockRepo.On("CreateComment", ctx, mock.Anything).Return(&domain.Comment{ID: 1, BlogID: 10, UserID: 5, Status: domain.CommentPublished, Blog: domain.Blog{UserID: 8}}, nil)
+
+	suite.NoError(suite.usecase.LikeBlog(ctx, 10, 5))
+	suite.NoError(suite.usecase.LikeBlog(ctx, 10, 5))
+	suite.NoError(suite.usecase.UnlikeBlog(ctx, 10, 6))
+	suite.Error(suite.usecase.UnlikeBlog(ctx, 10, 7))
+	suite.NoError(suite.usecase.UnlikeBlog(ctx, 10, 9))
+	_, err := suite.usecase.AddComment(ctx, 10, 5, "Nice")
+	suite.NoError(err)
+
+	// the second like and the unlike without a like count for nothing
+	suite.Equal(activityLog{10: {0, 1}}, activity)
+}
+
 func (suite *BlogUsecaseTestSuite) TestAddComment_Validation() {
 	ctx := context.Background()
 	_, err := suite.usecase.AddComment(ctx, 0, 5, "Hi")
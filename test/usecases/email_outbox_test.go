@@ -0,0 +1,94 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/test/mocks"
+	"github.com/blog-platform/usecases"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type stubRenderer struct {
+	err error
+}
+
+func (r *stubRenderer) Render(name string, data map[string]string) (*domain.RenderedEmail, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &domain.RenderedEmail{Subject: name, HTML: "<p>" + data["Link"] + "</p>", Text: data["Link"]}, nil
+}
+
+type EmailOutboxWorkerTestSuite struct {
+	suite.Suite
+	outboxRepo *mocks.MockOutboxRepository
+	mailer     *mocks.MockEmailService
+	renderer   *stubRenderer
+	worker     *usecases.EmailOutboxWorker
+}
+
+func (suite *EmailOutboxWorkerTestSuite) SetupTest() {
+	suite.outboxRepo = new(mocks.MockOutboxRepository)
+	suite.mailer = new(mocks.MockEmailService)
+	suite.renderer = &stubRenderer{}
+	suite.worker = usecases.NewEmailOutboxWorker(suite.outboxRepo, suite.renderer, suite.mailer)
+}
+
+func (suite *EmailOutboxWorkerTestSuite) TestDeliverDue_SendsAndMarksSent() {
+	ctx := context.Background()
+	email := &domain.OutboxEmail{ID: 1, Recipients: "a@example.com,b@example.com", Template: domain.EmailActivation, Data: `{"Link":"http://x/activate"}`}
+	suite.outboxRepo.On("ClaimDue", ctx, mock.Anything, mock.Anything, mock.Anything).Return([]*domain.OutboxEmail{email}, nil)
+	suite.mailer.On("Send", &domain.EmailMessage{
+		To:      []string{"a@example.com", "b@example.com"},
+		Subject: domain.EmailActivation,
+		Text:    "http://x/activate",
+		HTML:    "<p>http://x/activate</p>",
+	}).Return(nil)
+	suite.outboxRepo.On("MarkSent", ctx, int64(1), mock.AnythingOfType("time.Time")).Return(nil)
+
+	sent, err := suite.worker.DeliverDue(ctx)
+	suite.NoError(err)
+	suite.Equal(1, sent)
+	suite.mailer.AssertExpectations(suite.T())
+	suite.outboxRepo.AssertExpectations(suite.T())
+}
+
+func (suite *EmailOutboxWorkerTestSuite) TestDeliverDue_FailureSchedulesRetryWithBackoff() {
+	ctx := context.Background()
+	email := &domain.OutboxEmail{ID: 2, Recipients: "a@example.com", Template: domain.EmailPasswordReset, Attempts: 2}
+	suite.outboxRepo.On("ClaimDue", ctx, mock.Anything, mock.Anything, mock.Anything).Return([]*domain.OutboxEmail{email}, nil)
+	suite.mailer.On("Send", mock.Anything).Return(errors.New("smtp down"))
+
+	before := time.Now()
+	suite.outboxRepo.On("MarkFailed", ctx, int64(2), 3, mock.MatchedBy(func(next time.Time) bool {
+		// the third attempt waits 30s doubled twice
+		return next.Sub(before) >= 2*time.Minute && next.Sub(before) < 3*time.Minute
+	}), "smtp down", false).Return(nil)
+
+	sent, err := suite.worker.DeliverDue(ctx)
+	suite.NoError(err)
+	suite.Equal(0, sent)
+	suite.outboxRepo.AssertExpectations(suite.T())
+}
+
+func (suite *EmailOutboxWorkerTestSuite) TestDeliverDue_DeadLettersAfterMaxAttempts() {
+	ctx := context.Background()
+	email := &domain.OutboxEmail{ID: 3, Recipients: "a@example.com", Template: "missing", Attempts: 7}
+	suite.renderer.err = errors.New("unknown email template 'missing'")
+	suite.outboxRepo.On("ClaimDue", ctx, mock.Anything, mock.Anything, mock.Anything).Return([]*domain.OutboxEmail{email}, nil)
+	suite.outboxRepo.On("MarkFailed", ctx, int64(3), 8, mock.Anything, "unknown email template 'missing'", true).Return(nil)
+
+	_, err := suite.worker.DeliverDue(ctx)
+	suite.NoError(err)
+	suite.outboxRepo.AssertExpectations(suite.T())
+	suite.mailer.AssertNotCalled(suite.T(), "Send", mock.Anything)
+}
+
+func TestEmailOutboxWorkerTestSuite(t *testing.T) {
+	suite.Run(t, new(EmailOutboxWorkerTestSuite))
+}
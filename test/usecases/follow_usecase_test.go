@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/test/mocks"
+	"github.com/blog-platform/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type FollowUsecaseTestSuite struct {
+	suite.Suite
+	followRepo *mocks.MockFollowRepo
+	userRepo   *mocks.MockUserRepository
+	notifier   *mocks.MockNotifier
+	usecase    domain.IFollowUsecase
+}
+
+func (suite *FollowUsecaseTestSuite) SetupTest() {
+	suite.followRepo = new(mocks.MockFollowRepo)
+	suite.userRepo = new(mocks.MockUserRepository)
+	suite.notifier = new(mocks.MockNotifier)
+	suite.usecase = usecases.NewFollowUsecase(suite.followRepo, suite.userRepo, suite.notifier)
+}
+
+func (suite *FollowUsecaseTestSuite) TestFollow_Success() {
+	ctx := context.Background()
+	suite.userRepo.On("GetUserProfile", int64(2)).Return(&domain.User{ID: 2}, nil)
+	suite.followRepo.On("IsFollowing", ctx, int64(1), int64(2)).Return(false, nil)
+	suite.followRepo.On("Follow", ctx, int64(1), int64(2)).Return(nil)
+	suite.notifier.On("Notify", ctx, domain.NotificationEvent{
+		Type:        domain.NotificationFollow,
+		ActorID:     1,
+		RecipientID: 2,
+	}).Return(nil)
+
+	err := suite.usecase.Follow(ctx, 1, 2)
+	assert.NoError(suite.T(), err)
+	suite.followRepo.AssertExpectations(suite.T())
+	suite.notifier.AssertExpectations(suite.T())
+}
+
+func (suite *FollowUsecaseTestSuite) TestFollow_AlreadyFollowingDoesNotNotify() {
+	ctx := context.Background()
+	suite.userRepo.On("GetUserProfile", int64(2)).Return(&domain.User{ID: 2}, nil)
+	suite.followRepo.On("IsFollowing", ctx, int64(1), int64(2)).Return(true, nil)
+
+	err := suite.usecase.Follow(ctx, 1, 2)
+	assert.NoError(suite.T(), err)
+	suite.notifier.AssertNotCalled(suite.T(), "Notify", mock.Anything, mock.Anything)
+}
+
+func (suite *FollowUsecaseTestSuite) TestFollow_Self() {
+	err := suite.usecase.Follow(context.Background(), 1, 1)
+	assert.EqualError(suite.T(), err, "you cannot follow yourself")
+}
+
+func (suite *FollowUsecaseTestSuite) TestFollow_UserNotFound() {
+	suite.userRepo.On("GetUserProfile", int64(9)).Return(nil, nil)
+
+	err := suite.usecase.Follow(context.Background(), 1, 9)
+	assert.EqualError(suite.T(), err, "user not found")
+	suite.followRepo.AssertNotCalled(suite.T(), "Follow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *FollowUsecaseTestSuite) TestGetFollowers_DefaultsPaging() {
+	ctx := context.Background()
+	users := []*domain.PublicUser{{ID: 3}}
+	suite.followRepo.On("ListFollowers", ctx, int64(1), 1, 10).Return(users, int64(1), nil)
+
+	got, total, err := suite.usecase.GetFollowers(ctx, 1, 0, 0)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), total)
+	assert.Equal(suite.T(), users, got)
+}
+
+func (suite *FollowUsecaseTestSuite) TestGetFeed_ReturnsNextCursorOnFullPage() {
+	ctx := context.Background()
+	created := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	page := []*domain.Blog{
+		{ID: 7, CreatedAt: created.Add(time.Minute)},
+		{ID: 5, CreatedAt: created},
+	}
+	suite.followRepo.On("FetchFeed", ctx, int64(1), (*domain.FeedCursor)(nil), 2).Return(page, nil)
+
+	blogs, next, err := suite.usecase.GetFeed(ctx, 1, "", 2)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), page, blogs)
+	assert.NotEmpty(suite.T(), next)
+
+	// the returned cursor must round-trip to the last post of the page
+	expected := &domain.FeedCursor{CreatedAt: created, ID: 5}
+	suite.followRepo.On("FetchFeed", ctx, int64(1), expected, 2).Return([]*domain.Blog{}, nil)
+
+	blogs, next, err = suite.usecase.GetFeed(ctx, 1, next, 2)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), blogs)
+	assert.Empty(suite.T(), next)
+	suite.followRepo.AssertExpectations(suite.T())
+}
+
+func (suite *FollowUsecaseTestSuite) TestGetFeed_InvalidCursor() {
+	_, _, err := suite.usecase.GetFeed(context.Background(), 1, "not-a-cursor", 10)
+	assert.EqualError(suite.T(), err, "invalid cursor")
+}
+
+func TestFollowUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(FollowUsecaseTestSuite))
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+func (suite *UserUsecaseTestSuite) TestRequestMagicLink_Success() {
+	user := domain.User{ID: 1, Username: "testuser", Email: "test@example.com"}
+	suite.userRepo.On("FetchByEmail", "test@example.com").Return(user, nil)
+	suite.userRepo.On("CountMagicLinks", int64(1), mock.Anything).Return(int64(0), nil)
+	suite.userRepo.On("CreateMagicLink", mock.MatchedBy(func(l *domain.MagicLinkToken) bool {
+		return l.UserID == 1 && len(l.TokenHash) == 64 && l.RequestIP == "10.0.0.1" && l.ExpiresAt.After(time.Now())
+	}), mock.MatchedBy(func(e *domain.OutboxEmail) bool {
+		return e.Template == domain.EmailMagicLink && e.Recipients == "test@example.com"
+	})).Return(nil)
+
+	err := suite.userUsecase.RequestMagicLink("test@example.com", "10.0.0.1")
+	suite.NoError(err)
+	suite.userRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserUsecaseTestSuite) TestRequestMagicLink_LinksToClientPage() {
+	suite.T().Setenv("MAGIC_LINK_URL", "https://app.example.com/magic?lang=en")
+	suite.userRepo.On("FetchByEmail", "test@example.com").Return(domain.User{ID: 1, Email: "test@example.com"}, nil)
+	suite.userRepo.On("CountMagicLinks", int64(1), mock.Anything).Return(int64(0), nil)
+	var link string
+	suite.userRepo.On("CreateMagicLink", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		var data map[string]string
+		suite.Require().NoError(json.Unmarshal([]byte(args.Get(1).(*domain.OutboxEmail).Data), &data))
+		link = data["Link"]
+	}).Return(nil)
+
+	suite.NoError(suite.userUsecase.RequestMagicLink("test@example.com", "10.0.0.1"))
+	suite.Regexp(`^https://app\.example\.com/magic\?lang=en&token=[^&]+$`, link)
+}
+
+func (suite *UserUsecaseTestSuite) TestRequestMagicLink_UnknownEmailIsSilent() {
+	suite.userRepo.On("FetchByEmail", "nobody@example.com").Return(domain.User{}, errors.New("not found"))
+
+	err := suite.userUsecase.RequestMagicLink("nobody@example.com", "10.0.0.1")
+	suite.NoError(err)
+	suite.userRepo.AssertNotCalled(suite.T(), "CreateMagicLink", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestRequestMagicLink_PerAccountLimit() {
+	suite.userRepo.On("FetchByEmail", "test@example.com").Return(domain.User{ID: 1, Email: "test@example.com"}, nil)
+	suite.userRepo.On("CountMagicLinks", int64(1), mock.Anything).Return(int64(3), nil)
+
+	suite.NoError(suite.userUsecase.RequestMagicLink("test@example.com", "10.0.0.1"))
+	suite.userRepo.AssertNotCalled(suite.T(), "CreateMagicLink", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestConsumeMagicLink_Success() {
+	suite.userRepo.On("UseMagicLink", mock.AnythingOfType("string"), mock.Anything).Return(domain.MagicLinkToken{ID: 2, UserID: 1}, nil)
+	suite.userRepo.On("Fetch", "1").Return(domain.User{ID: 1, Role: "user"}, nil)
+	suite.jwtService.On("GenerateAccessToken", "1", "user").Return("access_token", nil)
+	suite.jwtService.On("GenerateRefreshToken", "1", "user").Return("refresh_token", nil)
+	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(nil).Twice()
+
+	result, err := suite.userUsecase.ConsumeMagicLink("token", "10.0.0.1")
+	suite.NoError(err)
+	suite.Equal("access_token", result.AccessToken)
+	suite.loginRepo.AssertCalled(suite.T(), "Record", mock.MatchedBy(func(a *domain.LoginAttempt) bool {
+		return a.Success && a.UserID != nil && *a.UserID == 1
+	}))
+}
+
+func (suite *UserUsecaseTestSuite) TestConsumeMagicLink_TOTPEnabledReturnsChallenge() {
+	suite.userRepo.On("UseMagicLink", mock.AnythingOfType("string"), mock.Anything).Return(domain.MagicLinkToken{UserID: 1}, nil)
+	suite.userRepo.On("Fetch", "1").Return(domain.User{ID: 1, TOTPEnabled: true}, nil)
+	suite.jwtService.On("GenerateMFAToken", "1").Return("mfa_token", nil)
+
+	result, err := suite.userUsecase.ConsumeMagicLink("token", "10.0.0.1")
+	suite.NoError(err)
+	suite.Equal("mfa_token", result.MFAToken)
+}
+
+func (suite *UserUsecaseTestSuite) TestConsumeMagicLink_Invalid() {
+	suite.userRepo.On("UseMagicLink", mock.AnythingOfType("string"), mock.Anything).Return(domain.MagicLinkToken{}, errors.New("magic link not found"))
+
+	_, err := suite.userUsecase.ConsumeMagicLink("used", "10.0.0.1")
+	suite.EqualError(err, "invalid or expired link")
+	suite.loginRepo.AssertCalled(suite.T(), "Record", mock.MatchedBy(func(a *domain.LoginAttempt) bool {
+		return !a.Success && a.UserID == nil
+	}))
+}
+
+func (suite *UserUsecaseTestSuite) TestConsumeMagicLink_LockedAccount() {
+	until := time.Now().Add(time.Hour)
+	suite.userRepo.On("UseMagicLink", mock.AnythingOfType("string"), mock.Anything).Return(domain.MagicLinkToken{UserID: 1}, nil)
+	suite.userRepo.On("Fetch", "1").Return(domain.User{ID: 1, LockedUntil: &until}, nil)
+
+	_, err := suite.userUsecase.ConsumeMagicLink("token", "10.0.0.1")
+	suite.EqualError(err, "invalid or expired link")
+}
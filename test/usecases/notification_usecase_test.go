@@ -0,0 +1,94 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/test/mocks"
+	"github.com/blog-platform/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type NotificationUsecaseTestSuite struct {
+	suite.Suite
+	notificationRepo *mocks.MockNotificationRepo
+	followRepo       *mocks.MockFollowRepo
+	usecase          domain.INotificationUsecase
+}
+
+func (suite *NotificationUsecaseTestSuite) SetupTest() {
+	suite.notificationRepo = new(mocks.MockNotificationRepo)
+	suite.followRepo = new(mocks.MockFollowRepo)
+	suite.usecase = usecases.NewNotificationUsecase(suite.notificationRepo, suite.followRepo)
+}
+
+func (suite *NotificationUsecaseTestSuite) TestNotify_SingleRecipient() {
+	ctx := context.Background()
+	blogID, commentID := int64(10), int64(4)
+	suite.notificationRepo.On("DisabledRecipients", ctx, domain.NotificationComment, []int64{8}).Return([]int64{}, nil)
+	suite.notificationRepo.On("CreateMany", ctx, []*domain.Notification{
+		{UserID: 8, ActorID: 5, Type: domain.NotificationComment, BlogID: &blogID, CommentID: &commentID},
+	}).Return(nil)
+
+	err := suite.usecase.Notify(ctx, domain.NotificationEvent{
+		Type: domain.NotificationComment, ActorID: 5, RecipientID: 8, BlogID: 10, CommentID: 4,
+	})
+	assert.NoError(suite.T(), err)
+	suite.notificationRepo.AssertExpectations(suite.T())
+}
+
+func (suite *NotificationUsecaseTestSuite) TestNotify_SkipsSelf() {
+	err := suite.usecase.Notify(context.Background(), domain.NotificationEvent{
+		Type: domain.NotificationLike, ActorID: 5, RecipientID: 5, BlogID: 10,
+	})
+	assert.NoError(suite.T(), err)
+	suite.notificationRepo.AssertNotCalled(suite.T(), "CreateMany", mock.Anything, mock.Anything)
+}
+
+func (suite *NotificationUsecaseTestSuite) TestNotify_NewPostFansOutToFollowersRespectingPreferences() {
+	ctx := context.Background()
+	blogID := int64(10)
+	suite.followRepo.On("ListFollowerIDs", ctx, int64(5)).Return([]int64{1, 2, 3}, nil)
+	suite.notificationRepo.On("DisabledRecipients", ctx, domain.NotificationNewPost, []int64{1, 2, 3}).Return([]int64{2}, nil)
+	suite.notificationRepo.On("CreateMany", ctx, []*domain.Notification{
+		{UserID: 1, ActorID: 5, Type: domain.NotificationNewPost, BlogID: &blogID},
+		{UserID: 3, ActorID: 5, Type: domain.NotificationNewPost, BlogID: &blogID},
+	}).Return(nil)
+
+	err := suite.usecase.Notify(ctx, domain.NotificationEvent{
+		Type: domain.NotificationNewPost, ActorID: 5, BlogID: 10,
+	})
+	assert.NoError(suite.T(), err)
+	suite.notificationRepo.AssertExpectations(suite.T())
+}
+
+func (suite *NotificationUsecaseTestSuite) TestNotify_UnknownType() {
+	err := suite.usecase.Notify(context.Background(), domain.NotificationEvent{Type: "poke", ActorID: 1, RecipientID: 2})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *NotificationUsecaseTestSuite) TestGetPreferences_DefaultsToEnabled() {
+	ctx := context.Background()
+	suite.notificationRepo.On("GetPreferences", ctx, int64(1)).Return([]domain.NotificationPreference{
+		{UserID: 1, Type: domain.NotificationLike, Enabled: false},
+	}, nil)
+
+	prefs, err := suite.usecase.GetPreferences(ctx, 1)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), prefs, len(domain.NotificationTypes))
+	assert.False(suite.T(), prefs[domain.NotificationLike])
+	assert.True(suite.T(), prefs[domain.NotificationComment])
+}
+
+func (suite *NotificationUsecaseTestSuite) TestUpdatePreferences_RejectsUnknownType() {
+	err := suite.usecase.UpdatePreferences(context.Background(), 1, map[string]bool{"poke": false})
+	assert.Error(suite.T(), err)
+	suite.notificationRepo.AssertNotCalled(suite.T(), "SetPreference", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestNotificationUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(NotificationUsecaseTestSuite))
+}
@@ -0,0 +1,224 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/test/mocks"
+	"github.com/blog-platform/usecases"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type OIDCUsecaseTestSuite struct {
+	suite.Suite
+	provider    *mocks.MockOIDCProvider
+	oidcRepo    *mocks.MockOIDCRepository
+	userRepo    *mocks.MockUserRepository
+	jwtService  *mocks.MockJWTService
+	tokenRepo   *mocks.MockTokenRepository
+	oidcUsecase domain.IOIDCUsecase
+	ctx         context.Context
+}
+
+func (suite *OIDCUsecaseTestSuite) SetupTest() {
+	suite.provider = new(mocks.MockOIDCProvider)
+	suite.oidcRepo = new(mocks.MockOIDCRepository)
+	suite.userRepo = new(mocks.MockUserRepository)
+	suite.jwtService = new(mocks.MockJWTService)
+	suite.tokenRepo = new(mocks.MockTokenRepository)
+	loginRepo := new(mocks.MockLoginAttemptRepository)
+	loginRepo.On("Record", mock.Anything).Return(nil).Maybe()
+
+	uu := usecases.NewUserUsecase(suite.userRepo, new(mocks.MockOutboxRepository), loginRepo, new(mocks.MockPasswordService), suite.jwtService, suite.tokenRepo, new(mocks.MockTOTPService), infrastructure.DefaultPasswordPolicy())
+	suite.oidcUsecase = usecases.NewOIDCUsecase(map[string]domain.IOIDCProvider{"corp": suite.provider}, suite.oidcRepo, suite.userRepo, uu)
+	suite.ctx = context.Background()
+}
+
+func (suite *OIDCUsecaseTestSuite) expectTokens(userID string) {
+	suite.jwtService.On("GenerateAccessToken", userID, "user").Return("access_token", nil)
+	suite.jwtService.On("GenerateRefreshToken", userID, "user").Return("refresh_token", nil)
+	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(nil).Twice()
+}
+
+func (suite *OIDCUsecaseTestSuite) expectState() *domain.OIDCLoginState {
+	state := &domain.OIDCLoginState{Provider: "corp", Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: time.Now().Add(time.Minute)}
+	suite.oidcRepo.On("ConsumeLoginState", suite.ctx, mock.AnythingOfType("string")).Return(state, nil)
+	return state
+}
+
+func (suite *OIDCUsecaseTestSuite) TestStartLogin() {
+	var saved *domain.OIDCLoginState
+	suite.oidcRepo.On("SaveLoginState", suite.ctx, mock.AnythingOfType("*domain.OIDCLoginState")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*domain.OIDCLoginState) }).
+		Return(nil)
+	suite.provider.On("AuthCodeURL", suite.ctx, mock.Anything, mock.Anything, mock.Anything).
+		Return("https://idp.example.com/authorize", nil)
+
+	u, err := suite.oidcUsecase.StartLogin(suite.ctx, "corp")
+	suite.NoError(err)
+	suite.Equal("https://idp.example.com/authorize", u)
+
+	call := suite.provider.Calls[0]
+	state, nonce, challenge := call.Arguments.String(1), call.Arguments.String(2), call.Arguments.String(3)
+	stateSum := sha256.Sum256([]byte(state))
+	suite.Equal(hex.EncodeToString(stateSum[:]), saved.StateHash, "only the hash of the state is stored")
+	suite.Equal(nonce, saved.Nonce)
+	verifierSum := sha256.Sum256([]byte(saved.CodeVerifier))
+	suite.Equal(base64.RawURLEncoding.EncodeToString(verifierSum[:]), challenge)
+	suite.Equal("corp", saved.Provider)
+	suite.True(saved.ExpiresAt.After(time.Now()))
+}
+
+func (suite *OIDCUsecaseTestSuite) TestStartLogin_UnknownProvider() {
+	_, err := suite.oidcUsecase.StartLogin(suite.ctx, "other")
+	suite.EqualError(err, "unknown identity provider")
+}
+
+func (suite *OIDCUsecaseTestSuite) TestFinishLogin_LinkedIdentity() {
+	suite.expectState()
+	suite.provider.On("Exchange", suite.ctx, "code", "verifier", "nonce").Return(&domain.OIDCIdentity{Subject: "sub-1"}, nil)
+	suite.oidcRepo.On("FindIdentity", suite.ctx, "corp", "sub-1").Return(&domain.ExternalIdentity{UserID: 7}, nil)
+	suite.userRepo.On("Fetch", "7").Return(domain.User{ID: 7, Role: "user"}, nil)
+	suite.expectTokens("7")
+
+	result, err := suite.oidcUsecase.FinishLogin(suite.ctx, "corp", "state", "code", "10.0.0.1")
+	suite.NoError(err)
+	suite.Equal("access_token", result.AccessToken)
+}
+
+func (suite *OIDCUsecaseTestSuite) TestFinishLogin_LinksByVerifiedEmail() {
+	suite.expectState()
+	suite.provider.On("Exchange", suite.ctx, "code", "verifier", "nonce").
+		Return(&domain.OIDCIdentity{Subject: "sub-1", Email: "a@example.com", EmailVerified: true}, nil)
+	suite.oidcRepo.On("FindIdentity", suite.ctx, "corp", "sub-1").Return(nil, nil)
+	suite.userRepo.On("FetchByEmail", "a@example.com").Return(domain.User{ID: 3, Role: "user", Status: "active"}, nil)
+	suite.oidcRepo.On("CreateIdentity", suite.ctx, mock.MatchedBy(func(i *domain.ExternalIdentity) bool {
+		return i.UserID == 3 && i.Provider == "corp" && i.Subject == "sub-1"
+	})).Return(nil)
+	suite.expectTokens("3")
+
+	_, err := suite.oidcUsecase.FinishLogin(suite.ctx, "corp", "state", "code", "10.0.0.1")
+	suite.NoError(err)
+}
+
+func (suite *OIDCUsecaseTestSuite) TestFinishLogin_DoesNotLinkInactiveAccount() {
+	suite.expectState()
+	suite.provider.On("Exchange", suite.ctx, "code", "verifier", "nonce").
+		Return(&domain.OIDCIdentity{Subject: "sub-1", Email: "a@example.com", EmailVerified: true}, nil)
+	suite.oidcRepo.On("FindIdentity", suite.ctx, "corp", "sub-1").Return(nil, nil)
+	suite.userRepo.On("FetchByEmail", "a@example.com").Return(domain.User{ID: 3, Role: "user", Status: "inactive"}, nil)
+
+	_, err := suite.oidcUsecase.FinishLogin(suite.ctx, "corp", "state", "code", "10.0.0.1")
+	suite.EqualError(err, "an account with this email already exists; sign in to it and link the identity provider from your account")
+	suite.oidcRepo.AssertNotCalled(suite.T(), "CreateIdentity", mock.Anything, mock.Anything)
+	suite.oidcRepo.AssertNotCalled(suite.T(), "CreateUserWithIdentity", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OIDCUsecaseTestSuite) TestStartLink_StoresUser() {
+	var saved *domain.OIDCLoginState
+	suite.oidcRepo.On("SaveLoginState", suite.ctx, mock.AnythingOfType("*domain.OIDCLoginState")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*domain.OIDCLoginState) }).
+		Return(nil)
+	suite.provider.On("AuthCodeURL", suite.ctx, mock.Anything, mock.Anything, mock.Anything).
+		Return("https://idp.example.com/authorize", nil)
+
+	_, err := suite.oidcUsecase.StartLink(suite.ctx, "corp", 5)
+	suite.NoError(err)
+	suite.Equal(int64(5), saved.UserID)
+}
+
+func (suite *OIDCUsecaseTestSuite) TestFinishLogin_LinksToSignedInUser() {
+	state := suite.expectState()
+	state.UserID = 5
+	suite.provider.On("Exchange", suite.ctx, "code", "verifier", "nonce").
+		Return(&domain.OIDCIdentity{Subject: "sub-1", Email: "other@example.com"}, nil)
+	suite.userRepo.On("Fetch", "5").Return(domain.User{ID: 5, Role: "user", Status: "active"}, nil)
+	suite.oidcRepo.On("FindIdentity", suite.ctx, "corp", "sub-1").Return(nil, nil)
+	suite.oidcRepo.On("CreateIdentity", suite.ctx, mock.MatchedBy(func(i *domain.ExternalIdentity) bool {
+		return i.UserID == 5 && i.Provider == "corp" && i.Subject == "sub-1"
+	})).Return(nil)
+	suite.expectTokens("5")
+
+	_, err := suite.oidcUsecase.FinishLogin(suite.ctx, "corp", "state", "code", "10.0.0.1")
+	suite.NoError(err)
+	suite.userRepo.AssertNotCalled(suite.T(), "FetchByEmail", mock.Anything)
+}
+
+func (suite *OIDCUsecaseTestSuite) TestFinishLogin_LinkRejectsIdentityOfAnotherUser() {
+	state := suite.expectState()
+	state.UserID = 5
+	suite.provider.On("Exchange", suite.ctx, "code", "verifier", "nonce").Return(&domain.OIDCIdentity{Subject: "sub-1"}, nil)
+	suite.userRepo.On("Fetch", "5").Return(domain.User{ID: 5, Role: "user", Status: "active"}, nil)
+	suite.oidcRepo.On("FindIdentity", suite.ctx, "corp", "sub-1").Return(&domain.ExternalIdentity{UserID: 7}, nil)
+
+	_, err := suite.oidcUsecase.FinishLogin(suite.ctx, "corp", "state", "code", "10.0.0.1")
+	suite.EqualError(err, "identity is linked to another account")
+	suite.oidcRepo.AssertNotCalled(suite.T(), "CreateIdentity", mock.Anything, mock.Anything)
+}
+
+func (suite *OIDCUsecaseTestSuite) TestFinishLogin_UnverifiedEmail() {
+	suite.expectState()
+	suite.provider.On("Exchange", suite.ctx, "code", "verifier", "nonce").
+		Return(&domain.OIDCIdentity{Subject: "sub-1", Email: "a@example.com"}, nil)
+	suite.oidcRepo.On("FindIdentity", suite.ctx, "corp", "sub-1").Return(nil, nil)
+
+	_, err := suite.oidcUsecase.FinishLogin(suite.ctx, "corp", "state", "code", "10.0.0.1")
+	suite.EqualError(err, "identity provider did not return a verified email")
+	suite.userRepo.AssertNotCalled(suite.T(), "FetchByEmail", mock.Anything)
+}
+
+func (suite *OIDCUsecaseTestSuite) TestFinishLogin_ProvisionsUser() {
+	suite.expectState()
+	suite.provider.On("Exchange", suite.ctx, "code", "verifier", "nonce").
+		Return(&domain.OIDCIdentity{Subject: "sub-1", Email: "jane@example.com", EmailVerified: true, PreferredUsername: "Jane Doe"}, nil)
+	suite.oidcRepo.On("FindIdentity", suite.ctx, "corp", "sub-1").Return(nil, nil)
+	suite.userRepo.On("FetchByEmail", "jane@example.com").Return(domain.User{}, errors.New("not found"))
+	suite.userRepo.On("FetchByUsername", "janedoe").Return(domain.User{ID: 1}, nil)
+	suite.userRepo.On("FetchByUsername", "janedoe2").Return(domain.User{}, errors.New("not found"))
+	suite.oidcRepo.On("CreateUserWithIdentity", suite.ctx, mock.MatchedBy(func(u *domain.User) bool {
+		return u.Username == "janedoe2" && u.Role == "user" && u.Status == "active" && u.Password == ""
+	}), mock.AnythingOfType("*domain.ExternalIdentity")).
+		Run(func(args mock.Arguments) { args.Get(1).(*domain.User).ID = 9 }).
+		Return(nil)
+	suite.expectTokens("9")
+
+	_, err := suite.oidcUsecase.FinishLogin(suite.ctx, "corp", "state", "code", "10.0.0.1")
+	suite.NoError(err)
+}
+
+func (suite *OIDCUsecaseTestSuite) TestFinishLogin_ExpiredState() {
+	state := &domain.OIDCLoginState{Provider: "corp", ExpiresAt: time.Now().Add(-time.Second)}
+	suite.oidcRepo.On("ConsumeLoginState", suite.ctx, mock.AnythingOfType("string")).Return(state, nil)
+
+	_, err := suite.oidcUsecase.FinishLogin(suite.ctx, "corp", "state", "code", "10.0.0.1")
+	suite.EqualError(err, "invalid or expired login state")
+	suite.provider.AssertNotCalled(suite.T(), "Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OIDCUsecaseTestSuite) TestFinishLogin_StateForOtherProvider() {
+	state := &domain.OIDCLoginState{Provider: "other", ExpiresAt: time.Now().Add(time.Minute)}
+	suite.oidcRepo.On("ConsumeLoginState", suite.ctx, mock.AnythingOfType("string")).Return(state, nil)
+
+	_, err := suite.oidcUsecase.FinishLogin(suite.ctx, "corp", "state", "code", "10.0.0.1")
+	suite.EqualError(err, "invalid or expired login state")
+}
+
+func (suite *OIDCUsecaseTestSuite) TestFinishLogin_ExchangeFails() {
+	suite.expectState()
+	suite.provider.On("Exchange", suite.ctx, "code", "verifier", "nonce").Return(nil, errors.New("nonce mismatch"))
+
+	_, err := suite.oidcUsecase.FinishLogin(suite.ctx, "corp", "state", "code", "10.0.0.1")
+	suite.EqualError(err, "identity provider login failed")
+}
+
+func TestOIDCUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCUsecaseTestSuite))
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/test/mocks"
+	"github.com/blog-platform/usecases"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type PersonalAccessTokenTestSuite struct {
+	suite.Suite
+	tokenRepo *mocks.MockPersonalAccessTokenRepository
+	userRepo  *mocks.MockUserRepository
+	usecase   domain.IPersonalAccessTokenUsecase
+	ctx       context.Context
+}
+
+func (suite *PersonalAccessTokenTestSuite) SetupTest() {
+	suite.tokenRepo = new(mocks.MockPersonalAccessTokenRepository)
+	suite.userRepo = new(mocks.MockUserRepository)
+	suite.usecase = usecases.NewPersonalAccessTokenUsecase(suite.tokenRepo, suite.userRepo)
+	suite.ctx = context.Background()
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func (suite *PersonalAccessTokenTestSuite) TestCreate_Success() {
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(&domain.User{ID: 1, Role: "user"}, nil)
+	suite.tokenRepo.On("CountActive", suite.ctx, int64(1)).Return(int64(0), nil)
+	var stored *domain.PersonalAccessToken
+	suite.tokenRepo.On("Create", suite.ctx, mock.AnythingOfType("*domain.PersonalAccessToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*domain.PersonalAccessToken) }).
+		Return(nil)
+
+	token, secret, err := suite.usecase.Create(suite.ctx, 1, " release notes ", []string{"write", "READ", "write"}, nil)
+	suite.Require().NoError(err)
+	suite.True(strings.HasPrefix(secret, domain.PersonalAccessTokenPrefix))
+	suite.Equal(sha256Hex(secret), stored.TokenHash, "only the hash is stored")
+	suite.True(strings.HasPrefix(secret, token.Prefix))
+	suite.Equal("release notes", token.Name)
+	suite.Equal("write,read", token.Scopes)
+}
+
+func (suite *PersonalAccessTokenTestSuite) TestCreate_AdminScopeNeedsAdmin() {
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(&domain.User{ID: 1, Role: "user"}, nil)
+
+	_, _, err := suite.usecase.Create(suite.ctx, 1, "ci", []string{"admin"}, nil)
+	suite.EqualError(err, "only admins can create tokens with the admin scope")
+}
+
+func (suite *PersonalAccessTokenTestSuite) TestCreate_InvalidInput() {
+	past := time.Now().Add(-time.Hour)
+	_, _, err := suite.usecase.Create(suite.ctx, 1, "ci", []string{"read"}, &past)
+	suite.EqualError(err, "expiry must be in the future")
+
+	_, _, err = suite.usecase.Create(suite.ctx, 1, "  ", []string{"read"}, nil)
+	suite.Error(err)
+
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(&domain.User{ID: 1, Role: "user"}, nil)
+	_, _, err = suite.usecase.Create(suite.ctx, 1, "ci", []string{"delete"}, nil)
+	suite.EqualError(err, "unknown scope 'delete'")
+}
+
+func (suite *PersonalAccessTokenTestSuite) TestAuthenticate_Success() {
+	stored := &domain.PersonalAccessToken{ID: 3, UserID: 1, Scopes: "read,write"}
+	suite.tokenRepo.On("FetchByHash", suite.ctx, sha256Hex("bpat_abc")).Return(stored, nil)
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(&domain.User{ID: 1, Role: "admin"}, nil)
+	suite.tokenRepo.On("TouchLastUsed", suite.ctx, int64(3), mock.Anything, "10.0.0.1").Return(nil)
+
+	claims, scopes, err := suite.usecase.Authenticate(suite.ctx, "bpat_abc", "10.0.0.1")
+	suite.Require().NoError(err)
+	suite.Equal("1", claims.UserID)
+	suite.Equal("admin", claims.UserRole)
+	suite.Equal([]string{"read", "write"}, scopes)
+}
+
+func (suite *PersonalAccessTokenTestSuite) TestAuthenticate_RecentUseNotRewritten() {
+	recent := time.Now().Add(-10 * time.Second)
+	stored := &domain.PersonalAccessToken{ID: 3, UserID: 1, Scopes: "read", LastUsedAt: &recent, LastUsedIP: "10.0.0.1"}
+	suite.tokenRepo.On("FetchByHash", suite.ctx, sha256Hex("bpat_abc")).Return(stored, nil)
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(&domain.User{ID: 1, Role: "user"}, nil)
+
+	_, _, err := suite.usecase.Authenticate(suite.ctx, "bpat_abc", "10.0.0.1")
+	suite.NoError(err)
+	suite.tokenRepo.AssertNotCalled(suite.T(), "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PersonalAccessTokenTestSuite) TestAuthenticate_RevokedOrExpired() {
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	suite.tokenRepo.On("FetchByHash", suite.ctx, sha256Hex("bpat_revoked")).Return(&domain.PersonalAccessToken{RevokedAt: &now}, nil)
+	suite.tokenRepo.On("FetchByHash", suite.ctx, sha256Hex("bpat_expired")).Return(&domain.PersonalAccessToken{ExpiresAt: &expired}, nil)
+	suite.tokenRepo.On("FetchByHash", suite.ctx, sha256Hex("bpat_unknown")).Return(nil, nil)
+
+	for _, token := range []string{"bpat_revoked", "bpat_expired", "bpat_unknown", "not-a-pat"} {
+		_, _, err := suite.usecase.Authenticate(suite.ctx, token, "10.0.0.1")
+		suite.EqualError(err, "invalid token", token)
+	}
+}
+
+func (suite *PersonalAccessTokenTestSuite) TestRevoke_NotFound() {
+	suite.tokenRepo.On("Revoke", suite.ctx, int64(1), int64(9), mock.Anything).Return(false, nil)
+	suite.EqualError(suite.usecase.Revoke(suite.ctx, 1, 9), "token not found")
+}
+
+func TestPersonalAccessTokenTestSuite(t *testing.T) {
+	suite.Run(t, new(PersonalAccessTokenTestSuite))
+}
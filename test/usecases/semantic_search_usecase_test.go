@@ -0,0 +1,100 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/test/mocks"
+	"github.com/blog-platform/usecases"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type SemanticSearchUsecaseTestSuite struct {
+	suite.Suite
+	blogRepo      *mocks.MockBlogRepo
+	embeddingRepo *mocks.MockEmbeddingRepository
+	usecase       domain.ISemanticSearchUsecase
+	ctx           context.Context
+	blogs         map[int64]*domain.Blog
+}
+
+func (suite *SemanticSearchUsecaseTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.blogRepo = new(mocks.MockBlogRepo)
+	suite.embeddingRepo = new(mocks.MockEmbeddingRepository)
+	suite.embeddingRepo.On("FetchEmbeddings", mock.Anything).Return([]*domain.BlogEmbedding{}, nil)
+	suite.embeddingRepo.On("SaveEmbedding", mock.Anything, mock.Anything).Return(nil)
+	embedder := infrastructure.NewLocalEmbeddingProvider(infrastructure.LocalEmbeddingDimensions)
+	suite.usecase = usecases.NewSemanticSearchUsecase(suite.blogRepo, embedder, infrastructure.NewMemoryVectorIndex(suite.embeddingRepo))
+
+	suite.blogs = map[int64]*domain.Blog{
+		1: {ID: 1, Title: "Sourdough at home", Content: "Baking bread with a starter, flour and water."},
+		2: {ID: 2, Title: "Goroutines", Content: "Concurrency in Go with goroutines and channels."},
+		3: {ID: 3, Title: "Select", Content: "Waiting on several channels in Go with select."},
+	}
+	for id, blog := range suite.blogs {
+		suite.blogRepo.On("FetchByID", mock.Anything, id).Return(blog, nil)
+	}
+	suite.blogRepo.On("FetchByID", mock.Anything, mock.Anything).Return(nil, errors.New("record not found"))
+}
+
+func (suite *SemanticSearchUsecaseTestSuite) reindex() {
+	blogs := []*domain.Blog{suite.blogs[1], suite.blogs[2], suite.blogs[3]}
+	suite.blogRepo.On("FetchAll", mock.Anything).Return(blogs, nil)
+	indexed, err := suite.usecase.ReindexAll(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Equal(3, indexed)
+}
+
+func (suite *SemanticSearchUsecaseTestSuite) TestSemanticSearch() {
+	suite.reindex()
+
+	results, err := suite.usecase.SemanticSearch(suite.ctx, "channels in go", 2)
+	suite.NoError(err)
+	suite.Len(results, 2)
+	suite.ElementsMatch([]int64{2, 3}, []int64{results[0].Blog.ID, results[1].Blog.ID})
+	suite.GreaterOrEqual(results[0].Score, results[1].Score)
+}
+
+func (suite *SemanticSearchUsecaseTestSuite) TestSemanticSearch_Validation() {
+	_, err := suite.usecase.SemanticSearch(suite.ctx, "  ", 10)
+	suite.EqualError(err, "query is required")
+
+	_, err = usecases.NewSemanticSearchUsecase(suite.blogRepo, nil, nil).SemanticSearch(suite.ctx, "go", 10)
+	suite.EqualError(err, "semantic search is not configured")
+}
+
+func (suite *SemanticSearchUsecaseTestSuite) TestRelatedBlogs_IndexesOnDemand() {
+	suite.NoError(suite.usecase.IndexBlog(suite.ctx, 1))
+	suite.NoError(suite.usecase.IndexBlog(suite.ctx, 2))
+
+	// post 3 was never indexed
+	results, err := suite.usecase.RelatedBlogs(suite.ctx, 3, 10)
+	suite.NoError(err)
+	suite.Len(results, 2)
+	suite.Equal(int64(2), results[0].Blog.ID)
+}
+
+func (suite *SemanticSearchUsecaseTestSuite) TestRelatedBlogs_SkipsDeletedPosts() {
+	suite.reindex()
+	suite.embeddingRepo.On("DeleteEmbedding", mock.Anything, int64(2)).Return(nil)
+	suite.NoError(suite.usecase.RemoveBlog(suite.ctx, 2))
+
+	results, err := suite.usecase.RelatedBlogs(suite.ctx, 3, 10)
+	suite.NoError(err)
+	suite.Len(results, 1)
+	suite.Equal(int64(1), results[0].Blog.ID)
+}
+
+func (suite *SemanticSearchUsecaseTestSuite) TestRelatedBlogs_NotFound() {
+	_, err := suite.usecase.RelatedBlogs(suite.ctx, 9, 10)
+	suite.EqualError(err, "blog not found")
+}
+
+func TestSemanticSearchUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(SemanticSearchUsecaseTestSuite))
+}
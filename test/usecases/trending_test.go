@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/test/mocks"
+	"github.com/blog-platform/usecases"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type TrendingRankerTestSuite struct {
+	suite.Suite
+	analyticsRepo *mocks.MockAnalyticsRepository
+	ranker        *usecases.TrendingRanker
+	ctx           context.Context
+	today         time.Time
+}
+
+func (suite *TrendingRankerTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.analyticsRepo = new(mocks.MockAnalyticsRepository)
+	suite.ranker = usecases.NewTrendingRanker(suite.analyticsRepo)
+	suite.today = time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// expectScores captures the scores saved for each window.
+func (suite *TrendingRankerTestSuite) expectScores() map[string]map[int64]float64 {
+	saved := make(map[string]map[int64]float64)
+	suite.analyticsRepo.On("ReplaceTrendingScores", suite.ctx, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		scores := make(map[int64]float64)
+		for _, s := range args.Get(2).([]*domain.BlogTrendingScore) {
+			suite.Equal(args.String(1), s.Period)
+			scores[s.BlogID] = s.Score
+		}
+		saved[args.String(1)] = scores
+	}).Return(nil)
+	return saved
+}
+
+func (suite *TrendingRankerTestSuite) TestRefresh() {
+	suite.analyticsRepo.On("FetchStatsSince", suite.ctx, suite.today.AddDate(0, 0, -6)).Return([]*domain.BlogDailyStats{
+		{BlogID: 1, Day: suite.today, Views: 10},
+		{BlogID: 2, Day: suite.today.AddDate(0, 0, -1), Views: 10},
+		{BlogID: 3, Day: suite.today.AddDate(0, 0, -4), Views: 100, Likes: 10, Comments: 5},
+		{BlogID: 4, Day: suite.today, Views: 2, Likes: 2, Comments: 1},
+		// unlikes only
+		{BlogID: 5, Day: suite.today, Likes: -2},
+	}, nil)
+	saved := suite.expectScores()
+
+	suite.NoError(suite.ranker.Refresh(suite.ctx))
+	day, week := saved[domain.TrendingDay], saved[domain.TrendingWeek]
+	suite.ElementsMatch([]int64{1, 2, 4}, keys(day))
+	suite.ElementsMatch([]int64{1, 2, 3, 4}, keys(week))
+
+	// the same activity counts for less as it ages, and likes and
+	// comments count for more than views
+	suite.Greater(day[1], day[2])
+	suite.Greater(day[4], day[1])
+	suite.Greater(week[3], week[1])
+}
+
+func (suite *TrendingRankerTestSuite) TestRefresh_Errors() {
+	suite.analyticsRepo.On("FetchStatsSince", suite.ctx, mock.Anything).Return(nil, errors.New("db down")).Once()
+	suite.EqualError(suite.ranker.Refresh(suite.ctx), "failed to fetch stats: db down")
+	suite.analyticsRepo.AssertNotCalled(suite.T(), "ReplaceTrendingScores", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func keys(scores map[int64]float64) []int64 {
+	ids := make([]int64, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestTrendingRankerTestSuite(t *testing.T) {
+	suite.Run(t, new(TrendingRankerTestSuite))
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+func (suite *UserUsecaseTestSuite) TestLogin_TOTPEnabledReturnsChallenge() {
+	user := domain.User{ID: 1, Username: "testuser", Password: "hashedpassword", Role: "user", TOTPEnabled: true, TOTPSecret: "SECRET"}
+	suite.userRepo.On("FetchByUsername", "testuser").Return(user, nil)
+	suite.pwdService.On("ComparePassword", []byte(user.Password), []byte("Password123!")).Return(nil)
+	suite.jwtService.On("GenerateMFAToken", "1").Return("mfa_token", nil)
+
+	result, err := suite.userUsecase.Login("testuser", "Password123!", "10.0.0.1")
+	suite.NoError(err)
+	suite.Equal("mfa_token", result.MFAToken)
+	suite.Empty(result.AccessToken)
+	suite.jwtService.AssertNotCalled(suite.T(), "GenerateAccessToken", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestVerifyMFA_TOTPCode() {
+	user := domain.User{ID: 1, Role: "user", TOTPEnabled: true, TOTPSecret: "SECRET"}
+	suite.jwtService.On("ValidateMFAToken", "mfa_token").Return("1", nil)
+	suite.userRepo.On("Fetch", "1").Return(user, nil)
+	suite.totpService.On("Validate", "SECRET", "123456", mock.Anything).Return(int64(100), true)
+	suite.userRepo.On("UseTOTPStep", int64(1), int64(100)).Return(true, nil)
+	suite.jwtService.On("GenerateAccessToken", "1", "user").Return("access_token", nil)
+	suite.jwtService.On("GenerateRefreshToken", "1", "user").Return("refresh_token", nil)
+	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(nil).Twice()
+
+	result, err := suite.userUsecase.VerifyMFA("mfa_token", "123456", "10.0.0.1")
+	suite.NoError(err)
+	suite.Equal("access_token", result.AccessToken)
+}
+
+func (suite *UserUsecaseTestSuite) TestVerifyMFA_ReplayedCode() {
+	user := domain.User{ID: 1, Role: "user", TOTPEnabled: true, TOTPSecret: "SECRET"}
+	suite.jwtService.On("ValidateMFAToken", "mfa_token").Return("1", nil)
+	suite.userRepo.On("Fetch", "1").Return(user, nil)
+	suite.totpService.On("Validate", "SECRET", "123456", mock.Anything).Return(int64(100), true)
+	suite.userRepo.On("UseTOTPStep", int64(1), int64(100)).Return(false, nil)
+	suite.userRepo.On("RecordLoginFailure", int64(1)).Return(1, nil)
+
+	_, err := suite.userUsecase.VerifyMFA("mfa_token", "123456", "10.0.0.1")
+	suite.EqualError(err, "invalid code")
+	suite.userRepo.AssertCalled(suite.T(), "RecordLoginFailure", int64(1))
+}
+
+func (suite *UserUsecaseTestSuite) TestVerifyMFA_RecoveryCode() {
+	user := domain.User{ID: 1, Role: "user", TOTPEnabled: true, TOTPSecret: "SECRET"}
+	suite.jwtService.On("ValidateMFAToken", "mfa_token").Return("1", nil)
+	suite.userRepo.On("Fetch", "1").Return(user, nil)
+	suite.userRepo.On("UseRecoveryCode", int64(1), mock.AnythingOfType("string"), mock.Anything).Return(true, nil)
+	suite.jwtService.On("GenerateAccessToken", "1", "user").Return("access_token", nil)
+	suite.jwtService.On("GenerateRefreshToken", "1", "user").Return("refresh_token", nil)
+	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(nil).Twice()
+
+	_, err := suite.userUsecase.VerifyMFA("mfa_token", "ABCDE-FGHIJ", "10.0.0.1")
+	suite.NoError(err)
+	suite.totpService.AssertNotCalled(suite.T(), "Validate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestVerifyMFA_InvalidToken() {
+	suite.jwtService.On("ValidateMFAToken", "bad").Return("", errors.New("invalid"))
+	_, err := suite.userUsecase.VerifyMFA("bad", "123456", "10.0.0.1")
+	suite.EqualError(err, "invalid or expired mfa token")
+}
+
+func (suite *UserUsecaseTestSuite) TestSetupTOTP() {
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(&domain.User{ID: 1, Email: "a@example.com"}, nil)
+	suite.totpService.On("GenerateSecret").Return("SECRET", nil)
+	suite.userRepo.On("SetTOTPSecret", int64(1), "SECRET").Return(nil)
+	suite.totpService.On("ProvisioningURI", "SECRET", "a@example.com").Return("otpauth://totp/x")
+
+	setup, err := suite.userUsecase.SetupTOTP(1)
+	suite.NoError(err)
+	suite.Equal("SECRET", setup.Secret)
+	suite.Equal("otpauth://totp/x", setup.URI)
+}
+
+func (suite *UserUsecaseTestSuite) TestSetupTOTP_AlreadyEnabled() {
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(&domain.User{ID: 1, TOTPEnabled: true}, nil)
+	_, err := suite.userUsecase.SetupTOTP(1)
+	suite.EqualError(err, "two-factor authentication is already enabled")
+}
+
+func (suite *UserUsecaseTestSuite) TestConfirmTOTP_ReturnsRecoveryCodes() {
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(&domain.User{ID: 1, TOTPSecret: "SECRET"}, nil)
+	suite.totpService.On("Validate", "SECRET", "123456", mock.Anything).Return(int64(7), true)
+	suite.userRepo.On("EnableTOTP", int64(1), int64(7), mock.MatchedBy(func(hashes []string) bool {
+		return len(hashes) == 10
+	})).Return(nil)
+
+	codes, err := suite.userUsecase.ConfirmTOTP(1, "123456")
+	suite.NoError(err)
+	suite.Len(codes, 10)
+	for _, c := range codes {
+		suite.Regexp(regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`), c)
+	}
+}
+
+func (suite *UserUsecaseTestSuite) TestConfirmTOTP_WrongCode() {
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(&domain.User{ID: 1, TOTPSecret: "SECRET"}, nil)
+	suite.totpService.On("Validate", "SECRET", "000000", mock.Anything).Return(int64(0), false)
+
+	_, err := suite.userUsecase.ConfirmTOTP(1, "000000")
+	suite.EqualError(err, "invalid code")
+	suite.userRepo.AssertNotCalled(suite.T(), "EnableTOTP", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestDisableTOTP() {
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(&domain.User{ID: 1, TOTPEnabled: true, TOTPSecret: "SECRET"}, nil)
+	suite.totpService.On("Validate", "SECRET", "123456", mock.Anything).Return(int64(100), true)
+	suite.userRepo.On("UseTOTPStep", int64(1), int64(100)).Return(true, nil)
+	suite.userRepo.On("DisableTOTP", int64(1)).Return(nil)
+
+	suite.NoError(suite.userUsecase.DisableTOTP(1, "123456", "10.0.0.1"))
+}
+
+func (suite *UserUsecaseTestSuite) TestDisableTOTP_WrongCodeCountsAsFailedLogin() {
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(&domain.User{ID: 1, TOTPEnabled: true, TOTPSecret: "SECRET"}, nil)
+	suite.totpService.On("Validate", "SECRET", "000000", mock.Anything).Return(int64(0), false)
+	suite.userRepo.On("RecordLoginFailure", int64(1)).Return(1, nil)
+
+	err := suite.userUsecase.DisableTOTP(1, "000000", "10.0.0.1")
+	suite.EqualError(err, "invalid code")
+	suite.userRepo.AssertCalled(suite.T(), "RecordLoginFailure", int64(1))
+	suite.loginRepo.AssertCalled(suite.T(), "Record", mock.MatchedBy(func(a *domain.LoginAttempt) bool {
+		return *a.UserID == 1 && a.IP == "10.0.0.1" && !a.Success
+	}))
+	suite.userRepo.AssertNotCalled(suite.T(), "DisableTOTP", mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestDisableTOTP_LockedAccount() {
+	until := time.Now().Add(time.Hour)
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(&domain.User{ID: 1, TOTPEnabled: true, TOTPSecret: "SECRET", LockedUntil: &until}, nil)
+
+	err := suite.userUsecase.DisableTOTP(1, "123456", "10.0.0.1")
+	suite.EqualError(err, "invalid code")
+	suite.totpService.AssertNotCalled(suite.T(), "Validate", mock.Anything, mock.Anything, mock.Anything)
+	suite.userRepo.AssertNotCalled(suite.T(), "DisableTOTP", mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestResetTOTP() {
+	suite.userRepo.On("Fetch", "2").Return(domain.User{ID: 2, TOTPEnabled: true}, nil)
+	suite.userRepo.On("DisableTOTP", int64(2)).Return(nil)
+	suite.NoError(suite.userUsecase.ResetTOTP("2"))
+}
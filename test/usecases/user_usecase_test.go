@@ -5,8 +5,10 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
 	"github.com/blog-platform/test/mocks"
 	"github.com/blog-platform/usecases"
 	"github.com/stretchr/testify/mock"
@@ -16,20 +18,27 @@ import (
 type UserUsecaseTestSuite struct {
 	suite.Suite
 	userRepo     *mocks.MockUserRepository
-	emailService *mocks.MockEmailService
+	outboxRepo   *mocks.MockOutboxRepository
+	loginRepo    *mocks.MockLoginAttemptRepository
 	pwdService   *mocks.MockPasswordService
 	jwtService   *mocks.MockJWTService
 	tokenRepo    *mocks.MockTokenRepository
+	totpService  *mocks.MockTOTPService
 	userUsecase  domain.IUserUsecase
 }
 
 func (suite *UserUsecaseTestSuite) SetupTest() {
 	suite.userRepo = new(mocks.MockUserRepository)
-	suite.emailService = new(mocks.MockEmailService)
+	suite.outboxRepo = new(mocks.MockOutboxRepository)
+	suite.loginRepo = new(mocks.MockLoginAttemptRepository)
+	suite.loginRepo.On("CountFailuresByIP", mock.Anything, mock.Anything).Return(int64(0), nil).Maybe()
+	suite.loginRepo.On("Record", mock.Anything).Return(nil).Maybe()
 	suite.pwdService = new(mocks.MockPasswordService)
+	suite.pwdService.On("NeedsRehash", mock.Anything).Return(false).Maybe()
 	suite.jwtService = new(mocks.MockJWTService)
 	suite.tokenRepo = new(mocks.MockTokenRepository)
-	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.emailService, suite.pwdService, suite.jwtService, suite.tokenRepo)
+	suite.totpService = new(mocks.MockTOTPService)
+	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.outboxRepo, suite.loginRepo, suite.pwdService, suite.jwtService, suite.tokenRepo, suite.totpService, infrastructure.DefaultPasswordPolicy())
 	os.Setenv("PROTOCOL", "http")
 	os.Setenv("DOMAIN", "localhost")
 	os.Setenv("PORT", "8080")
@@ -49,11 +58,16 @@ func (suite *UserUsecaseTestSuite) TestRegister_Success() {
 	suite.userRepo.On("FetchByEmail", user.Email).Return(domain.User{}, errors.New("not found"))
 	suite.userRepo.On("CountUsers").Return(int64(1), nil)
 	suite.pwdService.On("HashPassword", user.Password).Return("hashedpassword", nil)
-	suite.userRepo.On("Register", mock.AnythingOfType("*domain.User")).Return(createdUser, nil)
-	suite.emailService.On("SendEmail", []string{user.Email}, "Activate Account", "http://localhost:8080/user/1/activate").Return(nil)
+	suite.userRepo.On("RegisterWithEmail", mock.AnythingOfType("*domain.User")).Return(createdUser, nil)
 
 	_, err := suite.userUsecase.Register(user)
 	suite.NoError(err)
+	suite.Require().Len(suite.userRepo.Outbox, 1)
+	queued := suite.userRepo.Outbox[0]
+	suite.Equal(user.Email, queued.Recipients)
+	suite.Equal(domain.EmailActivation, queued.Template)
+	suite.Equal(domain.OutboxPending, queued.Status)
+	suite.Contains(queued.Data, "http://localhost:8080/user/1/activate")
 }
 
 func (suite *UserUsecaseTestSuite) TestRegister_MissingFields() {
@@ -82,7 +96,7 @@ func (suite *UserUsecaseTestSuite) TestRegister_InvalidPassword() {
 	}
 	_, err := suite.userUsecase.Register(user)
 	suite.Error(err)
-	suite.Equal("password must be consisted of at least one uppercase character, one lowercase character, one punctuation character, one number and be at least of length 8", err.Error())
+	suite.Equal("password must be at least 8 characters long and contain an uppercase letter, a lowercase letter, a number and a symbol", err.Error())
 }
 
 func (suite *UserUsecaseTestSuite) TestRegister_UsernameInUse() {
@@ -135,34 +149,13 @@ func (suite *UserUsecaseTestSuite) TestRegister_RegistrationFails_RepoError() {
 	suite.userRepo.On("FetchByEmail", user.Email).Return(domain.User{}, errors.New("not found"))
 	suite.userRepo.On("CountUsers").Return(int64(1), nil)
 	suite.pwdService.On("HashPassword", user.Password).Return("hashedpassword", nil)
-	suite.userRepo.On("Register", mock.AnythingOfType("*domain.User")).Return(domain.User{}, errors.New("db error"))
+	suite.userRepo.On("RegisterWithEmail", mock.AnythingOfType("*domain.User")).Return(domain.User{}, errors.New("db error"))
 
 	_, err := suite.userUsecase.Register(user)
 	suite.Error(err)
 	suite.Equal("unable to register user", err.Error())
 }
 
-func (suite *UserUsecaseTestSuite) TestRegister_SendEmailFails() {
-	user := &domain.User{
-		Username: "testuser",
-		Email:    "test@example.com",
-		Password: "Password123!",
-	}
-	createdUser := *user
-	createdUser.ID = 1
-
-	suite.userRepo.On("FetchByUsername", user.Username).Return(domain.User{}, errors.New("not found"))
-	suite.userRepo.On("FetchByEmail", user.Email).Return(domain.User{}, errors.New("not found"))
-	suite.userRepo.On("CountUsers").Return(int64(1), nil)
-	suite.pwdService.On("HashPassword", user.Password).Return("hashedpassword", nil)
-	suite.userRepo.On("Register", mock.AnythingOfType("*domain.User")).Return(createdUser, nil)
-	suite.emailService.On("SendEmail", []string{user.Email}, "Activate Account", "http://localhost:8080/user/1/activate").Return(errors.New("email error"))
-
-	_, err := suite.userUsecase.Register(user)
-	suite.Error(err)
-	suite.Equal("unable to send activation link", err.Error())
-}
-
 func (suite *UserUsecaseTestSuite) TestActivateAccount_Success() {
 	suite.userRepo.On("Fetch", "1").Return(domain.User{}, nil)
 	suite.userRepo.On("ActivateAccount", "1").Return(nil)
@@ -196,18 +189,24 @@ func (suite *UserUsecaseTestSuite) TestLogin_Success() {
 	suite.jwtService.On("GenerateRefreshToken", "1", "user").Return("refresh_token", nil)
 	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(nil).Twice()
 
-	accessToken, refreshToken, err := suite.userUsecase.Login("testuser", "Password123!")
+	result, err := suite.userUsecase.Login("testuser", "Password123!", "10.0.0.1")
 
 	suite.NoError(err)
-	suite.Equal("access_token", accessToken)
-	suite.Equal("refresh_token", refreshToken)
+	suite.Equal("access_token", result.AccessToken)
+	suite.Equal("refresh_token", result.RefreshToken)
+	suite.Empty(result.MFAToken)
 	suite.tokenRepo.AssertNumberOfCalls(suite.T(), "Save", 2)
 }
 
 func (suite *UserUsecaseTestSuite) TestLogin_InvalidIdentifier() {
 	suite.userRepo.On("FetchByUsername", "unknown").Return(domain.User{}, errors.New("not found"))
-	_, _, err := suite.userUsecase.Login("unknown", "Password123!")
-	suite.Error(err)
+	suite.userRepo.On("FetchByEmail", "unknown").Return(domain.User{}, errors.New("not found"))
+	suite.pwdService.On("HashPassword", mock.Anything).Return("dummyhash", nil).Once()
+	suite.pwdService.On("ComparePassword", []byte("dummyhash"), []byte("Password123!")).Return(errors.New("mismatch"))
+	_, err := suite.userUsecase.Login("unknown", "Password123!", "10.0.0.1")
+	suite.EqualError(err, "invalid credentials")
+	// the unknown account still costs a password comparison
+	suite.pwdService.AssertCalled(suite.T(), "ComparePassword", []byte("dummyhash"), []byte("Password123!"))
 }
 
 func (suite *UserUsecaseTestSuite) TestLogin_InvalidPassword() {
@@ -218,8 +217,136 @@ func (suite *UserUsecaseTestSuite) TestLogin_InvalidPassword() {
 	}
 	suite.userRepo.On("FetchByUsername", "testuser").Return(*user, nil)
 	suite.pwdService.On("ComparePassword", []byte(user.Password), []byte("WrongPassword!")).Return(errors.New("wrong password"))
-	_, _, err := suite.userUsecase.Login("testuser", "WrongPassword!")
-	suite.Error(err)
+	suite.userRepo.On("RecordLoginFailure", int64(1)).Return(1, nil)
+	_, err := suite.userUsecase.Login("testuser", "WrongPassword!", "10.0.0.1")
+	suite.EqualError(err, "invalid credentials")
+	suite.userRepo.AssertNotCalled(suite.T(), "LockAccount", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestLogin_ProgressiveDelay() {
+	user := domain.User{ID: 1, Username: "testuser", Password: "hashedpassword", FailedLogins: 3}
+	suite.userRepo.On("FetchByUsername", "testuser").Return(user, nil)
+	suite.pwdService.On("ComparePassword", []byte(user.Password), []byte("WrongPassword!")).Return(errors.New("wrong password"))
+	suite.userRepo.On("RecordLoginFailure", int64(1)).Return(4, nil)
+	suite.userRepo.On("LockAccount", int64(1), mock.MatchedBy(func(until time.Time) bool {
+		d := time.Until(until)
+		return d > 0 && d <= 2*time.Second
+	}), "", (*domain.OutboxEmail)(nil)).Return(nil)
+
+	_, err := suite.userUsecase.Login("testuser", "WrongPassword!", "10.0.0.1")
+	suite.EqualError(err, "invalid credentials")
+	suite.userRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserUsecaseTestSuite) TestLogin_LocksAccountAndSendsUnlockEmail() {
+	user := domain.User{ID: 1, Username: "testuser", Email: "test@example.com", Password: "hashedpassword", FailedLogins: 9}
+	suite.userRepo.On("FetchByUsername", "testuser").Return(user, nil)
+	suite.pwdService.On("ComparePassword", []byte(user.Password), []byte("WrongPassword!")).Return(errors.New("wrong password"))
+	suite.userRepo.On("RecordLoginFailure", int64(1)).Return(10, nil)
+	suite.userRepo.On("LockAccount", int64(1), mock.MatchedBy(func(until time.Time) bool {
+		return time.Until(until) > 14*time.Minute
+	}), mock.MatchedBy(func(hash string) bool { return len(hash) == 64 }), mock.Anything).Return(nil)
+
+	_, err := suite.userUsecase.Login("testuser", "WrongPassword!", "10.0.0.1")
+	suite.EqualError(err, "invalid credentials")
+	suite.Require().Len(suite.userRepo.Outbox, 1)
+	suite.Equal(domain.EmailAccountLocked, suite.userRepo.Outbox[0].Template)
+	suite.Equal("test@example.com", suite.userRepo.Outbox[0].Recipients)
+	suite.Contains(suite.userRepo.Outbox[0].Data, "/unlock?token=")
+}
+
+func (suite *UserUsecaseTestSuite) TestLogin_LockedAccountRejectsCorrectPassword() {
+	until := time.Now().Add(10 * time.Minute)
+	user := domain.User{ID: 1, Username: "testuser", Password: "hashedpassword", FailedLogins: 10, LockedUntil: &until}
+	suite.userRepo.On("FetchByUsername", "testuser").Return(user, nil)
+	suite.pwdService.On("HashPassword", mock.Anything).Return("dummyhash", nil).Once()
+	suite.pwdService.On("ComparePassword", []byte("dummyhash"), []byte("Password123!")).Return(errors.New("mismatch"))
+
+	_, err := suite.userUsecase.Login("testuser", "Password123!", "10.0.0.1")
+	suite.EqualError(err, "invalid credentials")
+	suite.pwdService.AssertNotCalled(suite.T(), "ComparePassword", []byte(user.Password), mock.Anything)
+	suite.userRepo.AssertNotCalled(suite.T(), "RecordLoginFailure", mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestLogin_SuccessResetsFailures() {
+	user := domain.User{ID: 1, Username: "testuser", Password: "hashedpassword", Role: "user", FailedLogins: 2}
+	suite.userRepo.On("FetchByUsername", "testuser").Return(user, nil)
+	suite.pwdService.On("ComparePassword", []byte(user.Password), []byte("Password123!")).Return(nil)
+	suite.userRepo.On("ResetLoginFailures", int64(1)).Return(nil)
+	suite.jwtService.On("GenerateAccessToken", "1", "user").Return("access_token", nil)
+	suite.jwtService.On("GenerateRefreshToken", "1", "user").Return("refresh_token", nil)
+	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(nil).Twice()
+
+	_, err := suite.userUsecase.Login("testuser", "Password123!", "10.0.0.1")
+	suite.NoError(err)
+	suite.userRepo.AssertCalled(suite.T(), "ResetLoginFailures", int64(1))
+}
+
+func (suite *UserUsecaseTestSuite) TestLogin_RehashesOutdatedHash() {
+	pwdService := new(mocks.MockPasswordService)
+	uu := usecases.NewUserUsecase(suite.userRepo, suite.outboxRepo, suite.loginRepo, pwdService, suite.jwtService, suite.tokenRepo, suite.totpService, infrastructure.DefaultPasswordPolicy())
+	user := domain.User{ID: 1, Username: "testuser", Password: "$2a$10$legacy", Role: "user"}
+	suite.userRepo.On("FetchByUsername", "testuser").Return(user, nil)
+	pwdService.On("ComparePassword", []byte(user.Password), []byte("Password123!")).Return(nil)
+	pwdService.On("NeedsRehash", user.Password).Return(true)
+	pwdService.On("HashPassword", "Password123!").Return("$argon2id$new", nil)
+	suite.userRepo.On("UpdatePasswordHash", int64(1), "$2a$10$legacy", "$argon2id$new").Return(nil)
+	suite.jwtService.On("GenerateAccessToken", "1", "user").Return("access_token", nil)
+	suite.jwtService.On("GenerateRefreshToken", "1", "user").Return("refresh_token", nil)
+	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(nil).Twice()
+
+	_, err := uu.Login("testuser", "Password123!", "10.0.0.1")
+	suite.NoError(err)
+	suite.userRepo.AssertCalled(suite.T(), "UpdatePasswordHash", int64(1), "$2a$10$legacy", "$argon2id$new")
+}
+
+func (suite *UserUsecaseTestSuite) TestLogin_RehashFailureDoesNotFailLogin() {
+	pwdService := new(mocks.MockPasswordService)
+	uu := usecases.NewUserUsecase(suite.userRepo, suite.outboxRepo, suite.loginRepo, pwdService, suite.jwtService, suite.tokenRepo, suite.totpService, infrastructure.DefaultPasswordPolicy())
+	user := domain.User{ID: 1, Username: "testuser", Password: "$2a$10$legacy", Role: "user"}
+	suite.userRepo.On("FetchByUsername", "testuser").Return(user, nil)
+	pwdService.On("ComparePassword", []byte(user.Password), []byte("Password123!")).Return(nil)
+	pwdService.On("NeedsRehash", user.Password).Return(true)
+	pwdService.On("HashPassword", "Password123!").Return("$argon2id$new", nil)
+	suite.userRepo.On("UpdatePasswordHash", int64(1), "$2a$10$legacy", "$argon2id$new").Return(errors.New("db error"))
+	suite.jwtService.On("GenerateAccessToken", "1", "user").Return("access_token", nil)
+	suite.jwtService.On("GenerateRefreshToken", "1", "user").Return("refresh_token", nil)
+	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(nil).Twice()
+
+	result, err := uu.Login("testuser", "Password123!", "10.0.0.1")
+	suite.NoError(err)
+	suite.Equal("access_token", result.AccessToken)
+}
+
+func (suite *UserUsecaseTestSuite) TestRegister_DeniedPassword() {
+	policy := infrastructure.DefaultPasswordPolicy()
+	suite.Require().NoError(policy.LoadDenyList(strings.NewReader("# common\npassword123!\n")))
+	uu := usecases.NewUserUsecase(suite.userRepo, suite.outboxRepo, suite.loginRepo, suite.pwdService, suite.jwtService, suite.tokenRepo, suite.totpService, policy)
+
+	_, err := uu.Register(&domain.User{Username: "testuser", Email: "test@example.com", Password: "Password123!"})
+	suite.EqualError(err, "password is too common, choose a different one")
+	suite.pwdService.AssertNotCalled(suite.T(), "HashPassword", mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestLogin_IPBlocked() {
+	loginRepo := new(mocks.MockLoginAttemptRepository)
+	loginRepo.On("CountFailuresByIP", "10.0.0.1", mock.Anything).Return(int64(50), nil)
+	uu := usecases.NewUserUsecase(suite.userRepo, suite.outboxRepo, loginRepo, suite.pwdService, suite.jwtService, suite.tokenRepo, suite.totpService, infrastructure.DefaultPasswordPolicy())
+
+	_, err := uu.Login("testuser", "Password123!", "10.0.0.1")
+	suite.EqualError(err, "too many failed login attempts")
+	suite.userRepo.AssertNotCalled(suite.T(), "FetchByUsername", mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestUnlockAccount_Success() {
+	suite.userRepo.On("FetchByUnlockToken", mock.AnythingOfType("string")).Return(domain.User{ID: 1}, nil)
+	suite.userRepo.On("ResetLoginFailures", int64(1)).Return(nil)
+	suite.NoError(suite.userUsecase.UnlockAccount("token"))
+}
+
+func (suite *UserUsecaseTestSuite) TestUnlockAccount_InvalidToken() {
+	suite.userRepo.On("FetchByUnlockToken", mock.AnythingOfType("string")).Return(domain.User{}, errors.New("not found"))
+	suite.EqualError(suite.userUsecase.UnlockAccount("token"), "invalid or expired token")
 }
 
 func (suite *UserUsecaseTestSuite) TestLogin_GenerateAccessTokenError() {
@@ -233,7 +360,7 @@ func (suite *UserUsecaseTestSuite) TestLogin_GenerateAccessTokenError() {
 	suite.pwdService.On("ComparePassword", []byte(user.Password), []byte("Password123!")).Return(nil)
 	suite.jwtService.On("GenerateAccessToken", "1", "user").Return("", errors.New("jwt error"))
 
-	_, _, err := suite.userUsecase.Login("testuser", "Password123!")
+	_, err := suite.userUsecase.Login("testuser", "Password123!", "10.0.0.1")
 	suite.Error(err)
 }
 
@@ -249,7 +376,7 @@ func (suite *UserUsecaseTestSuite) TestLogin_GenerateRefreshTokenError() {
 	suite.jwtService.On("GenerateAccessToken", "1", "user").Return("access_token", nil)
 	suite.jwtService.On("GenerateRefreshToken", "1", "user").Return("", errors.New("jwt error"))
 
-	_, _, err := suite.userUsecase.Login("testuser", "Password123!")
+	_, err := suite.userUsecase.Login("testuser", "Password123!", "10.0.0.1")
 	suite.Error(err)
 }
 
@@ -266,7 +393,7 @@ func (suite *UserUsecaseTestSuite) TestLogin_SaveAccessTokenError() {
 	suite.jwtService.On("GenerateRefreshToken", "1", "user").Return("refresh_token", nil)
 	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(errors.New("db error")).Once()
 
-	_, _, err := suite.userUsecase.Login("testuser", "Password123!")
+	_, err := suite.userUsecase.Login("testuser", "Password123!", "10.0.0.1")
 	suite.Error(err)
 }
 
@@ -284,7 +411,7 @@ func (suite *UserUsecaseTestSuite) TestLogin_SaveRefreshTokenError() {
 	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(nil).Once()
 	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(errors.New("db error")).Once()
 
-	_, _, err := suite.userUsecase.Login("testuser", "Password123!")
+	_, err := suite.userUsecase.Login("testuser", "Password123!", "10.0.0.1")
 	suite.Error(err)
 }
 
@@ -337,6 +464,7 @@ func (suite *UserUsecaseTestSuite) TestUpdateUserProfile_Success() {
 		"Username": "updateduser",
 		"Bio":      "updated bio",
 	}
+	suite.userRepo.On("FetchByUsername", "updateduser").Return(domain.User{}, errors.New("not found"))
 	suite.userRepo.On("UpdateUserProfile", userID, updates).Return(nil)
 	err := suite.userUsecase.UpdateUserProfile(userID, updates)
 	suite.NoError(err)
@@ -345,9 +473,67 @@ func (suite *UserUsecaseTestSuite) TestUpdateUserProfile_Success() {
 func (suite *UserUsecaseTestSuite) TestUpdateUserProfile_NoFields() {
 	userID := int64(1)
 	updates := map[string]interface{}{}
-	suite.userRepo.On("UpdateUserProfile", userID, updates).Return(nil)
 	err := suite.userUsecase.UpdateUserProfile(userID, updates)
 	suite.NoError(err)
+	suite.userRepo.AssertNotCalled(suite.T(), "UpdateUserProfile", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestUpdateUserProfile_RejectsStatus() {
+	err := suite.userUsecase.UpdateUserProfile(1, map[string]interface{}{"Status": "active"})
+	suite.EqualError(err, "field 'Status' cannot be updated")
+	suite.userRepo.AssertNotCalled(suite.T(), "UpdateUserProfile", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestUpdateUserProfile_UsernameTaken() {
+	suite.userRepo.On("FetchByUsername", "taken").Return(domain.User{ID: 2, Username: "taken"}, nil)
+	err := suite.userUsecase.UpdateUserProfile(1, map[string]interface{}{"Username": "taken"})
+	suite.EqualError(err, "this username is already in use")
+}
+
+func (suite *UserUsecaseTestSuite) TestUpdateUserProfile_EmailChangeIsPending() {
+	user := &domain.User{ID: 1, Username: "john", Email: "old@example.com"}
+	suite.userRepo.On("FetchByEmail", "new@example.com").Return(domain.User{}, errors.New("not found"))
+	suite.userRepo.On("UpdateUserProfile", int64(1), map[string]interface{}{"Bio": "hi"}).Return(nil)
+	suite.userRepo.On("GetUserProfile", int64(1)).Return(user, nil)
+	suite.userRepo.On("CreateEmailChange", mock.MatchedBy(func(c *domain.PendingEmailChange) bool {
+		return c.UserID == 1 && c.NewEmail == "new@example.com" && len(c.TokenHash) == 64
+	})).Return(nil)
+
+	err := suite.userUsecase.UpdateUserProfile(1, map[string]interface{}{"Email": "new@example.com", "Bio": "hi"})
+	suite.NoError(err)
+	suite.Require().Len(suite.userRepo.Outbox, 2)
+	suite.Equal("new@example.com", suite.userRepo.Outbox[0].Recipients)
+	suite.Equal(domain.EmailChange, suite.userRepo.Outbox[0].Template)
+	suite.Contains(suite.userRepo.Outbox[0].Data, "/email/confirm?token=")
+	suite.Equal("old@example.com", suite.userRepo.Outbox[1].Recipients)
+	suite.Equal(domain.EmailChangeNotice, suite.userRepo.Outbox[1].Template)
+	suite.NotContains(suite.userRepo.Outbox[1].Data, "token=")
+}
+
+func (suite *UserUsecaseTestSuite) TestUpdateUserProfile_EmailTaken() {
+	suite.userRepo.On("FetchByEmail", "new@example.com").Return(domain.User{ID: 2}, nil)
+	err := suite.userUsecase.UpdateUserProfile(1, map[string]interface{}{"Email": "new@example.com"})
+	suite.EqualError(err, "this email is already in use")
+	suite.userRepo.AssertNotCalled(suite.T(), "CreateEmailChange", mock.Anything)
+}
+
+func (suite *UserUsecaseTestSuite) TestConfirmEmailChange_Success() {
+	change := domain.PendingEmailChange{ID: 5, UserID: 1, NewEmail: "new@example.com", ExpiresAt: time.Now().Add(time.Hour)}
+	suite.userRepo.On("FetchEmailChange", mock.AnythingOfType("string")).Return(change, nil)
+	suite.userRepo.On("FetchByEmail", "new@example.com").Return(domain.User{}, errors.New("not found"))
+	suite.userRepo.On("ConfirmEmailChange", change).Return(nil)
+
+	suite.NoError(suite.userUsecase.ConfirmEmailChange("token"))
+	suite.userRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserUsecaseTestSuite) TestConfirmEmailChange_Expired() {
+	change := domain.PendingEmailChange{ID: 5, UserID: 1, NewEmail: "new@example.com", ExpiresAt: time.Now().Add(-time.Minute)}
+	suite.userRepo.On("FetchEmailChange", mock.AnythingOfType("string")).Return(change, nil)
+
+	err := suite.userUsecase.ConfirmEmailChange("token")
+	suite.EqualError(err, "invalid or expired token")
+	suite.userRepo.AssertNotCalled(suite.T(), "ConfirmEmailChange", mock.Anything)
 }
 
 func (suite *UserUsecaseTestSuite) TestRefreshToken_Success() {
@@ -355,7 +541,7 @@ func (suite *UserUsecaseTestSuite) TestRefreshToken_Success() {
 	tokenMock := new(mocks.MockTokenRepository)
 	suite.jwtService = jwtMock
 	suite.tokenRepo = tokenMock
-	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.emailService, suite.pwdService, jwtMock, tokenMock)
+	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.outboxRepo, suite.loginRepo, suite.pwdService, jwtMock, tokenMock, suite.totpService, infrastructure.DefaultPasswordPolicy())
 	claims := &domain.TokenClaims{UserID: "1", UserRole: "user"}
 	authHeader := "Bearer old_refresh"
 	jwtMock.On("ValidateRefreshToken", authHeader).Return(claims, nil)
@@ -373,7 +559,7 @@ func (suite *UserUsecaseTestSuite) TestRefreshToken_ValidateError() {
 	tokenMock := new(mocks.MockTokenRepository)
 	suite.jwtService = jwtMock
 	suite.tokenRepo = tokenMock
-	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.emailService, suite.pwdService, jwtMock, tokenMock)
+	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.outboxRepo, suite.loginRepo, suite.pwdService, jwtMock, tokenMock, suite.totpService, infrastructure.DefaultPasswordPolicy())
 	authHeader := "Bearer bad"
 	jwtMock.On("ValidateRefreshToken", authHeader).Return((*domain.TokenClaims)(nil), errors.New("invalid token"))
 	_, _, err := suite.userUsecase.RefreshToken(authHeader)
@@ -385,7 +571,7 @@ func (suite *UserUsecaseTestSuite) TestRefreshToken_GenerateAccessTokenError() {
 	tokenMock := new(mocks.MockTokenRepository)
 	suite.jwtService = jwtMock
 	suite.tokenRepo = tokenMock
-	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.emailService, suite.pwdService, jwtMock, tokenMock)
+	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.outboxRepo, suite.loginRepo, suite.pwdService, jwtMock, tokenMock, suite.totpService, infrastructure.DefaultPasswordPolicy())
 	authHeader := "Bearer old_refresh"
 	claims := &domain.TokenClaims{UserID: "1", UserRole: "user"}
 	jwtMock.On("ValidateRefreshToken", authHeader).Return(claims, nil)
@@ -399,7 +585,7 @@ func (suite *UserUsecaseTestSuite) TestRefreshToken_GenerateRefreshTokenError()
 	tokenMock := new(mocks.MockTokenRepository)
 	suite.jwtService = jwtMock
 	suite.tokenRepo = tokenMock
-	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.emailService, suite.pwdService, jwtMock, tokenMock)
+	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.outboxRepo, suite.loginRepo, suite.pwdService, jwtMock, tokenMock, suite.totpService, infrastructure.DefaultPasswordPolicy())
 	authHeader := "Bearer old_refresh"
 	claims := &domain.TokenClaims{UserID: "1", UserRole: "user"}
 	jwtMock.On("ValidateRefreshToken", authHeader).Return(claims, nil)
@@ -414,7 +600,7 @@ func (suite *UserUsecaseTestSuite) TestRefreshToken_SaveAccessTokenError() {
 	tokenMock := new(mocks.MockTokenRepository)
 	suite.jwtService = jwtMock
 	suite.tokenRepo = tokenMock
-	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.emailService, suite.pwdService, jwtMock, tokenMock)
+	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.outboxRepo, suite.loginRepo, suite.pwdService, jwtMock, tokenMock, suite.totpService, infrastructure.DefaultPasswordPolicy())
 	authHeader := "Bearer old_refresh"
 	claims := &domain.TokenClaims{UserID: "1", UserRole: "user"}
 	jwtMock.On("ValidateRefreshToken", authHeader).Return(claims, nil)
@@ -430,7 +616,7 @@ func (suite *UserUsecaseTestSuite) TestRefreshToken_SaveRefreshTokenError() {
 	tokenMock := new(mocks.MockTokenRepository)
 	suite.jwtService = jwtMock
 	suite.tokenRepo = tokenMock
-	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.emailService, suite.pwdService, jwtMock, tokenMock)
+	suite.userUsecase = usecases.NewUserUsecase(suite.userRepo, suite.outboxRepo, suite.loginRepo, suite.pwdService, jwtMock, tokenMock, suite.totpService, infrastructure.DefaultPasswordPolicy())
 	authHeader := "Bearer old_refresh"
 	claims := &domain.TokenClaims{UserID: "1", UserRole: "user"}
 	jwtMock.On("ValidateRefreshToken", authHeader).Return(claims, nil)
@@ -536,7 +722,7 @@ func (suite *UserUsecaseTestSuite) TestUpdatePasswordDirect_HashError() {
 	suite.pwdService.On("HashPassword", "NewPass123!").Return("", errors.New("hash fail"))
 	err := suite.userUsecase.UpdatePasswordDirect("1", "NewPass123!", "tokenHash")
 	suite.Error(err)
-	suite.Equal("could not hash password", err.Error())
+	suite.Equal("hash fail", err.Error())
 }
 
 func (suite *UserUsecaseTestSuite) TestUpdatePasswordDirect_UpdateError() {
@@ -554,8 +740,10 @@ func (suite *UserUsecaseTestSuite) TestForgotPassword_Success() {
 	suite.userRepo.On("FetchByEmail", user.Email).Return(user, nil)
 	suite.jwtService.On("GenerateAccessToken", "1", "user").Return("reset_token", nil)
 	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(nil)
-	suite.emailService.On("SendEmail", []string{user.Email}, "Reset Password", mock.MatchedBy(func(body string) bool {
-		return strings.Contains(body, "/password/1/update?token=reset_token")
+	suite.outboxRepo.On("Enqueue", mock.Anything, mock.MatchedBy(func(e *domain.OutboxEmail) bool {
+		return e.Recipients == user.Email &&
+			e.Template == domain.EmailPasswordReset &&
+			strings.Contains(e.Data, "/password/1/update?token=reset_token")
 	})).Return(nil)
 
 	err := suite.userUsecase.ForgotPassword(user.Email)
@@ -583,7 +771,7 @@ func (suite *UserUsecaseTestSuite) TestForgotPassword_GenerateTokenError() {
 	suite.Error(err)
 	suite.Equal("could not generate reset token", err.Error())
 	suite.tokenRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
-	suite.emailService.AssertNotCalled(suite.T(), "SendEmail", mock.Anything, mock.Anything, mock.Anything)
+	suite.outboxRepo.AssertNotCalled(suite.T(), "Enqueue", mock.Anything, mock.Anything)
 }
 
 func (suite *UserUsecaseTestSuite) TestForgotPassword_PersistTokenError() {
@@ -594,7 +782,7 @@ func (suite *UserUsecaseTestSuite) TestForgotPassword_PersistTokenError() {
 	err := suite.userUsecase.ForgotPassword(user.Email)
 	suite.Error(err)
 	suite.Equal("could not persist reset token", err.Error())
-	suite.emailService.AssertNotCalled(suite.T(), "SendEmail", mock.Anything, mock.Anything, mock.Anything)
+	suite.outboxRepo.AssertNotCalled(suite.T(), "Enqueue", mock.Anything, mock.Anything)
 }
 
 func (suite *UserUsecaseTestSuite) TestForgotPassword_SendEmailError() {
@@ -602,7 +790,7 @@ func (suite *UserUsecaseTestSuite) TestForgotPassword_SendEmailError() {
 	suite.userRepo.On("FetchByEmail", user.Email).Return(user, nil)
 	suite.jwtService.On("GenerateAccessToken", "1", "user").Return("reset_token", nil)
 	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(nil)
-	suite.emailService.On("SendEmail", []string{user.Email}, "Reset Password", mock.AnythingOfType("string")).Return(errors.New("smtp err"))
+	suite.outboxRepo.On("Enqueue", mock.Anything, mock.AnythingOfType("*domain.OutboxEmail")).Return(errors.New("db err"))
 	err := suite.userUsecase.ForgotPassword(user.Email)
 	suite.Error(err)
 	suite.Equal("could not send reset link", err.Error())
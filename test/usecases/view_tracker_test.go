@@ -0,0 +1,182 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/test/mocks"
+	"github.com/blog-platform/usecases"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const browser = "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0"
+
+type failingViewStore struct{}
+
+func (failingViewStore) MarkViewed(context.Context, string, time.Duration, time.Time) (bool, error) {
+	return false, errors.New("store down")
+}
+
+type ViewTrackerTestSuite struct {
+	suite.Suite
+	blogRepo      *mocks.MockBlogRepo
+	analyticsRepo *mocks.MockAnalyticsRepository
+	tracker       *usecases.ViewTracker
+	ctx           context.Context
+}
+
+func (suite *ViewTrackerTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.blogRepo = new(mocks.MockBlogRepo)
+	suite.analyticsRepo = new(mocks.MockAnalyticsRepository)
+	suite.blogRepo.On("FetchByID", mock.Anything, mock.MatchedBy(func(id int64) bool { return id != 404 })).Return(&domain.Blog{}, nil).Maybe()
+	suite.tracker = usecases.NewViewTracker(suite.blogRepo, suite.analyticsRepo, infrastructure.NewMemoryViewStore())
+}
+
+// expectStats captures the stats of the next flush.
+func (suite *ViewTrackerTestSuite) expectStats(err error) (*[]*domain.BlogDailyStats, map[string]int) {
+	var stats []*domain.BlogDailyStats
+	referrers := make(map[string]int)
+	suite.analyticsRepo.On("AddDailyStats", suite.ctx, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stats = args.Get(1).([]*domain.BlogDailyStats)
+		for _, r := range args.Get(2).([]*domain.BlogReferrerStats) {
+			referrers[r.Referrer] += r.Views
+		}
+	}).Return(err).Once()
+	return &stats, referrers
+}
+
+func (suite *ViewTrackerTestSuite) track(view domain.BlogView) bool {
+	counted, err := suite.tracker.TrackView(suite.ctx, view)
+	suite.Require().NoError(err)
+	return counted
+}
+
+func (suite *ViewTrackerTestSuite) TestCountsEachReaderOnce() {
+	suite.True(suite.track(domain.BlogView{BlogID: 1, UserID: 7, ClientIP: "10.0.0.1", UserAgent: browser}))
+	// refreshing, or from another device
+	suite.False(suite.track(domain.BlogView{BlogID: 1, UserID: 7, ClientIP: "10.0.0.2", UserAgent: browser}))
+	suite.True(suite.track(domain.BlogView{BlogID: 2, UserID: 7, ClientIP: "10.0.0.1", UserAgent: browser}))
+	suite.True(suite.track(domain.BlogView{BlogID: 1, UserID: 8, ClientIP: "10.0.0.1", UserAgent: browser}))
+
+	// anonymous readers are told apart by IP and user agent
+	suite.True(suite.track(domain.BlogView{BlogID: 1, ClientIP: "10.0.0.1", UserAgent: browser}))
+	suite.False(suite.track(domain.BlogView{BlogID: 1, ClientIP: "10.0.0.1", UserAgent: browser}))
+	suite.True(suite.track(domain.BlogView{BlogID: 1, ClientIP: "10.0.0.3", UserAgent: browser}))
+
+	suite.blogRepo.On("AddViews", suite.ctx, map[int64]int{1: 4, 2: 1}).Return(nil).Once()
+	suite.expectStats(nil)
+	suite.NoError(suite.tracker.Flush(suite.ctx))
+	// nothing left to write
+	suite.NoError(suite.tracker.Flush(suite.ctx))
+	suite.blogRepo.AssertExpectations(suite.T())
+}
+
+func (suite *ViewTrackerTestSuite) TestIgnoresBots() {
+	for _, agent := range []string{"", "Googlebot/2.1 (+http://www.google.com/bot.html)", "curl/8.5.0", "python-requests/2.31", "Mozilla/5.0 HeadlessChrome/120.0"} {
+		suite.False(suite.track(domain.BlogView{BlogID: 1, UserID: 7, UserAgent: agent}), agent)
+	}
+	suite.NoError(suite.tracker.Flush(suite.ctx))
+	suite.blogRepo.AssertNotCalled(suite.T(), "AddViews", mock.Anything, mock.Anything)
+	suite.analyticsRepo.AssertNotCalled(suite.T(), "AddDailyStats", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *ViewTrackerTestSuite) TestFailedFlushIsRetried() {
+	suite.True(suite.track(domain.BlogView{BlogID: 1, UserID: 7, UserAgent: browser}))
+	suite.blogRepo.On("AddViews", suite.ctx, map[int64]int{1: 1}).Return(errors.New("db down")).Once()
+	suite.expectStats(errors.New("db down"))
+	suite.EqualError(suite.tracker.Flush(suite.ctx), "failed to flush views: db down\nfailed to flush stats: db down")
+
+	suite.True(suite.track(domain.BlogView{BlogID: 1, UserID: 8, UserAgent: browser}))
+	suite.tracker.RecordActivity(1, 1, 0)
+	suite.blogRepo.On("AddViews", suite.ctx, map[int64]int{1: 2}).Return(nil).Once()
+	stats, referrers := suite.expectStats(nil)
+	suite.NoError(suite.tracker.Flush(suite.ctx))
+	suite.Require().Len(*stats, 1)
+	suite.Equal(2, (*stats)[0].Views)
+	suite.Equal(2, (*stats)[0].UniqueViewers)
+	suite.Equal(1, (*stats)[0].Likes)
+	suite.Equal(map[string]int{domain.DirectReferrer: 2}, referrers)
+	suite.blogRepo.AssertExpectations(suite.T())
+}
+
+func (suite *ViewTrackerTestSuite) TestFailingFlushIsDroppedEventually() {
+	suite.True(suite.track(domain.BlogView{BlogID: 1, UserID: 7, UserAgent: browser}))
+	suite.blogRepo.On("AddViews", suite.ctx, map[int64]int{1: 1}).Return(errors.New("invalid input")).Times(12)
+	suite.analyticsRepo.On("AddDailyStats", suite.ctx, mock.Anything, mock.Anything).Return(errors.New("invalid input")).Times(12)
+	for i := 0; i < 11; i++ {
+		suite.EqualError(suite.tracker.Flush(suite.ctx), "failed to flush views: invalid input\nfailed to flush stats: invalid input")
+	}
+	suite.EqualError(suite.tracker.Flush(suite.ctx), "failed to flush views, dropped after 12 attempts: invalid input\nfailed to flush stats, dropped after 12 attempts: invalid input")
+
+	// nothing is left to write
+	suite.NoError(suite.tracker.Flush(suite.ctx))
+	suite.blogRepo.AssertExpectations(suite.T())
+	suite.analyticsRepo.AssertExpectations(suite.T())
+}
+
+func (suite *ViewTrackerTestSuite) TestDailyStats() {
+	suite.T().Setenv("VIEW_DEDUP_WINDOW", "0")
+	tracker := usecases.NewViewTracker(suite.blogRepo, suite.analyticsRepo, infrastructure.NewMemoryViewStore())
+	for _, view := range []domain.BlogView{
+		{BlogID: 1, UserID: 7, UserAgent: browser, Referrer: "https://www.Google.com/search?q=go"},
+		{BlogID: 1, UserID: 7, UserAgent: browser},
+		{BlogID: 1, UserID: 8, UserAgent: browser, Referrer: "https://news.ycombinator.com/item?id=1"},
+		{BlogID: 1, UserID: 9, UserAgent: browser, Referrer: "not a url"},
+		{BlogID: 1, UserID: 10, UserAgent: browser, Referrer: "https://%ff%fe.example/"},
+		{BlogID: 1, UserID: 11, UserAgent: browser, Referrer: "https://" + strings.Repeat("é", 120) + ".com/"},
+	} {
+		counted, err := tracker.TrackView(suite.ctx, view)
+		suite.NoError(err)
+		suite.True(counted)
+	}
+	tracker.RecordActivity(1, 1, 0)
+	tracker.RecordActivity(1, 0, 1)
+	tracker.RecordActivity(2, -1, 0)
+
+	suite.blogRepo.On("AddViews", suite.ctx, map[int64]int{1: 6}).Return(nil).Once()
+	stats, referrers := suite.expectStats(nil)
+	suite.NoError(tracker.Flush(suite.ctx))
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	byBlog := make(map[int64]domain.BlogDailyStats)
+	for _, s := range *stats {
+		suite.Equal(today, s.Day)
+		byBlog[s.BlogID] = *s
+	}
+	suite.Equal(map[int64]domain.BlogDailyStats{
+		1: {BlogID: 1, Day: today, Views: 6, UniqueViewers: 5, Likes: 1, Comments: 1},
+		2: {BlogID: 2, Day: today, Likes: -1},
+	}, byBlog)
+	// hosts that are not UTF-8 are unknown, long ones are cut by character
+	suite.Equal(map[string]int{"google.com": 1, domain.DirectReferrer: 1, "news.ycombinator.com": 1, "unknown": 2, strings.Repeat("é", 100): 1}, referrers)
+}
+
+func (suite *ViewTrackerTestSuite) TestCountsWhenStoreFails() {
+	tracker := usecases.NewViewTracker(suite.blogRepo, suite.analyticsRepo, failingViewStore{})
+	for i := 0; i < 2; i++ {
+		counted, err := tracker.TrackView(suite.ctx, domain.BlogView{BlogID: 1, UserID: 7, UserAgent: browser})
+		suite.NoError(err)
+		suite.True(counted)
+	}
+}
+
+func (suite *ViewTrackerTestSuite) TestInvalidBlog() {
+	_, err := suite.tracker.TrackView(suite.ctx, domain.BlogView{BlogID: 0, UserAgent: browser})
+	suite.EqualError(err, "invalid blog ID")
+
+	suite.blogRepo.On("FetchByID", suite.ctx, int64(404)).Return(nil, errors.New("record not found"))
+	counted, err := suite.tracker.TrackView(suite.ctx, domain.BlogView{BlogID: 404, UserAgent: browser})
+	suite.EqualError(err, "blog not found")
+	suite.False(counted)
+}
+
+func TestViewTrackerTestSuite(t *testing.T) {
+	suite.Run(t, new(ViewTrackerTestSuite))
+}
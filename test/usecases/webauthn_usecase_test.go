@@ -0,0 +1,215 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blog-platform/domain"
+	"github.com/blog-platform/infrastructure"
+	"github.com/blog-platform/test/mocks"
+	"github.com/blog-platform/usecases"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebAuthnUsecaseTestSuite struct {
+	suite.Suite
+	webAuthnRepo    *mocks.MockWebAuthnRepository
+	userRepo        *mocks.MockUserRepository
+	loginRepo       *mocks.MockLoginAttemptRepository
+	jwtService      *mocks.MockJWTService
+	tokenRepo       *mocks.MockTokenRepository
+	authenticator   *mocks.SoftAuthenticator
+	webAuthnUsecase domain.IWebAuthnUsecase
+	ctx             context.Context
+	user            domain.User
+	challenges      map[string]*domain.WebAuthnChallenge
+}
+
+func (suite *WebAuthnUsecaseTestSuite) SetupTest() {
+	suite.webAuthnRepo = new(mocks.MockWebAuthnRepository)
+	suite.userRepo = new(mocks.MockUserRepository)
+	suite.loginRepo = new(mocks.MockLoginAttemptRepository)
+	suite.jwtService = new(mocks.MockJWTService)
+	suite.tokenRepo = new(mocks.MockTokenRepository)
+	suite.ctx = context.Background()
+	suite.user = domain.User{ID: 7, Username: "alice", Email: "alice@example.com", Role: "user", TOTPEnabled: true}
+
+	// the real verifier is used against a software authenticator
+	service := &infrastructure.WebAuthnService{RPID: "localhost", RPName: "Blog Platform", Origins: []string{"http://localhost:8080"}}
+	suite.authenticator = mocks.NewSoftAuthenticator("localhost", "http://localhost:8080")
+
+	uu := usecases.NewUserUsecase(suite.userRepo, new(mocks.MockOutboxRepository), suite.loginRepo, new(mocks.MockPasswordService), suite.jwtService, suite.tokenRepo, new(mocks.MockTOTPService), infrastructure.DefaultPasswordPolicy())
+	suite.webAuthnUsecase = usecases.NewWebAuthnUsecase(suite.webAuthnRepo, suite.userRepo, suite.loginRepo, service, uu)
+
+	suite.loginRepo.On("CountFailuresByIP", "10.0.0.1", mock.AnythingOfType("time.Time")).Return(int64(0), nil).Maybe()
+
+	// challenges are kept by hash and handed out once, like the repository
+	suite.challenges = map[string]*domain.WebAuthnChallenge{}
+	suite.webAuthnRepo.On("SaveChallenge", suite.ctx, mock.AnythingOfType("*domain.WebAuthnChallenge")).
+		Run(func(args mock.Arguments) {
+			c := args.Get(1).(*domain.WebAuthnChallenge)
+			suite.challenges[c.ChallengeHash] = c
+		}).
+		Return(nil).Maybe()
+	consume := suite.webAuthnRepo.On("ConsumeChallenge", suite.ctx, mock.AnythingOfType("string")).Maybe()
+	consume.Run(func(args mock.Arguments) {
+		hash := args.String(1)
+		consume.ReturnArguments = mock.Arguments{suite.challenges[hash], nil}
+		delete(suite.challenges, hash)
+	})
+}
+
+func (suite *WebAuthnUsecaseTestSuite) credential() *domain.WebAuthnCredential {
+	return &domain.WebAuthnCredential{
+		ID:           3,
+		UserID:       suite.user.ID,
+		Name:         "Laptop",
+		CredentialID: suite.authenticator.ID(),
+		PublicKey:    suite.authenticator.COSEKey(),
+		SignCount:    int64(suite.authenticator.SignCount),
+	}
+}
+
+func (suite *WebAuthnUsecaseTestSuite) beginLogin() string {
+	options, err := suite.webAuthnUsecase.BeginLogin(suite.ctx)
+	suite.Require().NoError(err)
+	return options.Challenge
+}
+
+func (suite *WebAuthnUsecaseTestSuite) TestRegistration() {
+	suite.userRepo.On("GetUserProfile", suite.user.ID).Return(&suite.user, nil)
+	suite.webAuthnRepo.On("ListCredentials", suite.ctx, suite.user.ID).
+		Return([]domain.WebAuthnCredential{{CredentialID: "old", Transports: "usb,nfc"}}, nil)
+
+	options, err := suite.webAuthnUsecase.BeginRegistration(suite.ctx, suite.user.ID)
+	suite.NoError(err)
+	suite.Equal("localhost", options.RP.ID)
+	suite.Equal("alice", options.User.Name)
+	suite.Equal("required", options.AuthenticatorSelection.UserVerification)
+	suite.Equal([]domain.WebAuthnCredentialDescriptor{{Type: "public-key", ID: "old", Transports: []string{"usb", "nfc"}}}, options.ExcludeCredentials)
+
+	var saved *domain.WebAuthnCredential
+	suite.webAuthnRepo.On("FetchCredential", suite.ctx, suite.authenticator.ID()).Return(nil, nil)
+	suite.webAuthnRepo.On("CreateCredential", suite.ctx, mock.AnythingOfType("*domain.WebAuthnCredential")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*domain.WebAuthnCredential) }).
+		Return(nil)
+
+	credential, err := suite.webAuthnUsecase.FinishRegistration(suite.ctx, suite.user.ID, " ", suite.authenticator.Register(options.Challenge))
+	suite.NoError(err)
+	suite.Equal(saved, credential)
+	suite.Equal("Passkey", saved.Name)
+	suite.Equal(suite.authenticator.ID(), saved.CredentialID)
+	suite.Equal(suite.authenticator.COSEKey(), saved.PublicKey)
+	suite.Equal("internal", saved.Transports)
+	suite.Empty(suite.challenges, "the challenge is single use")
+}
+
+func (suite *WebAuthnUsecaseTestSuite) TestFinishRegistration_ChallengeOfAnotherUser() {
+	suite.userRepo.On("GetUserProfile", suite.user.ID).Return(&suite.user, nil)
+	suite.webAuthnRepo.On("ListCredentials", suite.ctx, suite.user.ID).Return([]domain.WebAuthnCredential{}, nil)
+	options, err := suite.webAuthnUsecase.BeginRegistration(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+
+	_, err = suite.webAuthnUsecase.FinishRegistration(suite.ctx, 8, "", suite.authenticator.Register(options.Challenge))
+	suite.EqualError(err, "invalid or expired challenge")
+	suite.webAuthnRepo.AssertNotCalled(suite.T(), "CreateCredential", mock.Anything, mock.Anything)
+}
+
+func (suite *WebAuthnUsecaseTestSuite) TestFinishRegistration_AlreadyRegistered() {
+	suite.userRepo.On("GetUserProfile", suite.user.ID).Return(&suite.user, nil)
+	suite.webAuthnRepo.On("ListCredentials", suite.ctx, suite.user.ID).Return([]domain.WebAuthnCredential{}, nil)
+	suite.webAuthnRepo.On("FetchCredential", suite.ctx, suite.authenticator.ID()).Return(suite.credential(), nil)
+	options, err := suite.webAuthnUsecase.BeginRegistration(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+
+	_, err = suite.webAuthnUsecase.FinishRegistration(suite.ctx, suite.user.ID, "", suite.authenticator.Register(options.Challenge))
+	suite.EqualError(err, "passkey is already registered")
+}
+
+func (suite *WebAuthnUsecaseTestSuite) TestLogin_SkipsTOTP() {
+	credential := suite.credential()
+	suite.webAuthnRepo.On("FetchCredential", suite.ctx, suite.authenticator.ID()).Return(credential, nil)
+	suite.webAuthnRepo.On("UpdateSignCount", suite.ctx, credential.ID, int64(0), int64(1), mock.AnythingOfType("time.Time")).Return(true, nil)
+	suite.userRepo.On("Fetch", "7").Return(suite.user, nil)
+	suite.loginRepo.On("Record", mock.MatchedBy(func(a *domain.LoginAttempt) bool { return a.Success && *a.UserID == 7 })).Return(nil)
+	suite.jwtService.On("GenerateAccessToken", "7", "user").Return("access_token", nil)
+	suite.jwtService.On("GenerateRefreshToken", "7", "user").Return("refresh_token", nil)
+	suite.tokenRepo.On("Save", mock.AnythingOfType("*domain.Token")).Return(nil).Twice()
+
+	suite.authenticator.UserHandle = "Nw" // base64url of "7"
+	result, err := suite.webAuthnUsecase.FinishLogin(suite.ctx, suite.authenticator.Login(suite.beginLogin()), "10.0.0.1")
+	suite.NoError(err)
+	suite.Equal("access_token", result.AccessToken)
+	suite.Empty(result.MFAToken)
+	suite.jwtService.AssertNotCalled(suite.T(), "GenerateMFAToken", mock.Anything)
+}
+
+func (suite *WebAuthnUsecaseTestSuite) TestLogin_SignCountRegression() {
+	credential := suite.credential()
+	credential.SignCount = 5
+	suite.authenticator.SignCount = 2
+	suite.webAuthnRepo.On("FetchCredential", suite.ctx, suite.authenticator.ID()).Return(credential, nil)
+	suite.loginRepo.On("Record", mock.MatchedBy(func(a *domain.LoginAttempt) bool { return !a.Success })).Return(nil)
+
+	_, err := suite.webAuthnUsecase.FinishLogin(suite.ctx, suite.authenticator.Login(suite.beginLogin()), "10.0.0.1")
+	suite.EqualError(err, "passkey login failed")
+	suite.webAuthnRepo.AssertNotCalled(suite.T(), "UpdateSignCount", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *WebAuthnUsecaseTestSuite) TestLogin_UserHandleMismatch() {
+	suite.webAuthnRepo.On("FetchCredential", suite.ctx, suite.authenticator.ID()).Return(suite.credential(), nil)
+	suite.loginRepo.On("Record", mock.AnythingOfType("*domain.LoginAttempt")).Return(nil)
+
+	suite.authenticator.UserHandle = "OA" // base64url of "8"
+	_, err := suite.webAuthnUsecase.FinishLogin(suite.ctx, suite.authenticator.Login(suite.beginLogin()), "10.0.0.1")
+	suite.EqualError(err, "passkey login failed")
+}
+
+func (suite *WebAuthnUsecaseTestSuite) TestLogin_UnknownCredential() {
+	suite.webAuthnRepo.On("FetchCredential", suite.ctx, suite.authenticator.ID()).Return(nil, nil)
+	suite.loginRepo.On("Record", mock.MatchedBy(func(a *domain.LoginAttempt) bool { return a.UserID == nil && !a.Success })).Return(nil)
+
+	_, err := suite.webAuthnUsecase.FinishLogin(suite.ctx, suite.authenticator.Login(suite.beginLogin()), "10.0.0.1")
+	suite.EqualError(err, "passkey login failed")
+}
+
+func (suite *WebAuthnUsecaseTestSuite) TestLogin_ExpiredChallenge() {
+	challenge := suite.beginLogin()
+	for _, c := range suite.challenges {
+		c.ExpiresAt = time.Now().Add(-time.Second)
+	}
+
+	_, err := suite.webAuthnUsecase.FinishLogin(suite.ctx, suite.authenticator.Login(challenge), "10.0.0.1")
+	suite.EqualError(err, "invalid or expired challenge")
+}
+
+func (suite *WebAuthnUsecaseTestSuite) TestLogin_RegistrationChallengeRejected() {
+	suite.userRepo.On("GetUserProfile", suite.user.ID).Return(&suite.user, nil)
+	suite.webAuthnRepo.On("ListCredentials", suite.ctx, suite.user.ID).Return([]domain.WebAuthnCredential{}, nil)
+	options, err := suite.webAuthnUsecase.BeginRegistration(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+
+	_, err = suite.webAuthnUsecase.FinishLogin(suite.ctx, suite.authenticator.Login(options.Challenge), "10.0.0.1")
+	suite.EqualError(err, "invalid or expired challenge")
+}
+
+func (suite *WebAuthnUsecaseTestSuite) TestLogin_ThrottledIP() {
+	suite.loginRepo.On("CountFailuresByIP", "10.0.0.2", mock.AnythingOfType("time.Time")).Return(int64(50), nil)
+
+	_, err := suite.webAuthnUsecase.FinishLogin(suite.ctx, suite.authenticator.Login(suite.beginLogin()), "10.0.0.2")
+	suite.EqualError(err, "too many failed login attempts")
+	suite.webAuthnRepo.AssertNotCalled(suite.T(), "FetchCredential", mock.Anything, mock.Anything)
+}
+
+func (suite *WebAuthnUsecaseTestSuite) TestDeleteCredential_NotFound() {
+	suite.webAuthnRepo.On("DeleteCredential", suite.ctx, suite.user.ID, int64(9)).Return(false, nil)
+
+	err := suite.webAuthnUsecase.DeleteCredential(suite.ctx, suite.user.ID, 9)
+	suite.EqualError(err, "passkey not found")
+}
+
+func TestWebAuthnUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(WebAuthnUsecaseTestSuite))
+}
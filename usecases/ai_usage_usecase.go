@@ -0,0 +1,182 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+// default daily token quotas by role; other roles get the user quota
+const (
+	defaultAIUserDailyTokens  = 50000
+	defaultAIAdminDailyTokens = domain.AIUnlimited
+)
+
+type aiUsageUsecase struct {
+	usageRepo  domain.IAIUsageRepository
+	userRepo   domain.IUserRepository
+	roleQuotas map[string]int64
+	now        func() time.Time
+}
+
+// NewAIUsageUsecase reads the daily token quota of each role from
+// AI_DAILY_TOKENS_USER and AI_DAILY_TOKENS_ADMIN; "unlimited" lifts it.
+func NewAIUsageUsecase(ar domain.IAIUsageRepository, ur domain.IUserRepository) domain.IAIUsageUsecase {
+	return &aiUsageUsecase{
+		usageRepo: ar,
+		userRepo:  ur,
+		roleQuotas: map[string]int64{
+			"user":  envDailyTokens("AI_DAILY_TOKENS_USER", defaultAIUserDailyTokens),
+			"admin": envDailyTokens("AI_DAILY_TOKENS_ADMIN", defaultAIAdminDailyTokens),
+		},
+		now: time.Now,
+	}
+}
+
+func envDailyTokens(name string, def int64) int64 {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return def
+	}
+	if value == "unlimited" {
+		return domain.AIUnlimited
+	}
+	tokens, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || tokens < 0 {
+		log.Printf("%s: must be a number of tokens or 'unlimited', using %d", name, def)
+		return def
+	}
+	return tokens
+}
+
+// startOfDay is the start of the current UTC day, when quotas reset.
+func (au *aiUsageUsecase) startOfDay() time.Time {
+	return au.now().UTC().Truncate(24 * time.Hour)
+}
+
+// quota returns the daily tokens for a user and whether they are a per-user
+// override.
+func (au *aiUsageUsecase) quota(ctx context.Context, userID int64, role string) (int64, bool, error) {
+	override, err := au.usageRepo.FetchQuota(ctx, userID)
+	if err != nil {
+		return 0, false, err
+	}
+	if override != nil {
+		return override.DailyTokens, true, nil
+	}
+	if tokens, ok := au.roleQuotas[role]; ok {
+		return tokens, false, nil
+	}
+	return au.roleQuotas["user"], false, nil
+}
+
+func (au *aiUsageUsecase) CheckQuota(ctx context.Context, caller domain.AICaller) error {
+	if caller.UserID == 0 {
+		return nil // system requests
+	}
+	limit, _, err := au.quota(ctx, caller.UserID, caller.Role)
+	if err == nil && limit != domain.AIUnlimited {
+		var used int64
+		used, err = au.usageRepo.SumTokens(ctx, caller.UserID, au.startOfDay())
+		if err == nil && used >= limit {
+			return domain.ErrAIQuotaExceeded
+		}
+	}
+	if err != nil {
+		// like the rate limiter, let requests through when the store fails
+		log.Printf("ai quota: checking user %d: %v", caller.UserID, err)
+	}
+	return nil
+}
+
+func (au *aiUsageUsecase) Record(ctx context.Context, usage *domain.AIUsage) {
+	if err := au.usageRepo.Record(ctx, usage); err != nil {
+		log.Printf("ai usage: recording %s request of user %d: %v", usage.Feature, usage.UserID, err)
+	}
+}
+
+func validatePeriod(from, to time.Time) error {
+	if !to.After(from) {
+		return errors.New("from must be before to")
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		return errors.New("period cannot be longer than a year")
+	}
+	return nil
+}
+
+func (au *aiUsageUsecase) UsageByUser(ctx context.Context, from, to time.Time) ([]domain.AIUsageSummary, error) {
+	if err := validatePeriod(from, to); err != nil {
+		return nil, err
+	}
+	summaries, err := au.usageRepo.SummarizeByUser(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch usage: %w", err)
+	}
+	return summaries, nil
+}
+
+func (au *aiUsageUsecase) UsageForUser(ctx context.Context, userID int64, from, to time.Time) ([]domain.AIUsageSummary, error) {
+	if err := validatePeriod(from, to); err != nil {
+		return nil, err
+	}
+	summaries, err := au.usageRepo.SummarizeByDay(ctx, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch usage: %w", err)
+	}
+	return summaries, nil
+}
+
+func (au *aiUsageUsecase) GetQuota(ctx context.Context, userID int64) (*domain.AIQuotaStatus, error) {
+	user, err := au.userRepo.GetUserProfile(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	limit, override, err := au.quota(ctx, userID, user.Role)
+	if err != nil {
+		return nil, errors.New("failed to fetch quota")
+	}
+	start := au.startOfDay()
+	used, err := au.usageRepo.SumTokens(ctx, userID, start)
+	if err != nil {
+		return nil, errors.New("failed to fetch quota")
+	}
+	return &domain.AIQuotaStatus{
+		UserID:      userID,
+		DailyTokens: limit,
+		Override:    override,
+		UsedTokens:  used,
+		ResetsAt:    start.Add(24 * time.Hour),
+	}, nil
+}
+
+func (au *aiUsageUsecase) SetQuota(ctx context.Context, userID int64, dailyTokens int64) (*domain.AIQuotaStatus, error) {
+	if dailyTokens < 0 && dailyTokens != domain.AIUnlimited {
+		return nil, errors.New("daily_tokens must be at least 0, or -1 for no limit")
+	}
+	if _, err := au.userRepo.GetUserProfile(userID); err != nil {
+		return nil, errors.New("user not found")
+	}
+	if err := au.usageRepo.SaveQuota(ctx, &domain.AIQuota{UserID: userID, DailyTokens: dailyTokens}); err != nil {
+		return nil, errors.New("failed to save quota")
+	}
+	return au.GetQuota(ctx, userID)
+}
+
+func (au *aiUsageUsecase) ClearQuota(ctx context.Context, userID int64) error {
+	deleted, err := au.usageRepo.DeleteQuota(ctx, userID)
+	if err != nil {
+		return errors.New("failed to delete quota")
+	}
+	if !deleted {
+		return errors.New("quota not found")
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+// maxReferrers bounds the referrers listed for a period; daily lists are
+// complete.
+const maxReferrers = 20
+
+type analyticsUsecase struct {
+	analyticsRepo domain.IAnalyticsRepository
+	blogRepo      domain.IBlogRepository
+}
+
+func NewAnalyticsUsecase(ar domain.IAnalyticsRepository, br domain.IBlogRepository) domain.IAnalyticsUsecase {
+	return &analyticsUsecase{analyticsRepo: ar, blogRepo: br}
+}
+
+// periodDays lists every day of [from, to) with no activity.
+func periodDays(from, to time.Time) []domain.DayStats {
+	days := []domain.DayStats{}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, domain.DayStats{Day: day.Format(time.DateOnly)})
+	}
+	return days
+}
+
+// topReferrers sorts referrers by views, keeping at most limit if limit > 0.
+func topReferrers(views map[string]int, limit int) []domain.ReferrerCount {
+	referrers := make([]domain.ReferrerCount, 0, len(views))
+	for referrer, n := range views {
+		referrers = append(referrers, domain.ReferrerCount{Referrer: referrer, Views: n})
+	}
+	sort.Slice(referrers, func(i, j int) bool {
+		if referrers[i].Views != referrers[j].Views {
+			return referrers[i].Views > referrers[j].Views
+		}
+		return referrers[i].Referrer < referrers[j].Referrer
+	})
+	if limit > 0 && len(referrers) > limit {
+		referrers = referrers[:limit]
+	}
+	return referrers
+}
+
+func (au *analyticsUsecase) fetch(ctx context.Context, blogIDs []int64, from, to time.Time) ([]*domain.BlogDailyStats, []*domain.BlogReferrerStats, error) {
+	stats, err := au.analyticsRepo.FetchDailyStats(ctx, blogIDs, from, to)
+	if err != nil {
+		return nil, nil, errors.New("failed to fetch stats")
+	}
+	referrers, err := au.analyticsRepo.FetchReferrerStats(ctx, blogIDs, from, to)
+	if err != nil {
+		return nil, nil, errors.New("failed to fetch stats")
+	}
+	return stats, referrers, nil
+}
+
+func (au *analyticsUsecase) BlogAnalytics(ctx context.Context, blogID int64, from, to time.Time) (*domain.BlogAnalytics, error) {
+	if blogID <= 0 {
+		return nil, errors.New("invalid blog ID")
+	}
+	if err := validatePeriod(from, to); err != nil {
+		return nil, err
+	}
+	blog, err := au.blogRepo.FetchByID(ctx, blogID)
+	if err != nil {
+		return nil, errors.New("blog not found")
+	}
+	stats, referrers, err := au.fetch(ctx, []int64{blogID}, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &domain.BlogAnalytics{
+		BlogID: blogID,
+		Title:  blog.Title,
+		From:   from.Format(time.DateOnly),
+		To:     to.AddDate(0, 0, -1).Format(time.DateOnly),
+		Days:   periodDays(from, to),
+	}
+	index := make(map[string]int, len(analytics.Days))
+	for i, day := range analytics.Days {
+		index[day.Day] = i
+	}
+	for _, s := range stats {
+		if i, ok := index[s.Day.UTC().Format(time.DateOnly)]; ok {
+			analytics.Days[i].Add(s)
+			analytics.Totals.Add(s)
+		}
+	}
+	total := make(map[string]int)
+	daily := make([]map[string]int, len(analytics.Days))
+	for _, r := range referrers {
+		i, ok := index[r.Day.UTC().Format(time.DateOnly)]
+		if !ok {
+			continue
+		}
+		if daily[i] == nil {
+			daily[i] = make(map[string]int)
+		}
+		daily[i][r.Referrer] += r.Views
+		total[r.Referrer] += r.Views
+	}
+	for i, views := range daily {
+		if views != nil {
+			analytics.Days[i].Referrers = topReferrers(views, 0)
+		}
+	}
+	analytics.Referrers = topReferrers(total, maxReferrers)
+	return analytics, nil
+}
+
+func (au *analyticsUsecase) AuthorAnalytics(ctx context.Context, authorID int64, from, to time.Time) (*domain.AuthorAnalytics, error) {
+	if authorID <= 0 {
+		return nil, errors.New("invalid user ID")
+	}
+	if err := validatePeriod(from, to); err != nil {
+		return nil, err
+	}
+	blogs, err := au.blogRepo.FetchByFilter(ctx, domain.BlogFilter{UserID: &authorID})
+	if err != nil {
+		return nil, errors.New("failed to fetch posts")
+	}
+	blogIDs := make([]int64, 0, len(blogs))
+	posts := make(map[int64]*domain.PostStats, len(blogs))
+	for _, blog := range blogs {
+		blogIDs = append(blogIDs, blog.ID)
+		posts[blog.ID] = &domain.PostStats{BlogID: blog.ID, Title: blog.Title, Days: []domain.DayStats{}}
+	}
+	stats, referrers, err := au.fetch(ctx, blogIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &domain.AuthorAnalytics{
+		AuthorID: authorID,
+		From:     from.Format(time.DateOnly),
+		To:       to.AddDate(0, 0, -1).Format(time.DateOnly),
+		Days:     periodDays(from, to),
+		Posts:    make([]domain.PostStats, 0, len(posts)),
+	}
+	index := make(map[string]int, len(analytics.Days))
+	for i, day := range analytics.Days {
+		index[day.Day] = i
+	}
+	for _, s := range stats {
+		day := s.Day.UTC().Format(time.DateOnly)
+		i, ok := index[day]
+		post := posts[s.BlogID]
+		if !ok || post == nil {
+			continue
+		}
+		analytics.Days[i].Add(s)
+		analytics.Totals.Add(s)
+		post.Add(s)
+		dayStats := domain.DayStats{Day: day}
+		dayStats.Add(s)
+		post.Days = append(post.Days, dayStats)
+	}
+	total := make(map[string]int)
+	for _, r := range referrers {
+		if _, ok := index[r.Day.UTC().Format(time.DateOnly)]; ok {
+			total[r.Referrer] += r.Views
+		}
+	}
+	analytics.Referrers = topReferrers(total, maxReferrers)
+
+	for _, blog := range blogs {
+		analytics.Posts = append(analytics.Posts, *posts[blog.ID])
+	}
+	sort.SliceStable(analytics.Posts, func(i, j int) bool {
+		return analytics.Posts[i].Views > analytics.Posts[j].Views
+	})
+	return analytics, nil
+}
@@ -0,0 +1,167 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blog-platform/domain"
+)
+
+const (
+	maxQuestionLength = 500
+	// posts retrieved by semantic search before their chunks are ranked
+	askCandidatePosts = 5
+	// chunks sent to the provider with the question
+	askMaxChunks = 6
+	// chunks are runs of whole paragraphs up to this many characters
+	chunkTargetLength = 1200
+)
+
+var paragraphBreak = regexp.MustCompile(`\n\s*\n`)
+
+type askUsecase struct {
+	search    domain.ISemanticSearchUsecase
+	embedder  domain.IEmbeddingProvider
+	aiService domain.IAIService
+}
+
+// NewAskUsecase answers with "semantic search is not configured" when
+// embedder is nil, as retrieval depends on it.
+func NewAskUsecase(search domain.ISemanticSearchUsecase, embedder domain.IEmbeddingProvider, aiService domain.IAIService) domain.IAskUsecase {
+	return &askUsecase{search: search, embedder: embedder, aiService: aiService}
+}
+
+// Ask retrieves the posts closest to the question, ranks their chunks
+// against it and has the provider answer from the best ones. Posts come from
+// the blog store at question time, so deleted posts are never cited.
+func (au *askUsecase) Ask(ctx context.Context, question string) (*domain.BlogAnswer, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, errors.New("question is required")
+	}
+	if utf8.RuneCountInString(question) > maxQuestionLength {
+		return nil, errors.New("question cannot be longer than 500 characters")
+	}
+	if au.embedder == nil {
+		return nil, errSemanticSearchDisabled
+	}
+
+	posts, err := au.search.SemanticSearch(ctx, question, askCandidatePosts)
+	if err != nil {
+		return nil, err
+	}
+	var chunks []domain.BlogChunk
+	for _, post := range posts {
+		chunks = append(chunks, chunkBlog(post.Blog)...)
+	}
+	if len(chunks) == 0 {
+		return &domain.BlogAnswer{Answer: "No posts cover this question yet.", Citations: []domain.BlogCitation{}}, nil
+	}
+
+	texts := make([]string, len(chunks)+1)
+	texts[0] = question
+	for i, chunk := range chunks {
+		texts[i+1] = chunk.Title + "\n\n" + chunk.Text
+	}
+	vectors, err := au.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	scores := make([]float64, len(chunks))
+	for i := range chunks {
+		scores[i] = cosine(vectors[0], vectors[i+1])
+	}
+	order := make([]int, len(chunks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	selected := make([]domain.BlogChunk, 0, askMaxChunks)
+	for _, i := range order[:min(askMaxChunks, len(order))] {
+		selected = append(selected, chunks[i])
+	}
+	return au.aiService.AnswerQuestion(ctx, question, selected)
+}
+
+// chunkBlog splits a post into runs of whole paragraphs of up to
+// chunkTargetLength characters; longer paragraphs are cut between words.
+// Paragraphs are the blocks separated by blank lines, numbered from 1.
+func chunkBlog(blog *domain.Blog) []domain.BlogChunk {
+	var chunks []domain.BlogChunk
+	var current *domain.BlogChunk
+	number := 0
+	for _, paragraph := range paragraphBreak.Split(blog.Content, -1) {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		number++
+		for _, piece := range splitWords(paragraph, chunkTargetLength) {
+			if current != nil && utf8.RuneCountInString(current.Text)+2+utf8.RuneCountInString(piece) > chunkTargetLength {
+				chunks = append(chunks, *current)
+				current = nil
+			}
+			if current == nil {
+				current = &domain.BlogChunk{BlogID: blog.ID, Title: blog.Title, ParagraphStart: number, ParagraphEnd: number, Text: piece}
+				continue
+			}
+			current.Text += "\n\n" + piece
+			current.ParagraphEnd = number
+		}
+	}
+	if current != nil {
+		chunks = append(chunks, *current)
+	}
+	return chunks
+}
+
+// splitWords cuts text into pieces of at most limit characters between
+// words; a single longer word becomes a piece of its own.
+func splitWords(text string, limit int) []string {
+	if utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+	var pieces []string
+	var piece strings.Builder
+	length := 0
+	for _, word := range strings.Fields(text) {
+		n := utf8.RuneCountInString(word)
+		if length > 0 && length+1+n > limit {
+			pieces = append(pieces, piece.String())
+			piece.Reset()
+			length = 0
+		}
+		if length > 0 {
+			piece.WriteByte(' ')
+			length++
+		}
+		piece.WriteString(word)
+		length += n
+	}
+	if length > 0 {
+		pieces = append(pieces, piece.String())
+	}
+	return pieces
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
@@ -4,20 +4,133 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blog-platform/domain"
 )
 
+// insightsTimeout bounds generating insights for a new post in the background.
+const insightsTimeout = 2 * time.Minute
+
+// indexTimeout bounds updating the vector index in the background.
+const indexTimeout = time.Minute
+
+// moderation defaults
+const (
+	defaultModerationThreshold = 0.7
+	defaultModerationTimeout   = 10 * time.Second
+)
+
 type blogUsecase struct {
-	blogRepo  domain.IBlogRepository
-	aiService domain.IAIService
+	blogRepo     domain.IBlogRepository
+	aiService    domain.IAIService
+	notifier     domain.INotifier
+	indexer      domain.IBlogIndexer
+	prompts      domain.IPromptTemplateUsecase
+	activity     domain.IActivityRecorder
+	autoInsights bool
+	moderation   moderationConfig
+}
+
+type moderationConfig struct {
+	enabled bool
+	// comments flagged with at least this confidence are held
+	threshold float64
+	// status of comments that could not be moderated
+	fallback string
+	timeout  time.Duration
 }
 
-func NewBlogUsecase(repo domain.IBlogRepository, aiService domain.IAIService) domain.IBlogUsecase {
+// NewBlogUsecase generates insights for every new post when AI_AUTO_INSIGHTS
+// is "true", and moderates new comments when AI_MODERATION is "true"; see
+// moderationFromEnv. Posts are embedded for semantic search through indexer,
+// which may be nil. Writing helpers are prompted through prompts. Likes and
+// comments are added to the daily stats through activity, which may be nil.
+func NewBlogUsecase(repo domain.IBlogRepository, aiService domain.IAIService, notifier domain.INotifier, indexer domain.IBlogIndexer, prompts domain.IPromptTemplateUsecase, activity domain.IActivityRecorder) domain.IBlogUsecase {
 	return &blogUsecase{
-		blogRepo:  repo,
-		aiService: aiService,
+		blogRepo:     repo,
+		aiService:    aiService,
+		notifier:     notifier,
+		indexer:      indexer,
+		prompts:      prompts,
+		activity:     activity,
+		autoInsights: os.Getenv("AI_AUTO_INSIGHTS") == "true",
+		moderation:   moderationFromEnv(),
+	}
+}
+
+// moderationFromEnv reads AI_MODERATION_THRESHOLD (default 0.7),
+// AI_MODERATION_FALLBACK, "publish" or "hold" for comments the provider could
+// not classify (default publish), and AI_MODERATION_TIMEOUT (default 10s).
+func moderationFromEnv() moderationConfig {
+	config := moderationConfig{
+		enabled:   os.Getenv("AI_MODERATION") == "true",
+		threshold: defaultModerationThreshold,
+		fallback:  domain.CommentPublished,
+		timeout:   defaultModerationTimeout,
+	}
+	if value := os.Getenv("AI_MODERATION_THRESHOLD"); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			log.Printf("AI_MODERATION_THRESHOLD: must be between 0 and 1, using %v", config.threshold)
+		} else {
+			config.threshold = threshold
+		}
+	}
+	switch value := os.Getenv("AI_MODERATION_FALLBACK"); value {
+	case "", "publish":
+	case "hold":
+		config.fallback = domain.CommentHeld
+	default:
+		log.Printf("AI_MODERATION_FALLBACK: must be 'publish' or 'hold', using publish")
+	}
+	if value := os.Getenv("AI_MODERATION_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			log.Printf("AI_MODERATION_TIMEOUT: invalid duration '%s', using %s", value, config.timeout)
+		} else {
+			config.timeout = timeout
+		}
+	}
+	return config
+}
+
+// reindex updates the vector index in the background after a post is
+// written or deleted; failures are only logged.
+func (uc blogUsecase) reindex(ctx context.Context, blogID int64, deleted bool) {
+	if uc.indexer == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), indexTimeout)
+		defer cancel()
+		var err error
+		if deleted {
+			err = uc.indexer.RemoveBlog(ctx, blogID)
+		} else {
+			err = uc.indexer.IndexBlog(ctx, blogID)
+		}
+		if err != nil {
+			log.Printf("failed to update the vector index for blog %d: %v", blogID, err)
+		}
+	}()
+}
+
+// recordActivity counts likes and comments in the post's daily stats.
+func (uc blogUsecase) recordActivity(blogID int64, likes, comments int) {
+	if uc.activity != nil {
+		uc.activity.RecordActivity(blogID, likes, comments)
+	}
+}
+
+// notify delivers an event without failing the action that caused it.
+func (uc blogUsecase) notify(ctx context.Context, event domain.NotificationEvent) {
+	if err := uc.notifier.Notify(ctx, event); err != nil {
+		log.Printf("failed to emit %s notification: %v", event.Type, err)
 	}
 }
 
@@ -40,10 +153,12 @@ func (uc blogUsecase) CreateBlog(ctx context.Context, blog *domain.Blog, tags []
 		return errors.New("blog ID not set after creation")
 	}
 
+	linked := 0
 	for _, tag := range tags {
 		if tag == "" {
 			continue // skip empty tags
 		}
+		linked++
 
 		tagID, err := uc.blogRepo.FindOrCreateTag(ctx, tag)
 		if err != nil {
@@ -56,9 +171,69 @@ func (uc blogUsecase) CreateBlog(ctx context.Context, blog *domain.Blog, tags []
 		}
 	}
 
+	uc.notify(ctx, domain.NotificationEvent{
+		Type:    domain.NotificationNewPost,
+		ActorID: blog.UserID,
+		BlogID:  blog.ID,
+	})
+
+	uc.reindex(ctx, blog.ID, false)
+	if uc.autoInsights {
+		// the provider may take a while; the post is saved either way
+		go uc.applyInsights(context.WithoutCancel(ctx), blog.ID, blog.Title, blog.Content, linked == 0)
+	}
 	return nil
 }
 
+// applyInsights generates and stores insights for a new post and, when the
+// author gave no tags, links the suggested ones. Failures are only logged.
+func (uc *blogUsecase) applyInsights(ctx context.Context, blogID int64, title, content string, linkTags bool) {
+	ctx, cancel := context.WithTimeout(ctx, insightsTimeout)
+	defer cancel()
+
+	insights, err := uc.aiService.GenerateBlogInsights(ctx, title, content)
+	if err != nil {
+		log.Printf("failed to generate insights for blog %d: %v", blogID, err)
+		return
+	}
+	if err := uc.blogRepo.UpdateInsights(ctx, blogID, insights); err != nil {
+		log.Printf("failed to store insights for blog %d: %v", blogID, err)
+		return
+	}
+	if !linkTags {
+		return
+	}
+	for _, tag := range insights.Tags {
+		tagID, err := uc.blogRepo.FindOrCreateTag(ctx, tag)
+		if err == nil {
+			err = uc.blogRepo.LinkTagToBlog(ctx, blogID, tagID)
+		}
+		if err != nil {
+			log.Printf("failed to apply suggested tag '%s' to blog %d: %v", tag, blogID, err)
+		}
+	}
+}
+
+// RegenerateBlogInsights replaces the stored insights of a post; suggested
+// tags are stored but not applied.
+func (uc *blogUsecase) RegenerateBlogInsights(ctx context.Context, blogID int64) (*domain.BlogInsights, error) {
+	if blogID <= 0 {
+		return nil, errors.New("invalid blog ID")
+	}
+	blog, err := uc.blogRepo.FetchByID(ctx, blogID)
+	if err != nil {
+		return nil, errors.New("blog not found")
+	}
+	insights, err := uc.aiService.GenerateBlogInsights(ctx, blog.Title, blog.Content)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.blogRepo.UpdateInsights(ctx, blogID, insights); err != nil {
+		return nil, err
+	}
+	return insights, nil
+}
+
 func (uc blogUsecase) FetchBlogByID(ctx context.Context, id int64) (*domain.Blog, error) {
 	if id <= 0 {
 		return nil, errors.New("invalid blog ID")
@@ -81,7 +256,11 @@ func (uc *blogUsecase) FetchAllBlogs(ctx context.Context) ([]*domain.Blog, error
 }
 
 func (u *blogUsecase) DeleteBlog(ctx context.Context, ID int64, userID string) error {
-	return u.blogRepo.DeleteByID(ctx, ID, userID)
+	if err := u.blogRepo.DeleteByID(ctx, ID, userID); err != nil {
+		return err
+	}
+	u.reindex(ctx, ID, true)
+	return nil
 }
 
 func (uc *blogUsecase) UpdateBlog(ctx context.Context, id int64, userID string, updates map[string]interface{}) error {
@@ -109,15 +288,43 @@ func (uc *blogUsecase) UpdateBlog(ctx context.Context, id int64, userID string,
 	if len(filtered) == 0 {
 		return nil
 	}
-	return uc.blogRepo.UpdateByID(ctx, id, userID, filtered)
+	if err := uc.blogRepo.UpdateByID(ctx, id, userID, filtered); err != nil {
+		return err
+	}
+	uc.reindex(ctx, id, false)
+	return nil
 }
 
-func (uc *blogUsecase) GenerateBlogIdeas(topic string) (string, error) {
-	return uc.aiService.GenerateBlogIdeas(topic)
+func (uc *blogUsecase) GenerateBlogIdeas(ctx context.Context, topic string, opts domain.PromptOptions) (string, error) {
+	prompt, err := uc.prompts.Render(ctx, domain.AIFeatureIdeas, opts, domain.PromptVariables{Topic: topic})
+	if err != nil {
+		return "", err
+	}
+	return uc.aiService.GenerateBlogIdeas(ctx, *prompt)
 }
 
-func (uc *blogUsecase) SuggestBlogImprovements(content string) (string, error) {
-	return uc.aiService.SuggestBlogImprovements(content)
+func (uc *blogUsecase) SuggestBlogImprovements(ctx context.Context, content string, opts domain.PromptOptions) (string, error) {
+	prompt, err := uc.prompts.Render(ctx, domain.AIFeatureImprove, opts, domain.PromptVariables{Content: content})
+	if err != nil {
+		return "", err
+	}
+	return uc.aiService.SuggestBlogImprovements(ctx, *prompt)
+}
+
+func (uc *blogUsecase) StreamBlogIdeas(ctx context.Context, topic string, opts domain.PromptOptions, onDelta func(string) error) (string, error) {
+	prompt, err := uc.prompts.Render(ctx, domain.AIFeatureIdeas, opts, domain.PromptVariables{Topic: topic})
+	if err != nil {
+		return "", err
+	}
+	return uc.aiService.StreamBlogIdeas(ctx, *prompt, onDelta)
+}
+
+func (uc *blogUsecase) StreamBlogImprovements(ctx context.Context, content string, opts domain.PromptOptions, onDelta func(string) error) (string, error) {
+	prompt, err := uc.prompts.Render(ctx, domain.AIFeatureImprove, opts, domain.PromptVariables{Content: content})
+	if err != nil {
+		return "", err
+	}
+	return uc.aiService.StreamBlogImprovements(ctx, *prompt, onDelta)
 }
 func (uc *blogUsecase) GetAIService() domain.IAIService {
 	return uc.aiService
@@ -128,28 +335,80 @@ func (uc *blogUsecase) FetchPaginatedBlogs(ctx context.Context, page, limit int)
 }
 
 func (uc *blogUsecase) FetchBlogsByFilter(ctx context.Context, filter domain.BlogFilter) ([]*domain.Blog, error) {
+	switch filter.Sort {
+	case "", domain.BlogSortNewest, domain.BlogSortOldest:
+	case domain.BlogSortTrending:
+		if filter.Window == "" {
+			filter.Window = domain.TrendingDay
+		}
+		if _, ok := trendingWindows[filter.Window]; !ok {
+			return nil, errInvalidTrendingWindow
+		}
+	default:
+		return nil, errors.New("sort must be newest, oldest or trending")
+	}
 	return uc.blogRepo.FetchByFilter(ctx, filter)
 }
 
-func (uc *blogUsecase) TrackView(ctx context.Context, blogID int64) error {
-	if blogID <= 0 {
-		return errors.New("invalid blog ID")
+// FetchTrendingBlogs lists the posts ranked by the trending ranker, which
+// recalculates scores every few minutes.
+func (uc *blogUsecase) FetchTrendingBlogs(ctx context.Context, window string, page, limit int) ([]*domain.TrendingBlog, int64, error) {
+	if _, ok := trendingWindows[window]; !ok {
+		return nil, 0, errInvalidTrendingWindow
+	}
+	if page < 1 {
+		page = 1
 	}
-	return uc.blogRepo.IncrementView(ctx, blogID)
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	blogs, total, err := uc.blogRepo.FetchTrending(ctx, window, page, limit)
+	if err != nil {
+		return nil, 0, errors.New("failed to fetch trending blogs")
+	}
+	return blogs, total, nil
 }
 
 func (uc *blogUsecase) LikeBlog(ctx context.Context, blogID, userID int64) error {
 	if blogID <= 0 {
 		return errors.New("invalid blog ID")
 	}
-	return uc.blogRepo.AddLike(ctx, blogID, userID)
+	liked, err := uc.blogRepo.AddLike(ctx, blogID, userID)
+	if err != nil {
+		return err
+	}
+	if !liked {
+		// liking again changes nothing and notifies no one
+		return nil
+	}
+	uc.recordActivity(blogID, 1, 0)
+
+	authorID, err := uc.blogRepo.GetBlogAuthorID(ctx, blogID)
+	if err != nil {
+		log.Printf("failed to emit like notification: %v", err)
+		return nil
+	}
+	uc.notify(ctx, domain.NotificationEvent{
+		Type:        domain.NotificationLike,
+		ActorID:     userID,
+		RecipientID: authorID,
+		BlogID:      blogID,
+	})
+	return nil
 }
 
 func (uc *blogUsecase) UnlikeBlog(ctx context.Context, blogID, userID int64) error {
 	if blogID <= 0 {
 		return errors.New("invalid blog ID")
 	}
-	return uc.blogRepo.RemoveLike(ctx, blogID, userID)
+	unliked, err := uc.blogRepo.RemoveLike(ctx, blogID, userID)
+	if err != nil {
+		return err
+	}
+	if unliked {
+		uc.recordActivity(blogID, -1, 0)
+	}
+	return nil
 }
 
 func (uc *blogUsecase) GetPopularity(ctx context.Context, blogID int64) (int, int, error) {
@@ -172,6 +431,73 @@ func (uc *blogUsecase) SearchBlogs(ctx context.Context, query string, page, limi
 	return uc.blogRepo.SearchBlogs(ctx, query, page, limit)
 }
 
+// moderate sets the status of a new comment. Comments the provider flags
+// with enough confidence are held for review; when it cannot answer the
+// configured fallback applies.
+func (uc *blogUsecase) moderate(ctx context.Context, c *domain.Comment) error {
+	c.Status = domain.CommentPublished
+	if !uc.moderation.enabled {
+		return nil
+	}
+	post, err := uc.blogRepo.FetchByID(ctx, c.BlogID)
+	if err != nil {
+		return errors.New("blog not found")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, uc.moderation.timeout)
+	defer cancel()
+	moderation, err := uc.aiService.ModerateComment(ctx, post, c.Content)
+	if err != nil {
+		log.Printf("failed to moderate comment on blog %d, falling back to %s: %v", c.BlogID, uc.moderation.fallback, err)
+		c.Status = uc.moderation.fallback
+		if c.Status == domain.CommentHeld {
+			c.ModerationReason = "moderation unavailable"
+		}
+		return nil
+	}
+
+	c.ModerationLabel = moderation.Label
+	c.ModerationScore = moderation.Confidence
+	c.ModerationReason = moderation.Reason
+	if moderation.Label != domain.ModerationOK && moderation.Confidence >= uc.moderation.threshold {
+		c.Status = domain.CommentHeld
+	}
+	return nil
+}
+
+// notifyComment tells the post author, and the parent's author for replies,
+// about a published comment.
+func (uc *blogUsecase) notifyComment(ctx context.Context, c *domain.Comment, parent *domain.Comment) {
+	if parent == nil {
+		uc.notify(ctx, domain.NotificationEvent{
+			Type:        domain.NotificationComment,
+			ActorID:     c.UserID,
+			RecipientID: c.Blog.UserID,
+			BlogID:      c.BlogID,
+			CommentID:   c.ID,
+		})
+		return
+	}
+
+	uc.notify(ctx, domain.NotificationEvent{
+		Type:        domain.NotificationReply,
+		ActorID:     c.UserID,
+		RecipientID: parent.UserID,
+		BlogID:      c.BlogID,
+		CommentID:   c.ID,
+	})
+	// the post author hears about the new comment unless they were replied to directly
+	if c.Blog.UserID != parent.UserID {
+		uc.notify(ctx, domain.NotificationEvent{
+			Type:        domain.NotificationComment,
+			ActorID:     c.UserID,
+			RecipientID: c.Blog.UserID,
+			BlogID:      c.BlogID,
+			CommentID:   c.ID,
+		})
+	}
+}
+
 func (uc *blogUsecase) AddComment(ctx context.Context, blogID, userID int64, content string) (*domain.Comment, error) {
 	if blogID <= 0 || userID <= 0 {
 		return nil, errors.New("invalid blog or user id")
@@ -179,7 +505,49 @@ func (uc *blogUsecase) AddComment(ctx context.Context, blogID, userID int64, con
 	if strings.TrimSpace(content) == "" {
 		return nil, errors.New("content is required")
 	}
-	return uc.blogRepo.AddComment(ctx, blogID, userID, content)
+	c := &domain.Comment{BlogID: blogID, UserID: userID, Content: content}
+	if err := uc.moderate(ctx, c); err != nil {
+		return nil, err
+	}
+	c, err := uc.blogRepo.CreateComment(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Status == domain.CommentPublished {
+		uc.recordActivity(blogID, 0, 1)
+		uc.notifyComment(ctx, c, nil)
+	}
+	return c, nil
+}
+
+func (uc *blogUsecase) ReplyToComment(ctx context.Context, blogID, parentID, userID int64, content string) (*domain.Comment, error) {
+	if blogID <= 0 || parentID <= 0 || userID <= 0 {
+		return nil, errors.New("invalid blog, comment or user id")
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.New("content is required")
+	}
+
+	parent, err := uc.blogRepo.FetchCommentByID(ctx, parentID)
+	if err != nil || parent.BlogID != blogID || parent.Status != domain.CommentPublished {
+		return nil, errors.New("comment not found")
+	}
+
+	c := &domain.Comment{BlogID: blogID, UserID: userID, Content: content, ParentID: &parentID}
+	if err := uc.moderate(ctx, c); err != nil {
+		return nil, err
+	}
+	c, err = uc.blogRepo.CreateComment(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Status == domain.CommentPublished {
+		uc.recordActivity(blogID, 0, 1)
+		uc.notifyComment(ctx, c, parent)
+	}
+	return c, nil
 }
 
 func (uc *blogUsecase) GetComments(ctx context.Context, blogID int64, page, limit int) ([]*domain.Comment, int64, error) {
@@ -194,3 +562,46 @@ func (uc *blogUsecase) GetComments(ctx context.Context, blogID int64, page, limi
 	}
 	return uc.blogRepo.ListComments(ctx, blogID, page, limit)
 }
+
+func (uc *blogUsecase) ListHeldComments(ctx context.Context, page, limit int) ([]*domain.Comment, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return uc.blogRepo.ListHeldComments(ctx, page, limit)
+}
+
+// ReviewComment publishes or rejects a held comment. Notifications held
+// back with the comment are sent when it is published.
+func (uc *blogUsecase) ReviewComment(ctx context.Context, commentID, reviewerID int64, approve bool) (*domain.Comment, error) {
+	if commentID <= 0 {
+		return nil, errors.New("invalid comment id")
+	}
+	status := domain.CommentRejected
+	if approve {
+		status = domain.CommentPublished
+	}
+	c, err := uc.blogRepo.ReviewComment(ctx, commentID, status, reviewerID, time.Now())
+	if err != nil {
+		return nil, errors.New("failed to review comment")
+	}
+	if c == nil {
+		return nil, errors.New("held comment not found")
+	}
+
+	if !approve {
+		return c, nil
+	}
+	uc.recordActivity(c.BlogID, 0, 1)
+	var parent *domain.Comment
+	if c.ParentID != nil {
+		if parent, err = uc.blogRepo.FetchCommentByID(ctx, *c.ParentID); err != nil {
+			log.Printf("failed to notify about comment %d: %v", c.ID, err)
+			return c, nil
+		}
+	}
+	uc.notifyComment(ctx, c, parent)
+	return c, nil
+}
@@ -0,0 +1,138 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+const (
+	outboxBatchSize   = 20
+	outboxMaxAttempts = 8
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = 6 * time.Hour
+	// long enough for one batch to be delivered before another worker may retry it
+	outboxLease = 5 * time.Minute
+)
+
+// newOutboxEmail builds a pending outbox row for the given template.
+func newOutboxEmail(to []string, template string, data map[string]string) (*domain.OutboxEmail, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.OutboxEmail{
+		Recipients:    strings.Join(to, ","),
+		Template:      template,
+		Data:          string(encoded),
+		Status:        domain.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// EmailOutboxWorker delivers queued emails, retrying failures with
+// exponential backoff and dead-lettering them after outboxMaxAttempts.
+type EmailOutboxWorker struct {
+	outboxRepo domain.IOutboxRepository
+	renderer   domain.IEmailTemplateRenderer
+	mailer     domain.IEmailInfrastructure
+	now        func() time.Time
+}
+
+func NewEmailOutboxWorker(or domain.IOutboxRepository, renderer domain.IEmailTemplateRenderer, mailer domain.IEmailInfrastructure) *EmailOutboxWorker {
+	return &EmailOutboxWorker{
+		outboxRepo: or,
+		renderer:   renderer,
+		mailer:     mailer,
+		now:        time.Now,
+	}
+}
+
+// Run delivers due emails every interval until ctx is cancelled.
+func (w *EmailOutboxWorker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.DeliverDue(ctx); err != nil {
+			log.Printf("email outbox: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue sends one batch of due emails and returns how many were sent.
+func (w *EmailOutboxWorker) DeliverDue(ctx context.Context) (int, error) {
+	emails, err := w.outboxRepo.ClaimDue(ctx, w.now(), outboxBatchSize, outboxLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim emails: %w", err)
+	}
+
+	sent := 0
+	for _, e := range emails {
+		if err := w.deliver(e); err != nil {
+			w.fail(ctx, e, err)
+			continue
+		}
+		if err := w.outboxRepo.MarkSent(ctx, e.ID, w.now()); err != nil {
+			log.Printf("email outbox: failed to mark email %d as sent: %v", e.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (w *EmailOutboxWorker) deliver(e *domain.OutboxEmail) error {
+	var data map[string]string
+	if e.Data != "" {
+		if err := json.Unmarshal([]byte(e.Data), &data); err != nil {
+			return fmt.Errorf("invalid template data: %w", err)
+		}
+	}
+
+	rendered, err := w.renderer.Render(e.Template, data)
+	if err != nil {
+		return err
+	}
+	return w.mailer.Send(&domain.EmailMessage{
+		To:      strings.Split(e.Recipients, ","),
+		Subject: rendered.Subject,
+		Text:    rendered.Text,
+		HTML:    rendered.HTML,
+	})
+}
+
+func (w *EmailOutboxWorker) fail(ctx context.Context, e *domain.OutboxEmail, cause error) {
+	attempts := e.Attempts + 1
+	dead := attempts >= outboxMaxAttempts
+	next := w.now().Add(outboxBackoff(attempts))
+
+	if dead {
+		log.Printf("email outbox: giving up on email %d after %d attempts: %v", e.ID, attempts, cause)
+	}
+	if err := w.outboxRepo.MarkFailed(ctx, e.ID, attempts, next, cause.Error(), dead); err != nil {
+		log.Printf("email outbox: failed to record failure of email %d: %v", e.ID, err)
+	}
+}
+
+// outboxBackoff doubles the wait after every failed attempt.
+func outboxBackoff(attempts int) time.Duration {
+	d := outboxBaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,172 @@
+package usecases
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+const maxFeedLimit = 100
+
+type followUsecase struct {
+	followRepo domain.IFollowRepository
+	userRepo   domain.IUserRepository
+	notifier   domain.INotifier
+}
+
+func NewFollowUsecase(fr domain.IFollowRepository, ur domain.IUserRepository, notifier domain.INotifier) domain.IFollowUsecase {
+	return &followUsecase{
+		followRepo: fr,
+		userRepo:   ur,
+		notifier:   notifier,
+	}
+}
+
+func (uc *followUsecase) Follow(ctx context.Context, followerID, followeeID int64) error {
+	if followerID <= 0 || followeeID <= 0 {
+		return errors.New("invalid user id")
+	}
+	if followerID == followeeID {
+		return errors.New("you cannot follow yourself")
+	}
+
+	followee, err := uc.userRepo.GetUserProfile(followeeID)
+	if err != nil {
+		return errors.New("failed to fetch user")
+	}
+	if followee == nil {
+		return errors.New("user not found")
+	}
+
+	already, err := uc.followRepo.IsFollowing(ctx, followerID, followeeID)
+	if err != nil {
+		return errors.New("failed to follow user")
+	}
+	if already {
+		return nil
+	}
+
+	if err := uc.followRepo.Follow(ctx, followerID, followeeID); err != nil {
+		return errors.New("failed to follow user")
+	}
+
+	err = uc.notifier.Notify(ctx, domain.NotificationEvent{
+		Type:        domain.NotificationFollow,
+		ActorID:     followerID,
+		RecipientID: followeeID,
+	})
+	if err != nil {
+		log.Printf("failed to emit follow notification: %v", err)
+	}
+	return nil
+}
+
+func (uc *followUsecase) Unfollow(ctx context.Context, followerID, followeeID int64) error {
+	if followerID <= 0 || followeeID <= 0 {
+		return errors.New("invalid user id")
+	}
+	if err := uc.followRepo.Unfollow(ctx, followerID, followeeID); err != nil {
+		return errors.New("failed to unfollow user")
+	}
+	return nil
+}
+
+func (uc *followUsecase) GetFollowers(ctx context.Context, userID int64, page, limit int) ([]*domain.PublicUser, int64, error) {
+	if userID <= 0 {
+		return nil, 0, errors.New("invalid user id")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	return uc.followRepo.ListFollowers(ctx, userID, page, limit)
+}
+
+func (uc *followUsecase) GetFollowing(ctx context.Context, userID int64, page, limit int) ([]*domain.PublicUser, int64, error) {
+	if userID <= 0 {
+		return nil, 0, errors.New("invalid user id")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	return uc.followRepo.ListFollowing(ctx, userID, page, limit)
+}
+
+func (uc *followUsecase) GetFollowCounts(ctx context.Context, userID int64) (domain.FollowCounts, error) {
+	if userID <= 0 {
+		return domain.FollowCounts{}, errors.New("invalid user id")
+	}
+	return uc.followRepo.CountFollows(ctx, userID)
+}
+
+// GetFeed returns a page of posts from followed authors together with the
+// cursor for the next page. An empty next cursor means there are no more posts.
+func (uc *followUsecase) GetFeed(ctx context.Context, userID int64, cursor string, limit int) ([]*domain.Blog, string, error) {
+	if userID <= 0 {
+		return nil, "", errors.New("invalid user id")
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > maxFeedLimit {
+		limit = maxFeedLimit
+	}
+
+	var after *domain.FeedCursor
+	if cursor != "" {
+		c, err := decodeFeedCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = &c
+	}
+
+	blogs, err := uc.followRepo.FetchFeed(ctx, userID, after, limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch feed: %w", err)
+	}
+
+	next := ""
+	if len(blogs) == limit {
+		last := blogs[len(blogs)-1]
+		next = encodeFeedCursor(domain.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	return blogs, next, nil
+}
+
+func encodeFeedCursor(c domain.FeedCursor) string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeFeedCursor(s string) (domain.FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return domain.FeedCursor{}, errors.New("invalid cursor")
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return domain.FeedCursor{}, errors.New("invalid cursor")
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return domain.FeedCursor{}, errors.New("invalid cursor")
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || id <= 0 {
+		return domain.FeedCursor{}, errors.New("invalid cursor")
+	}
+	return domain.FeedCursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
@@ -0,0 +1,121 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+const (
+	magicLinkTTL       = 15 * time.Minute
+	magicLinkWindow    = 15 * time.Minute
+	magicLinkMaxWindow = 3 // links per account within magicLinkWindow
+)
+
+var errInvalidMagicLink = errors.New("invalid or expired link")
+
+// RequestMagicLink queues a single-use sign-in link. It returns nil whether or
+// not the email belongs to an account, so that it cannot be used to find
+// registered addresses; locked accounts and accounts that asked too often
+// silently get no email.
+func (uu *UserUsecase) RequestMagicLink(email string, ip string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return errors.New("email required")
+	}
+	now := time.Now()
+	if uu.tooManyFailuresFromIP(ip, now) {
+		return errors.New("too many failed login attempts")
+	}
+
+	user, err := uu.userRepo.FetchByEmail(email)
+	if err != nil {
+		return nil
+	}
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return nil
+	}
+	count, err := uu.userRepo.CountMagicLinks(user.ID, now.Add(-magicLinkWindow))
+	if err != nil || count >= magicLinkMaxWindow {
+		return nil
+	}
+
+	token, hash, err := newSecretToken()
+	if err != nil {
+		return errors.New("could not send sign-in link")
+	}
+	link := magicLinkURL(token)
+	msg, err := newOutboxEmail([]string{user.Email}, domain.EmailMagicLink, map[string]string{
+		"Username": user.Username,
+		"Link":     link,
+		"Minutes":  strconv.Itoa(int(magicLinkTTL.Minutes())),
+	})
+	if err != nil {
+		return errors.New("could not send sign-in link")
+	}
+
+	err = uu.userRepo.CreateMagicLink(&domain.MagicLinkToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		RequestIP: ip,
+		ExpiresAt: now.Add(magicLinkTTL),
+	}, msg)
+	if err != nil {
+		return errors.New("could not send sign-in link")
+	}
+	return nil
+}
+
+// magicLinkURL points at MAGIC_LINK_URL, a client page that POSTs the token
+// to /login/magic/verify, or by default at the API's own page doing the same.
+// Opening the link never uses it up, as mail scanners open links too.
+func magicLinkURL(token string) string {
+	base := os.Getenv("MAGIC_LINK_URL")
+	if base == "" {
+		base = fmt.Sprintf("%v://%v:%v/login/magic/verify", os.Getenv("PROTOCOL"), os.Getenv("DOMAIN"), os.Getenv("PORT"))
+	}
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + "token=" + url.QueryEscape(token)
+}
+
+// ConsumeMagicLink exchanges a sign-in link for tokens, or for a TOTP
+// challenge when the account has two-factor authentication. Attempts are
+// recorded and throttled like password logins.
+func (uu *UserUsecase) ConsumeMagicLink(token string, ip string) (*domain.LoginResult, error) {
+	now := time.Now()
+	if uu.tooManyFailuresFromIP(ip, now) {
+		return nil, errors.New("too many failed login attempts")
+	}
+	if token == "" {
+		uu.recordLoginAttempt(nil, ip, false)
+		return nil, errInvalidMagicLink
+	}
+
+	link, err := uu.userRepo.UseMagicLink(hashSecretToken(token), now)
+	if err != nil {
+		uu.recordLoginAttempt(nil, ip, false)
+		return nil, errInvalidMagicLink
+	}
+	user, err := uu.userRepo.Fetch(strconv.FormatInt(link.UserID, 10))
+	if err != nil {
+		log.Printf("magic link %d: %v", link.ID, err)
+		uu.recordLoginAttempt(nil, ip, false)
+		return nil, errInvalidMagicLink
+	}
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		uu.recordLoginAttempt(&user.ID, ip, false)
+		return nil, errInvalidMagicLink
+	}
+
+	return uu.CompleteLogin(user, ip)
+}
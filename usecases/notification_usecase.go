@@ -0,0 +1,164 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/blog-platform/domain"
+)
+
+type notificationUsecase struct {
+	notificationRepo domain.INotificationRepository
+	followRepo       domain.IFollowRepository
+}
+
+func NewNotificationUsecase(nr domain.INotificationRepository, fr domain.IFollowRepository) domain.INotificationUsecase {
+	return &notificationUsecase{
+		notificationRepo: nr,
+		followRepo:       fr,
+	}
+}
+
+func isNotificationType(kind string) bool {
+	for _, t := range domain.NotificationTypes {
+		if t == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify turns an event into notifications for every recipient that has not
+// opted out of its kind. Actors are never notified about their own actions.
+func (uc *notificationUsecase) Notify(ctx context.Context, event domain.NotificationEvent) error {
+	if !isNotificationType(event.Type) {
+		return fmt.Errorf("unknown notification type '%s'", event.Type)
+	}
+
+	var recipients []int64
+	if event.Type == domain.NotificationNewPost {
+		ids, err := uc.followRepo.ListFollowerIDs(ctx, event.ActorID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch followers: %w", err)
+		}
+		recipients = ids
+	} else if event.RecipientID > 0 {
+		recipients = []int64{event.RecipientID}
+	}
+
+	filtered := make([]int64, 0, len(recipients))
+	for _, id := range recipients {
+		if id > 0 && id != event.ActorID {
+			filtered = append(filtered, id)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	disabled, err := uc.notificationRepo.DisabledRecipients(ctx, event.Type, filtered)
+	if err != nil {
+		return fmt.Errorf("failed to fetch notification preferences: %w", err)
+	}
+	skip := make(map[int64]bool, len(disabled))
+	for _, id := range disabled {
+		skip[id] = true
+	}
+
+	var blogID, commentID *int64
+	if event.BlogID > 0 {
+		blogID = &event.BlogID
+	}
+	if event.CommentID > 0 {
+		commentID = &event.CommentID
+	}
+
+	notifications := make([]*domain.Notification, 0, len(filtered))
+	for _, id := range filtered {
+		if skip[id] {
+			continue
+		}
+		notifications = append(notifications, &domain.Notification{
+			UserID:    id,
+			ActorID:   event.ActorID,
+			Type:      event.Type,
+			BlogID:    blogID,
+			CommentID: commentID,
+		})
+	}
+	return uc.notificationRepo.CreateMany(ctx, notifications)
+}
+
+func (uc *notificationUsecase) ListNotifications(ctx context.Context, userID int64, unreadOnly bool, page, limit int) ([]*domain.Notification, int64, error) {
+	if userID <= 0 {
+		return nil, 0, errors.New("invalid user id")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	return uc.notificationRepo.List(ctx, userID, unreadOnly, page, limit)
+}
+
+func (uc *notificationUsecase) MarkRead(ctx context.Context, userID, id int64) error {
+	if userID <= 0 || id <= 0 {
+		return errors.New("invalid notification id")
+	}
+	return uc.notificationRepo.MarkRead(ctx, userID, id)
+}
+
+func (uc *notificationUsecase) MarkAllRead(ctx context.Context, userID int64) error {
+	if userID <= 0 {
+		return errors.New("invalid user id")
+	}
+	return uc.notificationRepo.MarkAllRead(ctx, userID)
+}
+
+func (uc *notificationUsecase) UnreadCount(ctx context.Context, userID int64) (int64, error) {
+	if userID <= 0 {
+		return 0, errors.New("invalid user id")
+	}
+	return uc.notificationRepo.CountUnread(ctx, userID)
+}
+
+// GetPreferences returns every notification kind with its effective setting.
+func (uc *notificationUsecase) GetPreferences(ctx context.Context, userID int64) (map[string]bool, error) {
+	if userID <= 0 {
+		return nil, errors.New("invalid user id")
+	}
+	stored, err := uc.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := make(map[string]bool, len(domain.NotificationTypes))
+	for _, t := range domain.NotificationTypes {
+		prefs[t] = true
+	}
+	for _, p := range stored {
+		if _, ok := prefs[p.Type]; ok {
+			prefs[p.Type] = p.Enabled
+		}
+	}
+	return prefs, nil
+}
+
+func (uc *notificationUsecase) UpdatePreferences(ctx context.Context, userID int64, prefs map[string]bool) error {
+	if userID <= 0 {
+		return errors.New("invalid user id")
+	}
+	for kind := range prefs {
+		if !isNotificationType(kind) {
+			return fmt.Errorf("unknown notification type '%s'", kind)
+		}
+	}
+	for kind, enabled := range prefs {
+		if err := uc.notificationRepo.SetPreference(ctx, userID, kind, enabled); err != nil {
+			return errors.New("failed to update notification preferences")
+		}
+	}
+	return nil
+}
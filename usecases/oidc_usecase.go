@@ -0,0 +1,243 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+// how long a user has to complete the login at the provider
+const oidcStateTTL = 10 * time.Minute
+
+var (
+	errOIDCLoginFailed   = errors.New("identity provider login failed")
+	errOIDCAccountExists = errors.New("an account with this email already exists; sign in to it and link the identity provider from your account")
+)
+
+type oidcUsecase struct {
+	providers   map[string]domain.IOIDCProvider
+	oidcRepo    domain.IOIDCRepository
+	userRepo    domain.IUserRepository
+	userUsecase domain.IUserUsecase
+	now         func() time.Time
+}
+
+func NewOIDCUsecase(providers map[string]domain.IOIDCProvider, or domain.IOIDCRepository, ur domain.IUserRepository, uu domain.IUserUsecase) domain.IOIDCUsecase {
+	return &oidcUsecase{
+		providers:   providers,
+		oidcRepo:    or,
+		userRepo:    ur,
+		userUsecase: uu,
+		now:         time.Now,
+	}
+}
+
+func (ou *oidcUsecase) StartLogin(ctx context.Context, provider string) (string, error) {
+	return ou.start(ctx, provider, 0)
+}
+
+func (ou *oidcUsecase) StartLink(ctx context.Context, provider string, userID int64) (string, error) {
+	return ou.start(ctx, provider, userID)
+}
+
+func (ou *oidcUsecase) start(ctx context.Context, provider string, userID int64) (string, error) {
+	p, ok := ou.providers[provider]
+	if !ok {
+		return "", errors.New("unknown identity provider")
+	}
+
+	state, stateHash, err := newSecretToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, _, err := newSecretToken()
+	if err != nil {
+		return "", err
+	}
+	verifier, _, err := newSecretToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = ou.oidcRepo.SaveLoginState(ctx, &domain.OIDCLoginState{
+		StateHash:    stateHash,
+		Provider:     provider,
+		UserID:       userID,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    ou.now().Add(oidcStateTTL),
+	})
+	if err != nil {
+		return "", errors.New("unable to start login")
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	return p.AuthCodeURL(ctx, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+}
+
+func (ou *oidcUsecase) FinishLogin(ctx context.Context, provider, state, code, ip string) (*domain.LoginResult, error) {
+	p, ok := ou.providers[provider]
+	if !ok {
+		return nil, errors.New("unknown identity provider")
+	}
+	if state == "" || code == "" {
+		return nil, errors.New("invalid or expired login state")
+	}
+
+	loginState, err := ou.oidcRepo.ConsumeLoginState(ctx, hashSecretToken(state))
+	if err != nil {
+		return nil, errors.New(err.Error())
+	}
+	if loginState == nil || loginState.Provider != provider || ou.now().After(loginState.ExpiresAt) {
+		return nil, errors.New("invalid or expired login state")
+	}
+
+	identity, err := p.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Printf("oidc: %s: %v", provider, err)
+		return nil, errOIDCLoginFailed
+	}
+
+	var user domain.User
+	if loginState.UserID != 0 {
+		user, err = ou.linkUser(ctx, provider, loginState.UserID, identity)
+	} else {
+		user, err = ou.resolveUser(ctx, provider, identity)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ou.userUsecase.CompleteLogin(user, ip)
+}
+
+// linkUser links the identity to the user who started the flow from their
+// account.
+func (ou *oidcUsecase) linkUser(ctx context.Context, provider string, userID int64, identity *domain.OIDCIdentity) (domain.User, error) {
+	user, err := ou.userRepo.Fetch(strconv.FormatInt(userID, 10))
+	if err != nil {
+		return domain.User{}, errors.New("user not found")
+	}
+	linked, err := ou.oidcRepo.FindIdentity(ctx, provider, identity.Subject)
+	if err != nil {
+		return domain.User{}, errors.New(err.Error())
+	}
+	if linked != nil {
+		if linked.UserID != user.ID {
+			return domain.User{}, errors.New("identity is linked to another account")
+		}
+		return user, nil
+	}
+
+	err = ou.oidcRepo.CreateIdentity(ctx, &domain.ExternalIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return domain.User{}, errors.New("unable to link account")
+	}
+	return user, nil
+}
+
+// resolveUser returns the user linked to the identity. Unlinked identities are
+// linked to the active user with the same email, but only when the provider
+// has verified it; otherwise a new account is provisioned. An inactive account
+// with the email may have been registered by someone else to take over the
+// identity, so it must be linked with StartLink after signing in.
+func (ou *oidcUsecase) resolveUser(ctx context.Context, provider string, identity *domain.OIDCIdentity) (domain.User, error) {
+	linked, err := ou.oidcRepo.FindIdentity(ctx, provider, identity.Subject)
+	if err != nil {
+		return domain.User{}, errors.New(err.Error())
+	}
+	if linked != nil {
+		user, err := ou.userRepo.Fetch(strconv.FormatInt(linked.UserID, 10))
+		if err != nil {
+			return domain.User{}, errors.New("user not found")
+		}
+		return user, nil
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return domain.User{}, errors.New("identity provider did not return a verified email")
+	}
+	externalIdentity := &domain.ExternalIdentity{
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+
+	user, err := ou.userRepo.FetchByEmail(identity.Email)
+	if err == nil {
+		if user.Status != "active" {
+			return domain.User{}, errOIDCAccountExists
+		}
+		externalIdentity.UserID = user.ID
+		if err := ou.oidcRepo.CreateIdentity(ctx, externalIdentity); err != nil {
+			return domain.User{}, errors.New("unable to link account")
+		}
+		return user, nil
+	}
+
+	username, err := ou.availableUsername(identity)
+	if err != nil {
+		return domain.User{}, err
+	}
+	// the email is verified by the provider, so the account is active at once;
+	// it has no password until the user sets one through forgot password
+	user = domain.User{
+		Username: username,
+		Email:    identity.Email,
+		Role:     "user",
+		Status:   "active",
+	}
+	if err := ou.oidcRepo.CreateUserWithIdentity(ctx, &user, externalIdentity); err != nil {
+		return domain.User{}, errors.New("unable to register user")
+	}
+	return user, nil
+}
+
+// availableUsername derives a username from the identity and appends a number
+// when it is taken.
+func (ou *oidcUsecase) availableUsername(identity *domain.OIDCIdentity) (string, error) {
+	base := sanitizeUsername(identity.PreferredUsername)
+	if base == "" {
+		base = sanitizeUsername(strings.SplitN(identity.Email, "@", 2)[0])
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	for i := 1; i <= 20; i++ {
+		candidate := base
+		if i > 1 {
+			candidate = fmt.Sprintf("%s%d", base, i)
+		}
+		if _, err := ou.userRepo.FetchByUsername(candidate); err != nil {
+			return candidate, nil
+		}
+	}
+	return "", errors.New("unable to choose a username")
+}
+
+func sanitizeUsername(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '.' || r == '-' {
+			b.WriteRune(r)
+		}
+	}
+	username := b.String()
+	if len(username) > 50 {
+		username = username[:50]
+	}
+	return username
+}
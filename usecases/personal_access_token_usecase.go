@@ -0,0 +1,152 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+const (
+	maxPersonalAccessTokens = 50
+	// last use is written at most this often per token to spare the database
+	tokenLastUsedResolution = time.Minute
+)
+
+var errInvalidPersonalAccessToken = errors.New("invalid token")
+
+type personalAccessTokenUsecase struct {
+	tokenRepo domain.IPersonalAccessTokenRepository
+	userRepo  domain.IUserRepository
+	now       func() time.Time
+}
+
+func NewPersonalAccessTokenUsecase(pr domain.IPersonalAccessTokenRepository, ur domain.IUserRepository) domain.IPersonalAccessTokenUsecase {
+	return &personalAccessTokenUsecase{
+		tokenRepo: pr,
+		userRepo:  ur,
+		now:       time.Now,
+	}
+}
+
+func (pu *personalAccessTokenUsecase) Create(ctx context.Context, userID int64, name string, scopes []string, expiresAt *time.Time) (*domain.PersonalAccessToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, "", errors.New("token name must be between 1 and 100 characters")
+	}
+	if expiresAt != nil && !expiresAt.After(pu.now()) {
+		return nil, "", errors.New("expiry must be in the future")
+	}
+
+	user, err := pu.userRepo.GetUserProfile(userID)
+	if err != nil {
+		return nil, "", errors.New("user not found")
+	}
+	scopes, err = normalizeScopes(scopes, user.Role)
+	if err != nil {
+		return nil, "", err
+	}
+
+	count, err := pu.tokenRepo.CountActive(ctx, userID)
+	if err != nil {
+		return nil, "", errors.New(err.Error())
+	}
+	if count >= maxPersonalAccessTokens {
+		return nil, "", errors.New("too many access tokens, revoke unused ones first")
+	}
+
+	secret, _, err := newSecretToken()
+	if err != nil {
+		return nil, "", err
+	}
+	// the stored hash covers the prefix as well
+	secret = domain.PersonalAccessTokenPrefix + secret
+
+	token := &domain.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashSecretToken(secret),
+		Prefix:    secret[:len(domain.PersonalAccessTokenPrefix)+6],
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: expiresAt,
+	}
+	if err := pu.tokenRepo.Create(ctx, token); err != nil {
+		return nil, "", errors.New("unable to create token")
+	}
+	return token, secret, nil
+}
+
+// normalizeScopes validates and de-duplicates scopes. Only admins may create
+// tokens with the admin scope.
+func normalizeScopes(scopes []string, role string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	seen := make(map[string]bool)
+	var result []string
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		switch scope {
+		case domain.ScopeRead, domain.ScopeWrite:
+		case domain.ScopeAdmin:
+			if role != "admin" {
+				return nil, errors.New("only admins can create tokens with the admin scope")
+			}
+		default:
+			return nil, errors.New("unknown scope '" + scope + "'")
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result, nil
+}
+
+func (pu *personalAccessTokenUsecase) List(ctx context.Context, userID int64) ([]domain.PersonalAccessToken, error) {
+	return pu.tokenRepo.ListActive(ctx, userID)
+}
+
+func (pu *personalAccessTokenUsecase) Revoke(ctx context.Context, userID, id int64) error {
+	revoked, err := pu.tokenRepo.Revoke(ctx, userID, id, pu.now())
+	if err != nil {
+		return errors.New(err.Error())
+	}
+	if !revoked {
+		return errors.New("token not found")
+	}
+	return nil
+}
+
+func (pu *personalAccessTokenUsecase) Authenticate(ctx context.Context, secret, ip string) (*domain.TokenClaims, []string, error) {
+	if !strings.HasPrefix(secret, domain.PersonalAccessTokenPrefix) {
+		return nil, nil, errInvalidPersonalAccessToken
+	}
+	token, err := pu.tokenRepo.FetchByHash(ctx, hashSecretToken(secret))
+	if err != nil || token == nil {
+		return nil, nil, errInvalidPersonalAccessToken
+	}
+	now := pu.now()
+	if token.RevokedAt != nil || (token.ExpiresAt != nil && !now.Before(*token.ExpiresAt)) {
+		return nil, nil, errInvalidPersonalAccessToken
+	}
+
+	// the role is read on every use so that a demotion applies to existing tokens
+	user, err := pu.userRepo.GetUserProfile(token.UserID)
+	if err != nil {
+		return nil, nil, errInvalidPersonalAccessToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= tokenLastUsedResolution || token.LastUsedIP != ip {
+		if err := pu.tokenRepo.TouchLastUsed(ctx, token.ID, now, ip); err != nil {
+			log.Printf("access token %d: failed to record use: %v", token.ID, err)
+		}
+	}
+
+	claims := &domain.TokenClaims{UserID: strconv.FormatInt(user.ID, 10), UserRole: user.Role}
+	return claims, strings.Split(token.Scopes, ","), nil
+}
@@ -0,0 +1,276 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/blog-platform/domain"
+)
+
+// prompt template limits
+const (
+	maxPromptTemplateLength = 10000
+	maxPromptOptionLength   = 50
+)
+
+// promptStyle renders the optional variables in the built-in prompts.
+const promptStyle = "{{with .Tone}} Aim for a {{.}} tone.{{end}}" +
+	"{{with .Audience}} The readers are {{.}}.{{end}}" +
+	"{{with .Language}} Answer in {{.}}.{{end}}"
+
+// builtinPromptTemplates are used until a template named after the feature
+// is saved. Without tone, audience or language they render the prompts the
+// features had before templates.
+var builtinPromptTemplates = map[string]*domain.PromptTemplate{
+	domain.AIFeatureIdeas: {
+		Name:         domain.AIFeatureIdeas,
+		Feature:      domain.AIFeatureIdeas,
+		SystemPrompt: "You are a helpful assistant that generates blog ideas." + promptStyle,
+		UserPrompt:   "Generate blog ideas about: {{.Topic}}",
+		Active:       true,
+	},
+	domain.AIFeatureImprove: {
+		Name:         domain.AIFeatureImprove,
+		Feature:      domain.AIFeatureImprove,
+		SystemPrompt: "You are a helpful assistant that suggests improvements for blog content." + promptStyle,
+		UserPrompt:   "Suggest improvements for this blog content: {{.Content}}",
+		Active:       true,
+	},
+}
+
+// promptInputs names the variable holding each feature's input, which its
+// templates must use.
+var promptInputs = map[string]string{
+	domain.AIFeatureIdeas:   "Topic",
+	domain.AIFeatureImprove: "Content",
+}
+
+var promptTemplateName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+var errPromptTemplateNotFound = errors.New("prompt template not found")
+
+type promptTemplateUsecase struct {
+	repo domain.IPromptTemplateRepository
+}
+
+func NewPromptTemplateUsecase(repo domain.IPromptTemplateRepository) domain.IPromptTemplateUsecase {
+	return &promptTemplateUsecase{repo: repo}
+}
+
+// budgetWriter fails once more than n bytes are written, stopping templates
+// that loop.
+type budgetWriter struct {
+	w io.Writer
+	n int
+}
+
+func (b *budgetWriter) Write(p []byte) (int, error) {
+	if len(p) > b.n {
+		return 0, errors.New("rendered prompt is too long")
+	}
+	b.n -= len(p)
+	return b.w.Write(p)
+}
+
+func renderPromptText(text string, vars domain.PromptVariables) (string, error) {
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	// room for every variable a few times over
+	budget := len(text) + 4*(len(vars.Topic)+len(vars.Content)+len(vars.Tone)+len(vars.Audience)+len(vars.Language))
+	var out strings.Builder
+	if err := tmpl.Execute(&budgetWriter{w: &out, n: budget}, vars); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func renderPrompt(t *domain.PromptTemplate, vars domain.PromptVariables) (*domain.Prompt, error) {
+	system, err := renderPromptText(t.SystemPrompt, vars)
+	if err != nil {
+		return nil, err
+	}
+	user, err := renderPromptText(t.UserPrompt, vars)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Prompt{System: system, User: user}, nil
+}
+
+// resolve finds the template a request selected, falling back to the
+// built-in prompt for the feature's own name.
+func (pu *promptTemplateUsecase) resolve(ctx context.Context, feature, name string, version int) (*domain.PromptTemplate, error) {
+	if name == "" {
+		name = feature
+	}
+	if version < 0 {
+		return nil, errors.New("invalid template version")
+	}
+	t, err := pu.repo.FetchTemplate(ctx, name, version)
+	if err != nil {
+		log.Printf("failed to fetch prompt template %s: %v", name, err)
+		return nil, errors.New("failed to fetch prompt template")
+	}
+	if t == nil && version == 0 {
+		t = builtinPromptTemplates[name]
+	}
+	if t == nil || t.Feature != feature {
+		return nil, errPromptTemplateNotFound
+	}
+	return t, nil
+}
+
+func (pu *promptTemplateUsecase) Render(ctx context.Context, feature string, opts domain.PromptOptions, vars domain.PromptVariables) (*domain.Prompt, error) {
+	for _, option := range []string{opts.Tone, opts.Audience, opts.Language} {
+		if utf8.RuneCountInString(option) > maxPromptOptionLength || strings.ContainsAny(option, "\r\n") {
+			return nil, errors.New("tone, audience and language must be single lines of at most 50 characters")
+		}
+	}
+	vars.Tone = strings.TrimSpace(opts.Tone)
+	vars.Audience = strings.TrimSpace(opts.Audience)
+	vars.Language = strings.TrimSpace(opts.Language)
+
+	t, err := pu.resolve(ctx, feature, strings.TrimSpace(opts.Template), opts.TemplateVersion)
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := renderPrompt(t, vars)
+	if err != nil {
+		log.Printf("failed to render prompt template %s version %d: %v", t.Name, t.Version, err)
+		return nil, errors.New("failed to render prompt template")
+	}
+	return prompt, nil
+}
+
+// validatePromptTemplate renders t with placeholder values, so that
+// templates that fail to parse or run, or that leave out the feature's
+// input, are rejected when saved rather than when used.
+func validatePromptTemplate(t *domain.PromptTemplate) error {
+	input, ok := promptInputs[t.Feature]
+	if !ok {
+		return errors.New("feature must be ideas or improve")
+	}
+	if strings.TrimSpace(t.SystemPrompt) == "" || strings.TrimSpace(t.UserPrompt) == "" {
+		return errors.New("system_prompt and user_prompt are required")
+	}
+	if utf8.RuneCountInString(t.SystemPrompt) > maxPromptTemplateLength || utf8.RuneCountInString(t.UserPrompt) > maxPromptTemplateLength {
+		return errors.New("prompts cannot be longer than 10000 characters")
+	}
+
+	samples := map[string]string{"Topic": "<topic>", "Content": "<content>"}
+	prompt, err := renderPrompt(t, domain.PromptVariables{
+		Topic:    samples["Topic"],
+		Content:  samples["Content"],
+		Tone:     "<tone>",
+		Audience: "<audience>",
+		Language: "<language>",
+	})
+	if err != nil {
+		return fmt.Errorf("invalid prompt template: %v", err)
+	}
+	if !strings.Contains(prompt.System+prompt.User, samples[input]) {
+		return fmt.Errorf("prompt template must use {{.%s}}", input)
+	}
+	return nil
+}
+
+func (pu *promptTemplateUsecase) SaveTemplate(ctx context.Context, t *domain.PromptTemplate, adminID int64) (*domain.PromptTemplate, error) {
+	if !promptTemplateName.MatchString(t.Name) {
+		return nil, errors.New("name must be up to 50 lowercase letters, digits and dashes")
+	}
+	if err := validatePromptTemplate(t); err != nil {
+		return nil, err
+	}
+
+	current, err := pu.repo.FetchTemplate(ctx, t.Name, 0)
+	if err != nil {
+		log.Printf("failed to fetch prompt template %s: %v", t.Name, err)
+		return nil, errors.New("failed to save prompt template")
+	}
+	if current == nil {
+		current = builtinPromptTemplates[t.Name]
+	}
+	if current != nil && current.Feature != t.Feature {
+		return nil, errors.New("template belongs to another feature")
+	}
+
+	saved := &domain.PromptTemplate{
+		Name:         t.Name,
+		Feature:      t.Feature,
+		SystemPrompt: t.SystemPrompt,
+		UserPrompt:   t.UserPrompt,
+		CreatedBy:    adminID,
+	}
+	if err := pu.repo.CreateVersion(ctx, saved); err != nil {
+		log.Printf("failed to save prompt template %s: %v", t.Name, err)
+		return nil, errors.New("failed to save prompt template")
+	}
+	return saved, nil
+}
+
+func (pu *promptTemplateUsecase) ListTemplates(ctx context.Context) ([]*domain.PromptTemplate, error) {
+	templates, err := pu.repo.ListActive(ctx)
+	if err != nil {
+		log.Printf("failed to list prompt templates: %v", err)
+		return nil, errors.New("failed to fetch prompt templates")
+	}
+	saved := map[string]bool{}
+	for _, t := range templates {
+		saved[t.Name] = true
+	}
+	for name, builtin := range builtinPromptTemplates {
+		if !saved[name] {
+			copied := *builtin
+			templates = append(templates, &copied)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// ListVersions ends with the built-in prompt, as version 0, for the names of
+// features, so that it can be copied back after being replaced.
+func (pu *promptTemplateUsecase) ListVersions(ctx context.Context, name string) ([]*domain.PromptTemplate, error) {
+	versions, err := pu.repo.ListVersions(ctx, name)
+	if err != nil {
+		log.Printf("failed to list prompt template %s: %v", name, err)
+		return nil, errors.New("failed to fetch prompt templates")
+	}
+	if builtin, ok := builtinPromptTemplates[name]; ok {
+		copied := *builtin
+		copied.Active = len(versions) == 0
+		versions = append(versions, &copied)
+	}
+	if len(versions) == 0 {
+		return nil, errPromptTemplateNotFound
+	}
+	return versions, nil
+}
+
+func (pu *promptTemplateUsecase) ActivateVersion(ctx context.Context, name string, version int) (*domain.PromptTemplate, error) {
+	if version < 1 {
+		return nil, errors.New("invalid template version")
+	}
+	found, err := pu.repo.Activate(ctx, name, version)
+	if err != nil {
+		log.Printf("failed to activate prompt template %s version %d: %v", name, version, err)
+		return nil, errors.New("failed to activate prompt template")
+	}
+	if !found {
+		return nil, errPromptTemplateNotFound
+	}
+	t, err := pu.repo.FetchTemplate(ctx, name, version)
+	if err != nil || t == nil {
+		return nil, errors.New("failed to fetch prompt template")
+	}
+	return t, nil
+}
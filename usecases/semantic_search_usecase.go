@@ -0,0 +1,180 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blog-platform/domain"
+)
+
+// semantic search limits
+const (
+	maxSemanticQueryLength = 500
+	maxSemanticResults     = 50
+	// embedding models read a limited number of tokens; longer posts are cut
+	maxEmbeddedLength = 8000
+	reindexBatchSize  = 32
+)
+
+var errSemanticSearchDisabled = errors.New("semantic search is not configured")
+
+type semanticSearchUsecase struct {
+	blogRepo domain.IBlogRepository
+	embedder domain.IEmbeddingProvider
+	index    domain.IVectorIndex
+}
+
+// NewSemanticSearchUsecase answers with "semantic search is not configured"
+// when embedder is nil.
+func NewSemanticSearchUsecase(br domain.IBlogRepository, embedder domain.IEmbeddingProvider, index domain.IVectorIndex) domain.ISemanticSearchUsecase {
+	return &semanticSearchUsecase{blogRepo: br, embedder: embedder, index: index}
+}
+
+// embeddingText is what a post is embedded as.
+func embeddingText(blog *domain.Blog) string {
+	text := blog.Title + "\n\n" + blog.Content
+	if utf8.RuneCountInString(text) > maxEmbeddedLength {
+		text = string([]rune(text)[:maxEmbeddedLength])
+	}
+	return text
+}
+
+func (su *semanticSearchUsecase) IndexBlog(ctx context.Context, blogID int64) error {
+	if su.embedder == nil {
+		return errSemanticSearchDisabled
+	}
+	blog, err := su.blogRepo.FetchByID(ctx, blogID)
+	if err != nil {
+		return errors.New("blog not found")
+	}
+	vectors, err := su.embedder.Embed(ctx, []string{embeddingText(blog)})
+	if err != nil {
+		return err
+	}
+	if err := su.index.Upsert(ctx, blogID, su.embedder.Model(), vectors[0]); err != nil {
+		log.Printf("failed to index blog %d: %v", blogID, err)
+		return errors.New("failed to index blog")
+	}
+	return nil
+}
+
+func (su *semanticSearchUsecase) RemoveBlog(ctx context.Context, blogID int64) error {
+	if su.embedder == nil {
+		return errSemanticSearchDisabled
+	}
+	return su.index.Delete(ctx, blogID)
+}
+
+func semanticLimit(limit int) int {
+	if limit <= 0 {
+		return 10
+	}
+	if limit > maxSemanticResults {
+		return maxSemanticResults
+	}
+	return limit
+}
+
+// scored loads the posts of matches, skipping any deleted since they were
+// indexed.
+func (su *semanticSearchUsecase) scored(ctx context.Context, matches []domain.VectorMatch) []domain.ScoredBlog {
+	results := make([]domain.ScoredBlog, 0, len(matches))
+	for _, match := range matches {
+		blog, err := su.blogRepo.FetchByID(ctx, match.BlogID)
+		if err != nil {
+			continue
+		}
+		results = append(results, domain.ScoredBlog{Blog: blog, Score: match.Score})
+	}
+	return results
+}
+
+func (su *semanticSearchUsecase) SemanticSearch(ctx context.Context, query string, limit int) ([]domain.ScoredBlog, error) {
+	if su.embedder == nil {
+		return nil, errSemanticSearchDisabled
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("query is required")
+	}
+	if utf8.RuneCountInString(query) > maxSemanticQueryLength {
+		return nil, errors.New("query cannot be longer than 500 characters")
+	}
+
+	vectors, err := su.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	matches, err := su.index.Search(ctx, su.embedder.Model(), vectors[0], semanticLimit(limit), 0)
+	if err != nil {
+		return nil, errors.New("failed to search blogs")
+	}
+	return su.scored(ctx, matches), nil
+}
+
+// RelatedBlogs embeds the post first if it has not been indexed yet, e.g.
+// because it predates semantic search.
+func (su *semanticSearchUsecase) RelatedBlogs(ctx context.Context, blogID int64, limit int) ([]domain.ScoredBlog, error) {
+	if su.embedder == nil {
+		return nil, errSemanticSearchDisabled
+	}
+	if blogID <= 0 {
+		return nil, errors.New("invalid blog ID")
+	}
+
+	embedding, err := su.index.Fetch(ctx, blogID)
+	if err != nil {
+		return nil, errors.New("failed to search blogs")
+	}
+	if embedding == nil || embedding.Model != su.embedder.Model() {
+		if err := su.IndexBlog(ctx, blogID); err != nil {
+			return nil, err
+		}
+		if embedding, err = su.index.Fetch(ctx, blogID); err != nil || embedding == nil {
+			return nil, errors.New("failed to search blogs")
+		}
+	}
+
+	matches, err := su.index.Search(ctx, embedding.Model, embedding.Vector, semanticLimit(limit), blogID)
+	if err != nil {
+		return nil, errors.New("failed to search blogs")
+	}
+	return su.scored(ctx, matches), nil
+}
+
+// ReindexAll embeds posts in batches, for backfilling existing posts or
+// after changing the embedding model. Posts that fail are logged and
+// skipped.
+func (su *semanticSearchUsecase) ReindexAll(ctx context.Context) (int, error) {
+	if su.embedder == nil {
+		return 0, errSemanticSearchDisabled
+	}
+	blogs, err := su.blogRepo.FetchAll(ctx)
+	if err != nil {
+		return 0, errors.New("failed to fetch blogs")
+	}
+
+	indexed := 0
+	for start := 0; start < len(blogs); start += reindexBatchSize {
+		batch := blogs[start:min(start+reindexBatchSize, len(blogs))]
+		texts := make([]string, len(batch))
+		for i, blog := range batch {
+			texts[i] = embeddingText(blog)
+		}
+		vectors, err := su.embedder.Embed(ctx, texts)
+		if err != nil {
+			return indexed, err
+		}
+		for i, blog := range batch {
+			if err := su.index.Upsert(ctx, blog.ID, su.embedder.Model(), vectors[i]); err != nil {
+				log.Printf("failed to index blog %d: %v", blog.ID, err)
+				continue
+			}
+			indexed++
+		}
+	}
+	return indexed, nil
+}
@@ -0,0 +1,110 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+// weights of a day's activity in trending scores
+const (
+	trendingViewWeight    = 1
+	trendingLikeWeight    = 3
+	trendingCommentWeight = 5
+)
+
+// trendingWindow is how many days of stats a window covers and how fast
+// they lose weight.
+type trendingWindow struct {
+	days     int
+	halfLife time.Duration
+}
+
+// The stats are daily, so the 24h window covers today and yesterday.
+var trendingWindows = map[string]trendingWindow{
+	domain.TrendingDay:  {days: 2, halfLife: 12 * time.Hour},
+	domain.TrendingWeek: {days: 7, halfLife: 48 * time.Hour},
+}
+
+var errInvalidTrendingWindow = fmt.Errorf("window must be %s or %s", domain.TrendingDay, domain.TrendingWeek)
+
+// trendingScore weighs the activity of a day by its age, taking the middle
+// of the day as when it happened.
+func trendingScore(s *domain.BlogDailyStats, halfLife time.Duration, now time.Time) float64 {
+	activity := float64(s.Views*trendingViewWeight + s.Likes*trendingLikeWeight + s.Comments*trendingCommentWeight)
+	if activity <= 0 {
+		return 0
+	}
+	age := now.Sub(s.Day.Add(12 * time.Hour))
+	if age < 0 {
+		age = 0
+	}
+	return activity * math.Exp2(-age.Hours()/halfLife.Hours())
+}
+
+// TrendingRanker recalculates the trending scores of every window from the
+// daily stats.
+type TrendingRanker struct {
+	analyticsRepo domain.IAnalyticsRepository
+	now           func() time.Time
+}
+
+func NewTrendingRanker(ar domain.IAnalyticsRepository) *TrendingRanker {
+	return &TrendingRanker{analyticsRepo: ar, now: time.Now}
+}
+
+// Refresh scores the posts with activity in each window.
+func (tr *TrendingRanker) Refresh(ctx context.Context) error {
+	now := tr.now().UTC()
+	today := now.Truncate(24 * time.Hour)
+	longest := 0
+	for _, w := range trendingWindows {
+		longest = max(longest, w.days)
+	}
+	stats, err := tr.analyticsRepo.FetchStatsSince(ctx, today.AddDate(0, 0, 1-longest))
+	if err != nil {
+		return fmt.Errorf("failed to fetch stats: %w", err)
+	}
+
+	for period, w := range trendingWindows {
+		since := today.AddDate(0, 0, 1-w.days)
+		totals := make(map[int64]float64)
+		for _, s := range stats {
+			if !s.Day.Before(since) {
+				totals[s.BlogID] += trendingScore(s, w.halfLife, now)
+			}
+		}
+		scores := make([]*domain.BlogTrendingScore, 0, len(totals))
+		for blogID, score := range totals {
+			if score > 0 {
+				scores = append(scores, &domain.BlogTrendingScore{BlogID: blogID, Period: period, Score: score, UpdatedAt: now})
+			}
+		}
+		if err := tr.analyticsRepo.ReplaceTrendingScores(ctx, period, scores); err != nil {
+			return fmt.Errorf("failed to save %s trending scores: %w", period, err)
+		}
+	}
+	return nil
+}
+
+// Run refreshes the scores now and then every interval until ctx is
+// cancelled.
+func (tr *TrendingRanker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := tr.Refresh(ctx); err != nil {
+			log.Printf("trending ranker: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package usecases
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+const recoveryCodeCount = 10
+
+var errInvalidCode = errors.New("invalid code")
+
+// VerifyMFA completes a login started by Login for an account with TOTP. Wrong
+// codes count as failed logins, so they are throttled and lock the account
+// like wrong passwords.
+func (uu *UserUsecase) VerifyMFA(mfaToken string, code string, ip string) (*domain.LoginResult, error) {
+	userID, err := uu.jwtService.ValidateMFAToken(mfaToken)
+	if err != nil {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+	user, err := uu.userRepo.Fetch(userID)
+	if err != nil || !user.TOTPEnabled {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+
+	if err := uu.verifySecondFactor(user, code, ip); err != nil {
+		return nil, err
+	}
+	return uu.loginSucceeded(user, ip)
+}
+
+// verifySecondFactor checks a code like a login: it is refused for a locked
+// account or a throttled IP, and a wrong code counts as a failed login.
+func (uu *UserUsecase) verifySecondFactor(user domain.User, code string, ip string) error {
+	now := time.Now()
+	failures, err := uu.loginAttemptRepo.CountFailuresByIP(ip, now.Add(-loginIPWindow))
+	if err == nil && failures >= loginIPMaxFailures {
+		return errors.New("too many failed login attempts")
+	}
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		uu.recordLoginAttempt(&user.ID, ip, false)
+		return errInvalidCode
+	}
+	if !uu.checkSecondFactor(user, code, now) {
+		uu.recordLoginAttempt(&user.ID, ip, false)
+		uu.loginFailed(user, now)
+		return errInvalidCode
+	}
+	return nil
+}
+
+// checkSecondFactor accepts a TOTP code that was not used before or an unused
+// recovery code.
+func (uu *UserUsecase) checkSecondFactor(user domain.User, code string, now time.Time) bool {
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		step, ok := uu.totpService.Validate(user.TOTPSecret, code, now)
+		if !ok {
+			return false
+		}
+		used, err := uu.userRepo.UseTOTPStep(user.ID, step)
+		return err == nil && used
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return false
+	}
+	used, err := uu.userRepo.UseRecoveryCode(user.ID, hashSecretToken(normalized), now)
+	return err == nil && used
+}
+
+func (uu *UserUsecase) SetupTOTP(userID int64) (*domain.TOTPSetup, error) {
+	user, err := uu.userRepo.GetUserProfile(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := uu.totpService.GenerateSecret()
+	if err != nil {
+		return nil, errors.New("could not start two-factor setup")
+	}
+	if err := uu.userRepo.SetTOTPSecret(userID, secret); err != nil {
+		return nil, errors.New("could not start two-factor setup")
+	}
+	return &domain.TOTPSetup{Secret: secret, URI: uu.totpService.ProvisioningURI(secret, user.Email)}, nil
+}
+
+func (uu *UserUsecase) ConfirmTOTP(userID int64, code string) ([]string, error) {
+	user, err := uu.userRepo.GetUserProfile(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("two-factor setup has not been started")
+	}
+
+	step, ok := uu.totpService.Validate(user.TOTPSecret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, errInvalidCode
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, errors.New("could not enable two-factor authentication")
+	}
+	if err := uu.userRepo.EnableTOTP(userID, step, hashes); err != nil {
+		return nil, errors.New("could not enable two-factor authentication")
+	}
+	return codes, nil
+}
+
+// DisableTOTP needs a second factor, which is throttled like VerifyMFA so
+// that a stolen session cannot be used to guess codes.
+func (uu *UserUsecase) DisableTOTP(userID int64, code string, ip string) error {
+	user, err := uu.userRepo.GetUserProfile(userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+	if !user.TOTPEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if err := uu.verifySecondFactor(*user, code, ip); err != nil {
+		return err
+	}
+	if err := uu.userRepo.DisableTOTP(userID); err != nil {
+		return errors.New("could not disable two-factor authentication")
+	}
+	return nil
+}
+
+// ResetTOTP lets an admin remove two-factor authentication from an account
+// whose owner lost both the authenticator and the recovery codes.
+func (uu *UserUsecase) ResetTOTP(id string) error {
+	user, err := uu.userRepo.Fetch(id)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if err := uu.userRepo.DisableTOTP(user.ID); err != nil {
+		return errors.New("could not reset two-factor authentication")
+	}
+	return nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes returns codes formatted as xxxxx-xxxxx and their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashSecretToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
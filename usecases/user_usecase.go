@@ -1,32 +1,57 @@
 package usecases
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"net/mail"
 	"os"
 	"strconv"
 	"strings"
-	"unicode"
+	"sync"
+	"time"
 
 	"github.com/blog-platform/domain"
 )
 
+// Login throttling
+const (
+	loginFreeAttempts     = 3  // failures before delays start
+	loginLockoutThreshold = 10 // failures before the account is locked
+	loginLockoutBase      = 15 * time.Minute
+	loginLockoutMax       = 24 * time.Hour
+	loginIPWindow         = 15 * time.Minute
+	loginIPMaxFailures    = 50 // failures per IP within loginIPWindow, across all accounts
+)
+
+var errInvalidCredentials = errors.New("invalid credentials")
+
 type UserUsecase struct {
-	userRepo        domain.IUserRepository
-	emailService    domain.IEmailInfrastructure
-	passwordService domain.IPasswordInfrastructure
-	jwtService      domain.IJWTInfrastructure
-	tokenRepo       domain.ITokenRepository
+	userRepo         domain.IUserRepository
+	outboxRepo       domain.IOutboxRepository
+	loginAttemptRepo domain.ILoginAttemptRepository
+	passwordService  domain.IPasswordInfrastructure
+	jwtService       domain.IJWTInfrastructure
+	tokenRepo        domain.ITokenRepository
+	totpService      domain.ITOTPInfrastructure
+	passwordPolicy   domain.IPasswordPolicy
+
+	dummyHashOnce *sync.Once
+	dummyHash     string
 }
 
-func NewUserUsecase(ur domain.IUserRepository, es domain.IEmailInfrastructure, ps domain.IPasswordInfrastructure, js domain.IJWTInfrastructure, tr domain.ITokenRepository) *UserUsecase {
+func NewUserUsecase(ur domain.IUserRepository, or domain.IOutboxRepository, la domain.ILoginAttemptRepository, ps domain.IPasswordInfrastructure, js domain.IJWTInfrastructure, tr domain.ITokenRepository, ts domain.ITOTPInfrastructure, pp domain.IPasswordPolicy) *UserUsecase {
 	return &UserUsecase{
-		userRepo:        ur,
-		emailService:    es,
-		passwordService: ps,
-		jwtService:      js,
-		tokenRepo:       tr,
+		userRepo:         ur,
+		outboxRepo:       or,
+		loginAttemptRepo: la,
+		passwordService:  ps,
+		jwtService:       js,
+		tokenRepo:        tr,
+		totpService:      ts,
+		passwordPolicy:   pp,
+		dummyHashOnce:    &sync.Once{},
 	}
 }
 
@@ -40,8 +65,8 @@ func (uu *UserUsecase) Register(user *domain.User) (domain.User, error) {
 		return domain.User{}, errors.New("invalid email format")
 	}
 
-	if !uu.validatePassword(user.Password) {
-		return domain.User{}, errors.New("password must be consisted of at least one uppercase character, one lowercase character, one punctuation character, one number and be at least of length 8")
+	if err := uu.passwordPolicy.Validate(user.Password); err != nil {
+		return domain.User{}, err
 	}
 
 	_, err = uu.userRepo.FetchByUsername(user.Username)
@@ -70,50 +95,97 @@ func (uu *UserUsecase) Register(user *domain.User) (domain.User, error) {
 		return domain.User{}, errors.New(err.Error())
 	}
 
-	registeredUser, err := uu.userRepo.Register(user)
+	// the activation email is queued in the same transaction as the user row
+	registeredUser, err := uu.userRepo.RegisterWithEmail(user, func(u domain.User) (*domain.OutboxEmail, error) {
+		link := fmt.Sprintf("%v://%v:%v/user/%v/activate", os.Getenv("PROTOCOL"), os.Getenv("DOMAIN"), os.Getenv("PORT"), u.ID)
+		return newOutboxEmail([]string{u.Email}, domain.EmailActivation, map[string]string{
+			"Username": u.Username,
+			"Link":     link,
+		})
+	})
 	if err != nil {
 		return domain.User{}, errors.New("unable to register user")
 	}
 
-	emailContent := fmt.Sprintf("%v://%v:%v/user/%v/activate", os.Getenv("PROTOCOL"), os.Getenv("DOMAIN"), os.Getenv("PORT"), registeredUser.ID)
-	err = uu.emailService.SendEmail([]string{registeredUser.Email}, "Activate Account", emailContent)
-	if err != nil {
-		return domain.User{}, errors.New("unable to send activation link")
-	}
-
 	return registeredUser, nil
 }
 
-func (uu *UserUsecase) Login(identifier string, password string) (string, string, error) {
+func (uu *UserUsecase) Login(identifier string, password string, ip string) (*domain.LoginResult, error) {
+	now := time.Now()
+	if uu.tooManyFailuresFromIP(ip, now) {
+		return nil, errors.New("too many failed login attempts")
+	}
+
+	// every failure below returns the same error after a password comparison
+	// so that responses do not reveal whether the account exists or is locked
 	user, err := uu.userRepo.FetchByUsername(identifier)
 	if err != nil {
-		_, err := mail.ParseAddress(identifier)
-		if err != nil {
-			return "", "", errors.New("invalid email format")
-		}
-
 		user, err = uu.userRepo.FetchByEmail(identifier)
-		if err != nil {
-			return "", "", errors.New("invalid identifier")
-		}
 	}
-
-	if !uu.validatePassword(password) {
-		return "", "", errors.New("invalid password format")
+	if err != nil {
+		uu.compareDummyPassword(password)
+		uu.recordLoginAttempt(nil, ip, false)
+		return nil, errInvalidCredentials
+	}
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		uu.compareDummyPassword(password)
+		uu.recordLoginAttempt(&user.ID, ip, false)
+		return nil, errInvalidCredentials
 	}
+
 	err = uu.passwordService.ComparePassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		return "", "", errors.New("invalid credentials")
+		uu.recordLoginAttempt(&user.ID, ip, false)
+		uu.loginFailed(user, now)
+		return nil, errInvalidCredentials
+	}
+	if uu.passwordService.NeedsRehash(user.Password) {
+		uu.rehashPassword(user, password)
 	}
 
+	return uu.CompleteLogin(user, ip)
+}
+
+// CompleteLogin finishes a login once the first factor is verified. Users
+// with TOTP get a challenge token instead; their failure counter is only
+// reset once the second factor is verified.
+func (uu *UserUsecase) CompleteLogin(user domain.User, ip string) (*domain.LoginResult, error) {
+	if user.TOTPEnabled {
+		mfaToken, err := uu.jwtService.GenerateMFAToken(strconv.FormatInt(user.ID, 10))
+		if err != nil {
+			return nil, errors.New(err.Error())
+		}
+		return &domain.LoginResult{MFAToken: mfaToken}, nil
+	}
+	return uu.loginSucceeded(user, ip)
+}
+
+// CompleteVerifiedLogin finishes a login whose method already proves two
+// factors, such as a user-verified passkey, so no TOTP challenge is issued.
+func (uu *UserUsecase) CompleteVerifiedLogin(user domain.User, ip string) (*domain.LoginResult, error) {
+	return uu.loginSucceeded(user, ip)
+}
+
+func (uu *UserUsecase) loginSucceeded(user domain.User, ip string) (*domain.LoginResult, error) {
+	uu.recordLoginAttempt(&user.ID, ip, true)
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := uu.userRepo.ResetLoginFailures(user.ID); err != nil {
+			log.Printf("login: failed to reset failures of user %d: %v", user.ID, err)
+		}
+	}
+	return uu.issueTokens(user)
+}
+
+// issueTokens generates and persists a new access and refresh token pair.
+func (uu *UserUsecase) issueTokens(user domain.User) (*domain.LoginResult, error) {
 	accessToken, err := uu.jwtService.GenerateAccessToken(strconv.FormatInt(user.ID, 10), user.Role)
 	if err != nil {
-		return "", "", errors.New(err.Error())
+		return nil, errors.New(err.Error())
 	}
 
 	refreshToken, err := uu.jwtService.GenerateRefreshToken(strconv.FormatInt(user.ID, 10), user.Role)
 	if err != nil {
-		return "", "", errors.New(err.Error())
+		return nil, errors.New(err.Error())
 	}
 
 	accessTokenObj := domain.Token{
@@ -131,15 +203,140 @@ func (uu *UserUsecase) Login(identifier string, password string) (string, string
 
 	err = uu.tokenRepo.Save(&accessTokenObj)
 	if err != nil {
-		return "", "", errors.New(err.Error())
+		return nil, errors.New(err.Error())
 	}
 
 	err = uu.tokenRepo.Save(&refreshTokenObj)
 	if err != nil {
-		return "", "", errors.New(err.Error())
+		return nil, errors.New(err.Error())
 	}
 
-	return accessToken, refreshToken, nil
+	return &domain.LoginResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// loginLockout returns how long an account is locked after the given number
+// of consecutive failures: nothing for the first few, then a delay doubling
+// from one second, then a lockout doubling from loginLockoutBase for every
+// further loginLockoutThreshold failures.
+func loginLockout(failures int) time.Duration {
+	switch {
+	case failures < loginFreeAttempts:
+		return 0
+	case failures < loginLockoutThreshold:
+		return time.Second << (failures - loginFreeAttempts)
+	}
+	d := loginLockoutBase
+	for i := loginLockoutThreshold * 2; i <= failures; i += loginLockoutThreshold {
+		d *= 2
+		if d >= loginLockoutMax {
+			return loginLockoutMax
+		}
+	}
+	return d
+}
+
+func (uu *UserUsecase) loginFailed(user domain.User, now time.Time) {
+	failures, err := uu.userRepo.RecordLoginFailure(user.ID)
+	if err != nil {
+		log.Printf("login: failed to record failure of user %d: %v", user.ID, err)
+		return
+	}
+	lockout := loginLockout(failures)
+	if lockout == 0 {
+		return
+	}
+	until := now.Add(lockout)
+
+	// the unlock email is only sent when the account becomes locked, not for
+	// the short delays before that or for every further failure
+	if failures != loginLockoutThreshold {
+		if err := uu.userRepo.LockAccount(user.ID, until, "", nil); err != nil {
+			log.Printf("login: failed to lock user %d: %v", user.ID, err)
+		}
+		return
+	}
+
+	token, hash, err := newSecretToken()
+	if err != nil {
+		log.Printf("login: failed to create unlock token: %v", err)
+		return
+	}
+	link := fmt.Sprintf("%v://%v:%v/unlock?token=%v", os.Getenv("PROTOCOL"), os.Getenv("DOMAIN"), os.Getenv("PORT"), token)
+	email, err := newOutboxEmail([]string{user.Email}, domain.EmailAccountLocked, map[string]string{
+		"Username":    user.Username,
+		"LockedUntil": until.UTC().Format(time.RFC1123),
+		"Link":        link,
+	})
+	if err != nil {
+		log.Printf("login: failed to build unlock email: %v", err)
+		return
+	}
+	if err := uu.userRepo.LockAccount(user.ID, until, hash, email); err != nil {
+		log.Printf("login: failed to lock user %d: %v", user.ID, err)
+	}
+}
+
+// tooManyFailuresFromIP reports whether the IP has exceeded the failed login
+// budget shared by all accounts.
+func (uu *UserUsecase) tooManyFailuresFromIP(ip string, now time.Time) bool {
+	failures, err := uu.loginAttemptRepo.CountFailuresByIP(ip, now.Add(-loginIPWindow))
+	return err == nil && failures >= loginIPMaxFailures
+}
+
+func (uu *UserUsecase) recordLoginAttempt(userID *int64, ip string, success bool) {
+	if err := uu.loginAttemptRepo.Record(&domain.LoginAttempt{UserID: userID, IP: ip, Success: success}); err != nil {
+		log.Printf("login: failed to record attempt: %v", err)
+	}
+}
+
+// rehashPassword upgrades a hash made with an older algorithm or weaker
+// parameters while the plaintext is at hand. Failures only delay the upgrade.
+func (uu *UserUsecase) rehashPassword(user domain.User, password string) {
+	hashed, err := uu.passwordService.HashPassword(password)
+	if err != nil {
+		log.Printf("login: failed to rehash password of user %d: %v", user.ID, err)
+		return
+	}
+	if err := uu.userRepo.UpdatePasswordHash(user.ID, user.Password, hashed); err != nil {
+		log.Printf("login: failed to store rehashed password of user %d: %v", user.ID, err)
+	}
+}
+
+// compareDummyPassword spends the same time as checking a real password.
+func (uu *UserUsecase) compareDummyPassword(password string) {
+	uu.dummyHashOnce.Do(func() {
+		uu.dummyHash, _ = uu.passwordService.HashPassword("dummy-password-for-timing")
+	})
+	_ = uu.passwordService.ComparePassword([]byte(uu.dummyHash), []byte(password))
+}
+
+func (uu *UserUsecase) UnlockAccount(token string) error {
+	if token == "" {
+		return errors.New("token required")
+	}
+	user, err := uu.userRepo.FetchByUnlockToken(hashSecretToken(token))
+	if err != nil {
+		return errors.New("invalid or expired token")
+	}
+	if err := uu.userRepo.ResetLoginFailures(user.ID); err != nil {
+		return errors.New("could not unlock account")
+	}
+	return nil
+}
+
+func (uu *UserUsecase) UnlockUser(id string) error {
+	user, err := uu.userRepo.Fetch(id)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if err := uu.userRepo.ResetLoginFailures(user.ID); err != nil {
+		return errors.New("could not unlock account")
+	}
+	return nil
+}
+
+func (uu *UserUsecase) ListLockedUsers(page, limit int) ([]domain.User, int64, error) {
+	return uu.userRepo.ListLocked(time.Now(), page, limit)
 }
 
 func (uu *UserUsecase) RefreshToken(authHeader string) (string, string, error) {
@@ -192,35 +389,6 @@ func (uu *UserUsecase) Logout(authHeader string) error {
 	return nil
 }
 
-func (uu *UserUsecase) validatePassword(password string) bool {
-	var (
-		hasMinLen  = false
-		hasUpper   = false
-		hasLower   = false
-		hasNumber  = false
-		hasSpecial = false
-	)
-
-	if len(password) >= 8 {
-		hasMinLen = true
-	}
-
-	for _, c := range password {
-		switch {
-		case unicode.IsUpper(c):
-			hasUpper = true
-		case unicode.IsLower(c):
-			hasLower = true
-		case unicode.IsNumber(c):
-			hasNumber = true
-		case unicode.IsPunct(c) || unicode.IsSymbol(c):
-			hasSpecial = true
-		}
-	}
-
-	return hasMinLen && hasUpper && hasLower && hasNumber && hasSpecial
-}
-
 func (uu *UserUsecase) ActivateAccount(id string) error {
 	_, err := uu.userRepo.Fetch(id)
 	if err != nil {
@@ -260,8 +428,127 @@ func (uu *UserUsecase) Demote(id string) error {
 	return uu.userRepo.Demote(id)
 }
 
+// profileFields are the fields users may change on their own profile.
+var profileFields = map[string]bool{
+	"Username":       true,
+	"Email":          true,
+	"Bio":            true,
+	"ProfilePicture": true,
+	"Phone":          true,
+}
+
+const emailChangeTTL = 24 * time.Hour
+
+// UpdateUserProfile applies the updates to the user's profile. A new email is
+// not applied directly; it is stored as a pending change until confirmed from
+// the new address.
 func (uu UserUsecase) UpdateUserProfile(userID int64, updates map[string]interface{}) error {
-	return uu.userRepo.UpdateUserProfile(userID, updates)
+	fields := make(map[string]interface{}, len(updates))
+	for k, v := range updates {
+		if !profileFields[k] {
+			return fmt.Errorf("field '%s' cannot be updated", k)
+		}
+		if k != "Username" && k != "Email" {
+			fields[k] = v
+		}
+	}
+
+	if value, ok := updates["Username"]; ok {
+		username, _ := value.(string)
+		username = strings.TrimSpace(username)
+		if username == "" {
+			return errors.New("invalid username")
+		}
+		existing, err := uu.userRepo.FetchByUsername(username)
+		if err == nil && existing.ID != userID {
+			return errors.New("this username is already in use")
+		}
+		fields["Username"] = username
+	}
+
+	var newEmail string
+	if value, ok := updates["Email"]; ok {
+		email, _ := value.(string)
+		addr, err := mail.ParseAddress(strings.TrimSpace(email))
+		if err != nil {
+			return errors.New("invalid email format")
+		}
+		newEmail = addr.Address
+		existing, err := uu.userRepo.FetchByEmail(newEmail)
+		if err == nil && existing.ID != userID {
+			return errors.New("this email is already in use")
+		}
+	}
+
+	if len(fields) > 0 {
+		if err := uu.userRepo.UpdateUserProfile(userID, fields); err != nil {
+			return err
+		}
+	}
+	if newEmail != "" {
+		return uu.requestEmailChange(userID, newEmail)
+	}
+	return nil
+}
+
+func (uu UserUsecase) requestEmailChange(userID int64, newEmail string) error {
+	user, err := uu.userRepo.GetUserProfile(userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+	if strings.EqualFold(user.Email, newEmail) {
+		return nil
+	}
+
+	token, hash, err := newSecretToken()
+	if err != nil {
+		return errors.New("could not request email change")
+	}
+	link := fmt.Sprintf("%v://%v:%v/email/confirm?token=%v", os.Getenv("PROTOCOL"), os.Getenv("DOMAIN"), os.Getenv("PORT"), token)
+	data := map[string]string{
+		"Username": user.Username,
+		"Email":    newEmail,
+		"Link":     link,
+	}
+	confirm, err := newOutboxEmail([]string{newEmail}, domain.EmailChange, data)
+	if err != nil {
+		return errors.New("could not request email change")
+	}
+	delete(data, "Link")
+	notice, err := newOutboxEmail([]string{user.Email}, domain.EmailChangeNotice, data)
+	if err != nil {
+		return errors.New("could not request email change")
+	}
+
+	change := &domain.PendingEmailChange{
+		UserID:    userID,
+		NewEmail:  newEmail,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(emailChangeTTL),
+	}
+	if err := uu.userRepo.CreateEmailChange(change, []*domain.OutboxEmail{confirm, notice}); err != nil {
+		return errors.New("could not request email change")
+	}
+	return nil
+}
+
+func (uu *UserUsecase) ConfirmEmailChange(token string) error {
+	if token == "" {
+		return errors.New("token required")
+	}
+	change, err := uu.userRepo.FetchEmailChange(hashSecretToken(token))
+	if err != nil || time.Now().After(change.ExpiresAt) {
+		return errors.New("invalid or expired token")
+	}
+	// the address may have been taken since the change was requested
+	existing, err := uu.userRepo.FetchByEmail(change.NewEmail)
+	if err == nil && existing.ID != change.UserID {
+		return errors.New("this email is already in use")
+	}
+	if err := uu.userRepo.ConfirmEmailChange(change); err != nil {
+		return errors.New("could not update email")
+	}
+	return nil
 }
 
 func (uu *UserUsecase) ResetPassword(userID string, oldPassword string, newPassword string) error {
@@ -274,13 +561,13 @@ func (uu *UserUsecase) ResetPassword(userID string, oldPassword string, newPassw
 		return errors.New("invalid old password")
 	}
 
-	if !uu.validatePassword(newPassword) {
-		return errors.New("password must be consisted of at least one uppercase character, one lowercase character, one punctuation character, one number and be at least of length 8")
+	if err := uu.passwordPolicy.Validate(newPassword); err != nil {
+		return err
 	}
 
 	hashed, err := uu.passwordService.HashPassword(newPassword)
 	if err != nil {
-		return errors.New("could not hash password")
+		return errors.New(err.Error())
 	}
 
 	if err := uu.userRepo.ResetPassword(userID, hashed); err != nil {
@@ -309,7 +596,14 @@ func (uu *UserUsecase) ForgotPassword(email string) error {
 	}
 
 	link := fmt.Sprintf("%v://%v:%v/password/%v/update?token=%v", os.Getenv("PROTOCOL"), os.Getenv("DOMAIN"), os.Getenv("PORT"), user.ID, accessToken)
-	if err := uu.emailService.SendEmail([]string{user.Email}, "Reset Password", link); err != nil {
+	resetEmail, err := newOutboxEmail([]string{user.Email}, domain.EmailPasswordReset, map[string]string{
+		"Username": user.Username,
+		"Link":     link,
+	})
+	if err != nil {
+		return errors.New("could not send reset link")
+	}
+	if err := uu.outboxRepo.Enqueue(context.Background(), resetEmail); err != nil {
 		return errors.New("could not send reset link")
 	}
 	return nil
@@ -326,12 +620,12 @@ func (uu *UserUsecase) UpdatePasswordDirect(userID string, newPassword string, t
 	if claims.UserID != userID {
 		return errors.New("token does not match user")
 	}
-	if !uu.validatePassword(newPassword) {
-		return errors.New("password must be consisted of at least one uppercase character, one lowercase character, one punctuation character, one number and be at least of length 8")
+	if err := uu.passwordPolicy.Validate(newPassword); err != nil {
+		return err
 	}
 	hashed, err := uu.passwordService.HashPassword(newPassword)
 	if err != nil {
-		return errors.New("could not hash password")
+		return errors.New(err.Error())
 	}
 	if err := uu.userRepo.ResetPassword(userID, hashed); err != nil {
 		return errors.New("could not update password")
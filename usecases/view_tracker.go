@@ -0,0 +1,289 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/blog-platform/domain"
+)
+
+const defaultViewDedupWindow = 30 * time.Minute
+
+// referrers are stored by host, cut to the column size in characters
+const (
+	maxReferrerLength = 100
+	unknownReferrer   = "unknown"
+)
+
+// maxFlushAttempts bounds how many flushes in a row may fail before the
+// buffered counts are dropped, about a minute at the 5s interval.
+const maxFlushAttempts = 12
+
+// botUserAgent matches crawlers, link previews and HTTP libraries. Requests
+// without a user agent are treated as bots too, as browsers always send one.
+var botUserAgent = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|preview|facebookexternalhit|headless|phantomjs|curl|wget|python|go-http-client|java/|okhttp|axios|node-fetch|httpclient|libwww`)
+
+type statsKey struct {
+	blogID int64
+	day    time.Time
+}
+
+type referrerKey struct {
+	statsKey
+	referrer string
+}
+
+// ViewTracker counts unique views and buffers the counts in memory, so that
+// a popular post costs one UPDATE per flush rather than one per view. Likes
+// and comments are buffered with the views for the daily stats. Run flushes
+// periodically; Flush must be called once more on shutdown.
+type ViewTracker struct {
+	blogRepo      domain.IBlogRepository
+	analyticsRepo domain.IAnalyticsRepository
+	store         domain.IViewStore
+	window        time.Duration
+	now           func() time.Time
+
+	mu        sync.Mutex
+	pending   map[int64]int // view_count increments
+	daily     map[statsKey]*domain.BlogDailyStats
+	referrers map[referrerKey]int
+
+	flushMu       sync.Mutex // one flush at a time, guards the failures below
+	viewFailures  int
+	statsFailures int
+}
+
+// NewViewTracker counts a reader at most once per post every
+// VIEW_DEDUP_WINDOW (default 30m), and as a unique viewer once per UTC day.
+func NewViewTracker(br domain.IBlogRepository, ar domain.IAnalyticsRepository, store domain.IViewStore) *ViewTracker {
+	window := defaultViewDedupWindow
+	if value := os.Getenv("VIEW_DEDUP_WINDOW"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			log.Printf("VIEW_DEDUP_WINDOW: must be a duration, using %s", window)
+		} else {
+			window = parsed
+		}
+	}
+	return &ViewTracker{
+		blogRepo:      br,
+		analyticsRepo: ar,
+		store:         store,
+		window:        window,
+		now:           time.Now,
+		pending:       make(map[int64]int),
+		daily:         make(map[statsKey]*domain.BlogDailyStats),
+		referrers:     make(map[referrerKey]int),
+	}
+}
+
+// viewKey identifies a reader of a post: the user, or a hash of the client IP
+// and user agent for anonymous readers.
+func viewKey(view domain.BlogView) string {
+	if view.UserID > 0 {
+		return fmt.Sprintf("view:%d:user:%d", view.BlogID, view.UserID)
+	}
+	sum := sha256.Sum256([]byte(view.ClientIP + "\x00" + view.UserAgent))
+	return fmt.Sprintf("view:%d:anon:%s", view.BlogID, hex.EncodeToString(sum[:16]))
+}
+
+// referrerHost reduces a Referer to its host, so that stats are kept per
+// site rather than per page.
+func referrerHost(referrer string) string {
+	referrer = strings.TrimSpace(referrer)
+	if referrer == "" {
+		return domain.DirectReferrer
+	}
+	u, err := url.Parse(referrer)
+	if err != nil || u.Hostname() == "" {
+		return unknownReferrer
+	}
+	host := u.Hostname()
+	if !utf8.ValidString(host) {
+		return unknownReferrer
+	}
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	if utf8.RuneCountInString(host) > maxReferrerLength {
+		host = string([]rune(host)[:maxReferrerLength])
+	}
+	return host
+}
+
+// stats returns the buffered stats of a post for the day of now. vt.mu must
+// be held.
+func (vt *ViewTracker) stats(blogID int64, now time.Time) (statsKey, *domain.BlogDailyStats) {
+	key := statsKey{blogID: blogID, day: now.UTC().Truncate(24 * time.Hour)}
+	stats, ok := vt.daily[key]
+	if !ok {
+		stats = &domain.BlogDailyStats{BlogID: blogID, Day: key.day}
+		vt.daily[key] = stats
+	}
+	return key, stats
+}
+
+// TrackView counts a view of an existing post unless it comes from a bot or
+// the reader was counted within the window. If the store fails, the view is
+// counted. Referrers and unique viewers are only looked at for counted views.
+func (vt *ViewTracker) TrackView(ctx context.Context, view domain.BlogView) (bool, error) {
+	if view.BlogID <= 0 {
+		return false, errors.New("invalid blog ID")
+	}
+	// posts are cached, so this rarely reaches the database
+	if _, err := vt.blogRepo.FetchByID(ctx, view.BlogID); err != nil {
+		return false, errors.New("blog not found")
+	}
+	if ua := strings.TrimSpace(view.UserAgent); ua == "" || botUserAgent.MatchString(ua) {
+		return false, nil
+	}
+	now := vt.now()
+	key := viewKey(view)
+	if vt.window > 0 {
+		seen, err := vt.store.MarkViewed(ctx, key, vt.window, now)
+		if err != nil {
+			log.Printf("failed to deduplicate view of blog %d: %v", view.BlogID, err)
+		} else if seen {
+			return false, nil
+		}
+	}
+	day := now.UTC().Truncate(24 * time.Hour)
+	seenToday, err := vt.store.MarkViewed(ctx, "day:"+day.Format(time.DateOnly)+":"+key, day.Add(24*time.Hour).Sub(now), now)
+	if err != nil {
+		log.Printf("failed to count unique viewer of blog %d: %v", view.BlogID, err)
+	}
+
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.pending[view.BlogID]++
+	statsKey, stats := vt.stats(view.BlogID, now)
+	stats.Views++
+	if !seenToday {
+		stats.UniqueViewers++
+	}
+	vt.referrers[referrerKey{statsKey: statsKey, referrer: referrerHost(view.Referrer)}]++
+	return true, nil
+}
+
+// RecordActivity adds likes, negative for unlikes, and published comments
+// to today's stats of a post.
+func (vt *ViewTracker) RecordActivity(blogID int64, likes, comments int) {
+	if blogID <= 0 {
+		return
+	}
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	_, stats := vt.stats(blogID, vt.now())
+	stats.Likes += likes
+	stats.Comments += comments
+}
+
+// Flush writes the buffered view counts and daily stats. Whichever fails is
+// kept for the next flush, unless it has failed maxFlushAttempts times in a
+// row and is dropped.
+func (vt *ViewTracker) Flush(ctx context.Context) error {
+	vt.flushMu.Lock()
+	defer vt.flushMu.Unlock()
+
+	vt.mu.Lock()
+	views, daily, referrers := vt.pending, vt.daily, vt.referrers
+	vt.pending = make(map[int64]int)
+	vt.daily = make(map[statsKey]*domain.BlogDailyStats)
+	vt.referrers = make(map[referrerKey]int)
+	vt.mu.Unlock()
+
+	var errs []error
+	if len(views) > 0 {
+		err := vt.blogRepo.AddViews(ctx, views)
+		switch {
+		case err == nil:
+			vt.viewFailures = 0
+		case retry(&vt.viewFailures):
+			vt.mu.Lock()
+			for id, n := range views {
+				vt.pending[id] += n
+			}
+			vt.mu.Unlock()
+			errs = append(errs, fmt.Errorf("failed to flush views: %w", err))
+		default:
+			errs = append(errs, fmt.Errorf("failed to flush views, dropped after %d attempts: %w", maxFlushAttempts, err))
+		}
+	}
+	if len(daily) > 0 || len(referrers) > 0 {
+		err := vt.flushStats(ctx, daily, referrers)
+		switch {
+		case err == nil:
+			vt.statsFailures = 0
+		case retry(&vt.statsFailures):
+			vt.mu.Lock()
+			for key, s := range daily {
+				_, stats := vt.stats(key.blogID, key.day)
+				stats.Views += s.Views
+				stats.UniqueViewers += s.UniqueViewers
+				stats.Likes += s.Likes
+				stats.Comments += s.Comments
+			}
+			for key, n := range referrers {
+				vt.referrers[key] += n
+			}
+			vt.mu.Unlock()
+			errs = append(errs, fmt.Errorf("failed to flush stats: %w", err))
+		default:
+			errs = append(errs, fmt.Errorf("failed to flush stats, dropped after %d attempts: %w", maxFlushAttempts, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// retry counts a failed write and reports whether its batch should be kept.
+// Failed batches are merged into newer counts, so a write that can never
+// succeed would otherwise grow the buffer forever.
+func retry(failures *int) bool {
+	*failures++
+	if *failures < maxFlushAttempts {
+		return true
+	}
+	*failures = 0
+	return false
+}
+
+func (vt *ViewTracker) flushStats(ctx context.Context, daily map[statsKey]*domain.BlogDailyStats, referrers map[referrerKey]int) error {
+	stats := make([]*domain.BlogDailyStats, 0, len(daily))
+	for _, s := range daily {
+		// copies, as the repository may sort or fill them
+		stat := *s
+		stats = append(stats, &stat)
+	}
+	rows := make([]*domain.BlogReferrerStats, 0, len(referrers))
+	for key, n := range referrers {
+		rows = append(rows, &domain.BlogReferrerStats{BlogID: key.blogID, Day: key.day, Referrer: key.referrer, Views: n})
+	}
+	return vt.analyticsRepo.AddDailyStats(ctx, stats, rows)
+}
+
+// Run flushes every interval until ctx is cancelled.
+func (vt *ViewTracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := vt.Flush(ctx); err != nil {
+			log.Printf("view tracker: %v", err)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package usecases
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blog-platform/domain"
+)
+
+const (
+	webAuthnTimeout        = 5 * time.Minute
+	maxWebAuthnCredentials = 20
+)
+
+var errPasskeyLoginFailed = errors.New("passkey login failed")
+
+type webAuthnUsecase struct {
+	webAuthnRepo     domain.IWebAuthnRepository
+	userRepo         domain.IUserRepository
+	loginAttemptRepo domain.ILoginAttemptRepository
+	webAuthn         domain.IWebAuthnInfrastructure
+	userUsecase      domain.IUserUsecase
+	now              func() time.Time
+}
+
+func NewWebAuthnUsecase(wr domain.IWebAuthnRepository, ur domain.IUserRepository, la domain.ILoginAttemptRepository, wa domain.IWebAuthnInfrastructure, uu domain.IUserUsecase) domain.IWebAuthnUsecase {
+	return &webAuthnUsecase{
+		webAuthnRepo:     wr,
+		userRepo:         ur,
+		loginAttemptRepo: la,
+		webAuthn:         wa,
+		userUsecase:      uu,
+		now:              time.Now,
+	}
+}
+
+// webAuthnUserHandle is the opaque user ID given to authenticators and
+// returned by them on discoverable logins.
+func webAuthnUserHandle(userID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(userID, 10)))
+}
+
+// newChallenge stores a single-use challenge for the ceremony and returns it
+// in the base64url form used by the browser API.
+func (wu *webAuthnUsecase) newChallenge(ctx context.Context, ceremony string, userID int64) (string, error) {
+	challenge, hash, err := newSecretToken()
+	if err != nil {
+		return "", err
+	}
+	err = wu.webAuthnRepo.SaveChallenge(ctx, &domain.WebAuthnChallenge{
+		ChallengeHash: hash,
+		Type:          ceremony,
+		UserID:        userID,
+		ExpiresAt:     wu.now().Add(webAuthnTimeout),
+	})
+	if err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// consumeChallenge returns the challenge of the response if it was issued for
+// the ceremony and has not expired or been used.
+func (wu *webAuthnUsecase) consumeChallenge(ctx context.Context, clientDataJSON, ceremony string) (string, *domain.WebAuthnChallenge, error) {
+	challenge, err := wu.webAuthn.Challenge(clientDataJSON)
+	if err != nil {
+		return "", nil, errors.New("invalid or expired challenge")
+	}
+	stored, err := wu.webAuthnRepo.ConsumeChallenge(ctx, hashSecretToken(challenge))
+	if err != nil {
+		return "", nil, errors.New(err.Error())
+	}
+	if stored == nil || stored.Type != ceremony || wu.now().After(stored.ExpiresAt) {
+		return "", nil, errors.New("invalid or expired challenge")
+	}
+	return challenge, stored, nil
+}
+
+func (wu *webAuthnUsecase) BeginRegistration(ctx context.Context, userID int64) (*domain.WebAuthnCreationOptions, error) {
+	user, err := wu.userRepo.GetUserProfile(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	credentials, err := wu.webAuthnRepo.ListCredentials(ctx, userID)
+	if err != nil {
+		return nil, errors.New(err.Error())
+	}
+	if len(credentials) >= maxWebAuthnCredentials {
+		return nil, errors.New("too many passkeys, remove unused ones first")
+	}
+
+	challenge, err := wu.newChallenge(ctx, domain.WebAuthnRegistration, userID)
+	if err != nil {
+		return nil, errors.New("could not start passkey registration")
+	}
+
+	// already registered authenticators are excluded so they are not enrolled twice
+	exclude := make([]domain.WebAuthnCredentialDescriptor, 0, len(credentials))
+	for _, c := range credentials {
+		exclude = append(exclude, credentialDescriptor(c))
+	}
+	return &domain.WebAuthnCreationOptions{
+		RP: wu.webAuthn.RelyingParty(),
+		User: domain.WebAuthnUserEntity{
+			ID:          webAuthnUserHandle(user.ID),
+			Name:        user.Username,
+			DisplayName: user.Username,
+		},
+		Challenge: challenge,
+		PubKeyCredParams: []domain.WebAuthnCredentialParameter{
+			{Type: "public-key", Alg: domain.COSEAlgES256},
+			{Type: "public-key", Alg: domain.COSEAlgEdDSA},
+			{Type: "public-key", Alg: domain.COSEAlgRS256},
+		},
+		Timeout:            webAuthnTimeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: domain.WebAuthnAuthenticatorSelection{
+			ResidentKey:      "required",
+			UserVerification: "required",
+		},
+		Attestation: "none",
+	}, nil
+}
+
+func credentialDescriptor(c domain.WebAuthnCredential) domain.WebAuthnCredentialDescriptor {
+	d := domain.WebAuthnCredentialDescriptor{Type: "public-key", ID: c.CredentialID}
+	if c.Transports != "" {
+		d.Transports = strings.Split(c.Transports, ",")
+	}
+	return d
+}
+
+func (wu *webAuthnUsecase) FinishRegistration(ctx context.Context, userID int64, name string, resp *domain.WebAuthnAttestationResponse) (*domain.WebAuthnCredential, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+	if len(name) > 100 {
+		return nil, errors.New("passkey name must be at most 100 characters")
+	}
+
+	challenge, stored, err := wu.consumeChallenge(ctx, resp.Response.ClientDataJSON, domain.WebAuthnRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if stored.UserID != userID {
+		return nil, errors.New("invalid or expired challenge")
+	}
+
+	data, err := wu.webAuthn.VerifyRegistration(challenge, resp)
+	if err != nil {
+		log.Printf("passkey registration for user %d: %v", userID, err)
+		return nil, errors.New("passkey verification failed")
+	}
+	existing, err := wu.webAuthnRepo.FetchCredential(ctx, data.CredentialID)
+	if err != nil {
+		return nil, errors.New(err.Error())
+	}
+	if existing != nil {
+		return nil, errors.New("passkey is already registered")
+	}
+
+	credential := &domain.WebAuthnCredential{
+		UserID:       userID,
+		Name:         name,
+		CredentialID: data.CredentialID,
+		PublicKey:    data.PublicKey,
+		SignCount:    int64(data.SignCount),
+		AAGUID:       data.AAGUID,
+		Transports:   strings.Join(resp.Response.Transports, ","),
+	}
+	if err := wu.webAuthnRepo.CreateCredential(ctx, credential); err != nil {
+		return nil, errors.New("could not save passkey")
+	}
+	return credential, nil
+}
+
+func (wu *webAuthnUsecase) BeginLogin(ctx context.Context) (*domain.WebAuthnRequestOptions, error) {
+	challenge, err := wu.newChallenge(ctx, domain.WebAuthnLogin, 0)
+	if err != nil {
+		return nil, errors.New("could not start passkey login")
+	}
+	// no allowCredentials: the authenticator offers its discoverable
+	// credentials, so the user does not have to identify first
+	return &domain.WebAuthnRequestOptions{
+		Challenge:        challenge,
+		Timeout:          webAuthnTimeout.Milliseconds(),
+		RPID:             wu.webAuthn.RelyingParty().ID,
+		AllowCredentials: []domain.WebAuthnCredentialDescriptor{},
+		UserVerification: "required",
+	}, nil
+}
+
+// FinishLogin verifies an assertion and issues the same tokens as a password
+// login. A user-verified passkey proves two factors, so no TOTP code is asked.
+func (wu *webAuthnUsecase) FinishLogin(ctx context.Context, resp *domain.WebAuthnAssertionResponse, ip string) (*domain.LoginResult, error) {
+	failures, err := wu.loginAttemptRepo.CountFailuresByIP(ip, wu.now().Add(-loginIPWindow))
+	if err == nil && failures >= loginIPMaxFailures {
+		return nil, errors.New("too many failed login attempts")
+	}
+
+	challenge, _, err := wu.consumeChallenge(ctx, resp.Response.ClientDataJSON, domain.WebAuthnLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := wu.webAuthnRepo.FetchCredential(ctx, resp.ID)
+	if err != nil || credential == nil {
+		wu.loginFailed(nil, ip, "unknown credential")
+		return nil, errPasskeyLoginFailed
+	}
+	if resp.Response.UserHandle != "" && resp.Response.UserHandle != webAuthnUserHandle(credential.UserID) {
+		wu.loginFailed(&credential.UserID, ip, "user handle mismatch")
+		return nil, errPasskeyLoginFailed
+	}
+
+	signCount, err := wu.webAuthn.VerifyAssertion(challenge, credential.PublicKey, resp)
+	if err != nil {
+		wu.loginFailed(&credential.UserID, ip, err.Error())
+		return nil, errPasskeyLoginFailed
+	}
+	// authenticators without a counter always report 0; otherwise it must
+	// grow, and a counter that does not suggests a cloned authenticator
+	if (signCount != 0 || credential.SignCount != 0) && int64(signCount) <= credential.SignCount {
+		wu.loginFailed(&credential.UserID, ip, "sign count did not increase")
+		return nil, errPasskeyLoginFailed
+	}
+	updated, err := wu.webAuthnRepo.UpdateSignCount(ctx, credential.ID, credential.SignCount, int64(signCount), wu.now())
+	if err != nil || !updated {
+		wu.loginFailed(&credential.UserID, ip, "sign count changed concurrently")
+		return nil, errPasskeyLoginFailed
+	}
+
+	user, err := wu.userRepo.Fetch(strconv.FormatInt(credential.UserID, 10))
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return wu.userUsecase.CompleteVerifiedLogin(user, ip)
+}
+
+func (wu *webAuthnUsecase) loginFailed(userID *int64, ip, reason string) {
+	log.Printf("passkey login from %s failed: %s", ip, reason)
+	if err := wu.loginAttemptRepo.Record(&domain.LoginAttempt{UserID: userID, IP: ip, Success: false}); err != nil {
+		log.Printf("login: failed to record attempt: %v", err)
+	}
+}
+
+func (wu *webAuthnUsecase) ListCredentials(ctx context.Context, userID int64) ([]domain.WebAuthnCredential, error) {
+	return wu.webAuthnRepo.ListCredentials(ctx, userID)
+}
+
+func (wu *webAuthnUsecase) DeleteCredential(ctx context.Context, userID, id int64) error {
+	deleted, err := wu.webAuthnRepo.DeleteCredential(ctx, userID, id)
+	if err != nil {
+		return errors.New(err.Error())
+	}
+	if !deleted {
+		return errors.New("passkey not found")
+	}
+	return nil
+}